@@ -9,6 +9,8 @@ import (
 	"museum/domain"
 	"museum/util"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -19,14 +21,37 @@ func printUsage() {
 	fmt.Println("\t- Starts the mūsēum API and proxy server")
 	fmt.Println("\tcreate <file>")
 	fmt.Println("\t- Creates a new exhibit")
-	fmt.Println("\tdelete <name>")
+	fmt.Println("\tvalidate <file>")
+	fmt.Println("\t- Checks an exhibit file without creating it")
+	fmt.Println("\timport-compose <compose.yml> (<file>)")
+	fmt.Println("\t- Translates a docker compose file into an exhibit file (printed if no file is given)")
+	fmt.Println("\tschema")
+	fmt.Println("\t- Prints the JSON Schema of exhibit files")
+	fmt.Println("\tdelete <name|id>")
 	fmt.Println("\t- Deletes a exhibit")
 	fmt.Println("\tlist (--json)")
 	fmt.Println("\t- Lists all exhibits")
 	fmt.Println("\trenew <name> <lease>")
 	fmt.Println("\t- Renews a lease on an exhibit")
-	fmt.Println("\twarmup <name>")
+	fmt.Println("\twarmup <name|id>")
 	fmt.Println("\t- Warms up an exhibit")
+	fmt.Println("\tshare <name|id> (<duration>)")
+	fmt.Println("\t- Creates a link that grants access to an exhibit for the duration (24h if none is given)")
+	fmt.Println("\tlogs <name|id> (<object>) (-f)")
+	fmt.Println("\t- Prints the logs of an object, or what it wrote before the exhibit failed to start, -f follows them")
+	fmt.Println("\tmaintenance <name|id> (<message>|--off)")
+	fmt.Println("\t- Keeps visitors out of an exhibit and prints the link that lets curators in, --off lets visitors in again")
+	fmt.Println("\tstate export (<file>)")
+	fmt.Println("\t- Exports all exhibits to a JSON or YAML bundle (printed if no file is given)")
+	fmt.Println("\tstate import <file> (--overwrite)")
+	fmt.Println("\t- Imports a bundle, exhibits that already exist are skipped unless --overwrite is given")
+	fmt.Println("\tbundle export <name|id> (<file>) (--bagit)")
+	fmt.Println("\t- Exports an exhibit with its images and volume data to a single archive (<name>.tar if no file is given)")
+	fmt.Println("\t- With --bagit the archive is a BagIt bag for long-term preservation, bags cannot be imported")
+	fmt.Println("\tbundle import <file>")
+	fmt.Println("\t- Recreates an exhibit from a bundle, no registry is needed")
+	fmt.Println("\tmigrate")
+	fmt.Println("\t- Upgrades all stored exhibits to the schema version of the running server")
 }
 
 func printSeparator() {
@@ -58,9 +83,72 @@ func main() {
 		}
 		fmt.Println("🧑‍🎨 exhibit " + exhibit.Name + " created successfully")
 		fmt.Println("‎‎‎👉 " + url)
+	case "validate":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing file argument")
+			os.Exit(1)
+		}
+		result, err := tool.Validate(os.Args[2])
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		for _, p := range result.Problems {
+			position := os.Args[2]
+			if p.Line > 0 {
+				position += ":" + strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+			}
+			fmt.Println("❌ " + position + ": " + p.Error())
+		}
+
+		if !result.Valid {
+			os.Exit(1)
+		}
+		fmt.Println("✅ " + os.Args[2] + " is a valid exhibit")
+	case "import-compose":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing file argument")
+			os.Exit(1)
+		}
+		exhibit, warnings, err := tool.ImportCompose(os.Args[2])
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		b, err := tool.MarshalExhibit(exhibit)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		// warnings go to stderr, so that the exhibit can be redirected into a file
+		for _, w := range warnings {
+			_, _ = fmt.Fprintln(os.Stderr, "⚠️ "+w)
+		}
+
+		if len(os.Args) < 4 {
+			fmt.Print(string(b))
+			return
+		}
+
+		err = os.WriteFile(os.Args[3], b, 0644)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("🧑‍🎨 exhibit " + exhibit.Name + " written to " + os.Args[3])
+	case "schema":
+		b, err := tool.Schema()
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Print(string(b))
 	case "delete":
 		if len(os.Args) < 3 {
-			fmt.Println("❌ missing id argument")
+			fmt.Println("❌ missing name or id argument")
 			os.Exit(1)
 		}
 		err := tool.Delete(os.Args[2])
@@ -92,11 +180,17 @@ func main() {
 			fmt.Print("    ")
 			if e.RuntimeInfo.Status == domain.Running {
 				fmt.Print("🟢 ")
+			} else if e.RuntimeInfo.Status == domain.Queued {
+				fmt.Print("⏳ ")
 			} else {
 				fmt.Print("🔴 ")
 			}
 			fmt.Println(" " + baseUrl + "/exhibit/" + e.Id)
 
+			if e.Collection != "" && e.Collection != domain.DefaultCollection {
+				fmt.Println("    🗂️  collection " + e.Collection)
+			}
+
 			if e.RuntimeInfo.Status == domain.Running {
 				d, err := time.ParseDuration(e.Lease)
 				if err != nil {
@@ -111,12 +205,21 @@ func main() {
 			for _, o := range e.Objects {
 				fmt.Println("        📜  " + o.Name + " (" + o.Image + ")")
 			}
+
+			if e.Fixity != nil && e.Fixity.Status == domain.FixityStatusMismatch {
+				fmt.Println("    ⚠️  fixity check failed " + durafmt.Parse(time.Since(time.Unix(e.Fixity.CheckedAt, 0)).Truncate(time.Second)).String() + " ago:")
+				for _, m := range e.Fixity.Mismatches {
+					fmt.Println("        ❌  " + m.String())
+				}
+			} else if e.Fixity != nil {
+				fmt.Println("    🔏  fixity verified " + durafmt.Parse(time.Since(time.Unix(e.Fixity.CheckedAt, 0)).Truncate(time.Second)).String() + " ago")
+			}
 		}
 
 		printSeparator()
 	case "warmup":
 		if len(os.Args) < 3 {
-			fmt.Println("❌ missing id argument")
+			fmt.Println("❌ missing name or id argument")
 			os.Exit(1)
 		}
 		url, err := tool.Warmup(os.Args[2])
@@ -126,7 +229,194 @@ func main() {
 		}
 		fmt.Println("‎‎‎🔥 exhibit warmed up successfully")
 		fmt.Println("‎‎‎👉 " + url)
+	case "share":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing name or id argument")
+			os.Exit(1)
+		}
+		expiresIn := ""
+		if len(os.Args) > 3 {
+			expiresIn = os.Args[3]
+		}
+		link, err := tool.Share(os.Args[2], expiresIn)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("🔗 share link valid until " + time.Unix(link.ExpiresAt, 0).Format(time.RFC1123))
+		fmt.Println("‎‎‎👉 " + link.Url)
+	case "logs":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing name or id argument")
+			os.Exit(1)
+		}
+		object, follow := "", false
+		for _, arg := range os.Args[3:] {
+			if arg == "-f" || arg == "--follow" {
+				follow = true
+			} else {
+				object = arg
+			}
+		}
+		err := tool.Logs(os.Args[2], object, follow, os.Stdout)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	case "maintenance":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing name or id argument")
+			os.Exit(1)
+		}
+		if len(os.Args) > 3 && os.Args[3] == "--off" {
+			err := tool.EndMaintenance(os.Args[2])
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			fmt.Println("✅ maintenance ended, visitors can see the exhibit again")
+			break
+		}
+		message := strings.Join(os.Args[3:], " ")
+		link, err := tool.Maintenance(os.Args[2], message)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("🚧 exhibit is in maintenance, visitors see the maintenance page")
+		fmt.Println("‎‎‎👉 " + link.Url)
+	case "state":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing state command (export or import)")
+			os.Exit(1)
+		}
+		runStateCommand(os.Args[2], os.Args[3:])
+	case "bundle":
+		if len(os.Args) < 3 {
+			fmt.Println("❌ missing bundle command (export or import)")
+			os.Exit(1)
+		}
+		runBundleCommand(os.Args[2], os.Args[3:])
+	case "migrate":
+		result, err := tool.Migrate()
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		for _, name := range result.Migrated {
+			fmt.Println("⬆️ migrated " + name + " to schema version " + strconv.Itoa(result.SchemaVersion))
+		}
+		for id, reason := range result.Failed {
+			fmt.Println("❌ failed to migrate " + id + ": " + reason)
+		}
+		fmt.Println("✅ " + strconv.Itoa(result.UpToDate) + " exhibits were up to date")
+
+		if len(result.Failed) > 0 {
+			os.Exit(1)
+		}
 	default:
 		printUsage()
 	}
 }
+
+func runStateCommand(command string, args []string) {
+	switch command {
+	case "export":
+		filePath := ""
+		if len(args) > 0 {
+			filePath = args[0]
+		}
+
+		bundle, b, err := tool.ExportState(filePath)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		if filePath == "" {
+			fmt.Println(string(b))
+			return
+		}
+		fmt.Println("📦 exported " + strconv.Itoa(len(bundle.Exhibits)) + " exhibits to " + filePath)
+	case "import":
+		if len(args) < 1 {
+			fmt.Println("❌ missing file argument")
+			os.Exit(1)
+		}
+
+		mode := domain.ImportSkip
+		if len(args) > 1 && args[1] == "--overwrite" {
+			mode = domain.ImportOverwrite
+		}
+
+		result, err := tool.ImportState(args[0], mode)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+
+		for _, name := range result.Imported {
+			fmt.Println("🧑‍🎨 imported " + name)
+		}
+		for _, name := range result.Overwritten {
+			fmt.Println("♻️ overwrote " + name)
+		}
+		for _, name := range result.Skipped {
+			fmt.Println("⏭️ skipped " + name + " (already exists)")
+		}
+		for name, reason := range result.Failed {
+			fmt.Println("❌ failed to import " + name + ": " + reason)
+		}
+
+		if len(result.Failed) > 0 {
+			os.Exit(1)
+		}
+	default:
+		fmt.Println("❌ unknown state command " + command)
+		os.Exit(1)
+	}
+}
+
+func runBundleCommand(command string, args []string) {
+	switch command {
+	case "export":
+		if len(args) < 1 {
+			fmt.Println("❌ missing name or id argument")
+			os.Exit(1)
+		}
+
+		filePath := ""
+		format := domain.ExhibitBundleFormatMuseum
+		for _, arg := range args[1:] {
+			if arg == "--bagit" {
+				format = domain.ExhibitBundleFormatBagIt
+			} else {
+				filePath = arg
+			}
+		}
+
+		exhibit, filePath, err := tool.ExportBundle(args[0], filePath, format)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("📦 exported " + exhibit.Name + " to " + filePath)
+	case "import":
+		if len(args) < 1 {
+			fmt.Println("❌ missing file argument")
+			os.Exit(1)
+		}
+
+		exhibit, url, err := tool.ImportBundle(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println("🧑‍🎨 exhibit " + exhibit.Name + " imported successfully")
+		fmt.Println("‎‎‎👉 " + url)
+	default:
+		fmt.Println("❌ unknown bundle command " + command)
+		os.Exit(1)
+	}
+}
@@ -3,17 +3,21 @@ package server
 import (
 	"context"
 	"fmt"
-	docker "github.com/docker/docker/client"
 	"github.com/nats-io/nats.go"
 	etcd "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/metric"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"museum/config"
 	proxymode "museum/config/proxy-mode"
+	statebackend "museum/config/state-backend"
+	"museum/controller/admin"
 	"museum/controller/api"
+	"museum/controller/catalogue"
 	"museum/controller/exhibit"
 	"museum/controller/health"
+	"museum/controller/oai"
 	"museum/http"
 	"museum/ioc"
 	"museum/observability"
@@ -39,12 +43,13 @@ func Run() {
 	cfg := ioc.Get[config.Config](c)
 
 	// register docker
-	ioc.RegisterSingleton[*docker.Client](c, service.NewDockerClient)
+	ioc.RegisterSingleton[service.ContainerRuntime](c, service.NewDockerClient)
 
 	// register jaeger
 	ioc.RegisterSingleton[tracesdk.SpanExporter](c, observability.NewSpanExporter)
 	ioc.RegisterSingleton[*observability.TracerProviderFactory](c, observability.NewTracerProviderFactory)
 	ioc.RegisterSingleton[trace.TracerProvider](c, observability.NewDefaultTracerProvider)
+	ioc.RegisterSingleton[metric.MeterProvider](c, observability.NewMeterProvider)
 
 	// register NATS
 	ioc.RegisterGenerator[*nats.Conn](c, persistence.NewNatsClient)
@@ -58,17 +63,24 @@ func Run() {
 		ioc.RegisterSingleton[persistence.Eventing](c, persistence.NewNatsEventing)
 	}
 
-	// register etcd
-	ioc.RegisterSingleton[*etcd.Client](c, persistence.NewEtcdClient)
-
 	// register shared state
-	ioc.RegisterSingleton[persistence.State](c, persistence.NewEtcdState)
+	switch cfg.GetStateBackend() {
+	case statebackend.BackendEtcd:
+		ioc.RegisterSingleton[*etcd.Client](c, persistence.NewEtcdClient)
+		ioc.RegisterSingleton[persistence.State](c, persistence.NewEtcdState)
+		break
+	case statebackend.BackendBolt:
+		ioc.RegisterSingleton[persistence.State](c, persistence.NewBoltState)
+		break
+	}
 
 	// register services
 	ioc.RegisterSingleton[service.VolumeProvisionerFactoryService](c, service.NewVolumeProvisionerFactoryService)
 	ioc.RegisterSingleton[service.RewriteService](c, service.NewRewriteService)
 	ioc.RegisterSingleton[service.EnvironmentTemplateResolverService](c, service.NewEnvironmentTemplateResolverService)
 	ioc.RegisterSingleton[service.LockService](c, service.NewLockService)
+	ioc.RegisterSingleton[service.CollectionService](c, service.NewCollectionService)
+	ioc.RegisterSingleton[service.CapacityService](c, service.NewCapacityService)
 	ioc.RegisterSingleton[service.RuntimeInfoService](c, service.NewRuntimeInfoService)
 	ioc.RegisterSingleton[service.ExhibitService](c, service.NewExhibitService)
 	ioc.RegisterSingleton[service.LastAccessedService](c, service.NewLastAccessedService)
@@ -82,6 +94,8 @@ func Run() {
 		break
 	}
 
+	ioc.RegisterSingleton[service.LoadService](c, service.NewLoadService)
+	ioc.RegisterSingleton[service.AuthService](c, service.NewAuthService)
 	ioc.RegisterSingleton[service.ApplicationProxyService](c, service.NewDockerApplicationProxyService)
 
 	// register livecheck
@@ -90,18 +104,40 @@ func Run() {
 	ioc.RegisterSingleton[service.LivecheckFactoryService](c, service.NewLivecheckFactoryService)
 
 	// register services
+	ioc.RegisterSingleton[service.LogService](c, service.NewLogService)
 	ioc.RegisterSingleton[service.ApplicationProvisionerService](c, service.NewDockerApplicationProvisionerService)
 	ioc.RegisterSingleton[service.ApplicationProvisionerHandlerService](c, service.NewApplicationProvisionerHandlerService)
 	ioc.RegisterSingleton[service.ExhibitCleanupService](c, service.NewExhibitCleanupService)
+	ioc.RegisterSingleton[service.ScheduleService](c, service.NewScheduleService)
+	ioc.RegisterSingleton[service.AutoscalerService](c, service.NewAutoscalerService)
+	ioc.RegisterSingleton[service.StateTransferService](c, service.NewStateTransferService)
+	ioc.RegisterSingleton[service.ExhibitBundleService](c, service.NewExhibitBundleService)
+	ioc.RegisterSingleton[service.FixityService](c, service.NewFixityService)
+	ioc.RegisterSingleton[service.OaiService](c, service.NewOaiService)
+	ioc.RegisterSingleton[service.AccessService](c, service.NewAccessService)
 
 	// register router and routes
 	ioc.RegisterSingleton[*http.Mux](c, http.NewMux)
 	ioc.ForFunc(c, health.RegisterRoutes)
 	ioc.ForFunc(c, exhibit.RegisterRoutes)
 	ioc.ForFunc(c, api.RegisterRoutes)
+	ioc.ForFunc(c, api.RegisterStateRoutes)
+	ioc.ForFunc(c, api.RegisterBundleRoutes)
+	ioc.ForFunc(c, api.RegisterFixityRoutes)
+	ioc.ForFunc(c, api.RegisterCitationRoutes)
+	ioc.ForFunc(c, oai.RegisterRoutes)
+	ioc.ForFunc(c, catalogue.RegisterRoutes)
+	ioc.ForFunc(c, api.RegisterLogRoutes)
+	ioc.ForFunc(c, admin.RegisterRoutes)
+	ioc.ForFunc(c, api.RegisterAccessRoutes)
+	ioc.ForFunc(c, api.RegisterCollectionRoutes)
+	ioc.ForFunc(c, api.RegisterAuthMiddleware)
 
 	go ioc.ForFunc(c, startProxyServer)
 	go ioc.ForFunc(c, startExhibitCleanup)
+	go ioc.ForFunc(c, startFixityCheck)
+	go ioc.ForFunc(c, startScheduler)
+	go ioc.ForFunc(c, startAutoscaler)
 
 	<-ctx.Done()
 }
@@ -134,6 +170,106 @@ func startExhibitCleanup(log *zap.SugaredLogger, cleanupService service.ExhibitC
 	}
 }
 
+func startFixityCheck(log *zap.SugaredLogger, fixityService service.FixityService, config config.Config) {
+	interval := time.Duration(config.GetFixityInterval()) * time.Hour
+	if interval <= 0 {
+		log.Infow("fixity checks are disabled")
+		return
+	}
+
+	// exhibits are checked once their last check is older than the interval, so the job can run more often
+	check := func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorw("failed to check fixity of exhibits", "error", err)
+			}
+		}()
+		<-time.After(min(interval, time.Hour))
+
+		err := fixityService.CheckDue(context.Background())
+		if err != nil {
+			log.Errorw("failed to check fixity of exhibits", "error", err)
+		}
+	}
+
+	for {
+		check()
+	}
+}
+
+// runWhileLeading evaluates every interval while this instance leads, only one instance evaluates at a time
+// and the others wait to take over once it is gone
+func runWhileLeading(log *zap.SugaredLogger, task string, interval time.Duration, lead func(ctx context.Context) (context.Context, error), evaluate func(ctx context.Context) error) {
+	campaign := func() context.Context {
+		for {
+			lead, err := lead(context.Background())
+			if err == nil {
+				return lead
+			}
+			log.Errorw("failed to lead the "+task, "error", err)
+			<-time.After(10 * time.Second)
+		}
+	}
+
+	run := func(lead context.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorw("failed to run the "+task, "error", err)
+			}
+		}()
+
+		select {
+		case <-time.After(interval):
+		case <-lead.Done():
+			return
+		}
+
+		err := evaluate(lead)
+		if err != nil && lead.Err() == nil {
+			log.Errorw("failed to run the "+task, "error", err)
+		}
+	}
+
+	for {
+		lead := campaign()
+		log.Infow("leading the " + task)
+
+		for lead.Err() == nil {
+			run(lead)
+		}
+		log.Warnw("lost the lead of the " + task + ", campaigning again")
+	}
+}
+
+func startScheduler(log *zap.SugaredLogger, scheduleService service.ScheduleService) {
+	runWhileLeading(log, "evaluation of schedules", 30*time.Second, scheduleService.Lead, scheduleService.Evaluate)
+}
+
+func startAutoscaler(log *zap.SugaredLogger, autoscalerService service.AutoscalerService) {
+	// every instance reports its load, the leader scales the exhibits with the load of all of them
+	report := func() {
+		defer func() {
+			if err := recover(); err != nil {
+				log.Errorw("failed to report the load of exhibits", "error", err)
+			}
+		}()
+		<-time.After(10 * time.Second)
+
+		err := autoscalerService.Report(context.Background())
+		if err != nil {
+			log.Errorw("failed to report the load of exhibits", "error", err)
+		}
+	}
+
+	go func() {
+		for {
+			report()
+		}
+	}()
+
+	runWhileLeading(log, "autoscaling of exhibits", 10*time.Second, autoscalerService.Lead, autoscalerService.Autoscale)
+}
+
 func startProxyServer(router *http.Mux, config config.Config, log *zap.SugaredLogger) {
 	log.Infof("starting server on port %s", config.GetPort())
 
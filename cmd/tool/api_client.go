@@ -1,12 +1,16 @@
 package tool
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"errors"
 	cloudevents "github.com/cloudevents/sdk-go/v2/event"
+	"io"
 	"museum/domain"
 	"net/http"
+	"net/url"
+	"strings"
 )
 
 type ApiClient interface {
@@ -15,15 +19,45 @@ type ApiClient interface {
 	CreateEvent(event *cloudevents.Event) error
 	GetBaseUrl() string
 	GetExhibitById(id string) (*domain.ExhibitDto, error)
+	GetExhibitByName(name string) (*domain.ExhibitDto, error)
 	GetAllExhibits() ([]domain.ExhibitDto, error)
+	ExportState() (*domain.StateBundle, error)
+	ImportState(bundle *domain.StateBundle, mode domain.ImportConflictMode) (*domain.StateImportResult, error)
+	MigrateState() (*domain.MigrationResult, error)
+	ExportBundle(id string, format domain.ExhibitBundleFormat, w io.Writer) error
+	ImportBundle(r io.Reader) (string, error)
+	CreateShareLink(id string, expiresIn string) (*domain.ShareLink, error)
+	StartMaintenance(id string, message string) (*domain.MaintenanceLink, error)
+	EndMaintenance(id string) error
+	GetLogs(id string, object string, w io.Writer) error
+	FollowLogs(id string, object string, w io.Writer) error
 }
 
 type ApiClientImpl struct {
 	BaseUrl string
+	// Token is sent as bearer token if it is set
+	Token string
+}
+
+type tokenTransport struct {
+	token string
+}
+
+func (t tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (a *ApiClientImpl) client() *http.Client {
+	if a.Token == "" {
+		return http.DefaultClient
+	}
+	return &http.Client{Transport: tokenTransport{token: a.Token}}
 }
 
 func (a *ApiClientImpl) GetAllExhibits() ([]domain.ExhibitDto, error) {
-	res, err := http.Get(a.BaseUrl + "/api/exhibits")
+	res, err := a.client().Get(a.BaseUrl + "/api/exhibits")
 	if err != nil {
 		return nil, err
 	}
@@ -47,7 +81,7 @@ func (a *ApiClientImpl) CreateExhibit(exhibit *domain.Exhibit) (string, error) {
 		return "", err
 	}
 
-	res, err := http.Post(a.BaseUrl+"/api/exhibits", "application/json", bytes.NewBuffer(b))
+	res, err := a.client().Post(a.BaseUrl+"/api/exhibits", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return "", err
 	}
@@ -71,22 +105,22 @@ func (a *ApiClientImpl) DeleteExhibitById(id string) error {
 		return err
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := a.client().Do(req)
 	if err != nil {
 		return err
 	}
 
+	if res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
 	status := make(map[string]string)
 	err = json.NewDecoder(res.Body).Decode(&status)
 	if err != nil {
 		return err
 	}
 
-	if res.StatusCode != http.StatusNoContent {
-		return errors.New("could not delete exhibit: " + status["error"])
-	}
-
-	return nil
+	return errors.New("could not delete exhibit: " + status["error"])
 }
 
 func (a *ApiClientImpl) CreateEvent(event *cloudevents.Event) error {
@@ -95,7 +129,7 @@ func (a *ApiClientImpl) CreateEvent(event *cloudevents.Event) error {
 		return err
 	}
 
-	res, err := http.Post(a.BaseUrl+"/api/events", "application/json", bytes.NewBuffer(b))
+	res, err := a.client().Post(a.BaseUrl+"/api/events", "application/json", bytes.NewBuffer(b))
 	if err != nil {
 		return err
 	}
@@ -114,7 +148,7 @@ func (a *ApiClientImpl) CreateEvent(event *cloudevents.Event) error {
 }
 
 func (a *ApiClientImpl) GetExhibitById(id string) (*domain.ExhibitDto, error) {
-	res, err := http.Get(a.BaseUrl + "/api/exhibits/" + id)
+	res, err := a.client().Get(a.BaseUrl + "/api/exhibits/" + id)
 	if err != nil {
 		return nil, err
 	}
@@ -132,6 +166,323 @@ func (a *ApiClientImpl) GetExhibitById(id string) (*domain.ExhibitDto, error) {
 	return exhibit, nil
 }
 
+func (a *ApiClientImpl) GetExhibitByName(name string) (*domain.ExhibitDto, error) {
+	res, err := a.client().Get(a.BaseUrl + "/api/exhibits/by-name/" + url.PathEscape(name))
+	if err != nil {
+		return nil, err
+	}
+
+	exhibit := &domain.ExhibitDto{}
+	err = json.NewDecoder(res.Body).Decode(exhibit)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("could not find exhibit " + name)
+	}
+
+	return exhibit, nil
+}
+
+func (a *ApiClientImpl) ExportState() (*domain.StateBundle, error) {
+	res, err := a.client().Get(a.BaseUrl + "/api/admin/state/export")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("could not export state")
+	}
+
+	bundle := &domain.StateBundle{}
+	err = json.NewDecoder(res.Body).Decode(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+func (a *ApiClientImpl) ImportState(bundle *domain.StateBundle, mode domain.ImportConflictMode) (*domain.StateImportResult, error) {
+	b, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.client().Post(a.BaseUrl+"/api/admin/state/import?conflict="+url.QueryEscape(string(mode)), "application/json", bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not import state: " + status["error"])
+	}
+
+	result := &domain.StateImportResult{}
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (a *ApiClientImpl) MigrateState() (*domain.MigrationResult, error) {
+	res, err := a.client().Post(a.BaseUrl+"/api/admin/state/migrate", "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("could not migrate state")
+	}
+
+	result := &domain.MigrationResult{}
+	err = json.NewDecoder(res.Body).Decode(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (a *ApiClientImpl) ExportBundle(id string, format domain.ExhibitBundleFormat, w io.Writer) error {
+	res, err := a.client().Get(a.BaseUrl + "/api/exhibits/" + id + "/bundle?format=" + string(format))
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return err
+		}
+
+		return errors.New("could not export bundle: " + status["error"])
+	}
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+func (a *ApiClientImpl) ImportBundle(r io.Reader) (string, error) {
+	res, err := a.client().Post(a.BaseUrl+"/api/bundles", "application/x-tar", r)
+	if err != nil {
+		return "", err
+	}
+
+	status := make(map[string]string)
+	err = json.NewDecoder(res.Body).Decode(&status)
+	if err != nil {
+		return "", err
+	}
+
+	if res.StatusCode != http.StatusCreated {
+		return "", errors.New("could not import bundle: " + status["error"])
+	}
+
+	return status["id"], nil
+}
+
+func (a *ApiClientImpl) CreateShareLink(id string, expiresIn string) (*domain.ShareLink, error) {
+	res, err := a.client().Post(a.BaseUrl+"/api/exhibits/"+id+"/share-links?expiresIn="+url.QueryEscape(expiresIn), "application/json", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(res.Body)
+
+	if res.StatusCode != http.StatusCreated {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not create share link: " + status["error"])
+	}
+
+	link := &domain.ShareLink{}
+	err = json.NewDecoder(res.Body).Decode(link)
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (a *ApiClientImpl) StartMaintenance(id string, message string) (*domain.MaintenanceLink, error) {
+	body, err := json.Marshal(map[string]string{"message": message})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, a.BaseUrl+"/api/exhibits/"+id+"/maintenance", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not start maintenance: " + status["error"])
+	}
+
+	link := &domain.MaintenanceLink{}
+	err = json.NewDecoder(res.Body).Decode(link)
+	if err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+func (a *ApiClientImpl) EndMaintenance(id string) error {
+	req, err := http.NewRequest(http.MethodDelete, a.BaseUrl+"/api/exhibits/"+id+"/maintenance", nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := a.client().Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(res.Body)
+
+	if res.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	status := make(map[string]string)
+	err = json.NewDecoder(res.Body).Decode(&status)
+	if err != nil {
+		return err
+	}
+
+	return errors.New("could not end maintenance: " + status["error"])
+}
+
+func (a *ApiClientImpl) getLogs(id string, object string, query string) (*http.Response, error) {
+	res, err := a.client().Get(a.BaseUrl + "/api/exhibits/" + id + "/objects/" + url.PathEscape(object) + "/logs" + query)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		defer func(body io.ReadCloser) {
+			_ = body.Close()
+		}(res.Body)
+
+		status := make(map[string]string)
+		err = json.NewDecoder(res.Body).Decode(&status)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("could not read logs: " + status["error"])
+	}
+
+	return res, nil
+}
+
+func (a *ApiClientImpl) GetLogs(id string, object string, w io.Writer) error {
+	res, err := a.getLogs(id, object, "")
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(res.Body)
+
+	_, err = io.Copy(w, res.Body)
+	return err
+}
+
+func (a *ApiClientImpl) FollowLogs(id string, object string, w io.Writer) error {
+	res, err := a.getLogs(id, object, "?follow=true")
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(res.Body)
+
+	return readLogEvents(res.Body, w)
+}
+
+// readLogEvents writes the lines of the log.line events of a log stream to w until the stream ends
+func readLogEvents(r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if e, ok := strings.CutPrefix(line, "event: "); ok {
+			event = e
+			continue
+		}
+
+		d, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+
+		data := make(map[string]string)
+		err := json.Unmarshal([]byte(d), &data)
+		if err != nil {
+			return err
+		}
+
+		switch event {
+		case "log.line":
+			_, err = io.WriteString(w, data["line"]+"\n")
+			if err != nil {
+				return err
+			}
+		case "log.error":
+			return errors.New("could not read logs: " + data["error"])
+		case "log.end":
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
 func (a *ApiClientImpl) GetBaseUrl() string {
 	return a.BaseUrl
 }
@@ -0,0 +1,24 @@
+package tool
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestReadLogEvents(t *testing.T) {
+	stream := "event: log.line\ndata: {\"line\":\"starting nginx\"}\n\n" +
+		"event: log.line\ndata: {\"line\":\"ready\"}\n\n" +
+		"event: log.end\ndata: {\"object\":\"web\"}\n\n" +
+		"event: log.line\ndata: {\"line\":\"never read\"}\n\n"
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, readLogEvents(strings.NewReader(stream), out))
+	assert.Equal(t, "starting nginx\nready\n", out.String())
+
+	out.Reset()
+	err := readLogEvents(strings.NewReader("event: log.line\ndata: {\"line\":\"a\"}\n\nevent: log.error\ndata: {\"error\":\"no such container\"}\n\n"), out)
+	assert.EqualError(t, err, "could not read logs: no such container")
+	assert.Equal(t, "a\n", out.String())
+}
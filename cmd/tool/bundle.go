@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"museum/domain"
+	"museum/ioc"
+	"os"
+)
+
+// ExportBundle downloads the bundle of an exhibit to filePath, or to <name>.tar (<name>-bag.tar for bags) if filePath is empty
+func ExportBundle(idOrName string, filePath string, format domain.ExhibitBundleFormat) (*domain.ExhibitDto, string, error) {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	exhibit, err := resolveExhibit(a, idOrName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if filePath == "" && format == domain.ExhibitBundleFormatBagIt {
+		filePath = exhibit.Name + "-bag.tar"
+	}
+	if filePath == "" {
+		filePath = exhibit.Name + ".tar"
+	}
+
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = a.ExportBundle(exhibit.Id, format, f)
+	if err == nil {
+		err = f.Close()
+	} else {
+		_ = f.Close()
+	}
+
+	// the server cannot report errors once it started streaming, such bundles end without a manifest
+	if err == nil {
+		last := domain.ExhibitBundleManifestPath
+		if format == domain.ExhibitBundleFormatBagIt {
+			last = exhibit.Name + "/tagmanifest-sha256.txt"
+		}
+		err = checkBundleComplete(filePath, last)
+	}
+
+	if err != nil {
+		_ = os.Remove(filePath)
+		return nil, "", err
+	}
+
+	return exhibit, filePath, nil
+}
+
+// checkBundleComplete checks that the last entry of a bundle is its manifest
+func checkBundleComplete(filePath string, manifest string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	last := ""
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return errors.New("bundle is incomplete: " + err.Error())
+		}
+		last = header.Name
+	}
+
+	if last != manifest {
+		return errors.New("bundle is incomplete, the export failed on the server")
+	}
+
+	return nil
+}
+
+// ImportBundle uploads a bundle and returns the url of the recreated exhibit
+func ImportBundle(filePath string) (*domain.ExhibitDto, string, error) {
+	c := createToolContainer()
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	a := ioc.Get[ApiClient](c)
+	id, err := a.ImportBundle(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exhibit, err := a.GetExhibitById(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return exhibit, a.GetBaseUrl() + "/exhibit/" + id, nil
+}
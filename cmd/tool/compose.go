@@ -0,0 +1,595 @@
+package tool
+
+import (
+	"bytes"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"museum/domain"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// composeServiceKeys are the keys of a compose service that are translated into an exhibit object
+var composeServiceKeys = []string{"image", "environment", "depends_on", "healthcheck", "volumes", "ports"}
+
+// composeIgnoredKeys have no meaning in an exhibit, they are dropped without a warning:
+// museum manages the lifecycle, names and network of objects itself
+var composeIgnoredKeys = []string{"container_name", "hostname", "restart", "networks", "expose"}
+
+var composeNameReplacer = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+type composeService struct {
+	Image       string              `yaml:"image"`
+	Environment yaml.Node           `yaml:"environment"`
+	DependsOn   yaml.Node           `yaml:"depends_on"`
+	Healthcheck *composeHealthcheck `yaml:"healthcheck"`
+	Volumes     []yaml.Node         `yaml:"volumes"`
+	Ports       []yaml.Node         `yaml:"ports"`
+}
+
+type composeHealthcheck struct {
+	Test          yaml.Node `yaml:"test"`
+	Interval      string    `yaml:"interval"`
+	Timeout       string    `yaml:"timeout"`
+	Retries       *int      `yaml:"retries"`
+	StartPeriod   string    `yaml:"start_period"`
+	StartInterval string    `yaml:"start_interval"`
+	Disable       bool      `yaml:"disable"`
+}
+
+type composeVolume struct {
+	Type   string `yaml:"type"`
+	Source string `yaml:"source"`
+	Target string `yaml:"target"`
+}
+
+type composePort struct {
+	Target   int    `yaml:"target"`
+	Protocol string `yaml:"protocol"`
+}
+
+// composeImport collects the exhibit translated from a compose file and everything that could not be translated
+type composeImport struct {
+	dir      string
+	exhibit  domain.Exhibit
+	warnings []string
+	// dependencies of every service in the order of the file
+	services     []string
+	dependencies map[string][]string
+	// patterns matching a service name as a host, in the order of services
+	hosts []*regexp.Regexp
+	// published ports by service, the first one is exposed
+	ports map[string][]string
+}
+
+func (c *composeImport) warn(message string) {
+	c.warnings = append(c.warnings, message)
+}
+
+// ImportCompose translates a docker compose file into an exhibit, the returned warnings list everything
+// that could not be translated and has to be checked by hand
+func ImportCompose(filePath string) (domain.Exhibit, []string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return domain.Exhibit{}, nil, err
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(filePath))
+	if err != nil {
+		return domain.Exhibit{}, nil, err
+	}
+
+	exhibit, warnings, err := convertCompose(content, dir)
+	if err != nil {
+		return domain.Exhibit{}, nil, err
+	}
+
+	// what is left to do by hand makes the exhibit invalid most of the time
+	for _, p := range exhibit.Validate() {
+		warnings = append(warnings, "exhibit is not valid yet: "+p.Error())
+	}
+
+	return exhibit, warnings, nil
+}
+
+// convertCompose translates a compose file, relative bind mounts are resolved against dir
+func convertCompose(content []byte, dir string) (domain.Exhibit, []string, error) {
+	root := yaml.Node{}
+	err := yaml.Unmarshal(content, &root)
+	if err != nil {
+		return domain.Exhibit{}, nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return domain.Exhibit{}, nil, errors.New("compose file must be a mapping")
+	}
+
+	c := &composeImport{
+		dir: dir,
+		exhibit: domain.Exhibit{
+			Spec:    domain.ExhibitSpecV1,
+			Lease:   "1h",
+			Objects: make([]domain.Object, 0),
+		},
+		warnings:     make([]string, 0),
+		services:     make([]string, 0),
+		dependencies: make(map[string][]string),
+		ports:        make(map[string][]string),
+	}
+
+	var services *yaml.Node
+	document := root.Content[0]
+	for i := 0; i+1 < len(document.Content); i += 2 {
+		key, value := document.Content[i].Value, document.Content[i+1]
+		switch key {
+		case "services":
+			services = value
+		case "name":
+			c.exhibit.Name = value.Value
+		case "volumes":
+			c.checkVolumeDefinitions(value)
+		case "version", "networks":
+			// the version is obsolete, networks are replaced by the network of the exhibit
+		default:
+			c.warn("top-level " + key + " is not supported and was left out")
+		}
+	}
+
+	if services == nil || services.Kind != yaml.MappingNode || len(services.Content) == 0 {
+		return domain.Exhibit{}, nil, errors.New("compose file has no services")
+	}
+
+	if c.exhibit.Name == "" {
+		c.exhibit.Name = filepath.Base(dir)
+	}
+	c.exhibit.Name = strings.Trim(composeNameReplacer.ReplaceAllString(c.exhibit.Name, "-"), "-_.")
+	if c.exhibit.Name == "" {
+		c.exhibit.Name = "exhibit"
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		c.services = append(c.services, services.Content[i].Value)
+		c.hosts = append(c.hosts, regexp.MustCompile(`(^|[^\w.-])`+regexp.QuoteMeta(services.Content[i].Value)+`([^\w.-]|$)`))
+	}
+
+	for i := 0; i+1 < len(services.Content); i += 2 {
+		err = c.convertService(services.Content[i].Value, services.Content[i+1])
+		if err != nil {
+			return domain.Exhibit{}, nil, err
+		}
+	}
+
+	c.exhibit.Order, err = c.startOrder()
+	if err != nil {
+		return domain.Exhibit{}, nil, err
+	}
+
+	c.expose()
+
+	return c.exhibit, c.warnings, nil
+}
+
+func (c *composeImport) checkVolumeDefinitions(volumes *yaml.Node) {
+	definitions := make(map[string]struct {
+		Driver   string `yaml:"driver"`
+		External bool   `yaml:"external"`
+	})
+	if err := volumes.Decode(&definitions); err != nil {
+		c.warn("top-level volumes could not be read: " + err.Error())
+		return
+	}
+
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if definition := definitions[name]; definition.Driver != "" || definition.External {
+			c.warn("volume " + name + " uses a volume driver or is external, it is translated to a local volume")
+		}
+	}
+}
+
+func (c *composeImport) convertService(name string, node *yaml.Node) error {
+	service := composeService{}
+	err := node.Decode(&service)
+	if err != nil {
+		return errors.New("service " + name + " could not be read: " + err.Error())
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		if !slices.Contains(composeServiceKeys, key) && !slices.Contains(composeIgnoredKeys, key) {
+			c.warn("service " + name + ": " + key + " is not supported and was left out")
+		}
+	}
+
+	object := domain.Object{Name: name}
+
+	object.Image, object.Label = splitImage(service.Image)
+	if service.Image == "" {
+		c.warn("service " + name + ": has no image, building images is not supported, the image of object " + name + " has to be set")
+	} else if strings.Contains(service.Image, "@") {
+		c.warn("service " + name + ": image digests are not supported, the label of object " + name + " has to be set")
+	}
+
+	object.Environment = c.convertEnvironment(name, &service.Environment)
+	object.Livecheck = c.convertHealthcheck(name, service.Healthcheck)
+	object.Mounts = c.convertVolumes(name, service.Volumes)
+	c.dependencies[name] = c.convertDependsOn(name, &service.DependsOn)
+	c.ports[name] = c.convertPorts(name, service.Ports)
+
+	c.exhibit.Objects = append(c.exhibit.Objects, object)
+
+	return nil
+}
+
+// splitImage splits an image reference into the image and its label, the tag follows the last colon after the registry
+func splitImage(image string) (string, string) {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		return image[:i], image[i+1:]
+	}
+
+	return image, "latest"
+}
+
+func (c *composeImport) convertEnvironment(service string, node *yaml.Node) domain.StringMap {
+	environment := make(domain.StringMap)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Tag == "!!null" {
+				c.warn("service " + service + ": environment variable " + key.Value + " is taken from the host and was left out")
+				continue
+			}
+			environment[key.Value] = value.Value
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			key, value, ok := strings.Cut(item.Value, "=")
+			if !ok {
+				c.warn("service " + service + ": environment variable " + key + " is taken from the host and was left out")
+				continue
+			}
+			environment[key] = value
+		}
+	}
+
+	// services reach each other by their service name, objects by the name museum gives their container
+	keys := make([]string, 0, len(environment))
+	for key := range environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := environment[key]
+		for i, other := range c.services {
+			host := c.hosts[i]
+			if host.MatchString(value) {
+				value = host.ReplaceAllString(value, "${1}{{ @"+other+" }}${2}")
+				c.warn("service " + service + ": environment variable " + key + " refers to service " + other + ", it was replaced by {{ @" + other + " }}")
+			}
+		}
+
+		if strings.Contains(value, "$") {
+			c.warn("service " + service + ": environment variable " + key + " uses variable interpolation, which is not resolved")
+		}
+
+		environment[key] = value
+	}
+
+	if len(environment) == 0 {
+		return nil
+	}
+	return environment
+}
+
+func (c *composeImport) convertHealthcheck(service string, healthcheck *composeHealthcheck) *domain.Livecheck {
+	if healthcheck == nil || healthcheck.Disable {
+		return nil
+	}
+
+	command := ""
+	switch healthcheck.Test.Kind {
+	case yaml.ScalarNode:
+		command = healthcheck.Test.Value
+	case yaml.SequenceNode:
+		test := make([]string, 0)
+		for _, item := range healthcheck.Test.Content {
+			test = append(test, item.Value)
+		}
+
+		switch {
+		case len(test) == 0 || test[0] == "NONE":
+			return nil
+		case test[0] == "CMD-SHELL":
+			command = strings.Join(test[1:], " ")
+		case test[0] == "CMD":
+			quoted := make([]string, 0, len(test)-1)
+			for _, arg := range test[1:] {
+				quoted = append(quoted, shellQuote(arg))
+			}
+			command = strings.Join(quoted, " ")
+		default:
+			c.warn("service " + service + ": healthcheck test " + test[0] + " is not supported and was left out")
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	livecheck := &domain.Livecheck{
+		Type:   domain.LivecheckTypeExec,
+		Config: domain.StringMap{"command": command},
+	}
+
+	if healthcheck.Interval != "" {
+		livecheck.Config["interval"] = healthcheck.Interval
+	}
+	if healthcheck.Retries != nil {
+		livecheck.Config["maxRetries"] = strconv.Itoa(*healthcheck.Retries)
+	}
+	if healthcheck.Timeout != "" || healthcheck.StartPeriod != "" || healthcheck.StartInterval != "" {
+		c.warn("service " + service + ": healthcheck timeout, start_period and start_interval are not supported and were left out")
+	}
+
+	return livecheck
+}
+
+// shellQuote quotes an argument of an exec form command, so that it can be run by sh -c
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`!*?&|;<>()[]{}#~") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+func (c *composeImport) convertVolumes(service string, nodes []yaml.Node) domain.StringMap {
+	mounts := make(domain.StringMap)
+
+	for _, node := range nodes {
+		volume := composeVolume{}
+		if node.Kind == yaml.ScalarNode {
+			parts := strings.Split(node.Value, ":")
+			volume.Target = parts[0]
+			if len(parts) > 1 {
+				volume.Source, volume.Target = parts[0], parts[1]
+			}
+		} else if err := node.Decode(&volume); err != nil {
+			c.warn("service " + service + ": volume could not be read: " + err.Error())
+			continue
+		}
+
+		bind := strings.HasPrefix(volume.Source, ".") || strings.HasPrefix(volume.Source, "/") || strings.HasPrefix(volume.Source, "~")
+		if volume.Type == "" {
+			volume.Type = "volume"
+			if bind {
+				volume.Type = "bind"
+			}
+		}
+
+		if volume.Type != "volume" && volume.Type != "bind" {
+			c.warn("service " + service + ": " + volume.Type + " mount at " + volume.Target + " is not supported and was left out")
+			continue
+		}
+
+		if volume.Source == "" {
+			c.warn("service " + service + ": anonymous volume at " + volume.Target + " was left out, its data is not kept anyway")
+			continue
+		}
+
+		name := volume.Source
+		path := ""
+		if volume.Type == "bind" {
+			path = volume.Source
+			if strings.HasPrefix(path, "~") {
+				c.warn("service " + service + ": bind mount " + path + " is relative to the home directory, the path of its volume has to be checked")
+			} else if !filepath.IsAbs(path) {
+				path = filepath.Join(c.dir, path)
+			}
+			name = c.bindVolumeName(path)
+		}
+
+		if _, ok := mounts[name]; ok {
+			c.warn("service " + service + ": volume " + name + " is mounted more than once, only " + mounts[name] + " was kept")
+			continue
+		}
+		mounts[name] = volume.Target
+
+		c.addVolume(name, path)
+	}
+
+	if len(mounts) == 0 {
+		return nil
+	}
+	return mounts
+}
+
+// bindVolumeName names the volume of a bind mount after its directory, the same path always gets the same volume
+func (c *composeImport) bindVolumeName(path string) string {
+	base := strings.Trim(composeNameReplacer.ReplaceAllString(filepath.Base(path), "-"), "-")
+	if base == "" {
+		base = "data"
+	}
+
+	name := base
+	for i := 2; ; i++ {
+		existing, ok := c.findVolume(name)
+		if !ok || existing.Driver.Config["path"] == path {
+			return name
+		}
+		name = base + "-" + strconv.Itoa(i)
+	}
+}
+
+func (c *composeImport) findVolume(name string) (domain.Volume, bool) {
+	for _, v := range c.exhibit.Volumes {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return domain.Volume{}, false
+}
+
+func (c *composeImport) addVolume(name string, path string) {
+	if _, ok := c.findVolume(name); ok {
+		return
+	}
+
+	if path == "" {
+		c.warn("volume " + name + " is a named volume, the path of its local driver has to be set")
+	}
+
+	c.exhibit.Volumes = append(c.exhibit.Volumes, domain.Volume{
+		Name:   name,
+		Driver: domain.Driver{Type: "local", Config: domain.StringMap{"path": path}},
+	})
+}
+
+func (c *composeImport) convertDependsOn(service string, node *yaml.Node) []string {
+	dependencies := make([]string, 0)
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			dependencies = append(dependencies, item.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			dependencies = append(dependencies, node.Content[i].Value)
+		}
+	}
+
+	known := make([]string, 0, len(dependencies))
+	for _, d := range dependencies {
+		if !slices.Contains(c.services, d) {
+			c.warn("service " + service + ": depends on unknown service " + d)
+			continue
+		}
+		known = append(known, d)
+	}
+
+	return known
+}
+
+// convertPorts returns the container ports a service publishes
+func (c *composeImport) convertPorts(service string, nodes []yaml.Node) []string {
+	ports := make([]string, 0)
+
+	for _, node := range nodes {
+		port := composePort{}
+		if node.Kind == yaml.ScalarNode {
+			spec, protocol, _ := strings.Cut(node.Value, "/")
+			parts := strings.Split(spec, ":")
+			target, err := strconv.Atoi(parts[len(parts)-1])
+			if err != nil {
+				c.warn("service " + service + ": port " + node.Value + " is not supported and was left out")
+				continue
+			}
+			port = composePort{Target: target, Protocol: protocol}
+		} else if err := node.Decode(&port); err != nil {
+			c.warn("service " + service + ": port could not be read: " + err.Error())
+			continue
+		}
+
+		if port.Protocol != "" && port.Protocol != "tcp" {
+			c.warn("service " + service + ": " + port.Protocol + " port " + strconv.Itoa(port.Target) + " is not supported and was left out")
+			continue
+		}
+
+		ports = append(ports, strconv.Itoa(port.Target))
+	}
+
+	return ports
+}
+
+// startOrder sorts the services so that every service starts after its dependencies, otherwise keeping the order of the file
+func (c *composeImport) startOrder() ([]string, error) {
+	order := make([]string, 0, len(c.services))
+	started := make(map[string]bool)
+
+	for len(order) < len(c.services) {
+		progress := false
+		for _, service := range c.services {
+			if started[service] {
+				continue
+			}
+
+			ready := true
+			for _, d := range c.dependencies[service] {
+				ready = ready && started[d]
+			}
+
+			if ready {
+				order = append(order, service)
+				started[service] = true
+				progress = true
+				break
+			}
+		}
+
+		if !progress {
+			return nil, errors.New("services depend on each other in a cycle, they cannot be ordered")
+		}
+	}
+
+	return order, nil
+}
+
+// expose exposes the first service that publishes a port, an exhibit can only expose a single port
+func (c *composeImport) expose() {
+	for _, service := range c.services {
+		ports := c.ports[service]
+		if len(ports) == 0 {
+			continue
+		}
+
+		if c.exhibit.Expose == "" {
+			c.exhibit.Expose = service
+			for i := range c.exhibit.Objects {
+				if c.exhibit.Objects[i].Name == service {
+					c.exhibit.Objects[i].Port = &ports[0]
+				}
+			}
+			ports = ports[1:]
+		}
+
+		for _, port := range ports {
+			c.warn("service " + service + ": port " + port + " is not exposed, an exhibit exposes a single port of a single object")
+		}
+	}
+
+	if c.exhibit.Expose == "" {
+		c.exhibit.Expose = c.services[0]
+		c.warn("no service publishes a port, " + c.services[0] + " is exposed on port 80")
+	}
+}
+
+// MarshalExhibit writes an exhibit file indented like the examples
+func MarshalExhibit(exhibit domain.Exhibit) ([]byte, error) {
+	b := bytes.Buffer{}
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	err := encoder.Encode(exhibit)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
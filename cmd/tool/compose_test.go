@@ -0,0 +1,152 @@
+package tool
+
+import (
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+)
+
+const wordpressCompose = `
+name: My Blog
+services:
+  wordpress:
+    image: wordpress:6.4-apache
+    restart: always
+    ports:
+      - "8080:80"
+    environment:
+      WORDPRESS_DB_HOST: db:3306
+      WORDPRESS_DB_PASSWORD: ${DB_PASSWORD}
+      WORDPRESS_DEBUG:
+    volumes:
+      - ./wp-content:/var/www/html/wp-content
+    depends_on:
+      db:
+        condition: service_healthy
+  db:
+    image: registry.example.org:5000/mariadb:11
+    command: --innodb-buffer-pool-size=256M
+    environment:
+      - MARIADB_ROOT_PASSWORD=secret
+      - MARIADB_DATABASE
+    healthcheck:
+      test: ["CMD", "healthcheck.sh", "--connect", "--innodb_initialized"]
+      interval: 10s
+      retries: 5
+      timeout: 5s
+    volumes:
+      - db_data:/var/lib/mysql
+      - /tmp
+volumes:
+  db_data:
+`
+
+func TestConvertCompose(t *testing.T) {
+	exhibit, warnings, err := convertCompose([]byte(wordpressCompose), "/srv/blog")
+	assert.NoError(t, err)
+
+	port := "80"
+	assert.Equal(t, domain.Exhibit{
+		Spec:   domain.ExhibitSpecV1,
+		Name:   "My-Blog",
+		Expose: "wordpress",
+		Lease:  "1h",
+		Objects: []domain.Object{
+			{
+				Name:  "wordpress",
+				Image: "wordpress",
+				Label: "6.4-apache",
+				Environment: domain.StringMap{
+					"WORDPRESS_DB_HOST":     "{{ @db }}:3306",
+					"WORDPRESS_DB_PASSWORD": "${DB_PASSWORD}",
+				},
+				Mounts: domain.StringMap{"wp-content": "/var/www/html/wp-content"},
+				Port:   &port,
+			},
+			{
+				Name:        "db",
+				Image:       "registry.example.org:5000/mariadb",
+				Label:       "11",
+				Environment: domain.StringMap{"MARIADB_ROOT_PASSWORD": "secret"},
+				Livecheck: &domain.Livecheck{
+					Type:   domain.LivecheckTypeExec,
+					Config: domain.StringMap{"command": "healthcheck.sh --connect --innodb_initialized", "interval": "10s", "maxRetries": "5"},
+				},
+				Mounts: domain.StringMap{"db_data": "/var/lib/mysql"},
+			},
+		},
+		Order: []string{"db", "wordpress"},
+		Volumes: []domain.Volume{
+			{Name: "wp-content", Driver: domain.Driver{Type: "local", Config: domain.StringMap{"path": "/srv/blog/wp-content"}}},
+			{Name: "db_data", Driver: domain.Driver{Type: "local", Config: domain.StringMap{"path": ""}}},
+		},
+	}, exhibit)
+
+	assert.ElementsMatch(t, []string{
+		"service wordpress: environment variable WORDPRESS_DEBUG is taken from the host and was left out",
+		"service wordpress: environment variable WORDPRESS_DB_HOST refers to service db, it was replaced by {{ @db }}",
+		"service wordpress: environment variable WORDPRESS_DB_PASSWORD uses variable interpolation, which is not resolved",
+		"service db: command is not supported and was left out",
+		"service db: environment variable MARIADB_DATABASE is taken from the host and was left out",
+		"service db: healthcheck timeout, start_period and start_interval are not supported and were left out",
+		"service db: anonymous volume at /tmp was left out, its data is not kept anyway",
+		"volume db_data is a named volume, the path of its local driver has to be set",
+	}, warnings)
+}
+
+func TestConvertComposeWithoutPorts(t *testing.T) {
+	exhibit, warnings, err := convertCompose([]byte(`
+services:
+  worker:
+    image: busybox
+    healthcheck:
+      test: pgrep sleep
+  shell:
+    build: .
+    healthcheck:
+      test: ["CMD", "sh", "-c", "echo 'ready'"]
+`), "/srv/my-app")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "my-app", exhibit.Name)
+	assert.Equal(t, "worker", exhibit.Expose)
+	assert.Equal(t, "pgrep sleep", exhibit.Objects[0].Livecheck.Config["command"])
+	assert.Equal(t, `sh -c 'echo '\''ready'\'''`, exhibit.Objects[1].Livecheck.Config["command"])
+	assert.Contains(t, warnings, "no service publishes a port, worker is exposed on port 80")
+	assert.Contains(t, warnings, "service shell: build is not supported and was left out")
+}
+
+func TestConvertComposeRejectsCycles(t *testing.T) {
+	_, _, err := convertCompose([]byte(`
+services:
+  a:
+    image: a
+    depends_on: [b]
+  b:
+    image: b
+    depends_on: [a]
+`), "/srv")
+	assert.Error(t, err)
+}
+
+func TestConvertComposeWarnsInOrder(t *testing.T) {
+	_, warnings, err := convertCompose([]byte(`
+services:
+  app:
+    image: app
+volumes:
+  logs:
+    external: true
+  data:
+    driver: nfs
+  cache:
+    driver: tmpfs
+`), "/srv")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"volume cache uses a volume driver or is external, it is translated to a local volume",
+		"volume data uses a volume driver or is external, it is translated to a local volume",
+		"volume logs uses a volume driver or is external, it is translated to a local volume",
+	}, warnings[:3])
+}
@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"encoding/json"
+	"gopkg.in/yaml.v3"
+	"museum/domain"
+	"museum/ioc"
+	"os"
+	"strings"
+)
+
+// isYamlFile decides the bundle format by the file extension, everything else is JSON
+func isYamlFile(filePath string) bool {
+	return strings.HasSuffix(filePath, ".yaml") || strings.HasSuffix(filePath, ".yml")
+}
+
+// ExportState writes a bundle of all exhibits to filePath, or returns it as JSON if filePath is empty
+func ExportState(filePath string) (*domain.StateBundle, []byte, error) {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	bundle, err := a.ExportState()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var b []byte
+	if isYamlFile(filePath) {
+		b, err = yaml.Marshal(bundle)
+	} else {
+		b, err = json.MarshalIndent(bundle, "", "  ")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if filePath == "" {
+		return bundle, b, nil
+	}
+
+	return bundle, nil, os.WriteFile(filePath, b, 0600)
+}
+
+func ImportState(filePath string, mode domain.ImportConflictMode) (*domain.StateImportResult, error) {
+	c := createToolContainer()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &domain.StateBundle{}
+	if isYamlFile(filePath) {
+		err = yaml.Unmarshal(content, bundle)
+	} else {
+		err = json.Unmarshal(content, bundle)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	a := ioc.Get[ApiClient](c)
+	return a.ImportState(bundle, mode)
+}
+
+// Migrate rewrites all stored exhibits in the schema version of the running museum
+func Migrate() (*domain.MigrationResult, error) {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	return a.MigrateState()
+}
@@ -1,10 +1,14 @@
 package tool
 
 import (
+	"errors"
+	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
+	"io"
 	"museum/domain"
 	"museum/ioc"
 	"os"
+	"strings"
 )
 
 func createToolContainer() *ioc.Container {
@@ -12,6 +16,7 @@ func createToolContainer() *ioc.Container {
 	ioc.RegisterSingleton[ApiClient](c, func() ApiClient {
 		return &ApiClientImpl{
 			BaseUrl: "http://localhost:8080",
+			Token:   os.Getenv("MUSEUM_TOKEN"),
 		}
 	})
 	return c
@@ -47,11 +52,25 @@ func Create(filePath string) (*domain.Exhibit, string, error) {
 	return exhibit, a.GetBaseUrl() + "/exhibit/" + id, nil
 }
 
-func Delete(id string) error {
+// resolveExhibit looks up an exhibit either by its id or by its name
+func resolveExhibit(a ApiClient, idOrName string) (*domain.ExhibitDto, error) {
+	if _, err := uuid.Parse(idOrName); err == nil {
+		return a.GetExhibitById(idOrName)
+	}
+
+	return a.GetExhibitByName(idOrName)
+}
+
+func Delete(idOrName string) error {
 	c := createToolContainer()
 
 	a := ioc.Get[ApiClient](c)
-	err := a.DeleteExhibitById(id)
+	exhibit, err := resolveExhibit(a, idOrName)
+	if err != nil {
+		return err
+	}
+
+	err = a.DeleteExhibitById(exhibit.Id)
 	if err != nil {
 		return err
 	}
@@ -59,11 +78,11 @@ func Delete(id string) error {
 	return nil
 }
 
-func Warmup(id string) (string, error) {
+func Warmup(idOrName string) (string, error) {
 	c := createToolContainer()
 
 	a := ioc.Get[ApiClient](c)
-	exhibit, err := a.GetExhibitById(id)
+	exhibit, err := resolveExhibit(a, idOrName)
 	if err != nil {
 		return "", err
 	}
@@ -78,7 +97,7 @@ func Warmup(id string) (string, error) {
 		return "", err
 	}
 
-	return a.GetBaseUrl() + "/exhibit/" + id, nil
+	return a.GetBaseUrl() + "/exhibit/" + exhibit.Id, nil
 }
 
 func List() (string, []domain.ExhibitDto, error) {
@@ -92,3 +111,70 @@ func List() (string, []domain.ExhibitDto, error) {
 
 	return a.GetBaseUrl(), exhibits, nil
 }
+
+// Share creates a link that grants access to an exhibit for expiresIn, whatever its access policy is
+func Share(idOrName string, expiresIn string) (*domain.ShareLink, error) {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	exhibit, err := resolveExhibit(a, idOrName)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.CreateShareLink(exhibit.Id, expiresIn)
+}
+
+// Maintenance keeps visitors out of an exhibit and returns the link that lets curators in
+func Maintenance(idOrName string, message string) (*domain.MaintenanceLink, error) {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	exhibit, err := resolveExhibit(a, idOrName)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.StartMaintenance(exhibit.Id, message)
+}
+
+// EndMaintenance lets visitors into an exhibit again
+func EndMaintenance(idOrName string) error {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	exhibit, err := resolveExhibit(a, idOrName)
+	if err != nil {
+		return err
+	}
+
+	return a.EndMaintenance(exhibit.Id)
+}
+
+// Logs writes the logs of an object of an exhibit to w, the object may be left out if the exhibit has only one.
+// With follow, the logs are written as the object writes them until it stops.
+func Logs(idOrName string, object string, follow bool, w io.Writer) error {
+	c := createToolContainer()
+
+	a := ioc.Get[ApiClient](c)
+	exhibit, err := resolveExhibit(a, idOrName)
+	if err != nil {
+		return err
+	}
+
+	if object == "" {
+		if len(exhibit.Objects) != 1 {
+			names := make([]string, 0, len(exhibit.Objects))
+			for _, o := range exhibit.Objects {
+				names = append(names, o.Name)
+			}
+			return errors.New("exhibit " + exhibit.Name + " has several objects, name one of " + strings.Join(names, ", "))
+		}
+		object = exhibit.Objects[0].Name
+	}
+
+	if follow {
+		return a.FollowLogs(exhibit.Id, object, w)
+	}
+	return a.GetLogs(exhibit.Id, object, w)
+}
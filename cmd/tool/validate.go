@@ -0,0 +1,40 @@
+package tool
+
+import (
+	"encoding/json"
+	"museum/domain"
+	"museum/service"
+	"museum/util/schema"
+	"os"
+)
+
+// Validate checks an exhibit file offline, it runs all checks that do not depend on the host museum runs on
+func Validate(filePath string) (domain.ValidationResult, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return domain.ValidationResult{}, err
+	}
+
+	file := domain.ParseExhibitFile(content)
+	file.AddProblems(file.Exhibit.Validate())
+
+	// the configuration of volume drivers can only be checked on the host, but the driver has to exist
+	factory := service.NewVolumeProvisionerFactoryService()
+	for i, v := range file.Exhibit.Volumes {
+		if _, err := factory.GetForDriverType(v.Driver.Type); err != nil {
+			file.AddProblems([]domain.ValidationProblem{{Pointer: schema.Pointer("volumes", i, "driver", "type"), Message: err.Error()}})
+		}
+	}
+
+	return file.Result(), nil
+}
+
+// Schema returns the JSON Schema of exhibit files
+func Schema() ([]byte, error) {
+	b, err := json.MarshalIndent(domain.ExhibitSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(b, '\n'), nil
+}
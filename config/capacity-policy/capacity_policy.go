@@ -0,0 +1,11 @@
+package capacityPolicy
+
+// Policy decides what happens to an exhibit that should start while the cluster is full
+type Policy string
+
+const (
+	// PolicyQueue lets the exhibit wait until another exhibit stops
+	PolicyQueue Policy = "queue"
+	// PolicyEvict stops the least recently accessed running exhibit to make room
+	PolicyEvict Policy = "evict"
+)
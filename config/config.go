@@ -1,6 +1,10 @@
 package config
 
-import proxymode "museum/config/proxy-mode"
+import (
+	capacitypolicy "museum/config/capacity-policy"
+	proxymode "museum/config/proxy-mode"
+	statebackend "museum/config/state-backend"
+)
 
 type Config interface {
 	GetEtcdHost() string
@@ -16,4 +20,34 @@ type Config interface {
 	GetCertFile() string
 	GetKeyFile() string
 	GetStartingTimeout() int
+	GetHoldTimeout() int
+	GetStateBackend() statebackend.Backend
+	GetBoltPath() string
+	GetLockTimeout() int
+	GetBundleVolumePath() string
+	GetFixityInterval() int
+	GetPublicUrl() string
+	GetPidPrefix() string
+	GetPublisher() string
+	GetAdminEmail() string
+	GetCatalogueTitle() string
+	GetCatalogueIntro() string
+	GetCatalogueLogo() string
+	GetCatalogueColor() string
+	GetCatalogueHidden() []string
+	GetApiTokens() []string
+	GetOidcIssuer() string
+	GetOidcAudience() string
+	GetOidcJwksUrl() string
+	GetOidcRolesClaim() string
+	GetOidcGroupsClaim() string
+	GetOidcClientId() string
+	GetOidcClientSecret() string
+	GetOidcScopes() []string
+	GetAccessSecret() string
+	GetCollectionsFile() string
+	GetMaxRunningExhibits() int
+	GetMaxRunningMemory() string
+	GetCapacityPolicy() capacitypolicy.Policy
+	GetPagesDir() string
 }
@@ -1,23 +1,56 @@
 package impl
 
 import (
+	capacitypolicy "museum/config/capacity-policy"
 	proxymode "museum/config/proxy-mode"
+	statebackend "museum/config/state-backend"
+	"strings"
 )
 
 type EnvConfig struct {
-	EtcdHost        string `env:"ETCD_HOST,required"`
-	EtcdBaseKey     string `env:"ETCD_BASE_KEY" envDefault:"museum"`
-	NatsHost        string `env:"NATS_HOST"`
-	NatsBaseKey     string `env:"NATS_BASE_KEY" envDefault:"museum"`
-	DockerHost      string `env:"DOCKER_HOST" envDefault:"unix:///var/run/docker.sock"`
-	Hostname        string `env:"HOSTNAME" envDefault:"localhost"`
-	Port            string `env:"PORT" envDefault:"8080"`
-	JaegerHost      string `env:"JAEGER_HOST"`
-	Environment     string `env:"ENVIRONMENT" envDefault:"development"`
-	ProxyMode       string `env:"PROXY_MODE" envDefault:"swarm-ext"`
-	CertFile        string `env:"CERT_FILE"`
-	KeyFile         string `env:"KEY_FILE"`
-	StartingTimeout int    `env:"STARTING_TIMEOUT" envDefault:"280"`
+	EtcdHost         string   `env:"ETCD_HOST"`
+	EtcdBaseKey      string   `env:"ETCD_BASE_KEY" envDefault:"museum"`
+	NatsHost         string   `env:"NATS_HOST"`
+	NatsBaseKey      string   `env:"NATS_BASE_KEY" envDefault:"museum"`
+	DockerHost       string   `env:"DOCKER_HOST" envDefault:"unix:///var/run/docker.sock"`
+	Hostname         string   `env:"HOSTNAME" envDefault:"localhost"`
+	Port             string   `env:"PORT" envDefault:"8080"`
+	JaegerHost       string   `env:"JAEGER_HOST"`
+	Environment      string   `env:"ENVIRONMENT" envDefault:"development"`
+	ProxyMode        string   `env:"PROXY_MODE" envDefault:"swarm-ext"`
+	CertFile         string   `env:"CERT_FILE"`
+	KeyFile          string   `env:"KEY_FILE"`
+	StartingTimeout  int      `env:"STARTING_TIMEOUT" envDefault:"280"`
+	HoldTimeout      int      `env:"HOLD_TIMEOUT" envDefault:"60"`
+	StateBackend     string   `env:"STATE_BACKEND" envDefault:"etcd"`
+	BoltPath         string   `env:"BOLT_PATH" envDefault:"museum.db"`
+	LockTimeout      int      `env:"LOCK_TIMEOUT" envDefault:"30"`
+	BundleVolumePath string   `env:"BUNDLE_VOLUME_PATH" envDefault:"volumes"`
+	FixityInterval   int      `env:"FIXITY_INTERVAL" envDefault:"24"`
+	PublicUrl        string   `env:"PUBLIC_URL"`
+	PidPrefix        string   `env:"PID_PREFIX" envDefault:"urn:uuid:"`
+	Publisher        string   `env:"PUBLISHER"`
+	AdminEmail       string   `env:"ADMIN_EMAIL"`
+	CatalogueTitle   string   `env:"CATALOGUE_TITLE"`
+	CatalogueIntro   string   `env:"CATALOGUE_INTRO"`
+	CatalogueLogo    string   `env:"CATALOGUE_LOGO"`
+	CatalogueColor   string   `env:"CATALOGUE_COLOR" envDefault:"#3498db"`
+	CatalogueHidden  []string `env:"CATALOGUE_HIDDEN" envSeparator:","`
+	ApiTokens        []string `env:"API_TOKENS" envSeparator:","`
+	OidcIssuer       string   `env:"OIDC_ISSUER"`
+	OidcAudience     string   `env:"OIDC_AUDIENCE"`
+	OidcJwksUrl      string   `env:"OIDC_JWKS_URL"`
+	OidcRolesClaim   string   `env:"OIDC_ROLES_CLAIM" envDefault:"roles"`
+	OidcGroupsClaim  string   `env:"OIDC_GROUPS_CLAIM" envDefault:"groups"`
+	OidcClientId     string   `env:"OIDC_CLIENT_ID"`
+	OidcClientSecret string   `env:"OIDC_CLIENT_SECRET"`
+	OidcScopes       []string `env:"OIDC_SCOPES" envDefault:"openid profile" envSeparator:" "`
+	AccessSecret     string   `env:"ACCESS_SECRET"`
+	CollectionsFile  string   `env:"COLLECTIONS_FILE"`
+	MaxRunning       int      `env:"MAX_RUNNING_EXHIBITS"`
+	MaxRunningMemory string   `env:"MAX_RUNNING_MEMORY"`
+	CapacityPolicy   string   `env:"CAPACITY_POLICY" envDefault:"queue"`
+	PagesDir         string   `env:"PAGES_DIR"`
 }
 
 func (e EnvConfig) GetEtcdHost() string {
@@ -78,3 +111,173 @@ func (e EnvConfig) GetKeyFile() string {
 func (e EnvConfig) GetStartingTimeout() int {
 	return e.StartingTimeout
 }
+
+func (e EnvConfig) GetHoldTimeout() int {
+	return e.HoldTimeout
+}
+
+func (e EnvConfig) GetStateBackend() statebackend.Backend {
+	switch e.StateBackend {
+	case "etcd":
+		if e.EtcdHost == "" {
+			panic("ETCD_HOST is required when using the etcd state backend")
+		}
+		return statebackend.BackendEtcd
+	case "bolt":
+		return statebackend.BackendBolt
+	default:
+		panic("invalid state backend " + e.StateBackend)
+	}
+}
+
+func (e EnvConfig) GetBoltPath() string {
+	return e.BoltPath
+}
+
+func (e EnvConfig) GetLockTimeout() int {
+	return e.LockTimeout
+}
+
+func (e EnvConfig) GetBundleVolumePath() string {
+	return e.BundleVolumePath
+}
+
+func (e EnvConfig) GetFixityInterval() int {
+	return e.FixityInterval
+}
+
+// GetPublicUrl returns the url mūsēum is reached at by visitors, it defaults to the hostname and port
+func (e EnvConfig) GetPublicUrl() string {
+	if e.PublicUrl != "" {
+		return strings.TrimSuffix(e.PublicUrl, "/")
+	}
+
+	scheme := "http"
+	if e.CertFile != "" && e.KeyFile != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + e.Hostname + ":" + e.Port
+}
+
+func (e EnvConfig) GetPidPrefix() string {
+	return e.PidPrefix
+}
+
+// GetPublisher returns the institution exhibits are published by in citations, it defaults to the hostname
+func (e EnvConfig) GetPublisher() string {
+	if e.Publisher != "" {
+		return e.Publisher
+	}
+	return e.Hostname
+}
+
+// GetAdminEmail returns the contact of the repository for harvesters, it defaults to museum@ the hostname
+func (e EnvConfig) GetAdminEmail() string {
+	if e.AdminEmail != "" {
+		return e.AdminEmail
+	}
+	return "museum@" + e.Hostname
+}
+
+// GetCatalogueTitle returns the heading of the public catalogue, it defaults to the publisher
+func (e EnvConfig) GetCatalogueTitle() string {
+	if e.CatalogueTitle != "" {
+		return e.CatalogueTitle
+	}
+	return e.GetPublisher()
+}
+
+func (e EnvConfig) GetCatalogueIntro() string {
+	return e.CatalogueIntro
+}
+
+func (e EnvConfig) GetCatalogueLogo() string {
+	return e.CatalogueLogo
+}
+
+func (e EnvConfig) GetCatalogueColor() string {
+	return e.CatalogueColor
+}
+
+// GetCatalogueHidden returns the names or ids of the exhibits that are left out of the catalogue
+func (e EnvConfig) GetCatalogueHidden() []string {
+	return e.CatalogueHidden
+}
+
+// GetApiTokens returns the static api tokens, each of them has the form name:role:token
+func (e EnvConfig) GetApiTokens() []string {
+	return e.ApiTokens
+}
+
+// GetOidcIssuer returns the issuer of the bearer tokens that the api accepts, OpenID Connect is disabled if it is empty
+func (e EnvConfig) GetOidcIssuer() string {
+	return e.OidcIssuer
+}
+
+// GetOidcAudience returns the audience that bearer tokens must have, it is not checked if it is empty
+func (e EnvConfig) GetOidcAudience() string {
+	return e.OidcAudience
+}
+
+// GetOidcJwksUrl returns the keys of the issuer, they are discovered if it is empty
+func (e EnvConfig) GetOidcJwksUrl() string {
+	return e.OidcJwksUrl
+}
+
+func (e EnvConfig) GetOidcRolesClaim() string {
+	return e.OidcRolesClaim
+}
+
+func (e EnvConfig) GetOidcGroupsClaim() string {
+	return e.OidcGroupsClaim
+}
+
+// GetOidcClientId returns the client that visitors log in with to see exhibits with an oidc access policy
+func (e EnvConfig) GetOidcClientId() string {
+	return e.OidcClientId
+}
+
+func (e EnvConfig) GetOidcClientSecret() string {
+	return e.OidcClientSecret
+}
+
+// GetOidcScopes returns the scopes that visitors are asked for when they log in
+func (e EnvConfig) GetOidcScopes() []string {
+	return e.OidcScopes
+}
+
+// GetAccessSecret returns the key that share links and visitor sessions are signed with, a random key kept in the state is used if it is empty
+func (e EnvConfig) GetAccessSecret() string {
+	return e.AccessSecret
+}
+
+// GetCollectionsFile returns the yaml file the collections and their quotas are read from, only the default
+// collection without a quota exists if it is empty
+func (e EnvConfig) GetCollectionsFile() string {
+	return e.CollectionsFile
+}
+
+func (e EnvConfig) GetMaxRunningExhibits() int {
+	return e.MaxRunning
+}
+
+func (e EnvConfig) GetMaxRunningMemory() string {
+	return e.MaxRunningMemory
+}
+
+func (e EnvConfig) GetCapacityPolicy() capacitypolicy.Policy {
+	switch e.CapacityPolicy {
+	case "", "queue":
+		return capacitypolicy.PolicyQueue
+	case "evict":
+		return capacitypolicy.PolicyEvict
+	default:
+		panic("invalid capacity policy " + e.CapacityPolicy)
+	}
+}
+
+// GetPagesDir returns the directory with the pages that replace the default pages of all exhibits,
+// e.g. loading.html, the default pages are used if it is empty
+func (e EnvConfig) GetPagesDir() string {
+	return e.PagesDir
+}
@@ -0,0 +1,8 @@
+package stateBackend
+
+type Backend string
+
+const (
+	BackendEtcd Backend = "etcd"
+	BackendBolt Backend = "bolt"
+)
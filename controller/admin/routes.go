@@ -0,0 +1,25 @@
+package admin
+
+import (
+	_ "embed"
+	"go.uber.org/zap"
+	"museum/http"
+)
+
+//go:embed admin.html
+var adminPage []byte
+
+// adminHandler serves the admin ui, it is a single page that works with the api
+func adminHandler(log *zap.SugaredLogger) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, err := res.Write(adminPage)
+		if err != nil {
+			log.Warnw("error writing admin page", "error", err, "requestId", req.RequestID)
+		}
+	}
+}
+
+func RegisterRoutes(r *http.Mux, log *zap.SugaredLogger) {
+	r.AddRoute(http.Get("/admin", adminHandler(log)))
+}
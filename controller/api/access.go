@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+	"time"
+)
+
+const (
+	defaultShareLinkLength = 24 * time.Hour
+	maxShareLinkLength     = 30 * 24 * time.Hour
+)
+
+func createShareLink(exhibitService service.ExhibitService, accessService service.AccessService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP POST /api/exhibits/"+req.Params["id"]+"/share-links", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		length := defaultShareLinkLength
+		if expiresIn := req.URL.Query().Get("expiresIn"); expiresIn != "" {
+			length, err = time.ParseDuration(expiresIn)
+			if err != nil || length <= 0 || length > maxShareLinkLength {
+				res.WriteHeader(gohttp.StatusBadRequest)
+				_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": "expiresIn must be a duration of at most " + maxShareLinkLength.String()})
+				return
+			}
+		}
+
+		expiresAt := time.Now().Add(length)
+		link := domain.ShareLink{Url: accessService.ShareLink(exhibit, expiresAt), ExpiresAt: expiresAt.Unix()}
+
+		principal, _ := domain.PrincipalFrom(req.Context())
+		log.Infow("share link created", "exhibitId", exhibit.Id, "subject", principal.Subject, "expiresAt", link.ExpiresAt, "requestId", req.RequestID)
+
+		res.WriteHeader(gohttp.StatusCreated)
+		err = res.WriteJson(link)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+		}
+	}
+}
+
+func startMaintenance(exhibitService service.ExhibitService, accessService service.AccessService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP PUT /api/exhibits/"+req.Params["id"]+"/maintenance", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		// the body is optional, it only carries the message visitors see
+		body := struct {
+			Message string `json:"message"`
+		}{}
+		if req.ContentLength != 0 {
+			err = json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				res.WriteHeader(gohttp.StatusBadRequest)
+				_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": "invalid body: " + err.Error()})
+				return
+			}
+		}
+
+		principal, _ := domain.PrincipalFrom(req.Context())
+		maintenance := &domain.Maintenance{Message: body.Message, Since: time.Now().Unix(), By: principal.Subject}
+
+		err = exhibitService.SetMaintenance(subCtx, exhibit.Id, maintenance)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error starting maintenance", "error", err, "exhibitId", exhibit.Id, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		log.Infow("maintenance started", "exhibitId", exhibit.Id, "subject", principal.Subject, "requestId", req.RequestID)
+
+		exhibit.RuntimeInfo.Maintenance = maintenance
+		err = res.WriteJson(domain.MaintenanceLink{Url: accessService.MaintenanceLink(exhibit)})
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+		}
+	}
+}
+
+func endMaintenance(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP DELETE /api/exhibits/"+req.Params["id"]+"/maintenance", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		err = exhibitService.SetMaintenance(subCtx, exhibit.Id, nil)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error ending maintenance", "error", err, "exhibitId", exhibit.Id, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		principal, _ := domain.PrincipalFrom(req.Context())
+		log.Infow("maintenance ended", "exhibitId", exhibit.Id, "subject", principal.Subject, "requestId", req.RequestID)
+
+		res.WriteHeader(gohttp.StatusNoContent)
+	}
+}
+
+func RegisterAccessRoutes(r *http.Mux, exhibitService service.ExhibitService, accessService service.AccessService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Post("/api/exhibits/{id}/share-links", createShareLink(exhibitService, accessService, log, provider)).Requires(domain.RoleCurator))
+	r.AddRoute(http.Put("/api/exhibits/{id}/maintenance", startMaintenance(exhibitService, accessService, log, provider)).Requires(domain.RoleAdmin))
+	r.AddRoute(http.Delete("/api/exhibits/{id}/maintenance", endMaintenance(exhibitService, log, provider)).Requires(domain.RoleAdmin))
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+	"strings"
+)
+
+// requiredRole returns the role a route asks for, api routes that do not say require an admin and all other routes
+// are public
+func requiredRole(route http.Route, req *http.Request) string {
+	if route.Access != "" {
+		return route.Access
+	}
+
+	if strings.HasPrefix(req.URL.Path, "/api/") {
+		return domain.RoleAdmin
+	}
+
+	return domain.RolePublic
+}
+
+func bearerToken(req *http.Request) string {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// targetCollection returns the collection of the exhibit or collection that the route is called for,
+// it is empty for routes that are not about a single one
+func targetCollection(exhibitService service.ExhibitService, req *http.Request) (string, error) {
+	if collection, ok := req.Params["collection"]; ok {
+		return collection, nil
+	}
+
+	var exhibit domain.Exhibit
+	var err error
+	if id, ok := req.Params["id"]; ok {
+		// exhibits are addressed by their name as well, it must not fall back to the roles in other collections
+		if _, e := uuid.Parse(id); e == nil {
+			exhibit, err = exhibitService.GetCachedExhibitById(req.Context(), id)
+		} else {
+			exhibit, err = exhibitService.GetCachedExhibitByName(req.Context(), id)
+		}
+	} else if name, ok := req.Params["name"]; ok {
+		exhibit, err = exhibitService.GetCachedExhibitByName(req.Context(), name)
+	} else {
+		return "", nil
+	}
+
+	// the route answers that the exhibit does not exist
+	notFound := domain.ExhibitNotFoundError{}
+	if errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return exhibit.CollectionName(), nil
+}
+
+// writeForbidden answers that the caller lacks a role, it returns false if err is not about that
+func writeForbidden(res *http.Response, err error) bool {
+	forbidden := domain.ForbiddenError{}
+	if !errors.As(err, &forbidden) {
+		return false
+	}
+
+	res.WriteHeader(gohttp.StatusForbidden)
+	_ = res.WriteJson(map[string]string{"status": "Forbidden", "error": forbidden.Error()})
+	return true
+}
+
+func authMiddleware(authService service.AuthService, exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.Middleware {
+	return func(route http.Route, next http.MuxHandlerFunc) http.MuxHandlerFunc {
+		return func(res *http.Response, req *http.Request) {
+			required := requiredRole(route, req)
+			if required == domain.RolePublic || !authService.Enabled() {
+				next(res, req)
+				return
+			}
+
+			subCtx, span := provider.
+				Tracer("API request").
+				Start(req.Context(), "Authenticate "+req.Method+" "+req.URL.Path, trace.WithAttributes(attribute.String("requestId", req.RequestID), attribute.String("required", required)))
+
+			token := bearerToken(req)
+			if token == "" {
+				span.End()
+				res.Header().Set("WWW-Authenticate", `Bearer realm="museum"`)
+				res.WriteHeader(gohttp.StatusUnauthorized)
+				_ = res.WriteJson(map[string]string{"status": "Unauthorized", "error": "a bearer token is required"})
+				return
+			}
+
+			principal, err := authService.Authenticate(subCtx, token)
+			if err != nil {
+				span.RecordError(err)
+				span.End()
+				log.Infow("rejected api token", "error", err, "requestId", req.RequestID)
+				res.Header().Set("WWW-Authenticate", `Bearer realm="museum", error="invalid_token"`)
+				res.WriteHeader(gohttp.StatusUnauthorized)
+				_ = res.WriteJson(map[string]string{"status": "Unauthorized", "error": err.Error()})
+				return
+			}
+
+			// routes of a single exhibit or collection need the role in its collection, the others need it in any
+			// collection and only show or change what the caller may see or change
+			role := principal.HighestRole()
+			collection, err := targetCollection(exhibitService, req)
+			if err != nil {
+				span.RecordError(err)
+				span.End()
+				log.Warnw("error finding the collection of the api call", "error", err, "requestId", req.RequestID)
+				res.WriteHeader(gohttp.StatusInternalServerError)
+				_ = res.WriteJson(map[string]string{"status": "Internal Server Error", "error": err.Error()})
+				return
+			}
+			if collection != "" {
+				role = principal.RoleIn(collection)
+			}
+
+			span.SetAttributes(attribute.String("subject", principal.Subject), attribute.String("role", role), attribute.String("collection", collection))
+			span.End()
+
+			if !domain.RoleAllows(role, required) {
+				log.Infow("api call forbidden", "subject", principal.Subject, "role", role, "collection", collection, "required", required, "requestId", req.RequestID)
+				res.WriteHeader(gohttp.StatusForbidden)
+				if collection != "" {
+					_ = res.WriteJson(map[string]string{"status": "Forbidden", "error": domain.ForbiddenError{Role: required, Collection: collection}.Error()})
+				} else {
+					_ = res.WriteJson(map[string]string{"status": "Forbidden", "error": "this requires the role " + required})
+				}
+				return
+			}
+
+			req.Request = req.Request.WithContext(domain.WithPrincipal(req.Context(), principal))
+			next(res, req)
+		}
+	}
+}
+
+// RegisterAuthMiddleware enforces the role that each route requires, see http.Route.Requires
+func RegisterAuthMiddleware(r *http.Mux, authService service.AuthService, exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.Use(authMiddleware(authService, exhibitService, log, provider))
+}
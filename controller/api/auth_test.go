@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/service/impl"
+	service "museum/service/interface"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubExhibitService knows the exhibits by id and by name, the other methods are not called by the middleware
+type stubExhibitService struct {
+	service.ExhibitService
+	exhibits []domain.Exhibit
+}
+
+func (s stubExhibitService) GetCachedExhibitById(_ context.Context, id string) (domain.Exhibit, error) {
+	for _, e := range s.exhibits {
+		if e.Id == id {
+			return e, nil
+		}
+	}
+	return domain.Exhibit{}, domain.ExhibitNotFoundError{By: "id", Value: id}
+}
+
+func (s stubExhibitService) GetCachedExhibitByName(_ context.Context, name string) (domain.Exhibit, error) {
+	for _, e := range s.exhibits {
+		if e.Name == name {
+			return e, nil
+		}
+	}
+	return domain.Exhibit{}, domain.ExhibitNotFoundError{By: "name", Value: name}
+}
+
+func TestAuthMiddlewareScopesExhibitsAddressedByName(t *testing.T) {
+	authService := impl.AuthServiceImpl{
+		Tokens:   []domain.ApiToken{{Name: "a", Role: domain.RoleCurator, Collection: "a", Token: "curator-of-a"}},
+		Provider: noop.NewTracerProvider(),
+		Log:      zap.NewNop().Sugar(),
+	}
+	exhibitService := stubExhibitService{exhibits: []domain.Exhibit{
+		{Id: "0b7c5d43-3f1a-4c57-9a45-6f3c1f1d2e10", Name: "in-a", Collection: "a"},
+		{Id: "5d0ff1a4-64b6-4b7e-8f0c-0f6f9f1f2a33", Name: "in-b", Collection: "b"},
+	}}
+
+	route := http.Delete("/api/exhibits/{id}", nil).Requires(domain.RoleCurator)
+	handler := authMiddleware(authService, exhibitService, zap.NewNop().Sugar(), noop.NewTracerProvider())(route, func(res *http.Response, req *http.Request) {
+		res.WriteHeader(gohttp.StatusNoContent)
+	})
+
+	call := func(id string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(gohttp.MethodDelete, "/api/exhibits/"+id, nil)
+		req.Header.Set("Authorization", "Bearer curator-of-a")
+		handler(&http.Response{ResponseWriter: rec}, &http.Request{Request: req, Params: map[string]string{"id": id}})
+		return rec.Code
+	}
+
+	assert.Equal(t, gohttp.StatusNoContent, call("0b7c5d43-3f1a-4c57-9a45-6f3c1f1d2e10"))
+	assert.Equal(t, gohttp.StatusNoContent, call("in-a"))
+
+	// the exhibit of the other collection is out of reach, whether it is addressed by id or by name
+	assert.Equal(t, gohttp.StatusForbidden, call("5d0ff1a4-64b6-4b7e-8f0c-0f6f9f1f2a33"))
+	assert.Equal(t, gohttp.StatusForbidden, call("in-b"))
+}
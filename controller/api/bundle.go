@@ -0,0 +1,113 @@
+package api
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+)
+
+// countingWriter remembers if anything was written, errors can only be reported as a status before that
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func exportBundle(exhibitService service.ExhibitService, bundleService service.ExhibitBundleService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/exhibits/"+req.Params["id"]+"/bundle", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		format := domain.ExhibitBundleFormat(req.URL.Query().Get("format"))
+		if format == "" {
+			format = domain.ExhibitBundleFormatMuseum
+		}
+		if format != domain.ExhibitBundleFormatMuseum && format != domain.ExhibitBundleFormatBagIt {
+			res.WriteHeader(gohttp.StatusBadRequest)
+			_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": "unknown bundle format " + string(format)})
+			return
+		}
+		span.SetAttributes(attribute.String("format", string(format)))
+
+		filename := exhibit.Name + ".tar"
+		if format == domain.ExhibitBundleFormatBagIt {
+			filename = exhibit.Name + "-bag.tar"
+		}
+
+		res.Header().Set("Content-Type", "application/x-tar")
+		res.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+		// a bundle that fails while it is streamed ends without its manifest, imports reject it
+		w := &countingWriter{w: res}
+		err = bundleService.Export(subCtx, exhibit.Id, format, w)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error exporting exhibit bundle", "error", err, "exhibitId", exhibit.Id, "requestId", req.RequestID)
+			if w.n == 0 {
+				res.Header().Set("Content-Type", "application/json")
+				res.Header().Del("Content-Disposition")
+				res.WriteErr(err)
+			}
+			return
+		}
+
+		span.AddEvent("bundle written")
+	}
+}
+
+func importBundle(bundleService service.ExhibitBundleService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP POST /api/bundles", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := bundleService.Import(subCtx, req.Body)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error importing exhibit bundle", "error", err, "requestId", req.RequestID)
+			if writeForbidden(res, err) {
+				return
+			}
+			res.WriteHeader(gohttp.StatusBadRequest)
+			_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": err.Error()})
+			return
+		}
+
+		res.WriteHeader(gohttp.StatusCreated)
+		err = res.WriteJson(map[string]string{"status": "Created", "id": exhibit.Id, "name": exhibit.Name})
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		span.AddEvent("response written")
+	}
+}
+
+func RegisterBundleRoutes(r *http.Mux, exhibitService service.ExhibitService, bundleService service.ExhibitBundleService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/exhibits/{id}/bundle", exportBundle(exhibitService, bundleService, log, provider)).Requires(domain.RoleCurator))
+	r.AddRoute(http.Post("/api/bundles", importBundle(bundleService, log, provider)).Requires(domain.RoleCurator))
+}
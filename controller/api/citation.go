@@ -0,0 +1,96 @@
+package api
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+	"strings"
+)
+
+// citationFormat picks the format from the format query parameter or from the Accept header, CSL-JSON is the default
+func citationFormat(req *http.Request) (domain.CitationFormat, bool) {
+	if format := req.URL.Query().Get("format"); format != "" {
+		_, ok := domain.CitationMediaTypes[domain.CitationFormat(format)]
+		return domain.CitationFormat(format), ok
+	}
+
+	accept := req.Header.Get("Accept")
+	for _, format := range []domain.CitationFormat{domain.CitationFormatCsl, domain.CitationFormatBibtex, domain.CitationFormatDataCite} {
+		if strings.Contains(accept, domain.CitationMediaTypes[format]) {
+			return format, true
+		}
+	}
+
+	return domain.CitationFormatCsl, true
+}
+
+func getCitation(exhibitService service.ExhibitService, config config.Config, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/exhibits/"+req.Params["id"]+"/citation", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		format, ok := citationFormat(req)
+		if !ok {
+			res.WriteHeader(gohttp.StatusBadRequest)
+			_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": "unknown citation format " + string(format) + ", it must be one of: csl, bibtex, datacite"})
+			return
+		}
+		span.SetAttributes(attribute.String("format", string(format)))
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		citation, err := exhibit.Citation(config.GetPublicUrl()+"/exhibit/"+exhibit.Id, config.GetPublisher())
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		var body []byte
+		switch format {
+		case domain.CitationFormatBibtex:
+			body = []byte(citation.Bibtex())
+		case domain.CitationFormatDataCite:
+			body, err = citation.DataCite()
+		default:
+			res.Header().Set("Content-Type", domain.CitationMediaTypes[format])
+			err = res.WriteJson(citation.Csl())
+			if err != nil {
+				span.RecordError(err)
+				log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			}
+			return
+		}
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing citation", "error", err, "exhibitId", exhibit.Id, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		res.Header().Set("Content-Type", domain.CitationMediaTypes[format]+"; charset=utf-8")
+		_, err = res.Write(body)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing citation", "error", err, "exhibitId", exhibit.Id, "requestId", req.RequestID)
+		}
+	}
+}
+
+func RegisterCitationRoutes(r *http.Mux, exhibitService service.ExhibitService, config config.Config, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/exhibits/{id}/citation", getCitation(exhibitService, config, log, provider)).Requires(domain.RolePublic))
+}
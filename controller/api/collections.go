@@ -0,0 +1,65 @@
+package api
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+)
+
+func getCollections(collectionService service.CollectionService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/collections", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		// callers only see the collections they may view
+		dtos := make([]domain.CollectionDto, 0)
+		for _, collection := range collectionService.GetCollections() {
+			if domain.Authorize(subCtx, collection.Name, domain.RoleViewer) != nil {
+				continue
+			}
+			dtos = append(dtos, domain.CollectionDto{Collection: collection, Usage: collectionService.GetUsage(subCtx, collection.Name)})
+		}
+
+		err := res.WriteJson(dtos)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func getCollection(collectionService service.CollectionService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/collections/"+req.Params["collection"], trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		collection, err := collectionService.GetCollection(req.Params["collection"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		err = res.WriteJson(domain.CollectionDto{Collection: collection, Usage: collectionService.GetUsage(subCtx, collection.Name)})
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func RegisterCollectionRoutes(r *http.Mux, collectionService service.CollectionService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/collections", getCollections(collectionService, log, provider)).Requires(domain.RoleViewer))
+	r.AddRoute(http.Get("/api/collections/{collection}", getCollection(collectionService, log, provider)).Requires(domain.RoleViewer))
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+)
+
+// toExhibitDto adds the outcome of the last fixity check to the dto, exhibits that were never checked have none
+func toExhibitDto(ctx context.Context, exhibit domain.Exhibit, fixityService service.FixityService) domain.ExhibitDto {
+	dto := exhibit.ToDto()
+	if report, err := fixityService.GetReport(ctx, exhibit.Id); err == nil {
+		dto.Fixity = report.ToDto()
+	}
+	return dto
+}
+
+func getFixity(exhibitService service.ExhibitService, fixityService service.FixityService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/exhibits/"+req.Params["id"]+"/fixity", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		report, err := fixityService.GetReport(subCtx, exhibit.Id)
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": "exhibit " + exhibit.Name + " was not checked yet"})
+			return
+		}
+
+		err = res.WriteJson(report)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func checkFixity(exhibitService service.ExhibitService, fixityService service.FixityService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP POST /api/exhibits/"+req.Params["id"]+"/fixity", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		report, err := fixityService.Check(subCtx, exhibit.Id)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error checking exhibit fixity", "error", err, "exhibitId", exhibit.Id, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		err = res.WriteJson(report)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func RegisterFixityRoutes(r *http.Mux, exhibitService service.ExhibitService, fixityService service.FixityService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/exhibits/{id}/fixity", getFixity(exhibitService, fixityService, log, provider)).Requires(domain.RoleViewer))
+	r.AddRoute(http.Post("/api/exhibits/{id}/fixity", checkFixity(exhibitService, fixityService, log, provider)).Requires(domain.RoleCurator))
+}
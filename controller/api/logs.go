@@ -0,0 +1,136 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+	"slices"
+	"strconv"
+)
+
+func getLogs(exhibitService service.ExhibitService, logService service.LogService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/exhibits/"+req.Params["id"]+"/objects/"+req.Params["object"]+"/logs", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibit, err := exhibitService.GetExhibitById(subCtx, req.Params["id"])
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		object := req.Params["object"]
+		i := slices.IndexFunc(exhibit.Objects, func(o domain.Object) bool { return o.Name == object })
+		if i < 0 {
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": "object " + object + " is not part of exhibit " + exhibit.Name})
+			return
+		}
+
+		// the containers only exist while the exhibit runs, after a failed start the logs they had until then are kept
+		if status := exhibit.RuntimeInfo.Status; status != domain.Starting && status != domain.Running && status != domain.Stopping && exhibit.RuntimeInfo.Failure == nil {
+			res.WriteHeader(gohttp.StatusConflict)
+			_ = res.WriteJson(map[string]string{"status": "Conflict", "error": "exhibit " + exhibit.Name + " is not running, its objects have no logs"})
+			return
+		}
+
+		options := domain.LogOptions{}
+		if tail := req.URL.Query().Get("tail"); tail != "" {
+			options.Tail, err = strconv.Atoi(tail)
+			if err != nil || options.Tail < 0 {
+				res.WriteHeader(gohttp.StatusBadRequest)
+				_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": "tail must be a positive number of lines"})
+				return
+			}
+		}
+
+		// replicas are counted from 1 like the suffixes of their containers, the first one has none
+		if replica := req.URL.Query().Get("replica"); replica != "" {
+			n, err := strconv.Atoi(replica)
+			if err != nil || n < 1 {
+				res.WriteHeader(gohttp.StatusBadRequest)
+				_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": "replica must be a number starting at 1"})
+				return
+			}
+			if n > exhibit.Objects[i].Replicas.Most() {
+				res.WriteHeader(gohttp.StatusNotFound)
+				_ = res.WriteJson(map[string]string{"status": "Not Found", "error": "object " + object + " has no replica " + replica})
+				return
+			}
+			options.Replica = n - 1
+		}
+
+		if req.URL.Query().Get("follow") == "true" {
+			options.Follow = true
+			followLogs(subCtx, logService, exhibit, object, options, res, req, log)
+			return
+		}
+
+		res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		err = logService.Logs(subCtx, exhibit.Id, object, options, res)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error reading logs", "error", err, "exhibitId", exhibit.Id, "object", object, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+// sseLineWriter sends every line that is written to it as a log.line event
+type sseLineWriter struct {
+	res     *http.Response
+	partial []byte
+}
+
+func (s *sseLineWriter) Write(p []byte) (int, error) {
+	s.partial = append(s.partial, p...)
+	for {
+		i := bytes.IndexByte(s.partial, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+
+		err := s.res.SendMessage("log.line", map[string]string{"line": string(s.partial[:i])})
+		if err != nil {
+			return 0, err
+		}
+		s.partial = s.partial[i+1:]
+	}
+}
+
+// followLogs streams the logs of an object as server-sent events until the object stops or the client goes away
+func followLogs(ctx context.Context, logService service.LogService, exhibit domain.Exhibit, object string, options domain.LogOptions, res *http.Response, req *http.Request, log *zap.SugaredLogger) {
+	err := res.SetupSSE()
+	if err != nil {
+		log.Warnw("error setting up SSE", "error", err, "requestId", req.RequestID)
+		res.WriteErr(err)
+		return
+	}
+
+	lines := &sseLineWriter{res: res}
+	err = logService.Logs(ctx, exhibit.Id, object, options, lines)
+	if err != nil && ctx.Err() == nil {
+		log.Warnw("error following logs", "error", err, "exhibitId", exhibit.Id, "object", object, "requestId", req.RequestID)
+		_ = res.SendMessage("log.error", map[string]string{"error": err.Error()})
+		return
+	}
+
+	if len(lines.partial) > 0 {
+		_ = res.SendMessage("log.line", map[string]string{"line": string(lines.partial)})
+	}
+	_ = res.SendMessage("log.end", map[string]string{"exhibitId": exhibit.Id, "object": object})
+}
+
+func RegisterLogRoutes(r *http.Mux, exhibitService service.ExhibitService, logService service.LogService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/exhibits/{id}/objects/{object}/logs", getLogs(exhibitService, logService, log, provider)).Requires(domain.RoleViewer))
+}
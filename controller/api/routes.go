@@ -7,27 +7,37 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
 	"io"
 	"museum/domain"
 	"museum/http"
 	"museum/persistence"
 	"museum/service"
 	gohttp "net/http"
+	"strings"
 	"time"
 )
 
-func getExhibits(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+func getExhibits(exhibitService service.ExhibitService, fixityService service.FixityService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
 	return func(res *http.Response, req *http.Request) {
 		subCtx, span := provider.
 			Tracer("API request").
 			Start(req.Context(), "HTTP GET /api/exhibits/", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
 		defer span.End()
 
+		// callers only see the exhibits of the collections they may view
+		collection := req.URL.Query().Get("collection")
 		exhibits := exhibitService.GetAllExhibits(subCtx)
-		dtos := make([]domain.ExhibitDto, len(exhibits))
+		dtos := make([]domain.ExhibitDto, 0, len(exhibits))
 
-		for i, exhibit := range exhibits {
-			dtos[i] = exhibit.ToDto()
+		for _, exhibit := range exhibits {
+			if collection != "" && exhibit.CollectionName() != collection {
+				continue
+			}
+			if domain.Authorize(subCtx, exhibit.CollectionName(), domain.RoleViewer) != nil {
+				continue
+			}
+			dtos = append(dtos, toExhibitDto(subCtx, exhibit, fixityService))
 		}
 
 		err := res.WriteJson(dtos)
@@ -39,7 +49,7 @@ func getExhibits(exhibitService service.ExhibitService, log *zap.SugaredLogger,
 	}
 }
 
-func getExhibitById(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+func getExhibitById(exhibitService service.ExhibitService, fixityService service.FixityService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
 	return func(res *http.Response, req *http.Request) {
 		subCtx, span := provider.
 			Tracer("API request").
@@ -55,7 +65,32 @@ func getExhibitById(exhibitService service.ExhibitService, log *zap.SugaredLogge
 			return
 		}
 
-		err = res.WriteJson(exhibit.ToDto())
+		err = res.WriteJson(toExhibitDto(subCtx, exhibit, fixityService))
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func getExhibitByName(exhibitService service.ExhibitService, fixityService service.FixityService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/exhibits/by-name/"+req.Params["name"], trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		name := req.Params["name"]
+		exhibit, err := exhibitService.GetExhibitByName(subCtx, name)
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		err = res.WriteJson(toExhibitDto(subCtx, exhibit, fixityService))
 		if err != nil {
 			span.RecordError(err)
 			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
@@ -64,6 +99,35 @@ func getExhibitById(exhibitService service.ExhibitService, log *zap.SugaredLogge
 	}
 }
 
+func deleteExhibitById(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP DELETE /api/exhibits/"+req.Params["id"], trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		exhibitId := req.Params["id"]
+		_, err := exhibitService.GetExhibitById(subCtx, exhibitId)
+		if err != nil {
+			span.RecordError(err)
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": err.Error()})
+			return
+		}
+
+		err = exhibitService.DeleteExhibitById(subCtx, exhibitId)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error deleting exhibit", "error", err, "requestId", req.RequestID, "exhibitId", exhibitId)
+			res.WriteErr(err)
+			return
+		}
+
+		res.WriteHeader(gohttp.StatusNoContent)
+		span.AddEvent("exhibit deleted")
+	}
+}
+
 func createExhibit(exhibitService service.ExhibitService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
 	return func(res *http.Response, req *http.Request) {
 		ctx, span := provider.
@@ -79,11 +143,21 @@ func createExhibit(exhibitService service.ExhibitService, log *zap.SugaredLogger
 			return
 		}
 
+		if req.URL.Query().Get("dryRun") == "true" {
+			validateExhibit(ctx, res, req, body, exhibitService, log)
+			return
+		}
+
+		// exhibit files can be posted as they are, e.g. by the admin ui
 		exhibit := &domain.Exhibit{}
-		err = json.Unmarshal(body, exhibit)
+		if strings.Contains(req.Header.Get("Content-Type"), "yaml") {
+			err = yaml.Unmarshal(body, exhibit)
+		} else {
+			err = json.Unmarshal(body, exhibit)
+		}
 		if err != nil {
 			span.RecordError(err)
-			log.Warnw("error unmarshalling json", "error", err, "requestId", req.RequestID)
+			log.Warnw("error unmarshalling exhibit", "error", err, "requestId", req.RequestID)
 			res.WriteErr(err)
 			return
 		}
@@ -97,7 +171,9 @@ func createExhibit(exhibitService service.ExhibitService, log *zap.SugaredLogger
 		if err != nil {
 			span.RecordError(err)
 			log.Warnw("error creating exhibit", "error", err, "requestId", req.RequestID)
-			res.WriteErr(err)
+			if !writeForbidden(res, err) {
+				res.WriteErr(err)
+			}
 			return
 		}
 
@@ -114,7 +190,42 @@ func createExhibit(exhibitService service.ExhibitService, log *zap.SugaredLogger
 	}
 }
 
-func handleEvents(handlerService service.ApplicationProvisionerHandlerService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+// validateExhibit answers a dry run of creating an exhibit with all problems of it,
+// the body is read as yaml, which JSON is a subset of, to locate the problems
+func validateExhibit(ctx context.Context, res *http.Response, req *http.Request, body []byte, exhibitService service.ExhibitService, log *zap.SugaredLogger) {
+	span := trace.SpanFromContext(ctx)
+
+	file := domain.ParseExhibitFile(body)
+	file.AddProblems(exhibitService.ValidateExhibit(ctx, file.Exhibit))
+
+	span.AddEvent("exhibit validated", trace.WithAttributes(attribute.Int("problems", len(file.Problems))))
+
+	err := res.WriteJson(file.Result())
+	if err != nil {
+		span.RecordError(err)
+		log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+		res.WriteErr(err)
+	}
+}
+
+func getExhibitSchema(log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		_, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/schemas/exhibit.json", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		res.Header().Set("Content-Type", "application/schema+json")
+		err := res.WriteJson(domain.ExhibitSchema())
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func handleEvents(exhibitService service.ExhibitService, handlerService service.ApplicationProvisionerHandlerService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
 	return func(res *http.Response, req *http.Request) {
 		ctx, span := provider.
 			Tracer("API request").
@@ -142,7 +253,23 @@ func handleEvents(handlerService service.ApplicationProvisionerHandlerService, l
 			return
 		}
 
+		// events change the exhibit, so they need a curator of its collection
+		target, err := exhibitService.GetCachedExhibitById(ctx, exhibit.Id)
+		if err == nil {
+			err = domain.Authorize(ctx, target.CollectionName(), domain.RoleCurator)
+		}
+		if writeForbidden(res, err) {
+			log.Infow("event forbidden", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+			return
+		}
+
 		err = handlerService.HandleEvent(ctx, event, exhibit.Id)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error handling event", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
+			res.WriteErr(err)
+			return
+		}
 
 		span.AddEvent("application started")
 
@@ -264,10 +391,13 @@ func handleExhibitStatus(exhibitService service.ExhibitService, eventing persist
 	}
 }
 
-func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, eventing persistence.Eventing, provisionerHandlerService service.ApplicationProvisionerHandlerService, log *zap.SugaredLogger, provider trace.TracerProvider) {
-	r.AddRoute(http.Get("/api/exhibits", getExhibits(exhibitService, log, provider)))
-	r.AddRoute(http.Get("/api/exhibits/{id}", getExhibitById(exhibitService, log, provider)))
-	r.AddRoute(http.Get("/api/exhibits/{id}/status", handleExhibitStatus(exhibitService, eventing, log, provider)))
-	r.AddRoute(http.Post("/api/exhibits", createExhibit(exhibitService, log, provider)))
-	r.AddRoute(http.Post("/api/events", handleEvents(provisionerHandlerService, log, provider)))
+func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, fixityService service.FixityService, eventing persistence.Eventing, provisionerHandlerService service.ApplicationProvisionerHandlerService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/exhibits", getExhibits(exhibitService, fixityService, log, provider)).Requires(domain.RoleViewer))
+	r.AddRoute(http.Get("/api/exhibits/by-name/{name}", getExhibitByName(exhibitService, fixityService, log, provider)).Requires(domain.RoleViewer))
+	r.AddRoute(http.Get("/api/exhibits/{id}", getExhibitById(exhibitService, fixityService, log, provider)).Requires(domain.RoleViewer))
+	r.AddRoute(http.Delete("/api/exhibits/{id}", deleteExhibitById(exhibitService, log, provider)).Requires(domain.RoleCurator))
+	r.AddRoute(http.Get("/api/exhibits/{id}/status", handleExhibitStatus(exhibitService, eventing, log, provider)).Requires(domain.RolePublic))
+	r.AddRoute(http.Post("/api/exhibits", createExhibit(exhibitService, log, provider)).Requires(domain.RoleCurator))
+	r.AddRoute(http.Post("/api/events", handleEvents(exhibitService, provisionerHandlerService, log, provider)).Requires(domain.RoleCurator))
+	r.AddRoute(http.Get("/api/schemas/exhibit.json", getExhibitSchema(log, provider)).Requires(domain.RolePublic))
 }
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/json"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"io"
+	"museum/domain"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+	"strings"
+)
+
+func exportState(stateTransferService service.StateTransferService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP GET /api/admin/state/export", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		bundle, err := stateTransferService.Export(subCtx)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error exporting state", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		span.AddEvent("state exported")
+
+		if req.URL.Query().Get("format") == "yaml" {
+			b, err := yaml.Marshal(bundle)
+			if err != nil {
+				span.RecordError(err)
+				log.Warnw("error marshalling yaml", "error", err, "requestId", req.RequestID)
+				res.WriteErr(err)
+				return
+			}
+
+			res.Header().Set("Content-Type", "application/yaml")
+			_, err = res.Write(b)
+			if err != nil {
+				log.Warnw("error writing yaml", "error", err, "requestId", req.RequestID)
+			}
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		err = res.WriteJson(bundle)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+		}
+	}
+}
+
+func importState(stateTransferService service.StateTransferService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP POST /api/admin/state/import", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error reading request body", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		// bundles can be uploaded in the format they were exported in
+		bundle := domain.StateBundle{}
+		if strings.Contains(req.Header.Get("Content-Type"), "yaml") {
+			err = yaml.Unmarshal(body, &bundle)
+		} else {
+			err = json.Unmarshal(body, &bundle)
+		}
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error unmarshalling state bundle", "error", err, "requestId", req.RequestID)
+			res.WriteHeader(gohttp.StatusBadRequest)
+			_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": err.Error()})
+			return
+		}
+
+		mode := domain.ImportConflictMode(req.URL.Query().Get("conflict"))
+		if mode == "" {
+			mode = domain.ImportSkip
+		}
+
+		span.AddEvent("request read")
+
+		result, err := stateTransferService.Import(subCtx, bundle, mode)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error importing state", "error", err, "requestId", req.RequestID)
+			res.WriteHeader(gohttp.StatusBadRequest)
+			_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": err.Error()})
+			return
+		}
+
+		err = res.WriteJson(result)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		span.AddEvent("response written")
+	}
+}
+
+func migrateState(stateTransferService service.StateTransferService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("API request").
+			Start(req.Context(), "HTTP POST /api/admin/state/migrate", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		result, err := stateTransferService.Migrate(subCtx)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error migrating state", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		err = res.WriteJson(result)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing json", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		span.AddEvent("response written")
+	}
+}
+
+func RegisterStateRoutes(r *http.Mux, stateTransferService service.StateTransferService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/api/admin/state/export", exportState(stateTransferService, log, provider)).Requires(domain.RoleAdmin))
+	r.AddRoute(http.Post("/api/admin/state/import", importState(stateTransferService, log, provider)).Requires(domain.RoleAdmin))
+	r.AddRoute(http.Post("/api/admin/state/migrate", migrateState(stateTransferService, log, provider)).Requires(domain.RoleAdmin))
+}
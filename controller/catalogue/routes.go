@@ -0,0 +1,202 @@
+package catalogue
+
+import (
+	"embed"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"html/template"
+	"museum/config"
+	"museum/controller/exhibit"
+	"museum/domain"
+	"museum/http"
+	service "museum/service/interface"
+	gohttp "net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+//go:embed templates/*.html
+var templates embed.FS
+
+type BrandTemplate struct {
+	Title string
+	Intro string
+	Logo  string
+	Color template.CSS
+}
+
+type ExhibitTemplate struct {
+	domain.Exhibit
+	// Status is running, starting or sleeping
+	Status      string
+	Collections []string
+	Citation    *domain.Citation
+}
+
+type TagTemplate struct {
+	Name     string
+	Count    int
+	Selected bool
+	// Url toggles the tag in the current search
+	Url string
+}
+
+type CataloguePageTemplate struct {
+	Brand    BrandTemplate
+	Query    string
+	Selected []string
+	Tags     []TagTemplate
+	Exhibits []ExhibitTemplate
+	Total    int
+}
+
+type ExhibitPageTemplate struct {
+	Brand   BrandTemplate
+	Exhibit ExhibitTemplate
+}
+
+func brand(c config.Config) BrandTemplate {
+	return BrandTemplate{
+		Title: c.GetCatalogueTitle(),
+		Intro: c.GetCatalogueIntro(),
+		Logo:  c.GetCatalogueLogo(),
+		// the color is configured by the operator, not by visitors
+		Color: template.CSS(c.GetCatalogueColor()),
+	}
+}
+
+func hidden(c config.Config, e domain.Exhibit) bool {
+	return slices.Contains(c.GetCatalogueHidden(), e.Id) || slices.Contains(c.GetCatalogueHidden(), e.Name)
+}
+
+func newExhibitTemplate(e domain.Exhibit) ExhibitTemplate {
+	status := "sleeping"
+	if e.RuntimeInfo != nil && e.RuntimeInfo.Status == domain.Running {
+		status = "running"
+	} else if e.RuntimeInfo != nil && (e.RuntimeInfo.Status == domain.Starting || e.RuntimeInfo.Status == domain.Queued) {
+		status = "starting"
+	}
+
+	return ExhibitTemplate{Exhibit: e, Status: status, Collections: e.MetaStrings(domain.CollectionMetaKey)}
+}
+
+// tagTemplates counts the tags of the visible exhibits, the links add or remove the tag from the search
+func tagTemplates(exhibits []domain.Exhibit, query string, selected []string) []TagTemplate {
+	counts := make(map[string]int)
+	for _, e := range exhibits {
+		for _, tag := range e.Tags() {
+			counts[tag]++
+		}
+	}
+
+	tags := make([]TagTemplate, 0, len(counts))
+	for name, count := range counts {
+		toggled := slices.DeleteFunc(slices.Clone(selected), func(s string) bool { return strings.EqualFold(s, name) })
+		isSelected := len(toggled) < len(selected)
+		if !isSelected {
+			toggled = append(toggled, name)
+		}
+
+		values := url.Values{"tag": toggled}
+		if query != "" {
+			values.Set("q", query)
+		}
+
+		tags = append(tags, TagTemplate{Name: name, Count: count, Selected: isSelected, Url: "/?" + values.Encode()})
+	}
+
+	slices.SortFunc(tags, func(a, b TagTemplate) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) })
+	return tags
+}
+
+func cataloguePage(exhibitService service.ExhibitService, c config.Config, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	tmpl := template.Must(template.ParseFS(templates, "templates/layout.html", "templates/catalogue.html"))
+
+	return func(res *http.Response, req *http.Request) {
+		// exhibits that link to / mean their own root
+		if exhibit.RedirectToReferer(res, req) == nil {
+			return
+		}
+
+		subCtx, span := provider.
+			Tracer("Catalogue request").
+			Start(req.Context(), "HTTP GET /", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		query := strings.TrimSpace(req.URL.Query().Get("q"))
+		selected := req.URL.Query()["tag"]
+
+		visible := slices.DeleteFunc(exhibitService.GetAllExhibits(subCtx), func(e domain.Exhibit) bool { return hidden(c, e) })
+		slices.SortFunc(visible, func(a, b domain.Exhibit) int {
+			return strings.Compare(strings.ToLower(a.Title()), strings.ToLower(b.Title()))
+		})
+
+		page := CataloguePageTemplate{
+			Brand:    brand(c),
+			Query:    query,
+			Selected: selected,
+			Tags:     tagTemplates(visible, query, selected),
+			Exhibits: make([]ExhibitTemplate, 0),
+			Total:    len(visible),
+		}
+		for _, e := range visible {
+			if e.MatchesSearch(query, selected) {
+				page.Exhibits = append(page.Exhibits, newExhibitTemplate(e))
+			}
+		}
+		span.SetAttributes(attribute.Int("exhibits", len(page.Exhibits)))
+
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := tmpl.ExecuteTemplate(res, "catalogue.html", page)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error executing template", "error", err, "requestId", req.RequestID)
+		}
+	}
+}
+
+func exhibitPage(exhibitService service.ExhibitService, c config.Config, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	tmpl := template.Must(template.ParseFS(templates, "templates/layout.html", "templates/exhibit.html"))
+
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("Catalogue request").
+			Start(req.Context(), "HTTP GET /catalogue/"+req.Params["id"], trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		// exhibits can be addressed by their id or by their name
+		var e domain.Exhibit
+		var err error
+		if _, parseErr := uuid.Parse(req.Params["id"]); parseErr == nil {
+			e, err = exhibitService.GetCachedExhibitById(subCtx, req.Params["id"])
+		} else {
+			e, err = exhibitService.GetCachedExhibitByName(subCtx, req.Params["id"])
+		}
+
+		if err != nil || hidden(c, e) {
+			res.WriteHeader(gohttp.StatusNotFound)
+			_ = res.WriteJson(map[string]string{"status": "Not Found", "error": "exhibit " + req.Params["id"] + " does not exist"})
+			return
+		}
+
+		page := ExhibitPageTemplate{Brand: brand(c), Exhibit: newExhibitTemplate(e)}
+		if citation, err := e.Citation(c.GetPublicUrl()+"/exhibit/"+e.Id, c.GetPublisher()); err == nil {
+			page.Exhibit.Citation = &citation
+		}
+
+		res.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = tmpl.ExecuteTemplate(res, "exhibit.html", page)
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error executing template", "error", err, "requestId", req.RequestID, "exhibitId", e.Id)
+		}
+	}
+}
+
+func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, config config.Config, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/", cataloguePage(exhibitService, config, log, provider)))
+	r.AddRoute(http.Get("/catalogue/{id}", exhibitPage(exhibitService, config, log, provider)))
+}
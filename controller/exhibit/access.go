@@ -0,0 +1,95 @@
+package exhibit
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/http"
+	service "museum/service/interface"
+	gohttp "net/http"
+	"strconv"
+)
+
+// withoutCredentials returns the url of the request without the credentials that were passed in its query
+func withoutCredentials(req *http.Request, params ...string) string {
+	u := *req.URL
+	query := u.Query()
+	for _, param := range params {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+	return u.RequestURI()
+}
+
+// checkAccess enforces the access policy of the exhibit, it answers the request itself and returns false
+// if the visitor may not see the exhibit yet
+func checkAccess(accessService service.AccessService, app domain.Exhibit, res *http.Response, req *http.Request, log *zap.SugaredLogger) bool {
+	switch accessService.Check(req.Context(), app, res, req) {
+	case domain.AccessGranted:
+		// the password of basic access is not meant for the exhibit
+		if app.AccessType() == domain.AccessBasic {
+			req.Header.Del("Authorization")
+		}
+		return true
+	case domain.AccessGrantedRedirect:
+		// the credentials are kept in a cookie now, they should not end up in the history or the exhibit
+		gohttp.Redirect(res, req.Request, withoutCredentials(req, domain.AccessTokenParam, domain.ShareLinkParam), gohttp.StatusSeeOther)
+	case domain.AccessUnauthorized:
+		res.Header().Set("WWW-Authenticate", "Basic realm="+strconv.Quote(app.Name)+`, charset="UTF-8"`)
+		gohttp.Error(res, "this exhibit requires a user and password", gohttp.StatusUnauthorized)
+	case domain.AccessLoginRequired:
+		loginUrl, err := accessService.Login(req.Context(), app, req.URL.RequestURI(), res)
+		if err != nil {
+			log.Warnw("error starting login", "error", err, "requestId", req.RequestID, "exhibitId", app.Id)
+			gohttp.Error(res, "logging in is not possible right now", gohttp.StatusInternalServerError)
+			return false
+		}
+		gohttp.Redirect(res, req.Request, loginUrl, gohttp.StatusFound)
+	default:
+		log.Infow("visitor was denied access", "requestId", req.RequestID, "exhibitId", app.Id, "access", app.AccessType())
+		gohttp.Error(res, "you are not allowed to see this exhibit", gohttp.StatusForbidden)
+	}
+
+	return false
+}
+
+// checkMaintenance keeps visitors out of an exhibit in maintenance, only curators that followed its maintenance
+// link get in. It answers the request itself and returns false if the visitor may not see the exhibit.
+func checkMaintenance(accessService service.AccessService, pages *pages, app domain.Exhibit, res *http.Response, req *http.Request, log *zap.SugaredLogger) bool {
+	if app.RuntimeInfo.Maintenance == nil {
+		return true
+	}
+
+	switch accessService.CheckMaintenance(req.Context(), app, res, req) {
+	case domain.AccessGranted:
+		return true
+	case domain.AccessGrantedRedirect:
+		gohttp.Redirect(res, req.Request, withoutCredentials(req, domain.MaintenanceParam), gohttp.StatusSeeOther)
+	default:
+		log.Debugw("exhibit is in maintenance, returning maintenance page", "requestId", req.RequestID, "exhibitId", app.Id)
+		res.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		pages.render(res, req, domain.PageMaintenance, app, pages.data(app), gohttp.StatusServiceUnavailable)
+	}
+
+	return false
+}
+
+func loginCallback(accessService service.AccessService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("Visitor login").
+			Start(req.Context(), "HTTP GET "+domain.AccessCallbackPath, trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		returnTo, err := accessService.FinishLogin(subCtx, res, req)
+		if err != nil {
+			span.RecordError(err)
+			log.Infow("visitor login failed", "error", err, "requestId", req.RequestID)
+			gohttp.Error(res, err.Error(), gohttp.StatusForbidden)
+			return
+		}
+
+		gohttp.Redirect(res, req.Request, returnTo, gohttp.StatusFound)
+	}
+}
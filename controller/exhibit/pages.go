@@ -0,0 +1,116 @@
+package exhibit
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"go.uber.org/zap"
+	"html/template"
+	"io/fs"
+	"museum/config"
+	"museum/domain"
+	"museum/http"
+	"museum/util/cache"
+	gohttp "net/http"
+	"os"
+	"path/filepath"
+)
+
+//go:embed loading.html failed.html stopping.html maintenance.html
+var defaultPages embed.FS
+
+// pages renders the pages visitors see instead of an exhibit, exhibits may replace every one of them
+type pages struct {
+	// global are the pages of exhibits that do not replace them, the ones in PAGES_DIR or the embedded ones
+	global map[string]*template.Template
+	// parsed caches the pages of exhibits by their template
+	parsed *cache.LRU[string, *template.Template]
+	host   string
+	log    *zap.SugaredLogger
+}
+
+// newPages reads the global pages, a page that is missing in PAGES_DIR is the embedded one
+func newPages(c config.Config, log *zap.SugaredLogger) *pages {
+	p := &pages{
+		global: make(map[string]*template.Template),
+		parsed: cache.NewLRU[string, *template.Template](100),
+		host:   c.GetHostname() + ":" + c.GetPort(),
+		log:    log,
+	}
+
+	for _, kind := range domain.PageKinds {
+		text, err := defaultPages.ReadFile(kind + ".html")
+		if err != nil {
+			panic("embedded page " + kind + " is missing: " + err.Error())
+		}
+
+		if dir := c.GetPagesDir(); dir != "" {
+			custom, err := os.ReadFile(filepath.Join(dir, kind+".html"))
+			switch {
+			case err == nil:
+				text = custom
+			case !errors.Is(err, fs.ErrNotExist):
+				panic("error reading page " + kind + " from " + dir + ": " + err.Error())
+			}
+		}
+
+		tmpl, err := domain.ParsePage(kind, string(text))
+		if err != nil {
+			panic("invalid page " + kind + ": " + err.Error())
+		}
+		p.global[kind] = tmpl
+	}
+
+	return p
+}
+
+// data returns what the pages of the exhibit have access to
+func (p *pages) data(app domain.Exhibit) domain.PageData {
+	return domain.NewPageData(app, p.host)
+}
+
+// template returns the page of the exhibit if it replaces it, the global page otherwise
+func (p *pages) template(kind string, app domain.Exhibit) *template.Template {
+	text := app.Pages.Get(kind)
+	if text == "" {
+		return p.global[kind]
+	}
+
+	if tmpl, ok := p.parsed.Get(text); ok {
+		return tmpl
+	}
+
+	tmpl, err := domain.ParsePage(kind, text)
+	if err != nil {
+		// exhibits are validated when they are created, this is only reached by exhibits that were created before
+		p.log.Warnw("error parsing page of exhibit, using the global page", "error", err, "page", kind, "exhibitId", app.Id)
+		return p.global[kind]
+	}
+	p.parsed.Put(text, tmpl)
+	return tmpl
+}
+
+// render answers the request with a page, the global page is used if the page of the exhibit fails
+func (p *pages) render(res *http.Response, req *http.Request, kind string, app domain.Exhibit, data domain.PageData, status int) {
+	buf := &bytes.Buffer{}
+	err := p.template(kind, app).Execute(buf, data)
+	if err != nil && app.Pages.Get(kind) != "" {
+		p.log.Warnw("error executing page of exhibit, using the global page", "error", err, "page", kind, "requestId", req.RequestID, "exhibitId", app.Id)
+		buf.Reset()
+		err = p.global[kind].Execute(buf, data)
+	}
+	if err != nil {
+		p.log.Warnw("error executing page", "error", err, "page", kind, "requestId", req.RequestID, "exhibitId", app.Id)
+		gohttp.Error(res, "the page of this exhibit could not be shown", gohttp.StatusInternalServerError)
+		return
+	}
+
+	res.Header().Set("Content-Type", "text/html; charset=utf-8")
+	res.Header().Set("Cache-Control", "no-store")
+	res.Header().Set("X-Museum-Page", kind)
+	res.WriteHeader(status)
+	_, err = res.Write(buf.Bytes())
+	if err != nil {
+		p.log.Debugw("error writing page", "error", err, "page", kind, "requestId", req.RequestID, "exhibitId", app.Id)
+	}
+}
@@ -2,8 +2,8 @@ package exhibit
 
 import (
 	"context"
-	_ "embed"
 	"errors"
+	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -13,91 +13,126 @@ import (
 	service "museum/service/interface"
 	gohttp "net/http"
 	"regexp"
-	"text/template"
+	"strconv"
 	"time"
 )
 
-//go:embed loading.html
-var loadingPage []byte
-
-type LoadingPageTemplate struct {
-	Exhibit   string
-	Host      string
-	ExhibitId string
-}
+const (
+	// holdPollInterval is how often held requests check whether the exhibit runs
+	holdPollInterval = 500 * time.Millisecond
+	// retryAfter is the number of seconds clients are asked to wait before they try an exhibit that is not ready again
+	retryAfter = 10
+)
 
-func proxyHandler(exhibitService service.ExhibitService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, log *zap.SugaredLogger, c config.Config, provider trace.TracerProvider) http.MuxHandlerFunc {
-	tmpl, _ := template.New("loading").Parse(string(loadingPage))
+func proxyHandler(exhibitService service.ExhibitService, accessService service.AccessService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, capacityService service.CapacityService, log *zap.SugaredLogger, c config.Config, provider trace.TracerProvider) http.MuxHandlerFunc {
+	pages := newPages(c, log)
 
 	return func(res *http.Response, req *http.Request) {
 		id, ok := req.Params["id"]
 		if !ok {
 			log.Warn("no id provided", "requestId", req.RequestID)
-			res.WriteHeader(gohttp.StatusBadRequest)
+			gohttp.Error(res, "no exhibit given", gohttp.StatusBadRequest)
 			return
 		}
 
-		app, err := exhibitService.GetExhibitById(req.Context(), id)
+		// exhibits can be addressed by their id or by their name,
+		// the cached read is enough here, starting an exhibit takes the locks itself
+		var app domain.Exhibit
+		var err error
+		if _, e := uuid.Parse(id); e == nil {
+			app, err = exhibitService.GetCachedExhibitById(req.Context(), id)
+		} else {
+			app, err = exhibitService.GetCachedExhibitByName(req.Context(), id)
+		}
+
+		// a mistyped name is the usual way to end up here, only errors of the state are the fault of museum
+		notFound := domain.ExhibitNotFoundError{}
+		if errors.As(err, &notFound) {
+			log.Infow("exhibit does not exist", "requestId", req.RequestID, "exhibitId", id)
+			gohttp.Error(res, "the exhibit could not be found", gohttp.StatusNotFound)
+			return
+		}
 		if err != nil {
 			log.Warnw("error getting exhibit", "error", err, "requestId", req.RequestID, "exhibitId", id)
-			res.WriteHeader(gohttp.StatusInternalServerError)
+			gohttp.Error(res, "the exhibit could not be loaded", gohttp.StatusInternalServerError)
 			return
 		}
 
-		// if the application is stopping, return a 503
+		// visitors have to meet the access policy before the exhibit is started or proxied
+		if !checkAccess(accessService, app, res, req, log) {
+			return
+		}
+
+		// curators keep visitors out while they work on the exhibit
+		if !checkMaintenance(accessService, pages, app, res, req, log) {
+			return
+		}
+
+		// if the application is stopping, return the stopping page with a 503
 		if app.RuntimeInfo.Status == domain.Stopping {
 			log.Warnw("application is stopping, returning 503", "requestId", req.RequestID, "status", app.RuntimeInfo.Status, "exhibitId", app.Id)
-			res.WriteHeader(gohttp.StatusServiceUnavailable)
+			res.Header().Set("X-Museum-Status", string(app.RuntimeInfo.Status))
+			res.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			pages.render(res, req, domain.PageStopping, app, pages.data(app), gohttp.StatusServiceUnavailable)
 			return
 		}
 
-		// if the application is not running, start it and return the loading page
-		// if the state is "starting", only return the loading page
-		if app.RuntimeInfo.Status != domain.Running {
-			log.Infow("application is not running, returning loading page", "requestId", req.RequestID, "status", app.RuntimeInfo.Status, "exhibitId", app.Id)
+		// an exhibit that just failed to start is not started again right away, visitors see why instead
+		if app.RuntimeInfo.Status == domain.Stopped && app.RuntimeInfo.Failure.Recent(time.Now()) {
+			log.Infow("application failed to start recently, returning failed page", "requestId", req.RequestID, "exhibitId", app.Id)
+			res.Header().Set("X-Museum-Status", string(app.RuntimeInfo.Status))
+			res.Header().Set("Retry-After", strconv.Itoa(int(domain.FailedPageLength.Seconds())))
+			pages.render(res, req, domain.PageFailed, app, pages.data(app), gohttp.StatusServiceUnavailable)
+			return
+		}
 
+		// if the application is not running, start it and return the loading page or hold the request until it runs
+		// if the state is "starting", only return the loading page or hold the request
+		if app.RuntimeInfo.Status != domain.Running {
 			ctx, span := provider.
 				Tracer("API request to non-running application").
 				Start(context.Background(), "HTTP "+req.Method+" "+req.URL.Path, trace.WithAttributes(attribute.String("requestId", req.RequestID), attribute.String("exhibitId", app.Id)))
 			defer span.End()
 
-			// if the application is not starting, start it
-			err := tmpl.Execute(res, LoadingPageTemplate{
-				Exhibit:   app.Name,
-				Host:      c.GetHostname() + ":" + c.GetPort(),
-				ExhibitId: app.Id,
-			})
-			span.AddEvent("loading page rendered")
-
+			// queued exhibits are asked again on every visit, that keeps their place in the queue
+			var started <-chan error
 			if app.RuntimeInfo.Status != domain.Starting {
-				log.Infow("starting application", "requestId", req.RequestID, "exhibitId", app.Id)
-				go func() {
-					// create a new span for starting the application, the tracer should outlive the request
-					subCtx, subSpan := provider.
-						Tracer("Starting application"+app.Name+" ("+app.Id+")").
-						Start(ctx, "Starting application", trace.WithAttributes(attribute.String("requestId", req.RequestID), attribute.String("exhibitId", app.Id)))
-					defer subSpan.End()
-
-					subSpan.AddEvent("starting application")
-					err := provisioner.StartApplication(subCtx, id)
-					if err != nil {
-						log.Warnw("error starting application", "error", err, "requestId", req.RequestID, "exhibitId", app.Id)
-						return
-					}
-					log.Infow("application started", "requestId", req.RequestID, "exhibitId", app.Id)
-					subSpan.AddEvent("application started")
-				}()
+				started = startApplication(ctx, provisioner, app, req, log, provider)
 			}
 
-			if err != nil {
-				res.WriteHeader(gohttp.StatusInternalServerError)
-				log.Warnw("error executing template", "error", err, "requestId", req.RequestID, "exhibitId", app.Id)
-				return
-			}
+			// api clients expect the response of the exhibit, not a page
+			if app.HoldsRequest(req.Request) {
+				log.Infow("application is not running, holding request", "requestId", req.RequestID, "status", app.RuntimeInfo.Status, "exhibitId", app.Id)
+				span.AddEvent("holding request")
 
-			span.AddEvent("loading page returned")
+				app, err = holdRequest(req.Context(), exhibitService, app, started, app.HoldTimeout(time.Duration(c.GetHoldTimeout())*time.Second))
+				if err != nil {
+					log.Infow("application did not start while the request was held", "error", err, "requestId", req.RequestID, "exhibitId", app.Id)
+					writeHeldFailure(res, app)
+					return
+				}
 
-			return
+				span.AddEvent("application running, proxying held request")
+			} else {
+				log.Infow("application is not running, returning loading page", "requestId", req.RequestID, "status", app.RuntimeInfo.Status, "exhibitId", app.Id)
+
+				// the loading page polls the exhibit until it is served without this header
+				res.Header().Set("X-Museum-Status", string(app.RuntimeInfo.Status))
+
+				position := 0
+				if app.RuntimeInfo.Status == domain.Queued {
+					position = capacityService.QueuePosition(ctx, app.Id)
+					res.Header().Set(domain.QueuePositionHeader, strconv.Itoa(position))
+				}
+
+				data := pages.data(app)
+				data.QueuePosition = position
+				pages.render(res, req, domain.PageLoading, app, data, gohttp.StatusOK)
+
+				span.AddEvent("loading page returned")
+
+				return
+			}
 		}
 
 		// proxy the request
@@ -109,7 +144,7 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 		}
 
 		go func() {
-			err := lastAccessedService.SetLastAccessed(context.Background(), id, time.Now().Unix())
+			err := lastAccessedService.SetLastAccessed(context.Background(), app.Id, time.Now().Unix())
 			if err != nil {
 				return
 			}
@@ -118,27 +153,114 @@ func proxyHandler(exhibitService service.ExhibitService, lastAccessedService ser
 	}
 }
 
-func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, log *zap.SugaredLogger, config config.Config, provider trace.TracerProvider) {
-	r.AddRoute(http.Any("/exhibit/{id}/>>", proxyHandler(exhibitService, lastAccessedService, proxy, provisioner, log, config, provider)))
+// startApplication starts the exhibit in the background, the channel receives the outcome once it is running or queued
+func startApplication(ctx context.Context, provisioner service.ApplicationProvisionerService, app domain.Exhibit, req *http.Request, log *zap.SugaredLogger, provider trace.TracerProvider) <-chan error {
+	log.Infow("starting application", "requestId", req.RequestID, "exhibitId", app.Id)
+
+	started := make(chan error, 1)
+	go func() {
+		// create a new span for starting the application, the tracer should outlive the request
+		subCtx, subSpan := provider.
+			Tracer("Starting application"+app.Name+" ("+app.Id+")").
+			Start(ctx, "Starting application", trace.WithAttributes(attribute.String("requestId", req.RequestID), attribute.String("exhibitId", app.Id)))
+		defer subSpan.End()
+
+		subSpan.AddEvent("starting application")
+		err := provisioner.StartApplication(subCtx, app.Id)
+		started <- err
+		if err != nil {
+			log.Warnw("error starting application", "error", err, "requestId", req.RequestID, "exhibitId", app.Id)
+			return
+		}
+		log.Infow("application started", "requestId", req.RequestID, "exhibitId", app.Id)
+		subSpan.AddEvent("application started")
+	}()
 
-	defaultRouteReg := regexp.MustCompile("/exhibit/([a-f0-9-]+)")
-	r.SetFallbackHandler(func(writer gohttp.ResponseWriter, req *http.Request) error {
-		// if no route was found, check if the request has a referer
-		// if it does and the referers base path is /exhibit/{id},
-		// redirect to that path
-		if req.Header.Get("Referer") != "" {
-			requestPath := req.URL.Path
-			exhibitId := defaultRouteReg.FindStringSubmatch(req.Header.Get("Referer"))[1]
+	return started
+}
 
-			if req.URL.RawQuery != "" {
-				requestPath += "?" + req.URL.RawQuery
+// heldFailure is the answer to a held request whose exhibit did not start, clients that asked for the exhibit can not read a page
+type heldFailure struct {
+	Status        string                 `json:"status"`
+	ExhibitStatus domain.Status          `json:"exhibitStatus"`
+	Failure       *domain.StartupFailure `json:"failure,omitempty"`
+}
+
+// writeHeldFailure answers a held request with a 503 that tells the client when to try again
+func writeHeldFailure(res *http.Response, app domain.Exhibit) {
+	res.Header().Set("X-Museum-Status", string(app.RuntimeInfo.Status))
+	res.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(gohttp.StatusServiceUnavailable)
+
+	var failure *domain.StartupFailure
+	if app.RuntimeInfo.Status == domain.Stopped {
+		failure = app.RuntimeInfo.Failure
+	}
+	_ = res.WriteJson(heldFailure{Status: "Service Unavailable", ExhibitStatus: app.RuntimeInfo.Status, Failure: failure})
+}
+
+// holdRequest waits until the exhibit runs, it gives up once the timeout passed or the exhibit failed to start.
+// started receives the outcome of the start of this request, it is nil if another request started the exhibit.
+func holdRequest(ctx context.Context, exhibitService service.ExhibitService, app domain.Exhibit, started <-chan error, timeout time.Duration) (domain.Exhibit, error) {
+	deadline := time.After(timeout)
+	poll := time.NewTicker(holdPollInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case err := <-started:
+			if err != nil {
+				return app, err
 			}
+			// the exhibit runs or waits in the queue now
+			started = nil
+		case <-poll.C:
+		case <-deadline:
+			return app, errors.New("exhibit did not start within " + timeout.String())
+		case <-ctx.Done():
+			return app, ctx.Err()
+		}
+
+		current, err := exhibitService.GetCachedExhibitById(ctx, app.Id)
+		if err != nil {
+			return app, err
+		}
+		app = current
 
-			writer.Header().Set("Location", "/exhibit/"+exhibitId+requestPath)
-			writer.WriteHeader(gohttp.StatusFound)
-			return nil
+		switch {
+		case app.RuntimeInfo.Status == domain.Running:
+			return app, nil
+		case started == nil && (app.RuntimeInfo.Status == domain.Stopped || app.RuntimeInfo.Status == domain.Stopping):
+			return app, errors.New("exhibit failed to start")
 		}
+	}
+}
+
+func RegisterRoutes(r *http.Mux, exhibitService service.ExhibitService, accessService service.AccessService, lastAccessedService service.LastAccessedService, proxy service.ApplicationProxyService, provisioner service.ApplicationProvisionerService, capacityService service.CapacityService, log *zap.SugaredLogger, config config.Config, provider trace.TracerProvider) {
+	r.AddRoute(http.Any("/exhibit/{id}/>>", proxyHandler(exhibitService, accessService, lastAccessedService, proxy, provisioner, capacityService, log, config, provider)))
+	r.AddRoute(http.Get(domain.AccessCallbackPath, loginCallback(accessService, log, provider)))
 
+	r.SetFallbackHandler(RedirectToReferer)
+}
+
+var refererExhibitRegex = regexp.MustCompile("/exhibit/([a-zA-Z0-9_.-]+)")
+
+// RedirectToReferer redirects requests for absolute paths from an exhibit page to the same
+// path below the exhibit, since exhibits don't know they are served below /exhibit/{id}
+func RedirectToReferer(writer gohttp.ResponseWriter, req *http.Request) error {
+	// if the request has a referer and the referers base path is /exhibit/{id}, redirect to that path
+	match := refererExhibitRegex.FindStringSubmatch(req.Header.Get("Referer"))
+	if match == nil {
 		return errors.New("no fallback route found")
-	})
+	}
+
+	requestPath := req.URL.Path
+	if req.URL.RawQuery != "" {
+		requestPath += "?" + req.URL.RawQuery
+	}
+
+	writer.Header().Set("Location", "/exhibit/"+match[1]+requestPath)
+	writer.WriteHeader(gohttp.StatusFound)
+	return nil
 }
@@ -0,0 +1,87 @@
+package exhibit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"museum/config/impl"
+	"museum/domain"
+	"museum/http"
+	service "museum/service/interface"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubExhibitService knows a single exhibit, the other methods are not called by the proxy handler
+type stubExhibitService struct {
+	service.ExhibitService
+	exhibit domain.Exhibit
+}
+
+func (s stubExhibitService) GetCachedExhibitById(_ context.Context, id string) (domain.Exhibit, error) {
+	if id != s.exhibit.Id {
+		return domain.Exhibit{}, domain.ExhibitNotFoundError{By: "id", Value: id}
+	}
+	return s.exhibit, nil
+}
+
+// grantingAccessService lets every visitor in
+type grantingAccessService struct {
+	service.AccessService
+}
+
+func (grantingAccessService) Check(context.Context, domain.Exhibit, *http.Response, *http.Request) domain.AccessDecision {
+	return domain.AccessGranted
+}
+
+// stubProvisioner answers every start with err, a nil err leaves the exhibit starting
+type stubProvisioner struct {
+	service.ApplicationProvisionerService
+	err error
+}
+
+func (s stubProvisioner) StartApplication(context.Context, string) error {
+	return s.err
+}
+
+func TestHeldRequestsNeverGetPages(t *testing.T) {
+	exhibit := domain.Exhibit{
+		Id:          "0b7c5d43-3f1a-4c57-9a45-6f3c1f1d2e10",
+		Name:        "held",
+		Hold:        &domain.HoldPolicy{Requests: domain.HoldAll, Timeout: "50ms"},
+		RuntimeInfo: &domain.ExhibitRuntimeInfo{Status: domain.Stopped},
+	}
+
+	tests := []struct {
+		name        string
+		provisioner stubProvisioner
+	}{
+		{"start failed", stubProvisioner{err: errors.New("image not found")}},
+		{"timed out", stubProvisioner{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := proxyHandler(stubExhibitService{exhibit: exhibit}, grantingAccessService{}, nil, nil, tt.provisioner, nil, zap.NewNop().Sugar(), impl.EnvConfig{HoldTimeout: 60}, noop.NewTracerProvider())
+
+			// even a browser gets no page once it is held
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(gohttp.MethodGet, "/exhibit/"+exhibit.Id+"/", nil)
+			req.Header.Set("Accept", "text/html")
+			handler(&http.Response{ResponseWriter: rec}, &http.Request{Request: req, Params: map[string]string{"id": exhibit.Id}})
+
+			assert.Equal(t, gohttp.StatusServiceUnavailable, rec.Code)
+			assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+			assert.NotContains(t, rec.Header().Get("Content-Type"), "text/html")
+
+			var body heldFailure
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, "Service Unavailable", body.Status)
+			assert.Equal(t, domain.Stopped, body.ExhibitStatus)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package health
 import (
 	_ "embed"
 	"go.uber.org/zap"
+	"museum/domain"
 	"museum/http"
 	gohttp "net/http"
 )
@@ -21,5 +22,5 @@ func healthEndpoint(log *zap.SugaredLogger) func(res *http.Response, req *http.R
 }
 
 func RegisterRoutes(r *http.Mux, log *zap.SugaredLogger) {
-	r.AddRoute(http.Get("/api/health", healthEndpoint(log)))
+	r.AddRoute(http.Get("/api/health", healthEndpoint(log)).Requires(domain.RolePublic))
 }
@@ -0,0 +1,52 @@
+package oai
+
+import (
+	"encoding/xml"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/http"
+	"museum/service"
+	gohttp "net/http"
+)
+
+// oaiHandler answers OAI-PMH requests, harvesters send the arguments as query or as form
+func oaiHandler(oaiService service.OaiService, log *zap.SugaredLogger, provider trace.TracerProvider) http.MuxHandlerFunc {
+	return func(res *http.Response, req *http.Request) {
+		subCtx, span := provider.
+			Tracer("OAI request").
+			Start(req.Context(), "HTTP "+req.Method+" /oai", trace.WithAttributes(attribute.String("requestId", req.RequestID)))
+		defer span.End()
+
+		args := req.URL.Query()
+		if req.Method == gohttp.MethodPost {
+			if err := req.ParseForm(); err != nil {
+				span.RecordError(err)
+				res.WriteHeader(gohttp.StatusBadRequest)
+				_ = res.WriteJson(map[string]string{"status": "Bad Request", "error": err.Error()})
+				return
+			}
+			args = req.PostForm
+		}
+
+		body, err := xml.MarshalIndent(oaiService.Handle(subCtx, args), "", "  ")
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error encoding oai response", "error", err, "requestId", req.RequestID)
+			res.WriteErr(err)
+			return
+		}
+
+		res.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		_, err = res.Write(append([]byte(xml.Header), body...))
+		if err != nil {
+			span.RecordError(err)
+			log.Warnw("error writing oai response", "error", err, "requestId", req.RequestID)
+		}
+	}
+}
+
+func RegisterRoutes(r *http.Mux, oaiService service.OaiService, log *zap.SugaredLogger, provider trace.TracerProvider) {
+	r.AddRoute(http.Get("/oai", oaiHandler(oaiService, log, provider)))
+	r.AddRoute(http.Post("/oai", oaiHandler(oaiService, log, provider)))
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// types of access policies
+const (
+	AccessPublic = "public"
+	AccessBasic  = "basic"
+	AccessToken  = "token"
+	AccessIp     = "ip"
+	AccessOidc   = "oidc"
+)
+
+var AccessTypes = []string{AccessPublic, AccessBasic, AccessToken, AccessIp, AccessOidc}
+
+// query parameters that carry credentials of visitors, they are removed before the exhibit is visited
+const (
+	AccessTokenParam = "museum_token"
+	ShareLinkParam   = "museum_share"
+)
+
+// AccessCallbackPath is where the OpenID Connect issuer sends visitors back to after they logged in
+const AccessCallbackPath = "/access/callback"
+
+// AccessPolicy decides which visitors may see an exhibit, exhibits without a policy are public
+type AccessPolicy struct {
+	Type   string            `json:"type" yaml:"type" jsonschema:"required" description:"Who may visit the exhibit"`
+	Users  map[string]string `json:"users,omitempty" yaml:"users,omitempty" description:"The users of basic access with their bcrypt password hashes, as created by htpasswd -nB"`
+	Token  string            `json:"token,omitempty" yaml:"token,omitempty" description:"The token that visitors pass as ?museum_token= for token access"`
+	Allow  []string          `json:"allow,omitempty" yaml:"allow,omitempty" description:"The IP addresses and CIDR ranges that may visit the exhibit for ip access"`
+	Groups []string          `json:"groups,omitempty" yaml:"groups,omitempty" description:"The groups visitors must be a member of for oidc access, every visitor that logs in may visit the exhibit if it is empty"`
+}
+
+// AccessDecision is the outcome of checking a visitor against the access policy of an exhibit
+type AccessDecision int
+
+const (
+	AccessGranted AccessDecision = iota
+	// AccessGrantedRedirect means that credentials in the query were accepted, the visitor is sent on without them
+	AccessGrantedRedirect
+	// AccessUnauthorized asks the visitor for a user and password
+	AccessUnauthorized
+	// AccessLoginRequired sends the visitor to the OpenID Connect issuer
+	AccessLoginRequired
+	AccessDenied
+)
+
+// AccessType returns the type of the access policy of the exhibit
+func (e Exhibit) AccessType() string {
+	if e.Access == nil || e.Access.Type == "" {
+		return AccessPublic
+	}
+	return e.Access.Type
+}
+
+// Paths returns the paths the exhibit is served below, it is addressed by its id and by its name.
+// Cookies of the exhibit are scoped to them, so they are never sent to other exhibits.
+func (e Exhibit) Paths() []string {
+	paths := []string{"/exhibit/" + e.Id + "/"}
+	if e.Name != "" && e.Name != e.Id {
+		paths = append(paths, "/exhibit/"+e.Name+"/")
+	}
+	return paths
+}
+
+func parsePrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		return netip.ParsePrefix(entry)
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// AllowsAddr reports whether addr is in one of the allowed addresses and ranges
+func (p AccessPolicy) AllowsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, entry := range p.Allow {
+		prefix, err := parsePrefix(entry)
+		if err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsGroups reports whether a visitor with groups may see the exhibit
+func (p AccessPolicy) AllowsGroups(groups []string) bool {
+	if len(p.Groups) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(p.Groups, func(g string) bool { return slices.Contains(groups, g) })
+}
+
+// ShareLink grants access to an exhibit until it expires, whatever its access policy is
+type ShareLink struct {
+	Url       string `json:"url"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
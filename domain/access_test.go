@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestAllowsAddr(t *testing.T) {
+	policy := AccessPolicy{Type: AccessIp, Allow: []string{"10.0.0.0/8", "192.168.1.5", "2001:db8::/32"}}
+
+	assert.True(t, policy.AllowsAddr(netip.MustParseAddr("10.1.2.3")))
+	assert.True(t, policy.AllowsAddr(netip.MustParseAddr("192.168.1.5")))
+	assert.True(t, policy.AllowsAddr(netip.MustParseAddr("::ffff:10.1.2.3")))
+	assert.True(t, policy.AllowsAddr(netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, policy.AllowsAddr(netip.MustParseAddr("192.168.1.6")))
+	assert.False(t, policy.AllowsAddr(netip.MustParseAddr("2001:db9::1")))
+}
+
+func TestAllowsGroups(t *testing.T) {
+	assert.True(t, AccessPolicy{Type: AccessOidc}.AllowsGroups(nil))
+	assert.True(t, AccessPolicy{Type: AccessOidc, Groups: []string{"lab", "staff"}}.AllowsGroups([]string{"staff"}))
+	assert.False(t, AccessPolicy{Type: AccessOidc, Groups: []string{"lab"}}.AllowsGroups([]string{"staff"}))
+}
+
+func TestValidateAccess(t *testing.T) {
+	validate := func(policy AccessPolicy) []string {
+		exhibit := Exhibit{Spec: ExhibitSpecV1, Name: "secret", Expose: "web", Lease: "1h", Objects: []Object{{Name: "web"}}, Access: &policy}
+		problems := make([]string, 0)
+		for _, p := range exhibit.Validate() {
+			if strings.HasPrefix(p.Pointer, "/access") {
+				problems = append(problems, p.Error())
+			}
+		}
+		return problems
+	}
+
+	assert.Empty(t, validate(AccessPolicy{Type: AccessBasic, Users: map[string]string{"reviewer": "$2y$05$abcdefghijklmnopqrstuv"}}))
+	assert.Empty(t, validate(AccessPolicy{Type: AccessOidc}))
+	assert.Equal(t, []string{"/access/users/alice: password must be a bcrypt hash, e.g. from htpasswd -nB"}, validate(AccessPolicy{Type: AccessBasic, Users: map[string]string{"alice": "secret"}}))
+	assert.Equal(t, []string{"/access/token: token must be at least 16 characters long"}, validate(AccessPolicy{Type: AccessToken, Token: "short"}))
+	assert.Equal(t, []string{"/access/allow/1: 10.0.0.0/33 is not an IP address or CIDR range"}, validate(AccessPolicy{Type: AccessIp, Allow: []string{"10.0.0.1", "10.0.0.0/33"}}))
+	assert.Equal(t, []string{"/access/type: access type must be one of: public, basic, token, ip, oidc"}, validate(AccessPolicy{Type: "vip"}))
+}
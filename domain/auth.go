@@ -0,0 +1,167 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// roles of api callers, every role may do everything the roles before it may do
+const (
+	RolePublic  = "public"
+	RoleViewer  = "viewer"
+	RoleCurator = "curator"
+	RoleAdmin   = "admin"
+)
+
+var roles = []string{RolePublic, RoleViewer, RoleCurator, RoleAdmin}
+
+func IsRole(role string) bool {
+	return slices.Contains(roles, role)
+}
+
+// RoleAllows reports whether a caller with role may call a route that requires the role required
+func RoleAllows(role string, required string) bool {
+	return IsRole(required) && slices.Index(roles, role) >= slices.Index(roles, required)
+}
+
+// HighestRole returns the most powerful role of the candidates, unknown roles are ignored
+func HighestRole(candidates []string) string {
+	highest := RolePublic
+	for _, candidate := range candidates {
+		if slices.Index(roles, candidate) > slices.Index(roles, highest) {
+			highest = candidate
+		}
+	}
+	return highest
+}
+
+// ParseScopedRole reads a role that is either global or scoped to a collection as role@collection,
+// admins manage the whole museum, so their role cannot be scoped
+func ParseScopedRole(value string) (role string, collection string, err error) {
+	role, collection, scoped := strings.Cut(value, "@")
+	if !IsRole(role) || role == RolePublic {
+		return "", "", errors.New("unknown role " + role)
+	}
+
+	if !scoped {
+		return role, "", nil
+	}
+
+	if role == RoleAdmin {
+		return "", "", errors.New("the admin role cannot be scoped to a collection")
+	}
+	if !CollectionNameRegex.MatchString(collection) {
+		return "", "", errors.New("invalid collection " + collection)
+	}
+	return role, collection, nil
+}
+
+// Principal is an authenticated caller of the api
+type Principal struct {
+	Subject string
+	// Role applies to all collections
+	Role string
+	// Collections are the roles the caller has in single collections, in addition to Role
+	Collections map[string]string
+	Groups      []string
+}
+
+// NewPrincipal returns the caller with the highest of its roles in each collection, roles are given as
+// accepted by ParseScopedRole and unknown ones are ignored
+func NewPrincipal(subject string, roles []string, groups []string) Principal {
+	principal := Principal{Subject: subject, Role: RolePublic, Groups: groups}
+	for _, value := range roles {
+		role, collection, err := ParseScopedRole(value)
+		switch {
+		case err != nil:
+			continue
+		case collection == "":
+			principal.Role = HighestRole([]string{principal.Role, role})
+		default:
+			if principal.Collections == nil {
+				principal.Collections = make(map[string]string)
+			}
+			principal.Collections[collection] = HighestRole([]string{principal.Collections[collection], role})
+		}
+	}
+	return principal
+}
+
+// RoleIn returns the role of the caller in collection
+func (p Principal) RoleIn(collection string) string {
+	return HighestRole([]string{p.Role, p.Collections[collection]})
+}
+
+// HighestRole returns the most powerful role the caller has in any collection
+func (p Principal) HighestRole() string {
+	highest := p.Role
+	for _, role := range p.Collections {
+		highest = HighestRole([]string{highest, role})
+	}
+	return highest
+}
+
+// ForbiddenError is returned if the caller of the api lacks the role in a collection
+type ForbiddenError struct {
+	Role       string
+	Collection string
+}
+
+func (f ForbiddenError) Error() string {
+	return "this requires the role " + f.Role + " in the collection " + f.Collection
+}
+
+// Authorize checks that the caller of ctx has role in collection. Calls without a caller are not restricted,
+// they are made by museum itself or the api is open.
+func Authorize(ctx context.Context, collection string, role string) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok || RoleAllows(principal.RoleIn(collection), role) {
+		return nil
+	}
+	return ForbiddenError{Role: role, Collection: collection}
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom returns the caller that was authenticated for the request of ctx
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// ApiToken is a static token that is configured as name:role:token, or as name:role@collection:token
+// to scope it to a collection
+type ApiToken struct {
+	Name       string
+	Role       string
+	Collection string
+	Token      string
+}
+
+func ParseApiToken(entry string) (ApiToken, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return ApiToken{}, errors.New("api token must have the form name:role:token")
+	}
+
+	role, collection, err := ParseScopedRole(parts[1])
+	if err != nil {
+		return ApiToken{}, errors.New("api token " + parts[0] + " has " + err.Error())
+	}
+
+	return ApiToken{Name: parts[0], Role: role, Collection: collection, Token: parts[2]}, nil
+}
+
+// Principal returns the caller that authenticates with the token
+func (t ApiToken) Principal() Principal {
+	if t.Collection == "" {
+		return Principal{Subject: "token:" + t.Name, Role: t.Role}
+	}
+	return Principal{Subject: "token:" + t.Name, Role: RolePublic, Collections: map[string]string{t.Collection: t.Role}}
+}
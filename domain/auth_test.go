@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRoleAllows(t *testing.T) {
+	assert.True(t, RoleAllows(RoleAdmin, RoleViewer))
+	assert.True(t, RoleAllows(RoleCurator, RoleCurator))
+	assert.False(t, RoleAllows(RoleViewer, RoleCurator))
+	assert.False(t, RoleAllows("", RoleViewer))
+	assert.False(t, RoleAllows(RoleAdmin, "superuser"))
+}
+
+func TestHighestRole(t *testing.T) {
+	assert.Equal(t, RoleCurator, HighestRole([]string{"viewer", "curator", "unknown"}))
+	assert.Equal(t, RolePublic, HighestRole(nil))
+}
+
+func TestParseApiToken(t *testing.T) {
+	token, err := ParseApiToken("ci:curator:abc:def")
+	assert.NoError(t, err)
+	assert.Equal(t, ApiToken{Name: "ci", Role: RoleCurator, Token: "abc:def"}, token)
+
+	_, err = ParseApiToken("ci:owner:abc")
+	assert.Error(t, err)
+
+	_, err = ParseApiToken("ci:public:abc")
+	assert.Error(t, err)
+
+	_, err = ParseApiToken("abc")
+	assert.Error(t, err)
+}
+
+func TestScopedRoles(t *testing.T) {
+	token, err := ParseApiToken("physics-ci:curator@physics:abc")
+	assert.NoError(t, err)
+	assert.Equal(t, ApiToken{Name: "physics-ci", Role: RoleCurator, Collection: "physics", Token: "abc"}, token)
+
+	principal := token.Principal()
+	assert.Equal(t, RoleCurator, principal.RoleIn("physics"))
+	assert.Equal(t, RolePublic, principal.RoleIn("chemistry"))
+	assert.Equal(t, RoleCurator, principal.HighestRole())
+
+	_, err = ParseApiToken("ci:admin@physics:abc")
+	assert.Error(t, err)
+
+	principal = NewPrincipal("alice", []string{"viewer", "curator@physics", "viewer@physics", "admin@chemistry", "curator@Bad"}, nil)
+	assert.Equal(t, RoleViewer, principal.Role)
+	assert.Equal(t, map[string]string{"physics": RoleCurator}, principal.Collections)
+	assert.Equal(t, RoleViewer, principal.RoleIn("chemistry"))
+}
+
+func TestAuthorize(t *testing.T) {
+	assert.NoError(t, Authorize(context.Background(), "physics", RoleCurator))
+
+	ctx := WithPrincipal(context.Background(), NewPrincipal("alice", []string{"viewer", "curator@physics"}, nil))
+	assert.NoError(t, Authorize(ctx, "physics", RoleCurator))
+	assert.ErrorAs(t, Authorize(ctx, "chemistry", RoleCurator), &ForbiddenError{})
+	assert.NoError(t, Authorize(ctx, "chemistry", RoleViewer))
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"sort"
+)
+
+// Admission is the answer of the cluster to an exhibit that should start
+type Admission struct {
+	// Admitted is true if the exhibit was let in and is starting
+	Admitted bool
+	// Evict is the id of the running exhibit that has to stop first to make room
+	Evict string
+	// Position is the place of the exhibit in the queue, starting at 1, if it has to wait
+	Position int
+}
+
+// QueuePositionHeader is the response header that tells the loading page how many exhibits are ahead in the queue
+const QueuePositionHeader = "X-Museum-Queue-Position"
+
+// SortQueue orders the queued exhibits by the time they were queued at, the first one starts next
+func SortQueue(exhibits []Exhibit) {
+	sort.SliceStable(exhibits, func(i, j int) bool {
+		a, b := exhibits[i].RuntimeInfo.QueuedAt, exhibits[j].RuntimeInfo.QueuedAt
+		if a == b {
+			return exhibits[i].Id < exhibits[j].Id
+		}
+		return a < b
+	})
+}
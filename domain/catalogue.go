@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// the meta keys exhibits are grouped by in the catalogue and in OAI-PMH sets, their values are a string or a list of strings
+const (
+	CollectionMetaKey = "collection"
+	TagsMetaKey       = "tags"
+)
+
+// MetaStrings reads a meta value that is either a string or a list of strings
+func (e Exhibit) MetaStrings(key string) []string {
+	switch v := e.Meta[key].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func (e Exhibit) Tags() []string {
+	return e.MetaStrings(TagsMetaKey)
+}
+
+// Title is the title of the exhibit in its metadata, exhibits without metadata are titled by their name
+func (e Exhibit) Title() string {
+	if e.Metadata != nil && e.Metadata.Title != "" {
+		return e.Metadata.Title
+	}
+	return e.Name
+}
+
+// MatchesSearch checks if the exhibit carries all tags and contains every word of the query
+// in its name, metadata or tags, both ignoring case
+func (e Exhibit) MatchesSearch(query string, tags []string) bool {
+	for _, tag := range tags {
+		if !slices.ContainsFunc(e.Tags(), func(t string) bool { return strings.EqualFold(t, tag) }) {
+			return false
+		}
+	}
+
+	text := []string{e.Name}
+	text = append(text, e.Tags()...)
+	text = append(text, e.MetaStrings(CollectionMetaKey)...)
+	if m := e.Metadata; m != nil {
+		text = append(text, m.Title, m.Description)
+		for _, c := range m.Creators {
+			text = append(text, c.Name, c.Affiliation)
+		}
+	}
+	haystack := strings.ToLower(strings.Join(text, "\n"))
+
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(haystack, word) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExhibitMatchesSearch(t *testing.T) {
+	e := newCitedExhibit()
+	e.Meta = map[string]interface{}{"tags": []interface{}{"Web", "Demo"}, "collection": "Examples"}
+
+	assert.True(t, e.MatchesSearch("", nil))
+	assert.True(t, e.MatchesSearch("nginx SIMULEVSKI", nil))
+	assert.True(t, e.MatchesSearch("examples", []string{"web", "Demo"}))
+	assert.False(t, e.MatchesSearch("nginx apache", nil))
+	assert.False(t, e.MatchesSearch("", []string{"web", "physics"}))
+
+	plain := Exhibit{Name: "plain", Meta: map[string]interface{}{"tags": "single"}}
+	assert.Equal(t, "plain", plain.Title())
+	assert.Equal(t, []string{"single"}, plain.Tags())
+	assert.True(t, plain.MatchesSearch("plain", []string{"single"}))
+}
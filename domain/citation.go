@@ -0,0 +1,346 @@
+package domain
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+type CitationFormat string
+
+const (
+	CitationFormatCsl      CitationFormat = "csl"
+	CitationFormatBibtex   CitationFormat = "bibtex"
+	CitationFormatDataCite CitationFormat = "datacite"
+)
+
+// CitationMediaTypes are the media types of the citation formats, as used for content negotiation by DOI resolvers
+var CitationMediaTypes = map[CitationFormat]string{
+	CitationFormatCsl:      "application/vnd.citationstyles.csl+json",
+	CitationFormatBibtex:   "application/x-bibtex",
+	CitationFormatDataCite: "application/vnd.datacite.datacite+xml",
+}
+
+// Citation is everything needed to cite an exhibit
+type Citation struct {
+	Pid       string
+	Name      string
+	Url       string
+	Publisher string
+	Metadata  Metadata
+}
+
+// Citation returns the citation of the exhibit, url is where the exhibit can be visited
+func (e Exhibit) Citation(url string, publisher string) (Citation, error) {
+	if e.Metadata == nil {
+		return Citation{}, errors.New("exhibit " + e.Name + " has no metadata to cite it by")
+	}
+
+	return Citation{
+		Pid:       e.PersistentId(),
+		Name:      e.Name,
+		Url:       url,
+		Publisher: publisher,
+		Metadata:  *e.Metadata,
+	}, nil
+}
+
+// Doi returns the DOI of the exhibit if its persistent identifier is one
+func (c Citation) Doi() string {
+	doi := c.Pid
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "doi:"} {
+		doi = strings.TrimPrefix(doi, prefix)
+	}
+
+	if !DoiRegex.MatchString(doi) {
+		return ""
+	}
+	return doi
+}
+
+// PidType returns the scheme of the persistent identifier, as named by DataCite
+func (c Citation) PidType() string {
+	switch {
+	case c.Doi() != "":
+		return "DOI"
+	case strings.HasPrefix(c.Pid, "ark:"):
+		return "ARK"
+	case strings.HasPrefix(c.Pid, "hdl:"):
+		return "Handle"
+	case strings.HasPrefix(c.Pid, "urn:"):
+		return "URN"
+	case strings.HasPrefix(c.Pid, "http://"), strings.HasPrefix(c.Pid, "https://"):
+		return "URL"
+	default:
+		return "Local"
+	}
+}
+
+type CslItem struct {
+	Id         string    `json:"id"`
+	Type       string    `json:"type"`
+	Title      string    `json:"title"`
+	Author     []CslName `json:"author"`
+	Issued     CslDate   `json:"issued"`
+	Abstract   string    `json:"abstract,omitempty"`
+	Publisher  string    `json:"publisher,omitempty"`
+	URL        string    `json:"URL"`
+	DOI        string    `json:"DOI,omitempty"`
+	License    string    `json:"license,omitempty"`
+	References string    `json:"references,omitempty"`
+}
+
+// CslName is a person with family and given name, or an organisation with a literal name
+type CslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type CslDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// Csl returns the citation as a CSL-JSON item
+func (c Citation) Csl() CslItem {
+	authors := make([]CslName, 0, len(c.Metadata.Creators))
+	for _, creator := range c.Metadata.Creators {
+		family, given := creator.FamilyGiven()
+		if given == "" {
+			authors = append(authors, CslName{Literal: family})
+		} else {
+			authors = append(authors, CslName{Family: family, Given: given})
+		}
+	}
+
+	item := CslItem{
+		Id:        c.Pid,
+		Type:      "software",
+		Title:     c.Metadata.Title,
+		Author:    authors,
+		Issued:    CslDate{DateParts: [][]int{{c.Metadata.Year}}},
+		Abstract:  c.Metadata.Description,
+		Publisher: c.Publisher,
+		URL:       c.Url,
+		DOI:       c.Doi(),
+		License:   c.Metadata.License,
+	}
+	if c.Metadata.Publication != "" {
+		item.References = "https://doi.org/" + c.Metadata.Publication
+	}
+
+	return item
+}
+
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// Bibtex returns the citation as a biblatex @software entry keyed by the name of the exhibit and the year
+// Text is the citation for reading, e.g. on the landing page of the exhibit
+func (c Citation) Text() string {
+	creators := make([]string, 0, len(c.Metadata.Creators))
+	for _, creator := range c.Metadata.Creators {
+		creators = append(creators, creator.Name)
+	}
+
+	link := c.Url
+	if doi := c.Doi(); doi != "" {
+		link = "https://doi.org/" + doi
+	}
+
+	return strings.Join(creators, "; ") + " (" + strconv.Itoa(c.Metadata.Year) + "). " + c.Metadata.Title + " [Software]. " + c.Publisher + ". " + link
+}
+
+func (c Citation) Bibtex() string {
+	authors := make([]string, 0, len(c.Metadata.Creators))
+	for _, creator := range c.Metadata.Creators {
+		family, given := creator.FamilyGiven()
+		if given == "" {
+			// organisations are braced, so that their name is not split into parts
+			authors = append(authors, "{"+bibtexEscaper.Replace(family)+"}")
+		} else {
+			authors = append(authors, bibtexEscaper.Replace(family)+", "+bibtexEscaper.Replace(given))
+		}
+	}
+
+	b := &strings.Builder{}
+	field := func(name string, value string) {
+		if value != "" {
+			b.WriteString("  " + name + " = {" + value + "},\n")
+		}
+	}
+
+	b.WriteString("@software{" + c.Name + "_" + strconv.Itoa(c.Metadata.Year) + ",\n")
+	field("author", strings.Join(authors, " and "))
+	// the title is braced twice to keep its capitalisation
+	field("title", "{"+bibtexEscaper.Replace(c.Metadata.Title)+"}")
+	field("year", strconv.Itoa(c.Metadata.Year))
+	field("publisher", bibtexEscaper.Replace(c.Publisher))
+	field("url", c.Url)
+	field("doi", c.Doi())
+	if c.Doi() == "" {
+		field("note", "Persistent identifier: "+bibtexEscaper.Replace(c.Pid))
+	}
+	if c.Metadata.Publication != "" {
+		field("addendum", "Related publication: https://doi.org/"+bibtexEscaper.Replace(c.Metadata.Publication))
+	}
+	field("license", bibtexEscaper.Replace(c.Metadata.License))
+	field("abstract", bibtexEscaper.Replace(c.Metadata.Description))
+	b.WriteString("}\n")
+
+	return b.String()
+}
+
+type DataCiteResource struct {
+	XMLName              xml.Name              `xml:"http://datacite.org/schema/kernel-4 resource"`
+	Xsi                  string                `xml:"xmlns:xsi,attr"`
+	SchemaLocation       string                `xml:"xsi:schemaLocation,attr"`
+	Identifier           *DataCiteIdentifier   `xml:"identifier,omitempty"`
+	Creators             []DataCiteCreator     `xml:"creators>creator"`
+	Titles               []string              `xml:"titles>title"`
+	Publisher            string                `xml:"publisher"`
+	PublicationYear      int                   `xml:"publicationYear"`
+	ResourceType         DataCiteResourceType  `xml:"resourceType"`
+	AlternateIdentifiers []DataCiteAlternateId `xml:"alternateIdentifiers>alternateIdentifier"`
+	// optional lists are pointers, so that they are left out instead of being written empty
+	RelatedIdentifiers *DataCiteRelatedIdentifiers `xml:"relatedIdentifiers,omitempty"`
+	RightsList         *DataCiteRightsList         `xml:"rightsList,omitempty"`
+	Descriptions       *DataCiteDescriptions       `xml:"descriptions,omitempty"`
+}
+
+type DataCiteRelatedIdentifiers struct {
+	RelatedIdentifiers []DataCiteRelatedIdentifier `xml:"relatedIdentifier"`
+}
+
+type DataCiteRightsList struct {
+	Rights []DataCiteRights `xml:"rights"`
+}
+
+type DataCiteDescriptions struct {
+	Descriptions []DataCiteDescription `xml:"description"`
+}
+
+type DataCiteIdentifier struct {
+	Type  string `xml:"identifierType,attr"`
+	Value string `xml:",chardata"`
+}
+
+type DataCiteCreator struct {
+	Name           DataCiteCreatorName     `xml:"creatorName"`
+	GivenName      string                  `xml:"givenName,omitempty"`
+	FamilyName     string                  `xml:"familyName,omitempty"`
+	NameIdentifier *DataCiteNameIdentifier `xml:"nameIdentifier,omitempty"`
+	Affiliation    string                  `xml:"affiliation,omitempty"`
+}
+
+type DataCiteCreatorName struct {
+	NameType string `xml:"nameType,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type DataCiteNameIdentifier struct {
+	Scheme    string `xml:"nameIdentifierScheme,attr"`
+	SchemeUri string `xml:"schemeURI,attr"`
+	Value     string `xml:",chardata"`
+}
+
+type DataCiteResourceType struct {
+	General string `xml:"resourceTypeGeneral,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type DataCiteAlternateId struct {
+	Type  string `xml:"alternateIdentifierType,attr"`
+	Value string `xml:",chardata"`
+}
+
+type DataCiteRelatedIdentifier struct {
+	Type         string `xml:"relatedIdentifierType,attr"`
+	RelationType string `xml:"relationType,attr"`
+	Value        string `xml:",chardata"`
+}
+
+type DataCiteRights struct {
+	Identifier       string `xml:"rightsIdentifier,attr,omitempty"`
+	IdentifierScheme string `xml:"rightsIdentifierScheme,attr,omitempty"`
+	Uri              string `xml:"rightsURI,attr,omitempty"`
+	Value            string `xml:",chardata"`
+}
+
+type DataCiteDescription struct {
+	Type  string `xml:"descriptionType,attr"`
+	Value string `xml:",chardata"`
+}
+
+// DataCite returns the citation as a DataCite metadata kernel 4 resource. DataCite only knows DOIs as identifiers,
+// other persistent identifiers are written as alternate identifiers.
+func (c Citation) DataCite() ([]byte, error) {
+	resource := DataCiteResource{
+		Xsi:                  "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation:       "http://datacite.org/schema/kernel-4 http://schema.datacite.org/meta/kernel-4/metadata.xsd",
+		Creators:             make([]DataCiteCreator, 0, len(c.Metadata.Creators)),
+		Titles:               []string{c.Metadata.Title},
+		Publisher:            c.Publisher,
+		PublicationYear:      c.Metadata.Year,
+		ResourceType:         DataCiteResourceType{General: "Software", Value: "Archived application"},
+		AlternateIdentifiers: []DataCiteAlternateId{{Type: "URL", Value: c.Url}},
+	}
+
+	if doi := c.Doi(); doi != "" {
+		resource.Identifier = &DataCiteIdentifier{Type: "DOI", Value: doi}
+	} else {
+		resource.AlternateIdentifiers = append(resource.AlternateIdentifiers, DataCiteAlternateId{Type: c.PidType(), Value: c.Pid})
+	}
+
+	for _, creator := range c.Metadata.Creators {
+		family, given := creator.FamilyGiven()
+		dc := DataCiteCreator{Name: DataCiteCreatorName{NameType: "Organizational", Value: family}, Affiliation: creator.Affiliation}
+		if given != "" {
+			dc.Name = DataCiteCreatorName{NameType: "Personal", Value: family + ", " + given}
+			dc.GivenName = given
+			dc.FamilyName = family
+		}
+		if creator.Orcid != "" {
+			dc.NameIdentifier = &DataCiteNameIdentifier{Scheme: "ORCID", SchemeUri: "https://orcid.org", Value: creator.Orcid}
+		}
+		resource.Creators = append(resource.Creators, dc)
+	}
+
+	if c.Metadata.Publication != "" {
+		resource.RelatedIdentifiers = &DataCiteRelatedIdentifiers{RelatedIdentifiers: []DataCiteRelatedIdentifier{{Type: "DOI", RelationType: "IsSupplementTo", Value: c.Metadata.Publication}}}
+	}
+
+	if license := c.Metadata.License; license != "" {
+		rights := DataCiteRights{Value: license}
+		if strings.HasPrefix(license, "http://") || strings.HasPrefix(license, "https://") {
+			rights.Uri = license
+		} else {
+			rights.Identifier = license
+			rights.IdentifierScheme = "SPDX"
+			rights.Uri = "https://spdx.org/licenses/" + license + ".html"
+		}
+		resource.RightsList = &DataCiteRightsList{Rights: []DataCiteRights{rights}}
+	}
+
+	if c.Metadata.Description != "" {
+		resource.Descriptions = &DataCiteDescriptions{Descriptions: []DataCiteDescription{{Type: "Abstract", Value: c.Metadata.Description}}}
+	}
+
+	b, err := xml.MarshalIndent(resource, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
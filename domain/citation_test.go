@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func newCitedExhibit() Exhibit {
+	return Exhibit{
+		Id:   "6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a",
+		Name: "my-site",
+		Metadata: &Metadata{
+			Title:       "Nginx & friends",
+			Creators:    []Creator{{Name: "Simulevski, Ariel", Orcid: "0000-0002-1825-0097", Affiliation: "University of Vienna"}, {Name: "Phaidra"}},
+			Year:        2024,
+			Description: "A simple example",
+			Publication: "10.1000/182",
+			License:     "MIT",
+		},
+	}
+}
+
+func TestCitationWithoutMetadata(t *testing.T) {
+	_, err := Exhibit{Name: "plain"}.Citation("http://localhost:8080/exhibit/x", "localhost")
+	assert.EqualError(t, err, "exhibit plain has no metadata to cite it by")
+}
+
+func TestCitationCsl(t *testing.T) {
+	citation, err := newCitedExhibit().Citation("http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", "localhost")
+	assert.NoError(t, err)
+
+	assert.Equal(t, CslItem{
+		Id:         "urn:uuid:6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a",
+		Type:       "software",
+		Title:      "Nginx & friends",
+		Author:     []CslName{{Family: "Simulevski", Given: "Ariel"}, {Literal: "Phaidra"}},
+		Issued:     CslDate{DateParts: [][]int{{2024}}},
+		Abstract:   "A simple example",
+		Publisher:  "localhost",
+		URL:        "http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a",
+		License:    "MIT",
+		References: "https://doi.org/10.1000/182",
+	}, citation.Csl())
+}
+
+func TestCitationBibtex(t *testing.T) {
+	citation, err := newCitedExhibit().Citation("http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", "localhost")
+	assert.NoError(t, err)
+
+	assert.Equal(t, `@software{my-site_2024,
+  author = {Simulevski, Ariel and {Phaidra}},
+  title = {{Nginx \& friends}},
+  year = {2024},
+  publisher = {localhost},
+  url = {http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a},
+  note = {Persistent identifier: urn:uuid:6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a},
+  addendum = {Related publication: https://doi.org/10.1000/182},
+  license = {MIT},
+  abstract = {A simple example},
+}
+`, citation.Bibtex())
+}
+
+func TestCitationDataCite(t *testing.T) {
+	exhibit := newCitedExhibit()
+	citation, err := exhibit.Citation("http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", "localhost")
+	assert.NoError(t, err)
+
+	b, err := citation.DataCite()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), `<resource xmlns="http://datacite.org/schema/kernel-4"`)
+
+	resource := DataCiteResource{}
+	assert.NoError(t, xml.Unmarshal(b, &resource))
+	assert.Nil(t, resource.Identifier)
+	assert.Equal(t, []DataCiteAlternateId{
+		{Type: "URL", Value: "http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a"},
+		{Type: "URN", Value: "urn:uuid:6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a"},
+	}, resource.AlternateIdentifiers)
+	assert.Equal(t, "Simulevski, Ariel", resource.Creators[0].Name.Value)
+	assert.Equal(t, "Personal", resource.Creators[0].Name.NameType)
+	assert.Equal(t, "0000-0002-1825-0097", resource.Creators[0].NameIdentifier.Value)
+	assert.Equal(t, "Organizational", resource.Creators[1].Name.NameType)
+	assert.Equal(t, 2024, resource.PublicationYear)
+	assert.Equal(t, "10.1000/182", resource.RelatedIdentifiers.RelatedIdentifiers[0].Value)
+	assert.Equal(t, "SPDX", resource.RightsList.Rights[0].IdentifierScheme)
+
+	// a DOI as persistent identifier is the identifier of the resource
+	exhibit.Pid = "https://doi.org/10.5555/museum.1"
+	exhibit.Metadata.Publication = ""
+	exhibit.Metadata.License = ""
+	citation, err = exhibit.Citation("http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", "localhost")
+	assert.NoError(t, err)
+
+	b, err = citation.DataCite()
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "relatedIdentifiers")
+	assert.NotContains(t, string(b), "rightsList")
+
+	resource = DataCiteResource{}
+	assert.NoError(t, xml.Unmarshal(b, &resource))
+	assert.Equal(t, &DataCiteIdentifier{Type: "DOI", Value: "10.5555/museum.1"}, resource.Identifier)
+	assert.Equal(t, "10.5555/museum.1", citation.Csl().DOI)
+}
+
+func TestValidateMetadata(t *testing.T) {
+	exhibit := newCitedExhibit()
+	exhibit.Pid = "not a pid"
+	exhibit.Metadata.Title = ""
+	exhibit.Metadata.Creators = append(exhibit.Metadata.Creators, Creator{Orcid: "1234"})
+	exhibit.Metadata.Year = 24
+	exhibit.Metadata.Publication = "https://doi.org/10.1000/182"
+
+	problems := make([]string, 0)
+	for _, p := range exhibit.Validate() {
+		if p.Pointer == "/pid" || strings.HasPrefix(p.Pointer, "/metadata") {
+			problems = append(problems, p.Error())
+		}
+	}
+
+	assert.Equal(t, []string{
+		"/pid: persistent identifier must not contain whitespace",
+		"/metadata/title: metadata must have a title",
+		"/metadata/creators/2/name: creator must have a name",
+		"/metadata/creators/2/orcid: orcid must be an ORCID iD like 0000-0002-1825-0097",
+		"/metadata/year: metadata year must be a four digit year",
+		"/metadata/publication: publication must be a DOI like 10.1000/182, without https://doi.org/",
+	}, problems)
+}
+
+func TestCitationText(t *testing.T) {
+	citation, err := newCitedExhibit().Citation("http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", "localhost")
+	assert.NoError(t, err)
+	assert.Equal(t, "Simulevski, Ariel; Phaidra (2024). Nginx & friends [Software]. localhost. http://localhost:8080/exhibit/6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", citation.Text())
+
+	citation.Pid = "doi:10.5281/zenodo.123"
+	assert.Equal(t, "Simulevski, Ariel; Phaidra (2024). Nginx & friends [Software]. localhost. https://doi.org/10.5281/zenodo.123", citation.Text())
+}
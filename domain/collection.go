@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"errors"
+	"github.com/docker/go-units"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strconv"
+)
+
+// DefaultCollection owns the exhibits that do not name a collection, it exists even if it is not configured
+const DefaultCollection = "default"
+
+var CollectionNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Collection is a tenant of the museum, it owns exhibits and limits what they may use
+type Collection struct {
+	Name  string `json:"name" yaml:"name"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+	Quota Quota  `json:"quota" yaml:"quota,omitempty"`
+}
+
+// Quota limits the exhibits of a collection, limits that are zero or empty do not apply
+type Quota struct {
+	MaxExhibits int `json:"maxExhibits,omitempty" yaml:"maxExhibits,omitempty"`
+	// MaxRunning counts the exhibits that are starting, running or stopping
+	MaxRunning int `json:"maxRunning,omitempty" yaml:"maxRunning,omitempty"`
+	// Cpus and Memory are shared by the objects of all running exhibits, as they are limited by their resources
+	Cpus   float64 `json:"cpus,omitempty" yaml:"cpus,omitempty"`
+	Memory string  `json:"memory,omitempty" yaml:"memory,omitempty"`
+}
+
+// CollectionUsage is what the exhibits of a collection use right now, Memory is in bytes
+type CollectionUsage struct {
+	Exhibits int     `json:"exhibits"`
+	Running  int     `json:"running"`
+	Cpus     float64 `json:"cpus"`
+	Memory   int64   `json:"memory"`
+}
+
+type CollectionDto struct {
+	Collection
+	Usage CollectionUsage `json:"usage"`
+}
+
+// CollectionName returns the collection that owns the exhibit
+func (e Exhibit) CollectionName() string {
+	if e.Collection == "" {
+		return DefaultCollection
+	}
+	return e.Collection
+}
+
+// Occupies reports whether the exhibit counts against the running exhibits of its collection
+func (e Exhibit) Occupies() bool {
+	if e.RuntimeInfo == nil {
+		return false
+	}
+	status := e.RuntimeInfo.Status
+	return status == Starting || status == Running || status == Stopping
+}
+
+// Resources returns the cpus and bytes of memory the objects of the exhibit are limited to together,
+// objects with replicas count with the most replicas they may have
+func (e Exhibit) Resources() (float64, int64) {
+	cpus, memory := 0.0, int64(0)
+	for _, o := range e.Objects {
+		if o.Resources == nil {
+			continue
+		}
+		replicas := o.Replicas.Most()
+		cpus += o.Resources.Cpus * float64(replicas)
+		memory += o.Resources.MemoryBytes() * int64(replicas)
+	}
+	return cpus, memory
+}
+
+// Budgeted reports whether the quota limits cpus or memory, the objects of the exhibits must declare resources then
+func (q Quota) Budgeted() bool {
+	return q.Cpus > 0 || q.Memory != ""
+}
+
+// MemoryBytes returns the memory budget in bytes, it is 0 if there is none
+func (q Quota) MemoryBytes() int64 {
+	if q.Memory == "" {
+		return 0
+	}
+	memory, err := units.RAMInBytes(q.Memory)
+	if err != nil {
+		return 0
+	}
+	return memory
+}
+
+// AllowsCreate returns why one more exhibit may not be created in a collection with usage
+func (q Quota) AllowsCreate(usage CollectionUsage) error {
+	if q.MaxExhibits > 0 && usage.Exhibits >= q.MaxExhibits {
+		return errors.New("the collection already has its maximum of " + strconv.Itoa(q.MaxExhibits) + " exhibits")
+	}
+	return nil
+}
+
+// AllowsStart returns why exhibit may not be started in a collection with usage
+func (q Quota) AllowsStart(usage CollectionUsage, exhibit Exhibit) error {
+	if q.MaxRunning > 0 && usage.Running >= q.MaxRunning {
+		return errors.New("the collection already runs its maximum of " + strconv.Itoa(q.MaxRunning) + " exhibits")
+	}
+
+	cpus, memory := exhibit.Resources()
+	if q.Cpus > 0 && usage.Cpus+cpus > q.Cpus {
+		return errors.New("the exhibit needs " + strconv.FormatFloat(cpus, 'f', -1, 64) + " cpus, but only " + strconv.FormatFloat(q.Cpus-usage.Cpus, 'f', -1, 64) + " of the collection are left")
+	}
+	if budget := q.MemoryBytes(); budget > 0 && usage.Memory+memory > budget {
+		return errors.New("the exhibit needs " + units.BytesSize(float64(memory)) + " of memory, but only " + units.BytesSize(float64(budget-usage.Memory)) + " of the collection are left")
+	}
+
+	return nil
+}
+
+// ParseCollections reads the collections file, the default collection is added unless the file configures it
+func ParseCollections(data []byte) ([]Collection, error) {
+	file := struct {
+		Collections []Collection `yaml:"collections"`
+	}{}
+	err := yaml.Unmarshal(data, &file)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range file.Collections {
+		if !CollectionNameRegex.MatchString(c.Name) {
+			return nil, errors.New("collection name " + strconv.Quote(c.Name) + " must start with a lowercase letter or digit and may only contain lowercase letters, digits and '-'")
+		}
+		if seen[c.Name] {
+			return nil, errors.New("collection " + c.Name + " is configured twice")
+		}
+		seen[c.Name] = true
+
+		if c.Quota.MaxExhibits < 0 || c.Quota.MaxRunning < 0 || c.Quota.Cpus < 0 {
+			return nil, errors.New("quota of collection " + c.Name + " must not be negative")
+		}
+		if _, err := units.RAMInBytes(c.Quota.Memory); c.Quota.Memory != "" && err != nil {
+			return nil, errors.New("memory quota of collection " + c.Name + " is invalid: " + err.Error())
+		}
+	}
+
+	if !seen[DefaultCollection] {
+		file.Collections = append(file.Collections, Collection{Name: DefaultCollection})
+	}
+
+	return file.Collections, nil
+}
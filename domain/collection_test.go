@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseCollections(t *testing.T) {
+	collections, err := ParseCollections([]byte(`
+collections:
+  - name: physics
+    title: Department of Physics
+    quota:
+      maxExhibits: 10
+      maxRunning: 2
+      cpus: 4
+      memory: 8g
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []Collection{
+		{Name: "physics", Title: "Department of Physics", Quota: Quota{MaxExhibits: 10, MaxRunning: 2, Cpus: 4, Memory: "8g"}},
+		{Name: DefaultCollection},
+	}, collections)
+
+	_, err = ParseCollections([]byte("collections: [{name: Physics}]"))
+	assert.Error(t, err)
+
+	_, err = ParseCollections([]byte("collections: [{name: physics}, {name: physics}]"))
+	assert.Error(t, err)
+
+	_, err = ParseCollections([]byte("collections: [{name: physics, quota: {memory: lots}}]"))
+	assert.Error(t, err)
+}
+
+func TestQuotaAllowsStart(t *testing.T) {
+	exhibit := Exhibit{
+		Objects:     []Object{{Name: "web", Resources: &Resources{Cpus: 0.5, Memory: "512m"}}, {Name: "db", Resources: &Resources{Cpus: 1, Memory: "1g"}}},
+		RuntimeInfo: &ExhibitRuntimeInfo{Status: Stopped},
+	}
+
+	cpus, memory := exhibit.Resources()
+	assert.Equal(t, 1.5, cpus)
+	assert.Equal(t, int64(1536*1024*1024), memory)
+	assert.False(t, exhibit.Occupies())
+
+	quota := Quota{MaxRunning: 2, Cpus: 2, Memory: "2g"}
+	assert.NoError(t, quota.AllowsStart(CollectionUsage{}, exhibit))
+	assert.ErrorContains(t, quota.AllowsStart(CollectionUsage{Running: 2}, exhibit), "maximum of 2")
+	assert.ErrorContains(t, quota.AllowsStart(CollectionUsage{Running: 1, Cpus: 1}, exhibit), "cpus")
+	assert.ErrorContains(t, quota.AllowsStart(CollectionUsage{Running: 1, Memory: 1 << 30}, exhibit), "memory")
+
+	assert.NoError(t, Quota{}.AllowsCreate(CollectionUsage{Exhibits: 100}))
+	assert.Error(t, Quota{MaxExhibits: 1}.AllowsCreate(CollectionUsage{Exhibits: 1}))
+}
@@ -36,6 +36,13 @@ type ExhibitStoppingEvent struct {
 	ExhibitId string `json:"exhibitId"`
 }
 
+// ExhibitFixityEvent is dispatched when a fixity check finds checksums that do not match the baseline
+type ExhibitFixityEvent struct {
+	ExhibitId  string           `json:"exhibitId"`
+	CheckedAt  int64            `json:"checkedAt"`
+	Mismatches []FixityMismatch `json:"mismatches"`
+}
+
 func (e ExhibitStartingStepEvent) ToMap() map[string]string {
 	return map[string]string{
 		"exhibitId":        e.ExhibitId,
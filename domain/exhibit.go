@@ -1,16 +1,44 @@
 package domain
 
+import "time"
+
+// ExhibitSpecV1 is the spec of exhibit files as documented in docs/exhibit_files.md
+const ExhibitSpecV1 = "v1"
+
+// SupportedExhibitSpecs are the exhibit file specs this version of museum can run
+var SupportedExhibitSpecs = []string{ExhibitSpecV1}
+
+// ExhibitNotFoundError is returned if no exhibit has the id or name, other errors of the state are not about the exhibit
+type ExhibitNotFoundError struct {
+	By    string
+	Value string
+}
+
+func (e ExhibitNotFoundError) Error() string {
+	return "exhibit with " + e.By + " " + e.Value + " not found"
+}
+
 type Exhibit struct {
-	Id          string                 `json:"id"`
-	Name        string                 `json:"name" yaml:"name"`
-	Expose      string                 `json:"expose" yaml:"expose"`
-	Rewrite     *bool                  `json:"rewrite" yaml:"rewrite"`
-	Objects     []Object               `json:"objects" yaml:"objects"`
-	Lease       string                 `json:"lease" yaml:"lease"`
-	Order       []string               `json:"order" yaml:"order"`
-	Meta        map[string]interface{} `json:"meta" yaml:"meta"`
-	Volumes     []Volume               `json:"volumes" yaml:"volumes"`
-	RuntimeInfo *ExhibitRuntimeInfo    `json:"-"`
+	Spec        string                 `json:"spec" yaml:"spec" jsonschema:"required" description:"The version of the exhibit file format"`
+	Id          string                 `json:"id" yaml:"id,omitempty" description:"The id of the exhibit, it is assigned on creation"`
+	Pid         string                 `json:"pid,omitempty" yaml:"pid,omitempty" description:"The persistent identifier of the exhibit, it is assigned on creation unless one was minted elsewhere"`
+	Name        string                 `json:"name" yaml:"name" jsonschema:"required" description:"The unique name of the exhibit, it can be used instead of the id in exhibit urls"`
+	Collection  string                 `json:"collection,omitempty" yaml:"collection,omitempty" description:"The collection that owns the exhibit and whose quota it counts against, defaults to default"`
+	Expose      string                 `json:"expose" yaml:"expose" jsonschema:"required" description:"The object to expose"`
+	Rewrite     *bool                  `json:"rewrite" yaml:"rewrite,omitempty" description:"Determines if requests will be rewritten by the rewrite service"`
+	Objects     []Object               `json:"objects" yaml:"objects" jsonschema:"required" description:"The containers of the exhibit"`
+	Lease       string                 `json:"lease" yaml:"lease" jsonschema:"required" description:"How long the exhibit keeps running after it was last accessed, as a duration string (e.g. 2h)"`
+	Order       []string               `json:"order" yaml:"order,omitempty" description:"The order in which the objects are started, defaults to the defined order"`
+	Meta        map[string]interface{} `json:"meta" yaml:"meta,omitempty" description:"Metadata that is passed on to external applications"`
+	Metadata    *Metadata              `json:"metadata,omitempty" yaml:"metadata,omitempty" description:"Descriptive metadata used to cite the exhibit"`
+	Volumes     []Volume               `json:"volumes" yaml:"volumes,omitempty" description:"The volumes that are mounted into the objects"`
+	Access      *AccessPolicy          `json:"access,omitempty" yaml:"access,omitempty" description:"Who may visit the exhibit, it is public if this is missing"`
+	Schedule    *Schedule              `json:"schedule,omitempty" yaml:"schedule,omitempty" description:"When the exhibit runs regardless of visitors, it only runs on demand if this is missing"`
+	Hold        *HoldPolicy            `json:"hold,omitempty" yaml:"hold,omitempty" description:"Which requests wait for the exhibit to start instead of receiving the loading page"`
+	Pages       *Pages                 `json:"pages,omitempty" yaml:"pages,omitempty" description:"Replace the pages visitors see while the exhibit is not running"`
+	CreatedAt   int64                  `json:"createdAt,omitempty" yaml:"createdAt,omitempty" description:"The unix time the exhibit was created at, it is set on creation"`
+	UpdatedAt   int64                  `json:"updatedAt,omitempty" yaml:"updatedAt,omitempty" description:"The unix time the exhibit was last changed at, it is set on creation and import"`
+	RuntimeInfo *ExhibitRuntimeInfo    `json:"-" yaml:"-"`
 }
 
 func (e Exhibit) ToDto() ExhibitDto {
@@ -19,23 +47,41 @@ func (e Exhibit) ToDto() ExhibitDto {
 		objects = append(objects, o.ToDto())
 	}
 
+	runtimeInfo := e.RuntimeInfo.ToDto()
+	if lease, err := time.ParseDuration(e.Lease); err == nil && runtimeInfo.Status == Running && !e.AlwaysOn() {
+		expiresAt := time.Unix(runtimeInfo.LastAccessed, 0).Add(lease)
+		// the lease does not expire while a window of the schedule is open
+		if until, scheduled := e.ScheduledUntil(time.Now()); scheduled && until.After(expiresAt) {
+			expiresAt = until
+		}
+		runtimeInfo.ExpiresAt = expiresAt.Unix()
+	}
+
 	return ExhibitDto{
 		Id:          e.Id,
+		Pid:         e.PersistentId(),
 		Name:        e.Name,
-		RuntimeInfo: e.RuntimeInfo.ToDto(),
+		Collection:  e.CollectionName(),
+		RuntimeInfo: runtimeInfo,
 		Lease:       e.Lease,
 		Objects:     objects,
 		Meta:        e.Meta,
+		Metadata:    e.Metadata,
+		Access:      e.AccessType(),
+		Schedule:    e.Schedule,
+		CreatedAt:   e.CreatedAt,
+		UpdatedAt:   e.UpdatedAt,
 	}
 }
 
 func (e Exhibit) GetTotalSteps() int {
 	steps := 0
 	for _, object := range e.Objects {
-		steps += 4
+		objectSteps := 4
 		if object.Livecheck != nil {
-			steps++
+			objectSteps++
 		}
+		steps += objectSteps * object.Replicas.Initial()
 	}
 	return steps
 }
@@ -0,0 +1,41 @@
+package domain
+
+// ExhibitBundleVersion is the version of the exhibit bundle format written by this version of museum.
+// Bundles of newer versions are rejected on import.
+const ExhibitBundleVersion = 1
+
+// Paths of an exhibit bundle, a tar archive that contains everything needed to run an exhibit
+const (
+	// ExhibitBundleManifestPath is the last file of a bundle, it is written once all other files are checksummed
+	ExhibitBundleManifestPath = "manifest.json"
+	// ExhibitBundleDefinitionPath is the exhibit file of the exhibit
+	ExhibitBundleDefinitionPath = "exhibit.yml"
+	// ExhibitBundleImagesPath is the directory that holds the images of all objects as an OCI image layout
+	ExhibitBundleImagesPath = "images/"
+	// ExhibitBundleVolumesPath is the directory that holds the data of every volume in a directory named after it
+	ExhibitBundleVolumesPath = "volumes/"
+)
+
+// ExhibitBundleManifest describes the content of an exhibit bundle
+type ExhibitBundleManifest struct {
+	Version int `json:"version"`
+	// CreatedAt is the unix time the bundle was written at
+	CreatedAt int64  `json:"createdAt"`
+	ExhibitId string `json:"exhibitId"`
+	Exhibit   string `json:"exhibit"`
+	// Images are the references of the images in the bundle, e.g. nginx:latest
+	Images  []string `json:"images"`
+	Volumes []string `json:"volumes"`
+	// Files maps the path of every file in the bundle, except the manifest, to its sha256 checksum
+	Files map[string]string `json:"files"`
+}
+
+// ExhibitBundleFormat is the format an exhibit is exported in
+type ExhibitBundleFormat string
+
+const (
+	// ExhibitBundleFormatMuseum is a bundle that can be imported by museum
+	ExhibitBundleFormatMuseum ExhibitBundleFormat = "museum"
+	// ExhibitBundleFormatBagIt is a BagIt bag (RFC 8493) for archives, the payload has the layout of a museum bundle
+	ExhibitBundleFormatBagIt ExhibitBundleFormat = "bagit"
+)
@@ -2,18 +2,32 @@ package domain
 
 type ExhibitDto struct {
 	Id          string                 `json:"id"`
+	Pid         string                 `json:"pid"`
 	Name        string                 `json:"name"`
+	Collection  string                 `json:"collection"`
 	RuntimeInfo RuntimeInfoDto         `json:"runtime_info"`
 	Lease       string                 `json:"lease"`
 	Objects     []ObjectDto            `json:"objects"`
 	Meta        map[string]interface{} `json:"meta"`
+	Metadata    *Metadata              `json:"metadata,omitempty"`
+	// Access is the type of the access policy, the credentials of the policy are left out
+	Access    string    `json:"access"`
+	Schedule  *Schedule `json:"schedule,omitempty"`
+	CreatedAt int64     `json:"createdAt,omitempty"`
+	UpdatedAt int64     `json:"updatedAt,omitempty"`
+	// Fixity is the outcome of the last fixity check, it is missing for exhibits that were not checked yet
+	Fixity *FixityDto `json:"fixity,omitempty"`
 }
 
 func (d ExhibitDto) ToExhibit() Exhibit {
 	return Exhibit{
-		Id:    d.Id,
-		Name:  d.Name,
-		Lease: d.Lease,
-		Meta:  d.Meta,
+		Id:         d.Id,
+		Pid:        d.Pid,
+		Name:       d.Name,
+		Collection: d.Collection,
+		Lease:      d.Lease,
+		Meta:       d.Meta,
+		Metadata:   d.Metadata,
+		Schedule:   d.Schedule,
 	}
 }
@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"gopkg.in/yaml.v3"
+	"museum/util/schema"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+//go:generate sh -c "go run ../cmd/museum schema > ../docs/exhibit.schema.json"
+
+// ExhibitSchema returns the JSON Schema of exhibit files, it is generated from Exhibit
+func ExhibitSchema() *schema.Schema {
+	s := schema.Generate(reflect.TypeOf(Exhibit{}))
+	s.Schema = schema.Draft
+	s.Title = "mūsēum exhibit"
+	s.Description = "An exhibit file as documented in docs/exhibit_files.md"
+
+	// values that are only known at runtime cannot be put into tags
+	s.Properties["spec"].Enum = SupportedExhibitSpecs
+	s.Properties["name"].Pattern = ExhibitNameRegex.String()
+	s.Properties["objects"].Items.Properties["livecheck"].Properties["type"].Enum = []string{LivecheckTypeHttp, LivecheckTypeExec}
+	s.Properties["access"].Properties["type"].Enum = AccessTypes
+
+	return s
+}
+
+var exhibitSchema = ExhibitSchema()
+
+// ExhibitFile is an exhibit decoded from a yaml or JSON file,
+// it keeps the node tree of the file to locate problems in it
+type ExhibitFile struct {
+	Exhibit Exhibit
+	// Problems are the syntax and schema problems of the file and the problems added to it
+	Problems []ValidationProblem
+
+	root *yaml.Node
+}
+
+// ParseExhibitFile decodes an exhibit and checks it against the exhibit schema.
+// The exhibit is decoded as far as possible even if the file does not match the schema, so that it can be checked further.
+func ParseExhibitFile(source []byte) ExhibitFile {
+	file := ExhibitFile{Problems: make([]ValidationProblem, 0)}
+
+	root := &yaml.Node{}
+	err := yaml.Unmarshal(source, root)
+	if err != nil {
+		file.Problems = append(file.Problems, ValidationProblem{Message: err.Error()})
+		return file
+	}
+	file.root = root
+
+	for _, v := range exhibitSchema.Validate(root) {
+		file.Problems = append(file.Problems, ValidationProblem{Pointer: v.Pointer, Message: v.Message, Line: v.Line, Column: v.Column})
+	}
+
+	// type errors are reported by the schema already, the fields that could be decoded are kept
+	if len(root.Content) > 0 {
+		_ = root.Content[0].Decode(&file.Exhibit)
+	}
+
+	return file
+}
+
+// AddProblems adds problems found in the decoded exhibit and locates them in the file.
+// Problems of fields that already failed the schema are left out, they would only repeat it.
+func (f *ExhibitFile) AddProblems(problems []ValidationProblem) {
+	reported := make([]string, 0, len(f.Problems))
+	for _, p := range f.Problems {
+		reported = append(reported, p.Pointer)
+	}
+
+	for _, p := range problems {
+		if coveredBy(reported, p.Pointer) {
+			continue
+		}
+
+		if f.root != nil {
+			p.Line, p.Column = schema.Locate(f.root, p.Pointer)
+		}
+		f.Problems = append(f.Problems, p)
+	}
+}
+
+func coveredBy(reported []string, pointer string) bool {
+	for _, r := range reported {
+		if pointer == r || strings.HasPrefix(pointer, r+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Result returns the validation result of the file, problems are sorted by their position
+func (f *ExhibitFile) Result() ValidationResult {
+	problems := slices.Clone(f.Problems)
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line != problems[j].Line {
+			return problems[i].Line < problems[j].Line
+		}
+		return problems[i].Column < problems[j].Column
+	})
+
+	return ValidationResult{Valid: len(problems) == 0, Problems: problems}
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExhibitSchemaIsUpToDate(t *testing.T) {
+	published, err := os.ReadFile("../docs/exhibit.schema.json")
+	assert.NoError(t, err)
+
+	generated, err := json.MarshalIndent(ExhibitSchema(), "", "  ")
+	assert.NoError(t, err)
+
+	assert.Equal(t, string(generated)+"\n", string(published), "docs/exhibit.schema.json is outdated, run go generate ./domain")
+}
+
+func TestExamplesAreValid(t *testing.T) {
+	examples, err := filepath.Glob("../examples/*.exhibit")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, examples)
+
+	for _, example := range examples {
+		source, err := os.ReadFile(example)
+		assert.NoError(t, err)
+
+		file := ParseExhibitFile(source)
+		file.AddProblems(file.Exhibit.Validate())
+		assert.True(t, file.Result().Valid, example)
+	}
+}
+
+func TestParseExhibitFileLocatesAllProblems(t *testing.T) {
+	file := ParseExhibitFile([]byte(`spec: v1
+name: my-exhibit
+expose: web
+lease: forever
+objects:
+  - name: web
+    image: nginx
+    label: latest
+    livecheck:
+      type: tcp
+    mounts:
+      data: /data
+order:
+  - web
+  - db
+`))
+	file.AddProblems(file.Exhibit.Validate())
+
+	assert.Equal(t, ValidationResult{
+		Valid: false,
+		Problems: []ValidationProblem{
+			{Pointer: "/lease", Message: "lease time must be a valid duration", Line: 4, Column: 1},
+			{Pointer: "/objects/0/livecheck/type", Message: "must be one of: http, exec", Line: 10, Column: 13},
+			{Pointer: "/objects/0/mounts/data", Message: "mount data does not have a corresponding volume", Line: 12, Column: 7},
+			{Pointer: "/order/1", Message: "order contains db, which is not an object of the exhibit", Line: 15, Column: 5},
+		},
+	}, file.Result())
+}
+
+func TestParseExhibitFileSyntaxError(t *testing.T) {
+	file := ParseExhibitFile([]byte("name: [unclosed"))
+	file.AddProblems(file.Exhibit.Validate())
+
+	result := file.Result()
+	assert.False(t, result.Valid)
+	assert.Len(t, result.Problems, 1)
+}
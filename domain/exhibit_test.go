@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExhibitDtoLeaseExpiry(t *testing.T) {
+	e := Exhibit{Name: "lease", Lease: "1h", RuntimeInfo: &ExhibitRuntimeInfo{Status: Running, LastAccessed: 1000}}
+	assert.Equal(t, int64(1000+3600), e.ToDto().RuntimeInfo.ExpiresAt)
+
+	// stopped exhibits have no lease running
+	e.RuntimeInfo.Status = Stopped
+	assert.Zero(t, e.ToDto().RuntimeInfo.ExpiresAt)
+}
@@ -0,0 +1,56 @@
+package domain
+
+type FixityStatus string
+
+const (
+	// FixityStatusOk means every checksum matched the baseline
+	FixityStatusOk FixityStatus = "ok"
+	// FixityStatusMismatch means at least one checksum differs from the baseline or could not be computed
+	FixityStatusMismatch FixityStatus = "mismatch"
+)
+
+// Prefixes of the items checked by fixity checks, e.g. image:nginx:latest or volume:data
+const (
+	FixityItemImage  = "image:"
+	FixityItemVolume = "volume:"
+)
+
+// FixityReport is the outcome of the last fixity check of an exhibit.
+// The first check records the checksums of the images and volumes as the baseline, later checks compare against it.
+type FixityReport struct {
+	Status FixityStatus `json:"status"`
+	// CheckedAt is the unix time of the last check, BaselineAt the one of the check that recorded the baseline
+	CheckedAt  int64             `json:"checkedAt"`
+	BaselineAt int64             `json:"baselineAt"`
+	Baseline   map[string]string `json:"baseline"`
+	Mismatches []FixityMismatch  `json:"mismatches"`
+}
+
+type FixityMismatch struct {
+	Item     string `json:"item"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	// Error is set if the checksum could not be computed, e.g. because a volume path is gone
+	Error string `json:"error,omitempty"`
+}
+
+func (m FixityMismatch) String() string {
+	if m.Error != "" {
+		return m.Item + ": " + m.Error
+	}
+	return m.Item + ": expected " + m.Expected + ", got " + m.Actual
+}
+
+func (r FixityReport) ToDto() *FixityDto {
+	return &FixityDto{
+		Status:     r.Status,
+		CheckedAt:  r.CheckedAt,
+		Mismatches: r.Mismatches,
+	}
+}
+
+type FixityDto struct {
+	Status     FixityStatus     `json:"status"`
+	CheckedAt  int64            `json:"checkedAt"`
+	Mismatches []FixityMismatch `json:"mismatches"`
+}
@@ -0,0 +1,77 @@
+package domain
+
+import (
+	gohttp "net/http"
+	"strings"
+	"time"
+)
+
+// which requests wait for an exhibit to start instead of receiving the loading page
+const (
+	// HoldAuto holds the requests that do not come from a browser navigating to the exhibit
+	HoldAuto = "auto"
+	HoldAll  = "all"
+	HoldNone = "none"
+)
+
+var HoldModes = []string{HoldAuto, HoldAll, HoldNone}
+
+// HoldPolicy decides which requests to an exhibit that is not running wait for it, instead of receiving the loading page
+type HoldPolicy struct {
+	Requests string `json:"requests,omitempty" yaml:"requests,omitempty" description:"Which requests wait for the exhibit to start, auto holds the requests that are not from browsers, defaults to auto"`
+	Timeout  string `json:"timeout,omitempty" yaml:"timeout,omitempty" description:"How long requests wait for the exhibit to start as a duration string (e.g. 90s), defaults to HOLD_TIMEOUT"`
+}
+
+// IsBrowserRequest reports whether a request comes from a browser that navigates to a page, those can show the
+// loading page. Other requests, like those of scripts and notebooks, expect the response of the exhibit.
+func IsBrowserRequest(req *gohttp.Request) bool {
+	if req.Method != gohttp.MethodGet && req.Method != gohttp.MethodHead {
+		return false
+	}
+
+	// browsers tell what they fetch, only navigations show a page
+	if mode := req.Header.Get("Sec-Fetch-Mode"); mode != "" {
+		return mode == "navigate"
+	}
+	if req.Header.Get("X-Requested-With") != "" {
+		return false
+	}
+
+	for _, accept := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(strings.TrimSpace(accept), ";")
+		if mediaType == "text/html" || mediaType == "application/xhtml+xml" {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldsRequest reports whether a request waits for the exhibit to start
+func (e Exhibit) HoldsRequest(req *gohttp.Request) bool {
+	mode := HoldAuto
+	if e.Hold != nil && e.Hold.Requests != "" {
+		mode = e.Hold.Requests
+	}
+
+	switch mode {
+	case HoldAll:
+		return true
+	case HoldNone:
+		return false
+	default:
+		return !IsBrowserRequest(req)
+	}
+}
+
+// HoldTimeout returns how long requests wait for the exhibit to start, def applies unless the exhibit sets a timeout
+func (e Exhibit) HoldTimeout(def time.Duration) time.Duration {
+	if e.Hold == nil || e.Hold.Timeout == "" {
+		return def
+	}
+
+	timeout, err := time.ParseDuration(e.Hold.Timeout)
+	if err != nil || timeout <= 0 {
+		return def
+	}
+	return timeout
+}
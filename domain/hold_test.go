@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	gohttp "net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newRequest(method string, headers map[string]string) *gohttp.Request {
+	req := httptest.NewRequest(method, "/exhibit/archive/api/items", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestIsBrowserRequest(t *testing.T) {
+	browser := map[string]string{"Accept": "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8"}
+	assert.True(t, IsBrowserRequest(newRequest(gohttp.MethodGet, browser)))
+	assert.True(t, IsBrowserRequest(newRequest(gohttp.MethodGet, map[string]string{"Accept": "text/html", "Sec-Fetch-Mode": "navigate"})))
+
+	assert.False(t, IsBrowserRequest(newRequest(gohttp.MethodPost, browser)))
+	assert.False(t, IsBrowserRequest(newRequest(gohttp.MethodGet, nil)))
+	assert.False(t, IsBrowserRequest(newRequest(gohttp.MethodGet, map[string]string{"Accept": "application/json"})))
+	assert.False(t, IsBrowserRequest(newRequest(gohttp.MethodGet, map[string]string{"Accept": "*/*"})))
+	assert.False(t, IsBrowserRequest(newRequest(gohttp.MethodGet, map[string]string{"Accept": "text/html", "Sec-Fetch-Mode": "cors"})))
+	assert.False(t, IsBrowserRequest(newRequest(gohttp.MethodGet, map[string]string{"Accept": "text/html", "X-Requested-With": "XMLHttpRequest"})))
+}
+
+func TestHoldsRequest(t *testing.T) {
+	browser := newRequest(gohttp.MethodGet, map[string]string{"Accept": "text/html"})
+	script := newRequest(gohttp.MethodGet, map[string]string{"Accept": "application/json"})
+
+	exhibit := Exhibit{}
+	assert.False(t, exhibit.HoldsRequest(browser))
+	assert.True(t, exhibit.HoldsRequest(script))
+	assert.Equal(t, time.Minute, exhibit.HoldTimeout(time.Minute))
+
+	exhibit.Hold = &HoldPolicy{Requests: HoldAll, Timeout: "90s"}
+	assert.True(t, exhibit.HoldsRequest(browser))
+	assert.Equal(t, 90*time.Second, exhibit.HoldTimeout(time.Minute))
+
+	exhibit.Hold = &HoldPolicy{Requests: HoldNone}
+	assert.False(t, exhibit.HoldsRequest(script))
+}
+
+func TestValidateHold(t *testing.T) {
+	exhibit := Exhibit{
+		Spec:    ExhibitSpecV1,
+		Name:    "archive",
+		Expose:  "web",
+		Lease:   "1h",
+		Objects: []Object{{Name: "web", Image: "nginx", Label: "latest"}},
+		Hold:    &HoldPolicy{Requests: "some", Timeout: "-1s"},
+	}
+
+	pointers := make([]string, 0)
+	for _, p := range exhibit.Validate() {
+		pointers = append(pointers, p.Pointer)
+	}
+	assert.Equal(t, []string{"/hold/requests", "/hold/timeout"}, pointers)
+}
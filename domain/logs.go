@@ -0,0 +1,22 @@
+package domain
+
+// StartupLogTail is the number of lines of every object that are kept when an exhibit fails to start
+const StartupLogTail = 100
+
+// LogOptions selects the logs of an object of an exhibit
+type LogOptions struct {
+	// Tail is the number of lines from the end of the logs, all lines are returned if it is 0
+	Tail int
+	// Follow keeps writing the logs as the object writes them, until it stops or the context is done
+	Follow bool
+	// Replica is the index of the replica whose logs are read, the first replica has index 0
+	Replica int
+}
+
+// StartupLogs are the ends of the logs of the objects of an exhibit during its last failed start,
+// they outlive the containers that are removed after the failure
+type StartupLogs struct {
+	At int64 `json:"at"`
+	// Objects maps the names of the objects that were started to the logs of their replicas by the index of the replica
+	Objects map[string][]string `json:"objects"`
+}
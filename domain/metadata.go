@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	DoiRegex   = regexp.MustCompile(`^10\.\d{4,9}/\S+$`)
+	OrcidRegex = regexp.MustCompile(`^\d{4}-\d{4}-\d{4}-\d{3}[\dX]$`)
+)
+
+// Metadata describes an exhibit for citations and harvesting, unlike meta it has a fixed format
+type Metadata struct {
+	Title       string    `json:"title" yaml:"title" jsonschema:"required" description:"The title of the exhibit"`
+	Creators    []Creator `json:"creators" yaml:"creators" jsonschema:"required" description:"The people or organisations that created the exhibit, in the order they are cited"`
+	Year        int       `json:"year" yaml:"year" jsonschema:"required" description:"The year the exhibit was published"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty" description:"A description of the exhibit"`
+	// Publication is a DOI without a resolver, e.g. 10.1000/182
+	Publication string `json:"publication,omitempty" yaml:"publication,omitempty" description:"The DOI of the publication the exhibit belongs to, e.g. 10.1000/182"`
+	License     string `json:"license,omitempty" yaml:"license,omitempty" description:"The license of the exhibit as an SPDX identifier (e.g. MIT) or a url"`
+}
+
+type Creator struct {
+	// Name is "Family, Given" for people and the name of organisations
+	Name        string `json:"name" yaml:"name" jsonschema:"required" description:"The name of the creator, as 'Family, Given' for people"`
+	Orcid       string `json:"orcid,omitempty" yaml:"orcid,omitempty" description:"The ORCID iD of the creator, e.g. 0000-0002-1825-0097"`
+	Affiliation string `json:"affiliation,omitempty" yaml:"affiliation,omitempty" description:"The institution the creator is affiliated with"`
+}
+
+// FamilyGiven splits the name of a person, organisations have no given name
+func (c Creator) FamilyGiven() (string, string) {
+	family, given, ok := strings.Cut(c.Name, ",")
+	if !ok {
+		return strings.TrimSpace(c.Name), ""
+	}
+	return strings.TrimSpace(family), strings.TrimSpace(given)
+}
+
+// PersistentId returns the persistent identifier of the exhibit. Exhibits created before
+// identifiers were assigned are identified by their id as a uuid urn.
+func (e Exhibit) PersistentId() string {
+	if e.Pid != "" {
+		return e.Pid
+	}
+	return "urn:uuid:" + e.Id
+}
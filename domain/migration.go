@@ -0,0 +1,13 @@
+package domain
+
+// MigrationResult describes a migration of all stored exhibits to the current schema version
+type MigrationResult struct {
+	// SchemaVersion is the version exhibits are stored with after the migration
+	SchemaVersion int `json:"schemaVersion"`
+	// Migrated are the names of the exhibits that were stored with an older version
+	Migrated []string `json:"migrated"`
+	// UpToDate is the number of exhibits that already were stored with the current version
+	UpToDate int `json:"upToDate"`
+	// Failed maps the ids of exhibits that could not be migrated to the reason
+	Failed map[string]string `json:"failed"`
+}
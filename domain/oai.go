@@ -0,0 +1,267 @@
+package domain
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	OaiDatestampFormat = "2006-01-02T15:04:05Z"
+	OaiDayFormat       = "2006-01-02"
+	OaiDcPrefix        = "oai_dc"
+)
+
+// oai error codes, see http://www.openarchives.org/OAI/openarchivesprotocol.html#ErrorConditions
+const (
+	OaiBadArgument             = "badArgument"
+	OaiBadResumptionToken      = "badResumptionToken"
+	OaiBadVerb                 = "badVerb"
+	OaiCannotDisseminateFormat = "cannotDisseminateFormat"
+	OaiIdDoesNotExist          = "idDoesNotExist"
+	OaiNoRecordsMatch          = "noRecordsMatch"
+)
+
+// OaiSets are the top level sets, the sets of the meta values are below them, e.g. collection:physics
+var OaiSets = []OaiSet{
+	{Spec: "collection", Name: "Collections"},
+	{Spec: "tag", Name: "Tags"},
+}
+
+var oaiSetSpecRegex = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// OaiPmh is the response to every OAI-PMH request, only one of the verb elements is set
+type OaiPmh struct {
+	XMLName             xml.Name                `xml:"http://www.openarchives.org/OAI/2.0/ OAI-PMH"`
+	Xsi                 string                  `xml:"xmlns:xsi,attr"`
+	SchemaLocation      string                  `xml:"xsi:schemaLocation,attr"`
+	ResponseDate        string                  `xml:"responseDate"`
+	Request             OaiRequest              `xml:"request"`
+	Errors              []OaiError              `xml:"error"`
+	Identify            *OaiIdentify            `xml:"Identify,omitempty"`
+	ListMetadataFormats *OaiListMetadataFormats `xml:"ListMetadataFormats,omitempty"`
+	ListSets            *OaiListSets            `xml:"ListSets,omitempty"`
+	ListIdentifiers     *OaiListIdentifiers     `xml:"ListIdentifiers,omitempty"`
+	ListRecords         *OaiListRecords         `xml:"ListRecords,omitempty"`
+	GetRecord           *OaiGetRecord           `xml:"GetRecord,omitempty"`
+}
+
+// OaiRequest echoes the request, the arguments are left out if the verb or the arguments are invalid
+type OaiRequest struct {
+	Verb            string `xml:"verb,attr,omitempty"`
+	Identifier      string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+	From            string `xml:"from,attr,omitempty"`
+	Until           string `xml:"until,attr,omitempty"`
+	Set             string `xml:"set,attr,omitempty"`
+	ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+	BaseUrl         string `xml:",chardata"`
+}
+
+type OaiError struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+func NewOaiPmh(baseUrl string, now time.Time) OaiPmh {
+	return OaiPmh{
+		Xsi:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: "http://www.openarchives.org/OAI/2.0/ http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd",
+		ResponseDate:   now.UTC().Format(OaiDatestampFormat),
+		Request:        OaiRequest{BaseUrl: baseUrl},
+	}
+}
+
+// Fail adds an error to the response, badVerb and badArgument drop the arguments from the request element
+func (o *OaiPmh) Fail(code string, message string) {
+	if code == OaiBadVerb || code == OaiBadArgument {
+		o.Request = OaiRequest{BaseUrl: o.Request.BaseUrl}
+	}
+	o.Errors = append(o.Errors, OaiError{Code: code, Message: message})
+}
+
+type OaiIdentify struct {
+	RepositoryName    string `xml:"repositoryName"`
+	BaseUrl           string `xml:"baseURL"`
+	ProtocolVersion   string `xml:"protocolVersion"`
+	AdminEmail        string `xml:"adminEmail"`
+	EarliestDatestamp string `xml:"earliestDatestamp"`
+	DeletedRecord     string `xml:"deletedRecord"`
+	Granularity       string `xml:"granularity"`
+}
+
+type OaiListMetadataFormats struct {
+	MetadataFormats []OaiMetadataFormat `xml:"metadataFormat"`
+}
+
+type OaiMetadataFormat struct {
+	MetadataPrefix    string `xml:"metadataPrefix"`
+	Schema            string `xml:"schema"`
+	MetadataNamespace string `xml:"metadataNamespace"`
+}
+
+var OaiDcFormat = OaiMetadataFormat{
+	MetadataPrefix:    OaiDcPrefix,
+	Schema:            "http://www.openarchives.org/OAI/2.0/oai_dc.xsd",
+	MetadataNamespace: "http://www.openarchives.org/OAI/2.0/oai_dc/",
+}
+
+type OaiListSets struct {
+	Sets []OaiSet `xml:"set"`
+}
+
+type OaiSet struct {
+	Spec string `xml:"setSpec"`
+	Name string `xml:"setName"`
+}
+
+type OaiListIdentifiers struct {
+	Headers         []OaiHeader         `xml:"header"`
+	ResumptionToken *OaiResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+type OaiListRecords struct {
+	Records         []OaiRecord         `xml:"record"`
+	ResumptionToken *OaiResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+type OaiGetRecord struct {
+	Record OaiRecord `xml:"record"`
+}
+
+// OaiResumptionToken is empty in the last response of a list that was split
+type OaiResumptionToken struct {
+	CompleteListSize int    `xml:"completeListSize,attr"`
+	Cursor           int    `xml:"cursor,attr"`
+	Token            string `xml:",chardata"`
+}
+
+type OaiHeader struct {
+	Identifier string   `xml:"identifier"`
+	Datestamp  string   `xml:"datestamp"`
+	SetSpecs   []string `xml:"setSpec"`
+}
+
+type OaiRecord struct {
+	Header   OaiHeader      `xml:"header"`
+	Metadata OaiDcContainer `xml:"metadata"`
+}
+
+type OaiDcContainer struct {
+	Dc OaiDc `xml:"oai_dc:dc"`
+}
+
+// OaiDc is a simple Dublin Core record, the prefixes are written literally since encoding/xml cannot declare them
+type OaiDc struct {
+	XmlnsOaiDc     string   `xml:"xmlns:oai_dc,attr"`
+	XmlnsDc        string   `xml:"xmlns:dc,attr"`
+	Xsi            string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Titles         []string `xml:"dc:title"`
+	Creators       []string `xml:"dc:creator"`
+	Subjects       []string `xml:"dc:subject"`
+	Descriptions   []string `xml:"dc:description"`
+	Publishers     []string `xml:"dc:publisher"`
+	Dates          []string `xml:"dc:date"`
+	Types          []string `xml:"dc:type"`
+	Identifiers    []string `xml:"dc:identifier"`
+	Relations      []string `xml:"dc:relation"`
+	Rights         []string `xml:"dc:rights"`
+}
+
+// Datestamp is the time the exhibit was last changed at, exhibits created before
+// the times were recorded have the unix epoch as datestamp
+func (e Exhibit) Datestamp() time.Time {
+	return time.Unix(max(e.CreatedAt, e.UpdatedAt), 0).UTC()
+}
+
+// OaiSetSpecs returns the sets the exhibit belongs to, sorted and without duplicates
+func (e Exhibit) OaiSetSpecs() []string {
+	specs := make([]string, 0)
+	for _, s := range e.oaiSets() {
+		specs = append(specs, s.Spec)
+	}
+	return specs
+}
+
+func (e Exhibit) oaiSets() []OaiSet {
+	sets := make([]OaiSet, 0)
+	add := func(parent string, key string) {
+		for _, value := range e.MetaStrings(key) {
+			slug := strings.Trim(oaiSetSpecRegex.ReplaceAllString(strings.ToLower(value), "-"), "-")
+			if slug == "" {
+				continue
+			}
+			sets = append(sets, OaiSet{Spec: parent + ":" + slug, Name: value})
+		}
+	}
+	add(OaiSets[0].Spec, CollectionMetaKey)
+	add(OaiSets[1].Spec, TagsMetaKey)
+
+	slices.SortFunc(sets, func(a, b OaiSet) int { return strings.Compare(a.Spec, b.Spec) })
+	return slices.CompactFunc(sets, func(a, b OaiSet) bool { return a.Spec == b.Spec })
+}
+
+// OaiSetsOf returns the sets of all exhibits, including the top level sets
+func OaiSetsOf(exhibits []Exhibit) []OaiSet {
+	sets := slices.Clone(OaiSets)
+	for _, e := range exhibits {
+		sets = append(sets, e.oaiSets()...)
+	}
+
+	slices.SortFunc(sets, func(a, b OaiSet) int { return strings.Compare(a.Spec, b.Spec) })
+	return slices.CompactFunc(sets, func(a, b OaiSet) bool { return a.Spec == b.Spec })
+}
+
+// InOaiSet checks if the exhibit belongs to the set or to one of its subsets
+func (e Exhibit) InOaiSet(spec string) bool {
+	return slices.ContainsFunc(e.OaiSetSpecs(), func(s string) bool {
+		return s == spec || strings.HasPrefix(s, spec+":")
+	})
+}
+
+// OaiDc describes the exhibit in simple Dublin Core, exhibits without metadata are described by their name
+func (e Exhibit) OaiDc(url string, publisher string) OaiDc {
+	dc := OaiDc{
+		XmlnsOaiDc:     OaiDcFormat.MetadataNamespace,
+		XmlnsDc:        "http://purl.org/dc/elements/1.1/",
+		Xsi:            "http://www.w3.org/2001/XMLSchema-instance",
+		SchemaLocation: OaiDcFormat.MetadataNamespace + " " + OaiDcFormat.Schema,
+		Titles:         []string{e.Title()},
+		Subjects:       e.Tags(),
+		Publishers:     []string{publisher},
+		Types:          []string{"Software"},
+		Identifiers:    []string{e.PersistentId(), url},
+	}
+
+	if e.CreatedAt != 0 {
+		dc.Dates = []string{time.Unix(e.CreatedAt, 0).UTC().Format(OaiDayFormat)}
+	}
+
+	if m := e.Metadata; m != nil {
+		dc.Dates = []string{strconv.Itoa(m.Year)}
+		for _, c := range m.Creators {
+			dc.Creators = append(dc.Creators, c.Name)
+		}
+		if m.Description != "" {
+			dc.Descriptions = []string{m.Description}
+		}
+		if m.Publication != "" {
+			dc.Relations = []string{"https://doi.org/" + m.Publication}
+		}
+		if m.License != "" {
+			dc.Rights = []string{m.License}
+		}
+	}
+
+	return dc
+}
+
+// OaiIdentifier is the identifier of an exhibit in the repository, oai:<host>:<id>
+func OaiIdentifier(host string, id string) string {
+	return fmt.Sprintf("oai:%s:%s", host, id)
+}
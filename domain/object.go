@@ -1,18 +1,22 @@
 package domain
 
+import "github.com/docker/go-units"
+
 const (
 	LivecheckTypeHttp = "http"
 	LivecheckTypeExec = "exec"
 )
 
 type Object struct {
-	Name        string     `json:"name" yaml:"name"`
-	Image       string     `json:"image" yaml:"image"`
-	Label       string     `json:"label" yaml:"label"`
-	Livecheck   *Livecheck `json:"livecheck" yaml:"livecheck"`
-	Environment StringMap  `json:"environment" yaml:"environment"`
-	Mounts      StringMap  `json:"mounts" yaml:"mounts"`
-	Port        *string    `json:"port" yaml:"port"`
+	Name        string     `json:"name" yaml:"name" jsonschema:"required" description:"The name of the object, other objects can reach it by this name"`
+	Image       string     `json:"image" yaml:"image" jsonschema:"required" description:"The container image"`
+	Label       string     `json:"label" yaml:"label" jsonschema:"required" description:"The tag of the container image"`
+	Livecheck   *Livecheck `json:"livecheck" yaml:"livecheck,omitempty" description:"How to check that the object is ready"`
+	Environment StringMap  `json:"environment" yaml:"environment,omitempty" description:"Environment variables of the container, values may reference other objects with {{ @object }}"`
+	Mounts      StringMap  `json:"mounts" yaml:"mounts,omitempty" description:"Volumes by name mapped to the path they are mounted at"`
+	Port        *string    `json:"port" yaml:"port,omitempty" description:"The port the exposed object listens on, defaults to 80"`
+	Resources   *Resources `json:"resources,omitempty" yaml:"resources,omitempty" description:"The cpus and memory the container is limited to, required in collections with a cpu or memory quota"`
+	Replicas    *Replicas  `json:"replicas,omitempty" yaml:"replicas,omitempty" description:"Runs several containers of the exposed object, which has to be stateless"`
 }
 
 func (o Object) ToDto() ObjectDto {
@@ -23,19 +27,36 @@ func (o Object) ToDto() ObjectDto {
 	}
 }
 
+type Resources struct {
+	Cpus   float64 `json:"cpus,omitempty" yaml:"cpus,omitempty" description:"The number of cpus the container may use, e.g. 0.5"`
+	Memory string  `json:"memory,omitempty" yaml:"memory,omitempty" description:"The memory the container may use, e.g. 512m"`
+}
+
+// MemoryBytes returns the memory limit in bytes, it is 0 if there is none
+func (r Resources) MemoryBytes() int64 {
+	if r.Memory == "" {
+		return 0
+	}
+	memory, err := units.RAMInBytes(r.Memory)
+	if err != nil {
+		return 0
+	}
+	return memory
+}
+
 type Livecheck struct {
-	Type   string    `json:"type" yaml:"type"`
-	Config StringMap `json:"config" yaml:"config"`
+	Type   string    `json:"type" yaml:"type" jsonschema:"required" description:"The type of the livecheck"`
+	Config StringMap `json:"config" yaml:"config,omitempty" description:"The configuration of the livecheck"`
 }
 
 type Volume struct {
-	Name   string `json:"name" yaml:"name"`
-	Driver Driver `json:"driver" yaml:"driver"`
+	Name   string `json:"name" yaml:"name" jsonschema:"required" description:"The name the volume is mounted by"`
+	Driver Driver `json:"driver" yaml:"driver" jsonschema:"required" description:"The driver that provides the volume"`
 }
 
 type Driver struct {
-	Type   string    `json:"type" yaml:"type"`
-	Config StringMap `json:"config" yaml:"config"`
+	Type   string    `json:"type" yaml:"type" jsonschema:"required" description:"The type of the volume driver"`
+	Config StringMap `json:"config" yaml:"config,omitempty" description:"The configuration of the volume driver"`
 }
 
 type StringMap map[string]string
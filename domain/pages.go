@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"html/template"
+	"time"
+)
+
+// kinds of pages visitors see instead of an exhibit
+const (
+	PageLoading     = "loading"
+	PageFailed      = "failed"
+	PageStopping    = "stopping"
+	PageMaintenance = "maintenance"
+)
+
+var PageKinds = []string{PageLoading, PageFailed, PageStopping, PageMaintenance}
+
+// FailedPageLength is how long visitors see the failed page after a start failed, before their visit starts it again
+const FailedPageLength = time.Minute
+
+// MaintenanceParam is the query parameter of links that let curators into an exhibit in maintenance
+const MaintenanceParam = "museum_maintenance"
+
+// Pages replace the pages museum shows visitors, every page is a Go html/template that is executed with PageData
+type Pages struct {
+	Loading     string `json:"loading,omitempty" yaml:"loading,omitempty" description:"The page shown while the exhibit starts"`
+	Failed      string `json:"failed,omitempty" yaml:"failed,omitempty" description:"The page shown after the exhibit failed to start"`
+	Stopping    string `json:"stopping,omitempty" yaml:"stopping,omitempty" description:"The page shown while the exhibit stops"`
+	Maintenance string `json:"maintenance,omitempty" yaml:"maintenance,omitempty" description:"The page shown while the exhibit is in maintenance"`
+}
+
+// Get returns the template of a kind of page, it is empty if the exhibit does not replace it
+func (p *Pages) Get(kind string) string {
+	if p == nil {
+		return ""
+	}
+
+	switch kind {
+	case PageLoading:
+		return p.Loading
+	case PageFailed:
+		return p.Failed
+	case PageStopping:
+		return p.Stopping
+	case PageMaintenance:
+		return p.Maintenance
+	default:
+		return ""
+	}
+}
+
+// StartupFailure is the step an object of an exhibit failed at during its last start
+type StartupFailure struct {
+	Object string `json:"object"`
+	Step   string `json:"step,omitempty"`
+	Error  string `json:"error"`
+	At     int64  `json:"at"`
+}
+
+// Recent reports whether the failure is recent enough to show the failed page instead of starting the exhibit again
+func (f *StartupFailure) Recent(now time.Time) bool {
+	return f != nil && now.Before(time.Unix(f.At, 0).Add(FailedPageLength))
+}
+
+// Maintenance keeps visitors out of an exhibit while curators work on it
+type Maintenance struct {
+	Message string `json:"message,omitempty"`
+	Since   int64  `json:"since"`
+	By      string `json:"by,omitempty"`
+}
+
+// MaintenanceLink lets curators into an exhibit while it is in maintenance
+type MaintenanceLink struct {
+	Url string `json:"url"`
+}
+
+// PageData is what the templates of pages have access to
+type PageData struct {
+	Exhibit   string
+	Host      string
+	ExhibitId string
+	// QueuePosition is the place of the exhibit in the queue, it is 0 unless the cluster is full
+	QueuePosition int
+	Status        Status
+	Metadata      *Metadata
+	Meta          map[string]interface{}
+	// Objects are the names of the objects in the order they are started, TotalSteps counts the steps of all of them
+	Objects    []string
+	TotalSteps int
+	// Failure is set on the failed page
+	Failure *StartupFailure
+	// Maintenance is set on the maintenance page
+	Maintenance *Maintenance
+}
+
+// NewPageData returns the data of the pages of an exhibit
+func NewPageData(e Exhibit, host string) PageData {
+	objects := make([]string, 0, len(e.Objects))
+	if len(e.Order) > 0 {
+		objects = append(objects, e.Order...)
+	} else {
+		for _, o := range e.Objects {
+			objects = append(objects, o.Name)
+		}
+	}
+
+	data := PageData{
+		Exhibit:    e.Name,
+		Host:       host,
+		ExhibitId:  e.Id,
+		Metadata:   e.Metadata,
+		Meta:       e.Meta,
+		Objects:    objects,
+		TotalSteps: e.GetTotalSteps(),
+	}
+	if e.RuntimeInfo != nil {
+		data.Status = e.RuntimeInfo.Status
+		data.Failure = e.RuntimeInfo.Failure
+		data.Maintenance = e.RuntimeInfo.Maintenance
+	}
+	return data
+}
+
+// ParsePage parses the template of a page
+func ParsePage(kind string, text string) (*template.Template, error) {
+	return template.New(kind).Parse(text)
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestNewPageData(t *testing.T) {
+	exhibit := Exhibit{
+		Id:      "0b7c5d43-3f1a-4c57-9a45-6f3c1f1d2e10",
+		Name:    "archive",
+		Objects: []Object{{Name: "web"}, {Name: "db"}},
+		Order:   []string{"db", "web"},
+		Meta:    map[string]interface{}{"room": "east wing"},
+		RuntimeInfo: &ExhibitRuntimeInfo{
+			Status:  Stopped,
+			Failure: &StartupFailure{Object: "db", Step: "livecheck", Error: "connection refused"},
+		},
+	}
+
+	data := NewPageData(exhibit, "museum.example:8080")
+	assert.Equal(t, []string{"db", "web"}, data.Objects)
+	assert.Equal(t, Stopped, data.Status)
+	assert.Equal(t, "livecheck", data.Failure.Step)
+
+	tmpl, err := ParsePage(PageFailed, `{{ .Exhibit }} in {{ index .Meta "room" }}: {{ .Failure.Object }} failed at {{ .Failure.Step }}`)
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, tmpl.Execute(buf, data))
+	assert.Equal(t, "archive in east wing: db failed at livecheck", buf.String())
+}
+
+func TestStartupFailureRecent(t *testing.T) {
+	now := time.Now()
+	var none *StartupFailure
+	assert.False(t, none.Recent(now))
+
+	failure := &StartupFailure{At: now.Add(-10 * time.Second).Unix()}
+	assert.True(t, failure.Recent(now))
+	assert.False(t, failure.Recent(now.Add(FailedPageLength)))
+}
+
+func TestValidatePages(t *testing.T) {
+	exhibit := Exhibit{
+		Spec:    ExhibitSpecV1,
+		Name:    "archive",
+		Expose:  "web",
+		Lease:   "1h",
+		Objects: []Object{{Name: "web", Image: "nginx", Label: "latest"}},
+		Pages:   &Pages{Loading: "<h1>{{ .Exhibit }}</h1>", Maintenance: "{{ if .Maintenance }}"},
+	}
+
+	pointers := make([]string, 0)
+	for _, p := range exhibit.Validate() {
+		pointers = append(pointers, p.Pointer)
+	}
+	assert.Equal(t, []string{"/pages/maintenance"}, pointers)
+}
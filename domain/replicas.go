@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// DefaultTargetInFlight is the number of requests in flight per replica the autoscaler aims for by default
+const DefaultTargetInFlight = 10
+
+// Replicas run several containers of a stateless object, the proxy balances the requests between them.
+// The number of replicas is either fixed by Count or autoscaled between Min and Max.
+type Replicas struct {
+	Count  int  `json:"count,omitempty" yaml:"count,omitempty" description:"The fixed number of containers"`
+	Min    int  `json:"min,omitempty" yaml:"min,omitempty" description:"The least number of containers of an autoscaled object, defaults to 1"`
+	Max    int  `json:"max,omitempty" yaml:"max,omitempty" description:"The most number of containers of an autoscaled object"`
+	Target int  `json:"target,omitempty" yaml:"target,omitempty" description:"The requests in flight per container the autoscaler aims for, defaults to 10"`
+	Sticky bool `json:"sticky,omitempty" yaml:"sticky,omitempty" description:"Sends the requests of a visitor to the same container as long as it runs"`
+}
+
+// Endpoint is a healthy replica of the exposed object that requests can be proxied to
+type Endpoint struct {
+	Replica int
+	Address string
+}
+
+// InstanceLoad is the most requests an instance of museum had in flight to an exhibit since its previous report
+type InstanceLoad struct {
+	InFlight   int   `json:"in_flight"`
+	ReportedAt int64 `json:"reported_at"`
+}
+
+// Autoscaled reports whether the number of replicas follows the requests in flight
+func (r *Replicas) Autoscaled() bool {
+	return r != nil && r.Count == 0 && r.Max > 0
+}
+
+// Initial returns the number of replicas the object starts with
+func (r *Replicas) Initial() int {
+	switch {
+	case r == nil:
+		return 1
+	case r.Count > 0:
+		return r.Count
+	default:
+		return max(r.Min, 1)
+	}
+}
+
+// Most returns the number of replicas the object may have at most
+func (r *Replicas) Most() int {
+	if r.Autoscaled() {
+		return max(r.Max, r.Initial())
+	}
+	return r.Initial()
+}
+
+// Desired returns the number of replicas that keeps the requests in flight per replica at the target
+func (r *Replicas) Desired(inFlight int) int {
+	target := r.Target
+	if target <= 0 {
+		target = DefaultTargetInFlight
+	}
+	desired := (inFlight + target - 1) / target
+	return min(max(desired, r.Initial()), r.Most())
+}
+
+// Replica returns the object as its replica with the given index, all replicas but the first have their index in
+// the name, so that their containers are named like exhibit_object_2
+func (o Object) Replica(index int) Object {
+	if index > 0 {
+		o.Name = o.Name + "_" + strconv.Itoa(index+1)
+	}
+	return o
+}
+
+// ExposedObject returns the object that requests are proxied to
+func (e Exhibit) ExposedObject() (Object, bool) {
+	for _, o := range e.Objects {
+		if o.Name == e.Expose {
+			return o, true
+		}
+	}
+	return Object{}, false
+}
+
+// RunningReplicas returns the number of replicas of the exposed object that run
+func (r *ExhibitRuntimeInfo) RunningReplicas() int {
+	return max(r.Replicas, 1)
+}
+
+// TotalInFlight sums up the loads the instances reported within the ttl
+func (r *ExhibitRuntimeInfo) TotalInFlight(now time.Time, ttl time.Duration) int {
+	total := 0
+	for instance, load := range r.Load {
+		if now.Sub(time.Unix(load.ReportedAt, 0)) > ttl {
+			delete(r.Load, instance)
+			continue
+		}
+		total += load.InFlight
+	}
+	return total
+}
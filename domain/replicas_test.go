@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReplicasDesired(t *testing.T) {
+	var none *Replicas
+	assert.Equal(t, 1, none.Initial())
+	assert.Equal(t, 1, none.Most())
+	assert.False(t, none.Autoscaled())
+
+	fixed := &Replicas{Count: 3}
+	assert.Equal(t, 3, fixed.Initial())
+	assert.Equal(t, 3, fixed.Most())
+	assert.False(t, fixed.Autoscaled())
+
+	autoscaled := &Replicas{Min: 2, Max: 5, Target: 4}
+	assert.True(t, autoscaled.Autoscaled())
+	assert.Equal(t, 2, autoscaled.Initial())
+	assert.Equal(t, 2, autoscaled.Desired(0))
+	assert.Equal(t, 2, autoscaled.Desired(8))
+	assert.Equal(t, 3, autoscaled.Desired(9))
+	assert.Equal(t, 5, autoscaled.Desired(100))
+
+	assert.Equal(t, 3, (&Replicas{Max: 3}).Desired(21))
+	assert.Equal(t, "web_3", Object{Name: "web"}.Replica(2).Name)
+	assert.Equal(t, "web", Object{Name: "web"}.Replica(0).Name)
+}
+
+func TestValidateReplicas(t *testing.T) {
+	exhibit := Exhibit{
+		Spec:   ExhibitSpecV1,
+		Name:   "replicas",
+		Expose: "web",
+		Lease:  "1h",
+		Objects: []Object{
+			{Name: "db", Image: "postgres", Label: "16", Replicas: &Replicas{Count: 2}},
+			{Name: "web", Image: "nginx", Label: "latest", Mounts: StringMap{"data": "/data"}, Replicas: &Replicas{Min: 3, Max: 2}},
+		},
+		Volumes: []Volume{{Name: "data", Driver: Driver{Type: "local"}}},
+	}
+
+	pointers := make([]string, 0)
+	for _, p := range exhibit.Validate() {
+		pointers = append(pointers, p.Pointer)
+	}
+	assert.Equal(t, []string{"/objects/0/replicas", "/objects/1/replicas", "/objects/1/replicas/min"}, pointers)
+
+	exhibit.Objects[0].Replicas = nil
+	exhibit.Objects[1].Mounts = nil
+	exhibit.Volumes = nil
+	exhibit.Objects[1].Replicas = &Replicas{Count: 2, Max: 4}
+	assert.Len(t, exhibit.Validate(), 1)
+
+	exhibit.Objects[1].Replicas = &Replicas{Max: 4, Sticky: true}
+	assert.Empty(t, exhibit.Validate())
+}
@@ -8,6 +8,8 @@ const (
 	Stopping   Status = "stopping"
 	Stopped    Status = "stopped"
 	NotCreated Status = "not_created"
+	// Queued exhibits wait for room in the cluster before they start
+	Queued Status = "queued"
 )
 
 type ExhibitRuntimeInfo struct {
@@ -15,11 +17,23 @@ type ExhibitRuntimeInfo struct {
 	Hostname          string   `json:"hostname"`
 	RelatedContainers []string `json:"related_containers"`
 	LastAccessed      int64    `json:"-"`
+	// QueuedAt is the unix time in nanoseconds the exhibit was queued at, it orders the queue
+	QueuedAt int64 `json:"queued_at,omitempty"`
+	// Replicas is the number of containers of the exposed object that run, it is 0 for objects without replicas
+	Replicas int `json:"replicas,omitempty"`
+	// Load is what the instances of museum reported to the autoscaler, by instance
+	Load map[string]InstanceLoad `json:"load,omitempty"`
+	// Failure is why the last start failed, it is cleared when the exhibit starts again
+	Failure *StartupFailure `json:"failure,omitempty"`
+	// Maintenance is set while the exhibit is in maintenance
+	Maintenance *Maintenance `json:"maintenance,omitempty"`
 }
 
 func (e *ExhibitRuntimeInfo) ToDto() RuntimeInfoDto {
 	return RuntimeInfoDto{
 		Status:       e.Status,
 		LastAccessed: e.LastAccessed,
+		Failure:      e.Failure,
+		Maintenance:  e.Maintenance,
 	}
 }
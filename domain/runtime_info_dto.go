@@ -3,4 +3,8 @@ package domain
 type RuntimeInfoDto struct {
 	Status       Status `json:"status"`
 	LastAccessed int64  `json:"last_accessed"`
+	// ExpiresAt is the unix time the lease of a running exhibit ends at, unless it is accessed again
+	ExpiresAt   int64           `json:"expires_at,omitempty"`
+	Failure     *StartupFailure `json:"failure,omitempty"`
+	Maintenance *Maintenance    `json:"maintenance,omitempty"`
 }
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"errors"
+	"museum/util/cron"
+	"time"
+)
+
+// ScheduleTimeLayout is the layout of the local times one-off schedule windows open and close at
+const ScheduleTimeLayout = "2006-01-02T15:04"
+
+// Schedule keeps an exhibit running at known times, e.g. during lectures, regardless of visitors
+type Schedule struct {
+	Timezone string           `json:"timezone,omitempty" yaml:"timezone,omitempty" description:"The IANA time zone the windows are in, e.g. Europe/Berlin, defaults to UTC"`
+	AlwaysOn bool             `json:"alwaysOn,omitempty" yaml:"alwaysOn,omitempty" description:"Keeps the exhibit running all the time, it is never stopped by the cleanup"`
+	Windows  []ScheduleWindow `json:"windows,omitempty" yaml:"windows,omitempty" description:"The times the exhibit is started before and kept running during"`
+}
+
+// ScheduleWindow is either recurring, opening at the times of a cron expression for a duration, or one-off
+type ScheduleWindow struct {
+	Cron     string `json:"cron,omitempty" yaml:"cron,omitempty" description:"The cron expression (minute hour day-of-month month day-of-week) of the times a recurring window opens, e.g. 0 9 * * 1"`
+	Duration string `json:"duration,omitempty" yaml:"duration,omitempty" description:"How long a recurring window stays open, as a duration string (e.g. 2h)"`
+	From     string `json:"from,omitempty" yaml:"from,omitempty" description:"The local time a one-off window opens at, e.g. 2026-11-03T09:00"`
+	To       string `json:"to,omitempty" yaml:"to,omitempty" description:"The local time a one-off window closes at, e.g. 2026-11-03T12:00"`
+}
+
+// Location returns the time zone of the windows
+func (s Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// OpenUntil returns when the window closes if it is open at the given time
+func (w ScheduleWindow) OpenUntil(at time.Time, loc *time.Location) (time.Time, bool, error) {
+	at = at.In(loc)
+
+	if w.Cron == "" {
+		from, err := time.ParseInLocation(ScheduleTimeLayout, w.From, loc)
+		if err != nil {
+			return time.Time{}, false, errors.New("from must be a local time like 2026-11-03T09:00")
+		}
+		to, err := time.ParseInLocation(ScheduleTimeLayout, w.To, loc)
+		if err != nil {
+			return time.Time{}, false, errors.New("to must be a local time like 2026-11-03T12:00")
+		}
+		if !to.After(from) {
+			return time.Time{}, false, errors.New("to must be after from")
+		}
+		return to, !at.Before(from) && at.Before(to), nil
+	}
+
+	expression, err := cron.Parse(w.Cron)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil || duration <= 0 {
+		return time.Time{}, false, errors.New("duration of a recurring window must be a positive duration string, e.g. 2h")
+	}
+
+	// the window is open if it opened within the last duration, the one that opened last closes last
+	opened := expression.Next(at.Add(-duration))
+	if opened.IsZero() || opened.After(at) {
+		return time.Time{}, false, nil
+	}
+	for next := expression.Next(opened); !next.IsZero() && !next.After(at); next = expression.Next(next) {
+		opened = next
+	}
+	return opened.Add(duration), true, nil
+}
+
+// ScheduledUntil returns when the last open window of the exhibit closes, if one is open at the given time.
+// Always on exhibits are scheduled forever, their end is the zero time.
+func (e Exhibit) ScheduledUntil(at time.Time) (time.Time, bool) {
+	if e.Schedule == nil {
+		return time.Time{}, false
+	}
+	if e.Schedule.AlwaysOn {
+		return time.Time{}, true
+	}
+
+	loc, err := e.Schedule.Location()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	until, scheduled := time.Time{}, false
+	for _, w := range e.Schedule.Windows {
+		end, open, err := w.OpenUntil(at, loc)
+		if err != nil || !open {
+			continue
+		}
+		if end.After(until) {
+			until = end
+		}
+		scheduled = true
+	}
+	return until, scheduled
+}
+
+// Scheduled reports whether the exhibit has to run at the given time
+func (e Exhibit) Scheduled(at time.Time) bool {
+	_, scheduled := e.ScheduledUntil(at)
+	return scheduled
+}
+
+// AlwaysOn reports whether the exhibit is exempt from the cleanup
+func (e Exhibit) AlwaysOn() bool {
+	return e.Schedule != nil && e.Schedule.AlwaysOn
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestScheduledUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	exhibit := Exhibit{Schedule: &Schedule{
+		Timezone: "Europe/Berlin",
+		Windows: []ScheduleWindow{
+			// the lecture on mondays
+			{Cron: "0 9 * * 1", Duration: "2h"},
+			{From: "2026-11-03T14:00", To: "2026-11-03T18:00"},
+		},
+	}}
+
+	monday := time.Date(2026, 10, 19, 10, 30, 0, 0, berlin)
+	until, scheduled := exhibit.ScheduledUntil(monday)
+	assert.True(t, scheduled)
+	assert.Equal(t, time.Date(2026, 10, 19, 11, 0, 0, 0, berlin), until)
+
+	// the same instant in another time zone is still in the window
+	until, scheduled = exhibit.ScheduledUntil(monday.UTC())
+	assert.True(t, scheduled)
+	assert.Equal(t, time.Date(2026, 10, 19, 11, 0, 0, 0, berlin).Unix(), until.Unix())
+
+	assert.False(t, exhibit.Scheduled(time.Date(2026, 10, 19, 11, 0, 0, 0, berlin)))
+	assert.False(t, exhibit.Scheduled(time.Date(2026, 10, 20, 10, 0, 0, 0, berlin)))
+	assert.True(t, exhibit.Scheduled(time.Date(2026, 11, 3, 14, 0, 0, 0, berlin)))
+	assert.False(t, exhibit.Scheduled(time.Date(2026, 11, 3, 18, 0, 0, 0, berlin)))
+
+	assert.True(t, Exhibit{Schedule: &Schedule{AlwaysOn: true}}.Scheduled(monday))
+	assert.False(t, Exhibit{}.Scheduled(monday))
+}
+
+func TestValidateSchedule(t *testing.T) {
+	exhibit := Exhibit{
+		Spec:    ExhibitSpecV1,
+		Name:    "lecture",
+		Expose:  "web",
+		Lease:   "1h",
+		Objects: []Object{{Name: "web", Image: "nginx", Label: "latest"}},
+		Schedule: &Schedule{
+			Timezone: "Mars/Olympus_Mons",
+			Windows: []ScheduleWindow{
+				{Cron: "0 9 * * 1", Duration: "2h"},
+				{Cron: "0 25 * * *", Duration: "1h"},
+				{Cron: "0 9 * * 1"},
+				{From: "2026-11-03T14:00", To: "2026-11-03T12:00"},
+				{Cron: "0 9 * * 1", Duration: "1h", From: "2026-11-03T14:00"},
+			},
+		},
+	}
+
+	pointers := make([]string, 0)
+	for _, p := range exhibit.Validate() {
+		pointers = append(pointers, p.Pointer)
+	}
+	assert.Equal(t, []string{"/schedule/timezone", "/schedule/windows/1", "/schedule/windows/2", "/schedule/windows/3", "/schedule/windows/4"}, pointers)
+}
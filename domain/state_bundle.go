@@ -0,0 +1,37 @@
+package domain
+
+// StateBundleVersion is the version of the state bundle format written by this version of museum.
+// Bundles of newer versions are rejected on import.
+const StateBundleVersion = 1
+
+type ImportConflictMode string
+
+const (
+	// ImportSkip keeps existing exhibits that have the same id or name as an imported one
+	ImportSkip ImportConflictMode = "skip"
+	// ImportOverwrite stops and replaces existing exhibits that have the same id or name as an imported one
+	ImportOverwrite ImportConflictMode = "overwrite"
+)
+
+// StateBundle is a backup of all exhibit definitions, it contains no runtime state
+type StateBundle struct {
+	Version int `json:"version" yaml:"version"`
+	// ExportedAt is the unix time the bundle was written at
+	ExportedAt int64 `json:"exportedAt" yaml:"exportedAt"`
+	// Revision is the state revision the bundle was read at
+	Revision int64                `json:"revision" yaml:"revision"`
+	Exhibits []StateBundleExhibit `json:"exhibits" yaml:"exhibits"`
+}
+
+type StateBundleExhibit struct {
+	Exhibit      Exhibit `json:"exhibit" yaml:"exhibit"`
+	LastAccessed int64   `json:"lastAccessed" yaml:"lastAccessed"`
+}
+
+// StateImportResult lists the names of the exhibits of a bundle by what happened to them
+type StateImportResult struct {
+	Imported    []string          `json:"imported"`
+	Overwritten []string          `json:"overwritten"`
+	Skipped     []string          `json:"skipped"`
+	Failed      map[string]string `json:"failed"`
+}
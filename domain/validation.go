@@ -0,0 +1,288 @@
+package domain
+
+import (
+	"github.com/docker/go-units"
+	"github.com/google/uuid"
+	"museum/util/schema"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var ExhibitNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// ValidationProblem is a single problem of an exhibit
+type ValidationProblem struct {
+	// Pointer is the JSON pointer of the invalid field, e.g. /objects/0/livecheck/type, empty for the exhibit itself
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+	// Line and Column locate the field in the exhibit file, they are 0 if the exhibit was not read from a file
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+}
+
+func (p ValidationProblem) Error() string {
+	if p.Pointer == "" {
+		return p.Message
+	}
+	return p.Pointer + ": " + p.Message
+}
+
+// ValidationResult is the outcome of validating an exhibit without creating it
+type ValidationResult struct {
+	Valid    bool                `json:"valid"`
+	Problems []ValidationProblem `json:"problems"`
+}
+
+// Validate checks that the exhibit is consistent in itself and returns all problems found.
+// Checks that depend on the host museum runs on, like the configuration of volume drivers, are not part of it.
+func (e Exhibit) Validate() []ValidationProblem {
+	problems := make([]ValidationProblem, 0)
+	report := func(pointer string, message string) {
+		problems = append(problems, ValidationProblem{Pointer: pointer, Message: message})
+	}
+
+	// check that the exhibit file is written in a spec this version understands
+	if e.Spec == "" {
+		report(schema.Pointer("spec"), "exhibit spec is missing, it must be one of: "+strings.Join(SupportedExhibitSpecs, ", "))
+	} else if !slices.Contains(SupportedExhibitSpecs, e.Spec) {
+		report(schema.Pointer("spec"), "exhibit spec "+e.Spec+" is not supported, it must be one of: "+strings.Join(SupportedExhibitSpecs, ", "))
+	}
+
+	// check that the name can be used as a slug in exhibit urls and as a docker network name
+	if !ExhibitNameRegex.MatchString(e.Name) {
+		report(schema.Pointer("name"), "exhibit name must start with a letter or digit and may only contain letters, digits, '_', '.' and '-'")
+	}
+
+	// the collection is part of lock keys and of the roles of api callers
+	if e.Collection != "" && !CollectionNameRegex.MatchString(e.Collection) {
+		report(schema.Pointer("collection"), "collection must start with a lowercase letter or digit and may only contain lowercase letters, digits and '-'")
+	}
+
+	// names that look like an id would be ambiguous in urls
+	if _, err := uuid.Parse(e.Name); err == nil {
+		report(schema.Pointer("name"), "exhibit name must not be a uuid")
+	}
+
+	// check that a container is exposed
+	if e.Expose == "" {
+		report(schema.Pointer("expose"), "exhibit must expose a container")
+	} else if !slices.ContainsFunc(e.Objects, func(o Object) bool { return o.Name == e.Expose }) {
+		report(schema.Pointer("expose"), "exhibit must expose a container that is part of the exhibit")
+	}
+
+	// check that the start order only names objects of the exhibit, objects are started by it
+	for i, name := range e.Order {
+		if !slices.ContainsFunc(e.Objects, func(o Object) bool { return o.Name == name }) {
+			report(schema.Pointer("order", i), "order contains "+name+", which is not an object of the exhibit")
+		}
+	}
+
+	// check that mounts have a volume
+	for i, o := range e.Objects {
+		mounts := make([]string, 0, len(o.Mounts))
+		for mount := range o.Mounts {
+			mounts = append(mounts, mount)
+		}
+		sort.Strings(mounts)
+
+		for _, m := range mounts {
+			if !slices.ContainsFunc(e.Volumes, func(v Volume) bool { return v.Name == m }) {
+				report(schema.Pointer("objects", i, "mounts", m), "mount "+m+" does not have a corresponding volume")
+			}
+		}
+	}
+
+	// replicas share nothing but the network, the proxy balances the requests to the exposed object between them
+	for i, o := range e.Objects {
+		r := o.Replicas
+		if r == nil {
+			continue
+		}
+
+		if o.Name != e.Expose {
+			report(schema.Pointer("objects", i, "replicas"), "only the exposed object can have replicas")
+		}
+		if len(o.Mounts) > 0 {
+			report(schema.Pointer("objects", i, "replicas"), "objects with replicas must be stateless, they cannot mount volumes")
+		}
+		if r.Count < 0 || r.Min < 0 || r.Max < 0 || r.Target < 0 {
+			report(schema.Pointer("objects", i, "replicas"), "replicas must not be negative")
+		} else if (r.Count > 0) == (r.Max > 0) {
+			report(schema.Pointer("objects", i, "replicas"), "replicas must either have a count or a max to be autoscaled")
+		} else if r.Max > 0 && r.Min > r.Max {
+			report(schema.Pointer("objects", i, "replicas", "min"), "min must not be greater than max")
+		}
+	}
+
+	// resources are passed to the container runtime as they are
+	for i, o := range e.Objects {
+		r := o.Resources
+		if r == nil {
+			continue
+		}
+
+		if r.Cpus < 0 {
+			report(schema.Pointer("objects", i, "resources", "cpus"), "cpus must not be negative")
+		}
+		if _, err := units.RAMInBytes(r.Memory); r.Memory != "" && err != nil {
+			report(schema.Pointer("objects", i, "resources", "memory"), "memory must be a size like 512m or 2g")
+		}
+	}
+
+	// validate lease time
+	if _, err := time.ParseDuration(e.Lease); err != nil {
+		report(schema.Pointer("lease"), "lease time must be a valid duration")
+	}
+
+	// persistent identifiers are used in urls and citations
+	if strings.ContainsFunc(e.Pid, unicode.IsSpace) {
+		report(schema.Pointer("pid"), "persistent identifier must not contain whitespace")
+	}
+
+	// validate the descriptive metadata, it has to be complete enough to cite the exhibit
+	if m := e.Metadata; m != nil {
+		if strings.TrimSpace(m.Title) == "" {
+			report(schema.Pointer("metadata", "title"), "metadata must have a title")
+		}
+
+		if len(m.Creators) == 0 {
+			report(schema.Pointer("metadata", "creators"), "metadata must name at least one creator")
+		}
+
+		for i, c := range m.Creators {
+			if strings.TrimSpace(c.Name) == "" {
+				report(schema.Pointer("metadata", "creators", i, "name"), "creator must have a name")
+			}
+			if c.Orcid != "" && !OrcidRegex.MatchString(c.Orcid) {
+				report(schema.Pointer("metadata", "creators", i, "orcid"), "orcid must be an ORCID iD like 0000-0002-1825-0097")
+			}
+		}
+
+		if m.Year < 1000 || m.Year > 9999 {
+			report(schema.Pointer("metadata", "year"), "metadata year must be a four digit year")
+		}
+
+		if m.Publication != "" && !DoiRegex.MatchString(m.Publication) {
+			report(schema.Pointer("metadata", "publication"), "publication must be a DOI like 10.1000/182, without https://doi.org/")
+		}
+	}
+
+	// validate the access policy, visitors must be able to meet it
+	if a := e.Access; a != nil {
+		switch a.Type {
+		case AccessPublic, AccessOidc:
+		case AccessBasic:
+			if len(a.Users) == 0 {
+				report(schema.Pointer("access", "users"), "basic access must have at least one user")
+			}
+
+			users := make([]string, 0, len(a.Users))
+			for user := range a.Users {
+				users = append(users, user)
+			}
+			sort.Strings(users)
+
+			for _, user := range users {
+				if strings.Contains(user, ":") {
+					report(schema.Pointer("access", "users", user), "user name must not contain ':'")
+				}
+				if !strings.HasPrefix(a.Users[user], "$2") {
+					report(schema.Pointer("access", "users", user), "password must be a bcrypt hash, e.g. from htpasswd -nB")
+				}
+			}
+		case AccessToken:
+			if len(a.Token) < 16 {
+				report(schema.Pointer("access", "token"), "token must be at least 16 characters long")
+			}
+		case AccessIp:
+			if len(a.Allow) == 0 {
+				report(schema.Pointer("access", "allow"), "ip access must allow at least one address or range")
+			}
+			for i, entry := range a.Allow {
+				if _, err := parsePrefix(entry); err != nil {
+					report(schema.Pointer("access", "allow", i), entry+" is not an IP address or CIDR range")
+				}
+			}
+		default:
+			report(schema.Pointer("access", "type"), "access type must be one of: "+strings.Join(AccessTypes, ", "))
+		}
+	}
+
+	// validate the schedule, the windows are evaluated in its time zone
+	if sc := e.Schedule; sc != nil {
+		loc, err := sc.Location()
+		if err != nil {
+			report(schema.Pointer("schedule", "timezone"), "time zone "+sc.Timezone+" is unknown, it must be an IANA time zone like Europe/Berlin")
+			loc = time.UTC
+		}
+
+		for i, w := range sc.Windows {
+			if (w.Cron == "") == (w.From == "" && w.To == "") {
+				report(schema.Pointer("schedule", "windows", i), "window must either be recurring with cron and duration or one-off with from and to")
+				continue
+			}
+			if _, _, err := w.OpenUntil(time.Now(), loc); err != nil {
+				report(schema.Pointer("schedule", "windows", i), err.Error())
+			}
+		}
+	}
+
+	// requests that are held wait for the exhibit to start, a timeout is required to answer them at all
+	if h := e.Hold; h != nil {
+		if h.Requests != "" && !slices.Contains(HoldModes, h.Requests) {
+			report(schema.Pointer("hold", "requests"), "requests must be one of: "+strings.Join(HoldModes, ", "))
+		}
+		if timeout, err := time.ParseDuration(h.Timeout); h.Timeout != "" && (err != nil || timeout <= 0) {
+			report(schema.Pointer("hold", "timeout"), "timeout must be a positive duration string like 90s")
+		}
+	}
+
+	// pages are templates, they have to parse to be shown at all
+	for _, kind := range PageKinds {
+		if text := e.Pages.Get(kind); text != "" {
+			if _, err := ParsePage(kind, text); err != nil {
+				report(schema.Pointer("pages", kind), "page is not a valid template: "+err.Error())
+			}
+		}
+	}
+
+	// validate livechecks
+	for i, o := range e.Objects {
+		l := o.Livecheck
+		if l == nil {
+			continue
+		}
+
+		if l.Type != LivecheckTypeHttp && l.Type != LivecheckTypeExec {
+			report(schema.Pointer("objects", i, "livecheck", "type"), "livecheck type must be one of: "+LivecheckTypeHttp+", "+LivecheckTypeExec)
+			continue
+		}
+
+		if l.Type != LivecheckTypeHttp {
+			continue
+		}
+
+		if method, ok := l.Config["method"]; ok && method != "GET" && method != "POST" && method != "PUT" && method != "DELETE" {
+			report(schema.Pointer("objects", i, "livecheck", "config", "method"), "http livecheck method must be one of: GET, POST, PUT, DELETE")
+		}
+
+		if status, ok := l.Config["status"]; ok {
+			if _, err := strconv.Atoi(status); err != nil {
+				report(schema.Pointer("objects", i, "livecheck", "config", "status"), "http livecheck status must be a valid integer")
+			}
+		}
+
+		if port, ok := l.Config["port"]; ok {
+			if _, err := strconv.Atoi(port); err != nil {
+				report(schema.Pointer("objects", i, "livecheck", "config", "port"), "http livecheck port must be a valid integer")
+			}
+		}
+	}
+
+	return problems
+}
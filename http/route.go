@@ -11,6 +11,14 @@ type Route struct {
 	Path    path.Path
 	Handler MuxHandlerFunc
 	Method  string
+	// Access is the role that is required to call the route, it is empty if the route does not say
+	Access string
+}
+
+// Requires returns the route with the role that is required to call it
+func (r Route) Requires(access string) Route {
+	r.Access = access
+	return r
 }
 
 func Any(p string, handler MuxHandlerFunc) Route {
@@ -36,3 +44,19 @@ func Post(p string, handler MuxHandlerFunc) Route {
 		Method:  http.MethodPost,
 	}
 }
+
+func Put(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodPut,
+	}
+}
+
+func Delete(p string, handler MuxHandlerFunc) Route {
+	return Route{
+		Path:    path.ConstructPath(p),
+		Handler: handler,
+		Method:  http.MethodDelete,
+	}
+}
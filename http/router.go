@@ -11,11 +11,15 @@ import (
 
 type FallbackHandler func(writer http.ResponseWriter, request *Request) error
 
+// Middleware wraps the handler of a matched route
+type Middleware func(route Route, next MuxHandlerFunc) MuxHandlerFunc
+
 type Mux struct {
-	routes     []Route
-	mux        *http.ServeMux
-	log        *zap.SugaredLogger
-	fallbackFn *FallbackHandler
+	routes      []Route
+	middlewares []Middleware
+	mux         *http.ServeMux
+	log         *zap.SugaredLogger
+	fallbackFn  *FallbackHandler
 }
 
 type Status struct {
@@ -59,7 +63,12 @@ func (r *Mux) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
 					rawQueryParams = queryParams[1]
 				}
 
-				route.Handler(&Response{writer}, &Request{
+				handler := route.Handler
+				for i := len(r.middlewares) - 1; i >= 0; i-- {
+					handler = r.middlewares[i](route, handler)
+				}
+
+				handler(&Response{writer}, &Request{
 					Request:        request,
 					Params:         pathParams,
 					RequestID:      requestId,
@@ -102,6 +111,11 @@ func (r *Mux) AddRoute(route Route) {
 	r.routes = append(r.routes, route)
 }
 
+// Use adds a middleware that wraps every route, the middleware that is added first runs first
+func (r *Mux) Use(middleware Middleware) {
+	r.middlewares = append(r.middlewares, middleware)
+}
+
 func (r *Mux) SetFallbackHandler(fallbackHandler FallbackHandler) {
 	r.fallbackFn = &fallbackHandler
 }
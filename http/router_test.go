@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	mux := NewMux(zap.NewNop().Sugar())
+	calls := make([]string, 0)
+	mux.AddRoute(Get("/open", func(res *Response, req *Request) { calls = append(calls, "open") }))
+	mux.AddRoute(Get("/closed", func(res *Response, req *Request) { calls = append(calls, "closed") }).Requires("admin"))
+
+	for _, name := range []string{"first", "second"} {
+		mux.Use(func(route Route, next MuxHandlerFunc) MuxHandlerFunc {
+			return func(res *Response, req *Request) {
+				calls = append(calls, name+":"+route.Access)
+				if route.Access == "admin" {
+					res.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next(res, req)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/open", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"first:", "second:", "open"}, calls)
+
+	calls = calls[:0]
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/closed", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, []string{"first:admin"}, calls)
+}
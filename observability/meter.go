@@ -0,0 +1,45 @@
+package observability
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.18.0"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"museum/config"
+)
+
+// NewMeterProvider exports metrics to the same OTLP collector as the traces
+func NewMeterProvider(config config.Config, log *zap.SugaredLogger) metric.MeterProvider {
+	if config.GetJaegerHost() == "" {
+		log.Warn("jaeger host not set, using noop meter provider")
+		return noop.NewMeterProvider()
+	}
+
+	client, err := grpc.NewClient(config.GetJaegerHost(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		log.Panicw("failed to create gRPC connection to collector", "error", err)
+	}
+
+	exp, err := otlpmetricgrpc.New(context.Background(), otlpmetricgrpc.WithGRPCConn(client))
+	if err != nil {
+		log.Panicw("failed to create metric exporter", "error", err)
+	}
+
+	return metricsdk.NewMeterProvider(
+		metricsdk.WithReader(metricsdk.NewPeriodicReader(exp)),
+		metricsdk.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName("museum"),
+			attribute.String("environment", config.GetEnvironment()),
+		)),
+	)
+}
@@ -5,8 +5,10 @@ import (
 	etcd "go.etcd.io/etcd/client/v3"
 	"go.uber.org/zap"
 	"museum/config"
+	"museum/domain"
 	"museum/observability"
 	"museum/persistence/impl"
+	"sync"
 	"time"
 )
 
@@ -33,7 +35,21 @@ func NewEtcdState(config config.Config, etcdClient *etcd.Client, providerFactory
 
 	log.Debugw("etcd persistence initialized")
 
-	return etcdState
+	return etcdTxnState{etcdState}
+}
+
+func NewBoltState(config config.Config, providerFactory *observability.TracerProviderFactory, log *zap.SugaredLogger) State {
+	boltState := &impl.BoltState{
+		Config:   config,
+		Provider: providerFactory.Build("bolt-service"),
+		Log:      log,
+	}
+
+	boltState.Init()
+
+	log.Debugw("bolt persistence initialized")
+
+	return boltTxnState{boltState}
 }
 
 func NewNatsEventing(config config.Config, log *zap.SugaredLogger, conn *nats.Conn, providerFactory *observability.TracerProviderFactory) Eventing {
@@ -52,3 +68,32 @@ func NewNoopEventing(log *zap.SugaredLogger) Eventing {
 		Log: log,
 	}
 }
+
+// NewMemoryState creates a State that only lives in memory, it is meant for tests
+func NewMemoryState() State {
+	return memoryTxnState{&impl.MemoryState{
+		Exhibits:     make(map[string][]byte),
+		Names:        make(map[string]string),
+		RuntimeInfo:  make(map[string][]byte),
+		LastAccessed: make(map[string]int64),
+		Fixity:       make(map[string][]byte),
+		StartupLogs:  make(map[string][]byte),
+		Secrets:      make(map[string][]byte),
+		Revision:     impl.NewRevisionWaiter(),
+		Mu:           &sync.RWMutex{},
+		Locks:        impl.NewLocalLocks(),
+	}}
+}
+
+// NewMemoryEventing creates an Eventing that only delivers events inside the process, it is meant for tests
+func NewMemoryEventing() *impl.MemoryEventing {
+	return &impl.MemoryEventing{
+		CreatedEvents:    make([]domain.Exhibit, 0),
+		StartingEvents:   make([]domain.ExhibitStartingStepEvent, 0),
+		StoppingEvents:   make([]domain.ExhibitStoppingEvent, 0),
+		FixityEvents:     make([]domain.ExhibitFixityEvent, 0),
+		StartingChannels: make(map[string][]chan domain.ExhibitStartingStepEvent),
+		StoppingChannels: make(map[string][]chan domain.ExhibitStoppingEvent),
+		Mu:               &sync.Mutex{},
+	}
+}
@@ -0,0 +1,221 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	etcd "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"museum/config/impl"
+	"museum/domain"
+	"museum/observability"
+	persistence "museum/persistence/impl"
+	"os"
+	"testing"
+	"time"
+)
+
+// newEtcdTestState returns an etcd backed state and a second client that plays the role of another museum instance
+func newEtcdTestState(t *testing.T) (etcdTxnState, *etcd.Client, string) {
+	host := os.Getenv("MUSEUM_TEST_ETCD_HOST")
+	if host == "" {
+		t.Skip("MUSEUM_TEST_ETCD_HOST not set, skipping etcd backend")
+	}
+
+	log := zap.NewNop().Sugar()
+	cfg := &impl.EnvConfig{EtcdHost: host, EtcdBaseKey: "museum-test-" + uuid.New().String()}
+
+	client := NewEtcdClient(cfg, log)
+	other := NewEtcdClient(cfg, log)
+	state := NewEtcdState(cfg, client, observability.NewTracerProviderFactory(&tracetest.NoopExporter{}, cfg), log).(etcdTxnState)
+
+	t.Cleanup(func() {
+		_ = state.Close()
+		_, _ = client.Delete(context.Background(), "/"+cfg.EtcdBaseKey+"/", etcd.WithPrefix())
+		_ = client.Close()
+		_ = other.Close()
+	})
+
+	return state, other, "/" + cfg.EtcdBaseKey + "/"
+}
+
+func TestEtcdStateCacheFollowsOtherInstances(t *testing.T) {
+	state, other, prefix := newEtcdTestState(t)
+	ctx := context.Background()
+
+	exhibit := newTestExhibit("other-instance")
+	b, _ := json.Marshal(exhibit)
+	res, err := other.Put(ctx, prefix+exhibit.Id+"/meta", string(b))
+	assert.NoError(t, err)
+
+	info := domain.ExhibitRuntimeInfo{Status: domain.Running, Hostname: "other-instance_nginx"}
+	b, _ = json.Marshal(info)
+	_, err = other.Put(ctx, prefix+exhibit.Id+"/runtime_info", string(b))
+	assert.NoError(t, err)
+
+	// lock keys below the exhibit must not confuse the cache
+	res, err = other.Put(ctx, prefix+exhibit.Id+"/locks/exhibit/write", "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, state.WaitForRevision(ctx, res.Header.Revision))
+
+	state.CacheMu.RLock()
+	assert.Contains(t, state.ExhibitCache, exhibit.Id)
+	assert.Equal(t, info, state.RuntimeInfoCache[exhibit.Id])
+	assert.Len(t, state.ExhibitCache, 1)
+	state.CacheMu.RUnlock()
+
+	// updates and deletes are applied the same way
+	info.Status = domain.Stopped
+	b, _ = json.Marshal(info)
+	_, err = other.Put(ctx, prefix+exhibit.Id+"/runtime_info", string(b))
+	assert.NoError(t, err)
+	deleted, err := other.Delete(ctx, prefix+exhibit.Id+"/meta")
+	assert.NoError(t, err)
+
+	assert.NoError(t, state.WaitForRevision(ctx, deleted.Header.Revision))
+
+	got, err := state.GetRuntimeInfo(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stopped, got.Status)
+	assert.Empty(t, state.GetAllExhibits(ctx))
+}
+
+func TestEtcdStateDeleteExhibitLeavesLockKeysToHolders(t *testing.T) {
+	state, other, prefix := newEtcdTestState(t)
+	ctx := context.Background()
+
+	exhibit := newTestExhibit("locked")
+	assert.NoError(t, state.CreateExhibit(ctx, exhibit))
+
+	lock := state.GetRwLock(ctx, exhibit.Id, "exhibit")
+	assert.NoError(t, lock.Lock(ctx))
+	reader := state.GetRwLock(ctx, exhibit.Id, "runtime_info")
+	assert.NoError(t, reader.RLock(ctx))
+
+	assert.NoError(t, state.DeleteExhibitById(ctx, exhibit.Id))
+
+	res, err := other.Get(ctx, prefix+exhibit.Id+"/locks/", etcd.WithPrefix(), etcd.WithCountOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), res.Count)
+
+	// the holders remove their keys, nothing of the exhibit is left then
+	assert.NoError(t, lock.Unlock())
+	assert.NoError(t, reader.RUnlock())
+
+	res, err = other.Get(ctx, prefix+exhibit.Id+"/", etcd.WithPrefix(), etcd.WithCountOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), res.Count)
+}
+
+func TestEtcdStateMigrateExhibits(t *testing.T) {
+	state, other, prefix := newEtcdTestState(t)
+	ctx := context.Background()
+
+	// an exhibit written by a museum that did not store schema versions yet
+	id := uuid.New().String()
+	legacy := `{"id":"` + id + `","name":"legacy","expose":"nginx","lease":"1h","objects":[{"name":"nginx","image":"nginx","label":"latest"}]}`
+	res, err := other.Put(ctx, prefix+id+"/meta", legacy)
+	assert.NoError(t, err)
+	_, err = other.Put(ctx, prefix+"names/legacy", id)
+	assert.NoError(t, err)
+	assert.NoError(t, state.WaitForRevision(ctx, res.Header.Revision))
+
+	// the cache holds the upgraded exhibit
+	exhibit, err := state.GetExhibitById(ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ExhibitSpecV1, exhibit.Spec)
+
+	assert.NoError(t, state.CreateExhibit(ctx, newTestExhibit("current")))
+
+	result, err := state.MigrateExhibits(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, result.Migrated)
+	assert.Equal(t, 1, result.UpToDate)
+	assert.Empty(t, result.Failed)
+
+	get, err := other.Get(ctx, prefix+id+"/meta")
+	assert.NoError(t, err)
+	stored := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(get.Kvs[0].Value, &stored))
+	assert.Equal(t, float64(persistence.ExhibitSchemaVersion), stored["schemaVersion"])
+	assert.Equal(t, domain.ExhibitSpecV1, stored["spec"])
+}
+
+func TestEtcdStateCampaignEndsWithSession(t *testing.T) {
+	state, other, prefix := newEtcdTestState(t)
+	ctx := context.Background()
+
+	lead, err := state.Campaign(ctx, "scheduler")
+	assert.NoError(t, err)
+
+	// the session expires, as it would during a partition
+	res, err := other.Get(ctx, prefix+"elections/scheduler/", etcd.WithPrefix())
+	assert.NoError(t, err)
+	assert.Len(t, res.Kvs, 1)
+	_, err = other.Revoke(ctx, etcd.LeaseID(res.Kvs[0].Lease))
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return lead.Err() != nil }, 5*time.Second, 10*time.Millisecond)
+
+	// the instance campaigns again with a new session
+	campaignCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	lead, err = state.Campaign(campaignCtx, "scheduler")
+	assert.NoError(t, err)
+	assert.NoError(t, lead.Err())
+}
+
+func TestEtcdStateDeleteExhibitReadsOnCommit(t *testing.T) {
+	state, other, prefix := newEtcdTestState(t)
+	ctx := context.Background()
+
+	exhibit := newTestExhibit("renamed")
+	assert.NoError(t, state.CreateExhibit(ctx, exhibit))
+
+	// nothing is read while the transaction is built, another instance renames the exhibit before it commits
+	txn := state.Txn(ctx).DeleteExhibitById(exhibit.Id)
+	_, err := other.Delete(ctx, prefix+"names/"+exhibit.Name)
+	assert.NoError(t, err)
+	exhibit.Name = "renamed-again"
+	b, _ := json.Marshal(exhibit)
+	_, err = other.Put(ctx, prefix+exhibit.Id+"/meta", string(b))
+	assert.NoError(t, err)
+	_, err = other.Put(ctx, prefix+"names/"+exhibit.Name, exhibit.Id)
+	assert.NoError(t, err)
+
+	// the delete drops the name the exhibit has when it commits
+	assert.NoError(t, txn.Commit())
+	res, err := other.Get(ctx, prefix, etcd.WithPrefix(), etcd.WithCountOnly())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), res.Count)
+}
+
+func TestEtcdStateSyncReadsWritesOfOtherInstances(t *testing.T) {
+	state, other, prefix := newEtcdTestState(t)
+	ctx := context.Background()
+
+	exhibit := newTestExhibit("synced")
+	assert.NoError(t, state.CreateExhibit(ctx, exhibit))
+
+	info := domain.ExhibitRuntimeInfo{Status: domain.Running, Hostname: "synced_nginx"}
+	b, _ := json.Marshal(info)
+	_, err := other.Put(ctx, prefix+exhibit.Id+"/runtime_info", string(b))
+	assert.NoError(t, err)
+
+	// a write outside the base key moves the store past the last event the watch has seen
+	_, err = other.Put(ctx, prefix[:len(prefix)-1]+"-elsewhere", "")
+	assert.NoError(t, err)
+	t.Cleanup(func() { _, _ = other.Delete(context.Background(), prefix[:len(prefix)-1]+"-elsewhere") })
+
+	syncCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	assert.NoError(t, state.Sync(syncCtx))
+
+	// no waiting for the watch after the sync
+	got, err := state.GetRuntimeInfo(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, info, got)
+}
@@ -0,0 +1,109 @@
+package impl
+
+import (
+	"context"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/util"
+	"time"
+)
+
+var (
+	boltExhibitsBucket     = []byte("exhibits")
+	boltNamesBucket        = []byte("names")
+	boltRuntimeInfoBucket  = []byte("runtime_info")
+	boltLastAccessedBucket = []byte("last_accessed")
+	boltFixityBucket       = []byte("fixity")
+	boltStartupLogsBucket  = []byte("startup_logs")
+	boltSecretsBucket      = []byte("secrets")
+)
+
+// BoltState is a single node State backed by an embedded bbolt file.
+// Locks are held in-process, so only one museum instance may use the file at a time.
+type BoltState struct {
+	DB       *bolt.DB
+	Config   config.Config
+	Provider trace.TracerProvider
+	Log      *zap.SugaredLogger
+
+	// Revision is the id of the last committed bolt write transaction
+	Revision *RevisionWaiter
+
+	Locks *LocalLocks
+}
+
+func (b *BoltState) Init() {
+	b.Log.Infow("initializing bolt persistence", "path", b.Config.GetBoltPath())
+
+	db, err := bolt.Open(b.Config.GetBoltPath(), 0600, &bolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		b.Log.Fatalw("error opening bolt database", "error", err, "path", b.Config.GetBoltPath())
+	}
+	b.DB = db
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{boltExhibitsBucket, boltNamesBucket, boltRuntimeInfoBucket, boltLastAccessedBucket, boltFixityBucket, boltStartupLogsBucket, boltSecretsBucket} {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		b.Log.Fatalw("error creating bolt buckets", "error", err)
+	}
+
+	b.Revision = NewRevisionWaiter()
+	err = db.View(func(tx *bolt.Tx) error {
+		b.Revision.Advance(int64(tx.ID()))
+		return nil
+	})
+	if err != nil {
+		b.Log.Fatalw("error reading bolt revision", "error", err)
+	}
+
+	b.Locks = NewLocalLocks()
+
+	b.Log.Debugw("bolt buckets created")
+}
+
+func (b *BoltState) Close() error {
+	return b.DB.Close()
+}
+
+func (b *BoltState) GetRevision() int64 {
+	return b.Revision.Get()
+}
+
+// Sync returns right away, every write is read right after it was committed
+func (b *BoltState) Sync(context.Context) error {
+	return nil
+}
+
+func (b *BoltState) WaitForRevision(ctx context.Context, revision int64) error {
+	return b.Revision.Wait(ctx, revision)
+}
+
+func (b *BoltState) Campaign(ctx context.Context, election string) (context.Context, error) {
+	return b.Locks.Campaign(ctx, election)
+}
+
+func (b *BoltState) GetRwLock(ctx context.Context, id string, lockName string) util.RwErrMutex {
+	key := id + "/" + "locks" + "/" + lockName
+
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetRwLock", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id), attribute.String("lockName", lockName)))
+	defer span.End()
+
+	lock := b.Locks.Get(key)
+
+	span.AddEvent("lock retrieved")
+
+	return lock
+}
@@ -0,0 +1,173 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+)
+
+func (b *BoltState) CreateExhibit(ctx context.Context, app domain.Exhibit) error {
+	return b.Txn(ctx).CreateExhibit(app).Commit()
+}
+
+func (b *BoltState) GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error) {
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetExhibitById", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	span.AddEvent("searching for exhibit")
+
+	exhibit := domain.Exhibit{}
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltExhibitsBucket).Get([]byte(id))
+		if v == nil {
+			return domain.ExhibitNotFoundError{By: "id", Value: id}
+		}
+
+		var err error
+		exhibit, _, err = unmarshalExhibit(v)
+		if err != nil {
+			return errors.New("error reading exhibit with id " + id + ": " + err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	span.AddEvent("found exhibit")
+
+	return exhibit, nil
+}
+
+func (b *BoltState) GetExhibitIdByName(ctx context.Context, name string) (string, error) {
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetExhibitIdByName", trace.WithAttributes(attribute.String("name", name)))
+	defer span.End()
+
+	span.AddEvent("searching for exhibit name")
+
+	id := ""
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltNamesBucket).Get([]byte(name))
+		if v == nil {
+			return domain.ExhibitNotFoundError{By: "name", Value: name}
+		}
+
+		id = string(v)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	span.AddEvent("found exhibit name")
+
+	return id, nil
+}
+
+func (b *BoltState) GetAllExhibits(ctx context.Context) []domain.Exhibit {
+	exhibits := make([]domain.Exhibit, 0)
+
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetAllExhibits")
+	defer span.End()
+
+	span.AddEvent("searching for exhibits")
+
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltExhibitsBucket).ForEach(func(k, v []byte) error {
+			exhibit, _, err := unmarshalExhibit(v)
+			if err != nil {
+				b.Log.Warnw("skipping exhibit that cannot be read", "error", err, "exhibitId", string(k))
+				return nil
+			}
+
+			exhibits = append(exhibits, exhibit)
+			return nil
+		})
+	})
+	if err != nil {
+		return []domain.Exhibit{}
+	}
+
+	span.AddEvent("found exhibits")
+
+	return exhibits
+}
+
+func (b *BoltState) DeleteExhibitById(ctx context.Context, id string) error {
+	return b.Txn(ctx).DeleteExhibitById(id).Commit()
+}
+
+func (b *BoltState) MigrateExhibits(ctx context.Context) (domain.MigrationResult, error) {
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "MigrateExhibits")
+	defer span.End()
+
+	result := newMigrationResult()
+	revision := int64(0)
+
+	// all exhibits are migrated in one transaction, so a failed write leaves every exhibit as it was
+	err := b.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(boltExhibitsBucket)
+
+		// the bucket must not be written to while iterating it
+		migrated := make(map[string][]byte)
+		err := bucket.ForEach(func(k, v []byte) error {
+			exhibit, version, err := unmarshalExhibit(v)
+			if err != nil {
+				result.Failed[string(k)] = err.Error()
+				return nil
+			}
+
+			if version == ExhibitSchemaVersion {
+				result.UpToDate++
+				return nil
+			}
+
+			value, err := marshalExhibit(exhibit)
+			if err != nil {
+				result.Failed[string(k)] = err.Error()
+				return nil
+			}
+
+			migrated[string(k)] = value
+			result.Migrated = append(result.Migrated, exhibit.Name)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for id, value := range migrated {
+			err = bucket.Put([]byte(id), value)
+			if err != nil {
+				return err
+			}
+		}
+
+		revision = int64(tx.ID())
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return domain.MigrationResult{}, err
+	}
+
+	b.Revision.Advance(revision)
+	span.SetAttributes(attribute.Int("migrated", len(result.Migrated)), attribute.Int("failed", len(result.Failed)))
+
+	return result, nil
+}
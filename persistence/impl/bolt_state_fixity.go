@@ -0,0 +1,43 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+)
+
+func (b *BoltState) GetFixity(ctx context.Context, id string) (domain.FixityReport, error) {
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetFixity", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	report := domain.FixityReport{}
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltFixityBucket).Get([]byte(id))
+		if v == nil {
+			return errors.New("fixity for exhibit with id " + id + " not found")
+		}
+
+		return json.Unmarshal(v, &report)
+	})
+	if err != nil {
+		return domain.FixityReport{}, err
+	}
+
+	span.AddEvent("found fixity for exhibit")
+
+	return report, nil
+}
+
+func (b *BoltState) SetFixity(ctx context.Context, id string, report domain.FixityReport) error {
+	return b.Txn(ctx).SetFixity(id, report).Commit()
+}
+
+func (b *BoltState) DeleteFixity(ctx context.Context, id string) error {
+	return b.Txn(ctx).DeleteFixity(id).Commit()
+}
@@ -0,0 +1,51 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+)
+
+func (b *BoltState) GetLastAccessed(ctx context.Context, id string) (int64, error) {
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetLastAccessed", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	span.AddEvent("searching for last_accessed time for exhibit")
+
+	var lastAccessed int64 = -1
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltLastAccessedBucket).Get([]byte(id))
+		if v == nil {
+			return errors.New("last_accessed for exhibit with id " + id + " not found")
+		}
+
+		i, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return err
+		}
+
+		lastAccessed = i
+		return nil
+	})
+	if err != nil {
+		return -1, err
+	}
+
+	span.AddEvent("found last_accessed time for exhibit")
+
+	return lastAccessed, nil
+}
+
+func (b *BoltState) SetLastAccessed(ctx context.Context, id string, lastAccessed int64) error {
+	return b.Txn(ctx).SetLastAccessed(id, lastAccessed).Commit()
+}
+
+func (b *BoltState) DeleteLastAccessed(ctx context.Context, id string) error {
+	return b.Txn(ctx).DeleteLastAccessed(id).Commit()
+}
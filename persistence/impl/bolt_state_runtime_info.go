@@ -0,0 +1,46 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+)
+
+func (b *BoltState) SetRuntimeInfo(ctx context.Context, id string, runtimeInfo domain.ExhibitRuntimeInfo) error {
+	return b.Txn(ctx).SetRuntimeInfo(id, runtimeInfo).Commit()
+}
+
+func (b *BoltState) GetRuntimeInfo(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error) {
+	// create new trace span for event service
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetRuntimeInfo", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	span.AddEvent("searching for runtime info for exhibit")
+
+	runtimeInfo := domain.ExhibitRuntimeInfo{}
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltRuntimeInfoBucket).Get([]byte(id))
+		if v == nil {
+			return errors.New("runtime_info for exhibit with id " + id + " not found")
+		}
+
+		return json.Unmarshal(v, &runtimeInfo)
+	})
+	if err != nil {
+		return domain.ExhibitRuntimeInfo{}, err
+	}
+
+	span.AddEvent("found runtime info for exhibit")
+
+	return runtimeInfo, nil
+}
+
+func (b *BoltState) DeleteRuntimeInfo(ctx context.Context, id string) error {
+	return b.Txn(ctx).DeleteRuntimeInfo(id).Commit()
+}
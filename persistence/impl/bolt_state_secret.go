@@ -0,0 +1,42 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func (b *BoltState) GetOrCreateSecret(ctx context.Context, name string, secret []byte) ([]byte, error) {
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetOrCreateSecret", trace.WithAttributes(attribute.String("name", name)))
+	defer span.End()
+
+	var stored []byte
+	revision := int64(0)
+	err := b.DB.Update(func(tx *bolt.Tx) error {
+		secrets := tx.Bucket(boltSecretsBucket)
+
+		// bolt only returns values that are valid during the transaction
+		if v := secrets.Get([]byte(name)); v != nil {
+			stored = bytes.Clone(v)
+			return nil
+		}
+
+		stored = secret
+		revision = int64(tx.ID())
+		return secrets.Put([]byte(name), secret)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if revision != 0 {
+		b.Revision.Advance(revision)
+	}
+
+	return stored, nil
+}
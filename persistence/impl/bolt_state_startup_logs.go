@@ -0,0 +1,43 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+)
+
+func (b *BoltState) GetStartupLogs(ctx context.Context, id string) (domain.StartupLogs, error) {
+	_, span := b.Provider.
+		Tracer("bolt persistence").
+		Start(ctx, "GetStartupLogs", trace.WithAttributes(attribute.String("id", id)))
+	defer span.End()
+
+	logs := domain.StartupLogs{}
+	err := b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltStartupLogsBucket).Get([]byte(id))
+		if v == nil {
+			return errors.New("startup logs for exhibit with id " + id + " not found")
+		}
+
+		return json.Unmarshal(v, &logs)
+	})
+	if err != nil {
+		return domain.StartupLogs{}, err
+	}
+
+	span.AddEvent("found startup logs for exhibit")
+
+	return logs, nil
+}
+
+func (b *BoltState) SetStartupLogs(ctx context.Context, id string, logs domain.StartupLogs) error {
+	return b.Txn(ctx).SetStartupLogs(id, logs).Commit()
+}
+
+func (b *BoltState) DeleteStartupLogs(ctx context.Context, id string) error {
+	return b.Txn(ctx).DeleteStartupLogs(id).Commit()
+}
@@ -0,0 +1,194 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+	"strconv"
+)
+
+type BoltStateTxn struct {
+	state *BoltState
+	ctx   context.Context
+
+	ops []func(tx *bolt.Tx) error
+}
+
+func (b *BoltState) Txn(ctx context.Context) *BoltStateTxn {
+	return &BoltStateTxn{
+		state: b,
+		ctx:   ctx,
+		ops:   make([]func(tx *bolt.Tx) error, 0),
+	}
+}
+
+func (t *BoltStateTxn) CreateExhibit(exhibit domain.Exhibit) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		exhibits := tx.Bucket(boltExhibitsBucket)
+		names := tx.Bucket(boltNamesBucket)
+
+		if exhibits.Get([]byte(exhibit.Id)) != nil {
+			return errors.New("exhibit with id " + exhibit.Id + " already exists")
+		}
+
+		if names.Get([]byte(exhibit.Name)) != nil {
+			return errors.New("exhibit with name " + exhibit.Name + " already exists")
+		}
+
+		b, err := marshalExhibit(exhibit)
+		if err != nil {
+			return err
+		}
+
+		err = exhibits.Put([]byte(exhibit.Id), b)
+		if err != nil {
+			return err
+		}
+
+		return names.Put([]byte(exhibit.Name), []byte(exhibit.Id))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) DeleteExhibitById(id string) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		exhibits := tx.Bucket(boltExhibitsBucket)
+
+		// the name is needed to drop the name index entry
+		v := exhibits.Get([]byte(id))
+		if v == nil {
+			return nil
+		}
+
+		exhibit := domain.Exhibit{}
+		err := json.Unmarshal(v, &exhibit)
+		if err == nil {
+			err = tx.Bucket(boltNamesBucket).Delete([]byte(exhibit.Name))
+			if err != nil {
+				return err
+			}
+		}
+
+		return exhibits.Delete([]byte(id))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) SetRuntimeInfo(id string, runtimeInfo domain.ExhibitRuntimeInfo) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		b, err := json.Marshal(runtimeInfo)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(boltRuntimeInfoBucket).Put([]byte(id), b)
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) DeleteRuntimeInfo(id string) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRuntimeInfoBucket).Delete([]byte(id))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) SetLastAccessed(id string, lastAccessed int64) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		return tx.Bucket(boltLastAccessedBucket).Put([]byte(id), []byte(strconv.FormatInt(lastAccessed, 10)))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) DeleteLastAccessed(id string) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		return tx.Bucket(boltLastAccessedBucket).Delete([]byte(id))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) SetFixity(id string, report domain.FixityReport) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(boltFixityBucket).Put([]byte(id), b)
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) DeleteFixity(id string) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		return tx.Bucket(boltFixityBucket).Delete([]byte(id))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) SetStartupLogs(id string, logs domain.StartupLogs) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		b, err := json.Marshal(logs)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket(boltStartupLogsBucket).Put([]byte(id), b)
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) DeleteStartupLogs(id string) *BoltStateTxn {
+	t.ops = append(t.ops, func(tx *bolt.Tx) error {
+		return tx.Bucket(boltStartupLogsBucket).Delete([]byte(id))
+	})
+
+	return t
+}
+
+func (t *BoltStateTxn) Commit() error {
+	// create new trace span for event service
+	_, span := t.state.Provider.
+		Tracer("bolt persistence").
+		Start(t.ctx, "Commit", trace.WithAttributes(attribute.Int("ops", len(t.ops))))
+	defer span.End()
+
+	span.AddEvent("committing transaction")
+
+	// bolt rolls back the whole transaction if any op returns an error
+	revision := int64(0)
+	err := t.state.DB.Update(func(tx *bolt.Tx) error {
+		for _, op := range t.ops {
+			err := op(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		revision = int64(tx.ID())
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	t.state.Revision.Advance(revision)
+
+	span.AddEvent("transaction committed")
+
+	return nil
+}
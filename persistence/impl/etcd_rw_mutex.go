@@ -0,0 +1,152 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	etcd "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"strconv"
+	"time"
+)
+
+// etcdUnlockTimeout bounds releasing a lock, the key expires with the session otherwise
+const etcdUnlockTimeout = 5 * time.Second
+
+// EtcdRwMutex is a distributed util.RwErrMutex. Readers and writers queue up with keys
+// below {Prefix}read/ and {Prefix}write/ that are ordered by their creation revision.
+// The keys are bound to the session lease, so locks of crashed instances are released
+// once the lease expires. It follows the etcd RWMutex recipe, but gives up waiting once
+// the context is done and removes its key again so it does not block others.
+type EtcdRwMutex struct {
+	Session *concurrency.Session
+	Prefix  string
+
+	key      string
+	revision int64
+}
+
+func (m *EtcdRwMutex) RLock(ctx context.Context) error {
+	// readers only wait for writers queued before them
+	return m.lock(ctx, m.Prefix+"read", m.Prefix+"write")
+}
+
+func (m *EtcdRwMutex) Lock(ctx context.Context) error {
+	// writers wait for everybody queued before them
+	return m.lock(ctx, m.Prefix+"write", m.Prefix)
+}
+
+func (m *EtcdRwMutex) RUnlock() error {
+	return m.unlock()
+}
+
+func (m *EtcdRwMutex) Unlock() error {
+	return m.unlock()
+}
+
+func (m *EtcdRwMutex) lock(ctx context.Context, keyPrefix string, waitPrefix string) error {
+	err := m.createKey(ctx, keyPrefix)
+	if err != nil {
+		return err
+	}
+
+	for {
+		done, err := m.waitOnLastRev(ctx, waitPrefix)
+		if err != nil {
+			// do not leave the key behind, it would block everyone queued after it until the session expires
+			if e := m.unlock(); e != nil {
+				err = errors.Join(err, e)
+			}
+			return err
+		}
+
+		if done {
+			return nil
+		}
+	}
+}
+
+// createKey creates a unique key below prefix that is bound to the session lease
+func (m *EtcdRwMutex) createKey(ctx context.Context, prefix string) error {
+	client := m.Session.Client()
+
+	for {
+		key := prefix + "/" + strconv.FormatInt(time.Now().UnixNano(), 10)
+
+		res, err := client.Txn(ctx).
+			If(etcd.Compare(etcd.Version(key), "=", 0)).
+			Then(etcd.OpPut(key, "", etcd.WithLease(m.Session.Lease()))).
+			Commit()
+		if err != nil {
+			return err
+		}
+
+		if res.Succeeded {
+			m.key = key
+			m.revision = res.Header.Revision
+			return nil
+		}
+	}
+}
+
+// waitOnLastRev waits until the last key below prefix that was created before the own key is deleted.
+// It returns true if there is no such key left.
+func (m *EtcdRwMutex) waitOnLastRev(ctx context.Context, prefix string) (bool, error) {
+	client := m.Session.Client()
+
+	res, err := client.Get(ctx, prefix, append(etcd.WithLastRev(), etcd.WithMaxModRev(m.revision-1))...)
+	if err != nil {
+		return false, err
+	}
+
+	if len(res.Kvs) == 0 {
+		return true, nil
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// the blocking key existed when the own key was created, so its deletion is seen when watching from there
+	w := client.Watch(etcd.WithRequireLeader(watchCtx), string(res.Kvs[0].Key), etcd.WithRev(m.revision))
+	for resp := range w {
+		// the deletion might have been compacted away, look again which key is blocking
+		if resp.CompactRevision != 0 {
+			return false, nil
+		}
+
+		if err := resp.Err(); err != nil {
+			return false, err
+		}
+
+		for _, event := range resp.Events {
+			if event.Type == etcd.EventTypeDelete {
+				return false, nil
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	return false, errors.New("watch on lock " + string(res.Kvs[0].Key) + " closed")
+}
+
+func (m *EtcdRwMutex) unlock() error {
+	if m.key == "" {
+		return errors.New("lock " + m.Prefix + " is not held")
+	}
+
+	// the lock is released even if the context that acquired it is done
+	ctx, cancel := context.WithTimeout(m.Session.Client().Ctx(), etcdUnlockTimeout)
+	defer cancel()
+
+	_, err := m.Session.Client().Delete(ctx, m.key)
+	if err != nil {
+		return err
+	}
+
+	m.key = ""
+	m.revision = 0
+
+	return nil
+}
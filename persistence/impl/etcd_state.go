@@ -4,7 +4,6 @@ import (
 	"context"
 	etcd "go.etcd.io/etcd/client/v3"
 	"go.etcd.io/etcd/client/v3/concurrency"
-	recipe "go.etcd.io/etcd/client/v3/experimental/recipes"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
@@ -12,8 +11,13 @@ import (
 	"museum/domain"
 	"museum/util"
 	"sync"
+	"time"
 )
 
+// etcdWriteVisibleTimeout is how long a write waits for the cache to catch up
+// with it before it is returned without read-your-writes consistency
+const etcdWriteVisibleTimeout = 5 * time.Second
+
 type EtcdState struct {
 	Client   *etcd.Client
 	Config   config.Config
@@ -21,11 +25,16 @@ type EtcdState struct {
 	Session  *concurrency.Session
 	Log      *zap.SugaredLogger
 
-	ExhibitCache   map[string]domain.Exhibit
-	ExhibitCacheMu *sync.RWMutex
+	// the caches are only written by the prefix watch, CacheRevision is the
+	// etcd revision up to which all events have been applied to them
+	ExhibitCache      map[string]domain.Exhibit
+	RuntimeInfoCache  map[string]domain.ExhibitRuntimeInfo
+	LastAccessedCache map[string]int64
+	FixityCache       map[string]domain.FixityReport
+	CacheMu           *sync.RWMutex
+	CacheRevision     *RevisionWaiter
 
-	RuntimeInfoCache   map[string]domain.ExhibitRuntimeInfo
-	RuntimeInfoCacheMu *sync.RWMutex
+	cancelWatch context.CancelFunc
 }
 
 func (e *EtcdState) Init() {
@@ -39,47 +48,57 @@ func (e *EtcdState) Init() {
 	e.Log.Debugw("etcd session created")
 	e.Session = session
 
-	e.Log.Infow("retrieving all exhibits")
-	exhibits := e.GetAllExhibits(context.Background())
-	e.Log.Debugw("retrieved all exhibits")
-
-	e.ExhibitCache = make(map[string]domain.Exhibit)
-	e.ExhibitCacheMu = &sync.RWMutex{}
+	e.CacheMu = &sync.RWMutex{}
+	e.CacheRevision = NewRevisionWaiter()
 
-	for _, exhibit := range exhibits {
-		e.ExhibitCache[exhibit.Id] = exhibit
-		e.watchExhibit(exhibit.Id)
+	e.Log.Infow("loading exhibit cache")
+	err = e.loadCache(context.Background())
+	if err != nil {
+		e.Log.Fatalw("error loading exhibit cache", "error", err)
 	}
+	e.Log.Debugw("loaded exhibit cache", "revision", e.CacheRevision.Get())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancelWatch = cancel
+	go e.watchCache(ctx)
+}
 
-	e.Log.Infow("retrieving all exhibit runtime info")
-	runtimeInfo := make(map[string]domain.ExhibitRuntimeInfo)
-	for _, exhibit := range exhibits {
-		info, err := e.GetRuntimeInfo(context.Background(), exhibit.Id)
-		if err != nil {
-			e.Log.Errorw("error retrieving exhibit runtime info", "error", err)
-			continue
-		}
-		runtimeInfo[exhibit.Id] = info
+// Close stops the cache watch and revokes the session, locks held by this instance are released
+func (e *EtcdState) Close() error {
+	if e.cancelWatch != nil {
+		e.cancelWatch()
 	}
-	e.Log.Debugw("retrieved all exhibit runtime info")
 
-	e.RuntimeInfoCache = runtimeInfo
-	e.RuntimeInfoCacheMu = &sync.RWMutex{}
+	return e.Session.Close()
+}
 
-	for _, exhibit := range exhibits {
-		e.watchRuntimeInfo(exhibit.Id)
-	}
+func (e *EtcdState) GetRevision() int64 {
+	return e.CacheRevision.Get()
+}
 
-	createChan := e.Client.Watch(context.Background(), "/"+e.Config.GetEtcdBaseKey()+"/")
-	go func(w etcd.WatchChan) {
-		for {
-			e.handleCreateEvent(w)
-		}
-	}(createChan)
+func (e *EtcdState) WaitForRevision(ctx context.Context, revision int64) error {
+	// create new trace span for event service
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "WaitForRevision", trace.WithAttributes(attribute.Int64("revision", revision)))
+	defer span.End()
+
+	return e.CacheRevision.Wait(subCtx, revision)
+}
+
+// waitForWrite makes a write of this instance visible to its own reads
+func (e *EtcdState) waitForWrite(ctx context.Context, revision int64) {
+	ctx, cancel := context.WithTimeout(ctx, etcdWriteVisibleTimeout)
+	defer cancel()
+
+	err := e.CacheRevision.Wait(ctx, revision)
+	if err != nil {
+		e.Log.Warnw("cache did not catch up with write", "revision", revision, "cacheRevision", e.CacheRevision.Get(), "error", err)
+	}
 }
 
 func (e *EtcdState) GetRwLock(ctx context.Context, id string, lockName string) util.RwErrMutex {
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "locks" + "/" + lockName
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "locks" + "/" + lockName + "/"
 
 	// create new trace span for event service
 	_, span := e.Provider.
@@ -90,7 +109,7 @@ func (e *EtcdState) GetRwLock(ctx context.Context, id string, lockName string) u
 	span.AddEvent("retrieving lock")
 
 	// create lock
-	lock := recipe.NewRWMutex(e.Session, key)
+	lock := &EtcdRwMutex{Session: e.Session, Prefix: key}
 
 	span.AddEvent("lock retrieved")
 
@@ -5,143 +5,239 @@ import (
 	"encoding/json"
 	etcd "go.etcd.io/etcd/client/v3"
 	"museum/domain"
+	"strconv"
 	"strings"
+	"time"
 )
 
-func (e *EtcdState) handleExhibitEvent(exhibitId string, w etcd.WatchChan) (deleted bool) {
-	events := <-w
+// etcdCaches are the maps that are kept up to date by the prefix watch
+type etcdCaches struct {
+	exhibits     map[string]domain.Exhibit
+	runtimeInfo  map[string]domain.ExhibitRuntimeInfo
+	lastAccessed map[string]int64
+	fixity       map[string]domain.FixityReport
+}
 
-	e.ExhibitCacheMu.Lock()
-	defer e.ExhibitCacheMu.Unlock()
+// etcdKey is a parsed key below the base key
+type etcdKey struct {
+	exhibitId string
+	kind      string
+}
 
-	for _, event := range events.Events {
-		e.Log.Infow("received meta event for exhibit", "exhibitId", exhibitId, "event", event.Type.String())
+// parseKey parses keys of the form /{base}/{exhibitId}/{kind}.
+// Keys of the name index, secrets and locks are not cached and return false.
+func (e *EtcdState) parseKey(key string) (etcdKey, bool) {
+	rest, ok := strings.CutPrefix(key, "/"+e.Config.GetEtcdBaseKey()+"/")
+	if !ok {
+		return etcdKey{}, false
+	}
 
-		if event.Type == etcd.EventTypeDelete {
-			e.Log.Debugw("exhibit deleted", "exhibitId", exhibitId)
-			delete(e.ExhibitCache, exhibitId)
-			return true
-		}
+	parts := strings.Split(rest, "/")
+	if len(parts) != 2 || parts[0] == "names" || parts[0] == "secrets" {
+		return etcdKey{}, false
+	}
 
-		updatedExhibit := domain.Exhibit{}
-		err := json.Unmarshal(event.Kv.Value, &updatedExhibit)
-		if err != nil {
-			e.Log.Errorw("error unmarshalling exhibit", "error", err)
+	return etcdKey{exhibitId: parts[0], kind: parts[1]}, true
+}
+
+// loadCache replaces the caches with a consistent snapshot of all exhibits and runtime info
+func (e *EtcdState) loadCache(ctx context.Context) error {
+	resp, err := e.Client.Get(ctx, "/"+e.Config.GetEtcdBaseKey()+"/", etcd.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	caches := etcdCaches{
+		exhibits:     make(map[string]domain.Exhibit),
+		runtimeInfo:  make(map[string]domain.ExhibitRuntimeInfo),
+		lastAccessed: make(map[string]int64),
+		fixity:       make(map[string]domain.FixityReport),
+	}
+
+	for _, kv := range resp.Kvs {
+		key, ok := e.parseKey(string(kv.Key))
+		if !ok {
 			continue
 		}
 
-		e.Log.Debugw("exhibit updated", "exhibitId", exhibitId)
-		e.ExhibitCache[exhibitId] = updatedExhibit
+		e.applyPut(key, kv.Value, caches)
 	}
 
-	return false
-}
+	e.CacheMu.Lock()
+	e.ExhibitCache = caches.exhibits
+	e.RuntimeInfoCache = caches.runtimeInfo
+	e.LastAccessedCache = caches.lastAccessed
+	e.FixityCache = caches.fixity
+	e.CacheMu.Unlock()
 
-func (e *EtcdState) handleRuntimeInfoEvent(w etcd.WatchChan) bool {
-	events := <-w
+	e.CacheRevision.Advance(resp.Header.Revision)
 
-	e.RuntimeInfoCacheMu.Lock()
-	defer e.RuntimeInfoCacheMu.Unlock()
+	return nil
+}
 
-	for _, event := range events.Events {
-		exhibitId := strings.Split(string(event.Kv.Key), "/")[1]
+// watchCache keeps the caches up to date with a single prefix watch until ctx is done.
+// If the watch is compacted or breaks, the caches are reloaded and the watch is restarted.
+func (e *EtcdState) watchCache(ctx context.Context) {
+	for ctx.Err() == nil {
+		e.watchCacheOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
 
-		e.Log.Infow("received runtime_info event for exhibit", "exhibitId", exhibitId, "event", event.Type.String())
-		if event.Type == etcd.EventTypeDelete {
-			e.Log.Debugw("runtime_info deleted", "exhibitId", exhibitId)
-			delete(e.RuntimeInfoCache, exhibitId)
-			return true
+		// back off a bit, the watch might have been closed because etcd is unreachable
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
 		}
 
-		updatedRuntimeInfo := domain.ExhibitRuntimeInfo{}
-		err := json.Unmarshal(event.Kv.Value, &updatedRuntimeInfo)
+		e.Log.Infow("resyncing exhibit cache", "revision", e.CacheRevision.Get())
+		err := e.loadCache(ctx)
 		if err != nil {
-			e.Log.Errorw("error unmarshalling exhibit runtime info", "error", err)
+			e.Log.Warnw("error resyncing exhibit cache", "error", err)
 			continue
 		}
+		e.Log.Debugw("resynced exhibit cache", "revision", e.CacheRevision.Get())
+	}
+}
+
+func (e *EtcdState) watchCacheOnce(ctx context.Context) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-		e.Log.Debugw("runtime_info updated", "exhibitId", exhibitId)
-		e.RuntimeInfoCache[exhibitId] = updatedRuntimeInfo
+	w := e.Client.Watch(etcd.WithRequireLeader(watchCtx), "/"+e.Config.GetEtcdBaseKey()+"/",
+		etcd.WithPrefix(),
+		etcd.WithRev(e.CacheRevision.Get()+1),
+		etcd.WithProgressNotify(),
+	)
+
+	for resp := range w {
+		if resp.CompactRevision != 0 {
+			e.Log.Warnw("exhibit cache watch compacted", "compactRevision", resp.CompactRevision, "revision", e.CacheRevision.Get())
+			return
+		}
+
+		if err := resp.Err(); err != nil {
+			e.Log.Warnw("exhibit cache watch failed", "error", err)
+			return
+		}
+
+		e.applyEvents(resp.Events)
+		e.CacheRevision.Advance(resp.Header.Revision)
 	}
 
-	return false
+	if ctx.Err() == nil {
+		e.Log.Warnw("exhibit cache watch closed", "revision", e.CacheRevision.Get())
+	}
 }
 
-func (e *EtcdState) handleCreateEvent(w etcd.WatchChan) {
-	events := <-w
+func (e *EtcdState) applyEvents(events []*etcd.Event) {
+	e.CacheMu.Lock()
+	defer e.CacheMu.Unlock()
 
-	for _, event := range events.Events {
-		if !event.IsCreate() {
+	caches := etcdCaches{
+		exhibits:     e.ExhibitCache,
+		runtimeInfo:  e.RuntimeInfoCache,
+		lastAccessed: e.LastAccessedCache,
+		fixity:       e.FixityCache,
+	}
+
+	for _, event := range events {
+		key, ok := e.parseKey(string(event.Kv.Key))
+		if !ok {
 			continue
 		}
 
-		if strings.HasSuffix(string(event.Kv.Key), "meta") {
-			e.handleNewExhibit(event)
+		if event.Type == etcd.EventTypeDelete {
+			e.applyDelete(key, caches)
+			continue
 		}
 
-		if strings.HasSuffix(string(event.Kv.Key), "runtime_info") {
-			e.handleNewRuntimeInfo(event)
-		}
+		e.applyPut(key, event.Kv.Value, caches)
 	}
 }
 
-func (e *EtcdState) handleNewRuntimeInfo(event *etcd.Event) {
-	e.RuntimeInfoCacheMu.Lock()
-	defer e.RuntimeInfoCacheMu.Unlock()
+func (e *EtcdState) applyPut(key etcdKey, value []byte, caches etcdCaches) {
+	switch key.kind {
+	case "meta":
+		exhibit, _, err := unmarshalExhibit(value)
+		if err != nil {
+			e.Log.Errorw("error unmarshalling exhibit", "error", err, "exhibitId", key.exhibitId)
+			return
+		}
 
-	newRuntimeInfo := domain.ExhibitRuntimeInfo{}
-	err := json.Unmarshal(event.Kv.Value, &newRuntimeInfo)
-	if err != nil {
-		e.Log.Errorw("error unmarshalling exhibit runtime info", "error", err)
-		return
-	}
+		e.Log.Debugw("exhibit cached", "exhibitId", key.exhibitId)
+		caches.exhibits[key.exhibitId] = exhibit
+	case "runtime_info":
+		info := domain.ExhibitRuntimeInfo{}
+		err := json.Unmarshal(value, &info)
+		if err != nil {
+			e.Log.Errorw("error unmarshalling exhibit runtime info", "error", err, "exhibitId", key.exhibitId)
+			return
+		}
+
+		e.Log.Debugw("runtime_info cached", "exhibitId", key.exhibitId)
+		caches.runtimeInfo[key.exhibitId] = info
+	case "last_accessed":
+		lastAccessed, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			e.Log.Errorw("error parsing exhibit last_accessed", "error", err, "exhibitId", key.exhibitId)
+			return
+		}
 
-	exhibitId := strings.Split(string(event.Kv.Key), "/")[1]
+		caches.lastAccessed[key.exhibitId] = lastAccessed
+	case "fixity":
+		report := domain.FixityReport{}
+		err := json.Unmarshal(value, &report)
+		if err != nil {
+			e.Log.Errorw("error unmarshalling exhibit fixity", "error", err, "exhibitId", key.exhibitId)
+			return
+		}
 
-	e.Log.Debugw("new exhibit runtime info created", "exhibitId", exhibitId)
-	e.RuntimeInfoCache[exhibitId] = newRuntimeInfo
-	e.watchRuntimeInfo(exhibitId)
-	return
+		caches.fixity[key.exhibitId] = report
+	}
+}
+
+func (e *EtcdState) applyDelete(key etcdKey, caches etcdCaches) {
+	switch key.kind {
+	case "meta":
+		e.Log.Debugw("exhibit deleted", "exhibitId", key.exhibitId)
+		delete(caches.exhibits, key.exhibitId)
+	case "runtime_info":
+		e.Log.Debugw("runtime_info deleted", "exhibitId", key.exhibitId)
+		delete(caches.runtimeInfo, key.exhibitId)
+	case "last_accessed":
+		delete(caches.lastAccessed, key.exhibitId)
+	case "fixity":
+		delete(caches.fixity, key.exhibitId)
+	}
 }
 
-func (e *EtcdState) handleNewExhibit(event *etcd.Event) {
-	e.ExhibitCacheMu.Lock()
-	defer e.ExhibitCacheMu.Unlock()
+// etcdSyncInterval is how often Sync asks the watch again for its progress
+const etcdSyncInterval = 100 * time.Millisecond
 
-	newExhibit := domain.Exhibit{}
-	err := json.Unmarshal(event.Kv.Value, &newExhibit)
+// Sync waits until the cache holds every write that was committed before it was called. The watch only sees
+// writes below the base key, a progress notification moves it to the current revision of the whole store.
+func (e *EtcdState) Sync(ctx context.Context) error {
+	res, err := e.Client.Get(ctx, "/"+e.Config.GetEtcdBaseKey(), etcd.WithCountOnly())
 	if err != nil {
-		e.Log.Errorw("error unmarshalling exhibit", "error", err)
-		return
+		return err
 	}
 
-	e.Log.Debugw("new exhibit created", "exhibitId", newExhibit.Id)
-	e.ExhibitCache[newExhibit.Id] = newExhibit
-	e.watchRuntimeInfo(newExhibit.Id)
-	e.watchExhibit(newExhibit.Id)
-	return
-}
-
-func (e *EtcdState) watchExhibit(exhibitId string) {
-	w := e.Client.Watch(context.Background(), "/"+e.Config.GetEtcdBaseKey()+"/"+exhibitId+"/meta", etcd.WithPrefix())
-	go func(exhibitId string, w etcd.WatchChan) {
-		for {
-			deleted := e.handleExhibitEvent(exhibitId, w)
-			if deleted {
-				return
-			}
+	for e.CacheRevision.Get() < res.Header.Revision {
+		// the request is ignored while the watch catches up, so it is repeated
+		err = e.Client.RequestProgress(etcd.WithRequireLeader(ctx))
+		if err != nil {
+			return err
 		}
-	}(exhibitId, w)
-}
 
-func (e *EtcdState) watchRuntimeInfo(exhibitId string) {
-	w := e.Client.Watch(context.Background(), "/"+e.Config.GetEtcdBaseKey()+"/"+exhibitId+"/"+"runtime_info")
-	go func(exhibitId string, w etcd.WatchChan) {
-		for {
-			deleted := e.handleRuntimeInfoEvent(w)
-			if deleted {
-				return
-			}
+		waitCtx, cancel := context.WithTimeout(ctx, etcdSyncInterval)
+		err = e.CacheRevision.Wait(waitCtx, res.Header.Revision)
+		cancel()
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err()
 		}
-	}(exhibitId, w)
+	}
+
+	return nil
 }
@@ -0,0 +1,85 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	etcd "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	configImpl "museum/config/impl"
+	"museum/domain"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEtcdStateParseKey(t *testing.T) {
+	e := &EtcdState{Config: &configImpl.EnvConfig{EtcdBaseKey: "museum"}}
+
+	key, ok := e.parseKey("/museum/0b5fb5f2/runtime_info")
+	assert.True(t, ok)
+	assert.Equal(t, etcdKey{exhibitId: "0b5fb5f2", kind: "runtime_info"}, key)
+
+	for _, k := range []string{"/museum/names/my-exhibit", "/museum/0b5fb5f2/locks/exhibit", "/other/0b5fb5f2/meta", "/museum/0b5fb5f2"} {
+		_, ok := e.parseKey(k)
+		assert.False(t, ok, k)
+	}
+}
+
+func TestEtcdStateResyncAfterCompaction(t *testing.T) {
+	host := os.Getenv("MUSEUM_TEST_ETCD_HOST")
+	if host == "" {
+		t.Skip("MUSEUM_TEST_ETCD_HOST not set, skipping etcd backend")
+	}
+
+	client, err := etcd.New(etcd.Config{Endpoints: []string{host}, DialTimeout: 5 * time.Second})
+	assert.NoError(t, err)
+
+	cfg := &configImpl.EnvConfig{EtcdBaseKey: "museum-test-" + uuid.New().String()}
+	prefix := "/" + cfg.EtcdBaseKey + "/"
+	t.Cleanup(func() {
+		_, _ = client.Delete(context.Background(), prefix, etcd.WithPrefix())
+		_ = client.Close()
+	})
+
+	e := &EtcdState{
+		Client:        client,
+		Config:        cfg,
+		Provider:      noop.NewTracerProvider(),
+		Log:           zap.NewNop().Sugar(),
+		CacheMu:       &sync.RWMutex{},
+		CacheRevision: NewRevisionWaiter(),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.NoError(t, e.loadCache(ctx))
+
+	// write while nobody watches and compact the history the watch would resume from
+	exhibit := domain.Exhibit{Id: uuid.New().String(), Name: "compacted"}
+	b, _ := json.Marshal(exhibit)
+	_, err = client.Put(ctx, prefix+exhibit.Id+"/meta", string(b))
+	assert.NoError(t, err)
+	res, err := client.Put(ctx, prefix+exhibit.Id+"/last_accessed", "0")
+	assert.NoError(t, err)
+	_, err = client.Compact(ctx, res.Header.Revision)
+	assert.NoError(t, err)
+
+	go e.watchCache(ctx)
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer waitCancel()
+	assert.NoError(t, e.CacheRevision.Wait(waitCtx, res.Header.Revision))
+
+	got, err := e.GetExhibitById(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "compacted", got.Name)
+
+	e.CacheMu.RLock()
+	assert.Contains(t, e.ExhibitCache, exhibit.Id)
+	e.CacheMu.RUnlock()
+}
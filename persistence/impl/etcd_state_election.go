@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"context"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+	"time"
+)
+
+// etcdResignTimeout bounds giving up the leadership, the key expires with the session otherwise
+const etcdResignTimeout = 5 * time.Second
+
+// Campaign runs the election with a session of its own. A leader whose session expired, e.g. during a partition,
+// stops leading and can campaign again with a new one, while the shared session of the locks would stay dead.
+func (e *EtcdState) Campaign(ctx context.Context, election string) (context.Context, error) {
+	key := "/" + e.Config.GetEtcdBaseKey() + "/elections/" + election + "/"
+
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "Campaign", trace.WithAttributes(attribute.String("key", key)))
+	defer span.End()
+
+	session, err := concurrency.NewSession(e.Client)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	leader := concurrency.NewElection(session, key)
+	err = leader.Campaign(subCtx, strconv.FormatInt(int64(session.Lease()), 16))
+	if err != nil {
+		span.RecordError(err)
+		_ = session.Close()
+		return nil, err
+	}
+	span.AddEvent("elected")
+
+	leadCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		defer func() {
+			resignCtx, cancelResign := context.WithTimeout(context.Background(), etcdResignTimeout)
+			defer cancelResign()
+			_ = leader.Resign(resignCtx)
+			_ = session.Close()
+		}()
+
+		// the leadership ends with the session, or when another instance is the leader after all
+		observed := leader.Observe(leadCtx)
+		for {
+			select {
+			case <-leadCtx.Done():
+				return
+			case <-session.Done():
+				e.Log.Warnw("etcd session of the election ended", "election", election)
+				return
+			case res, ok := <-observed:
+				if !ok {
+					return
+				}
+				if len(res.Kvs) == 0 || string(res.Kvs[0].Key) != leader.Key() {
+					e.Log.Warnw("another instance leads the election", "election", election)
+					return
+				}
+			}
+		}
+	}()
+
+	return leadCtx, nil
+}
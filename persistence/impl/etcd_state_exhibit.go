@@ -2,7 +2,6 @@ package impl
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	etcd "go.etcd.io/etcd/client/v3"
 	"go.opentelemetry.io/otel/attribute"
@@ -12,96 +11,79 @@ import (
 )
 
 func (e *EtcdState) CreateExhibit(ctx context.Context, app domain.Exhibit) error {
-	e.ExhibitCacheMu.Lock()
-	defer e.ExhibitCacheMu.Unlock()
+	return e.Txn(ctx).CreateExhibit(app).Commit()
+}
 
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + app.Id + "/" + "meta"
+func (e *EtcdState) GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error) {
+	if e.ExhibitCache != nil {
+		e.CacheMu.RLock()
+		exhibit, ok := e.ExhibitCache[id]
+		e.CacheMu.RUnlock()
+
+		if ok {
+			return exhibit, nil
+		}
+	}
+
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "meta"
 
 	// create new trace span for event service
 	subCtx, span := e.Provider.
 		Tracer("etcd persistence").
-		Start(ctx, "CreateExhibit", trace.WithAttributes(attribute.String("key", key), attribute.String("id", app.Id)))
+		Start(ctx, "GetExhibitById", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
 	defer span.End()
 
-	span.AddEvent("checking if exhibit already exists")
+	span.AddEvent("searching for exhibit")
 
-	// check if app already exists, if so, return error
-	res, err := e.Client.Get(subCtx, key)
+	resp, err := e.Client.Get(subCtx, key)
 	if err != nil {
-		return err
-	}
-
-	if res.Count > 0 {
-		return errors.New("exhibit with id " + app.Id + " already exists")
+		return domain.Exhibit{}, err
 	}
 
-	b, err := json.Marshal(app)
-	if err != nil {
-		return err
+	if resp.Count == 0 {
+		return domain.Exhibit{}, domain.ExhibitNotFoundError{By: "id", Value: id}
 	}
 
-	_, err = e.Client.Put(subCtx, key, string(b))
+	span.AddEvent("found exhibit")
+	exhibit, _, err := unmarshalExhibit(resp.Kvs[0].Value)
 	if err != nil {
-		return err
+		return domain.Exhibit{}, errors.New("error reading exhibit with id " + id + ": " + err.Error())
 	}
 
-	span.AddEvent("added exhibit to etcd")
-
-	if e.ExhibitCache != nil {
-		e.ExhibitCache[app.Id] = app
-	}
-
-	e.watchExhibit(app.Id)
-	e.watchRuntimeInfo(app.Id)
-
-	return nil
+	return exhibit, nil
 }
 
-func (e *EtcdState) GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error) {
-	if e.ExhibitCache != nil {
-		e.ExhibitCacheMu.RLock()
-		defer e.ExhibitCacheMu.RUnlock()
-
-		if exhibit, ok := e.ExhibitCache[id]; ok {
-			return exhibit, nil
-		}
-	}
-
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "meta"
+func (e *EtcdState) GetExhibitIdByName(ctx context.Context, name string) (string, error) {
+	key := "/" + e.Config.GetEtcdBaseKey() + "/names/" + name
 
 	// create new trace span for event service
 	subCtx, span := e.Provider.
 		Tracer("etcd persistence").
-		Start(ctx, "GetExhibitById", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
+		Start(ctx, "GetExhibitIdByName", trace.WithAttributes(attribute.String("key", key), attribute.String("name", name)))
 	defer span.End()
 
-	span.AddEvent("searching for exhibit")
+	span.AddEvent("searching for exhibit name")
 
 	resp, err := e.Client.Get(subCtx, key)
 	if err != nil {
-		return domain.Exhibit{}, err
+		return "", err
 	}
 
 	if resp.Count == 0 {
-		return domain.Exhibit{}, errors.New("exhibit with id " + id + " not found")
+		return "", domain.ExhibitNotFoundError{By: "name", Value: name}
 	}
 
-	span.AddEvent("found exhibit")
-	exhibit := domain.Exhibit{}
-	err = json.Unmarshal(resp.Kvs[0].Value, &exhibit)
-	if err != nil {
-		return domain.Exhibit{}, err
-	}
+	span.AddEvent("found exhibit name")
 
-	return exhibit, nil
+	return string(resp.Kvs[0].Value), nil
 }
 
 func (e *EtcdState) GetAllExhibits(ctx context.Context) []domain.Exhibit {
 	exhibits := make([]domain.Exhibit, 0)
 
 	if e.ExhibitCache != nil {
-		e.ExhibitCacheMu.RLock()
-		defer e.ExhibitCacheMu.RUnlock()
+		e.CacheMu.RLock()
+		defer e.CacheMu.RUnlock()
 
 		for _, exhibit := range e.ExhibitCache {
 			exhibits = append(exhibits, exhibit)
@@ -127,14 +109,14 @@ func (e *EtcdState) GetAllExhibits(ctx context.Context) []domain.Exhibit {
 
 	span.AddEvent("found exhibits")
 	for _, kv := range resp.Kvs {
-		//check that kv ends with /object
-		if !strings.HasSuffix(string(kv.Key), "/meta") {
+		//check that kv ends with /meta and is not part of the name index
+		if !strings.HasSuffix(string(kv.Key), "/meta") || strings.HasPrefix(string(kv.Key), searchKey+"names/") {
 			continue
 		}
 
-		exhibit := domain.Exhibit{}
-		err := json.Unmarshal(kv.Value, &exhibit)
+		exhibit, _, err := unmarshalExhibit(kv.Value)
 		if err != nil {
+			e.Log.Warnw("skipping exhibit that cannot be read", "error", err, "key", string(kv.Key))
 			continue
 		}
 		exhibits = append(exhibits, exhibit)
@@ -144,27 +126,71 @@ func (e *EtcdState) GetAllExhibits(ctx context.Context) []domain.Exhibit {
 }
 
 func (e *EtcdState) DeleteExhibitById(ctx context.Context, id string) error {
-	e.ExhibitCacheMu.Lock()
-	defer e.ExhibitCacheMu.Unlock()
+	return e.Txn(ctx).DeleteExhibitById(id).Commit()
+}
 
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "meta"
+func (e *EtcdState) MigrateExhibits(ctx context.Context) (domain.MigrationResult, error) {
+	searchKey := "/" + e.Config.GetEtcdBaseKey() + "/"
 
 	// create new trace span for event service
 	subCtx, span := e.Provider.
 		Tracer("etcd persistence").
-		Start(ctx, "DeleteExhibitById", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
+		Start(ctx, "MigrateExhibits", trace.WithAttributes(attribute.String("key", searchKey)))
 	defer span.End()
 
-	span.AddEvent("deleting exhibit")
-
-	_, err := e.Client.Delete(subCtx, key)
+	// the cache only holds upgraded exhibits, the stored versions have to be read from etcd
+	resp, err := e.Client.Get(subCtx, searchKey, etcd.WithPrefix())
 	if err != nil {
-		return err
+		span.RecordError(err)
+		return domain.MigrationResult{}, err
 	}
 
-	if e.ExhibitCache != nil {
-		delete(e.ExhibitCache, id)
+	result := newMigrationResult()
+	revision := int64(0)
+	for _, kv := range resp.Kvs {
+		key, ok := e.parseKey(string(kv.Key))
+		if !ok || key.kind != "meta" {
+			continue
+		}
+
+		exhibit, version, err := unmarshalExhibit(kv.Value)
+		if err != nil {
+			result.Failed[key.exhibitId] = err.Error()
+			continue
+		}
+
+		if version == ExhibitSchemaVersion {
+			result.UpToDate++
+			continue
+		}
+
+		b, err := marshalExhibit(exhibit)
+		if err != nil {
+			result.Failed[key.exhibitId] = err.Error()
+			continue
+		}
+
+		// an exhibit that was changed in the meantime was written with the current version already
+		res, err := e.Client.Txn(subCtx).
+			If(etcd.Compare(etcd.ModRevision(string(kv.Key)), "=", kv.ModRevision)).
+			Then(etcd.OpPut(string(kv.Key), string(b))).
+			Commit()
+		if err != nil {
+			result.Failed[key.exhibitId] = err.Error()
+			continue
+		}
+
+		if res.Succeeded {
+			result.Migrated = append(result.Migrated, exhibit.Name)
+			revision = res.Header.Revision
+		}
+	}
+
+	span.SetAttributes(attribute.Int("migrated", len(result.Migrated)), attribute.Int("failed", len(result.Failed)))
+
+	if revision > 0 {
+		e.waitForWrite(subCtx, revision)
 	}
 
-	return nil
+	return result, nil
 }
@@ -0,0 +1,56 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+)
+
+func (e *EtcdState) GetFixity(ctx context.Context, id string) (domain.FixityReport, error) {
+	if e.FixityCache != nil {
+		e.CacheMu.RLock()
+		report, ok := e.FixityCache[id]
+		e.CacheMu.RUnlock()
+
+		if ok {
+			return report, nil
+		}
+	}
+
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "fixity"
+
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "GetFixity", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
+	defer span.End()
+
+	resp, err := e.Client.Get(subCtx, key)
+	if err != nil {
+		return domain.FixityReport{}, err
+	}
+
+	if resp.Count == 0 {
+		return domain.FixityReport{}, errors.New("fixity for exhibit with id " + id + " not found")
+	}
+
+	span.AddEvent("found fixity for exhibit")
+
+	report := domain.FixityReport{}
+	err = json.Unmarshal(resp.Kvs[0].Value, &report)
+	if err != nil {
+		return domain.FixityReport{}, err
+	}
+
+	return report, nil
+}
+
+func (e *EtcdState) SetFixity(ctx context.Context, id string, report domain.FixityReport) error {
+	return e.Txn(ctx).SetFixity(id, report).Commit()
+}
+
+func (e *EtcdState) DeleteFixity(ctx context.Context, id string) error {
+	return e.Txn(ctx).DeleteFixity(id).Commit()
+}
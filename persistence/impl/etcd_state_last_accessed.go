@@ -2,12 +2,23 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"strconv"
 )
 
 func (e *EtcdState) GetLastAccessed(ctx context.Context, id string) (int64, error) {
+	if e.LastAccessedCache != nil {
+		e.CacheMu.RLock()
+		lastAccessed, ok := e.LastAccessedCache[id]
+		e.CacheMu.RUnlock()
+
+		if ok {
+			return lastAccessed, nil
+		}
+	}
+
 	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "last_accessed"
 
 	// create new trace span for event service
@@ -23,6 +34,10 @@ func (e *EtcdState) GetLastAccessed(ctx context.Context, id string) (int64, erro
 		return -1, err
 	}
 
+	if resp.Count == 0 {
+		return -1, errors.New("last_accessed for exhibit with id " + id + " not found")
+	}
+
 	span.AddEvent("found last_accessed time for exhibit")
 
 	i, err := strconv.ParseInt(string(resp.Kvs[0].Value), 10, 64)
@@ -34,39 +49,9 @@ func (e *EtcdState) GetLastAccessed(ctx context.Context, id string) (int64, erro
 }
 
 func (e *EtcdState) SetLastAccessed(ctx context.Context, id string, lastAccessed int64) error {
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "last_accessed"
-
-	// create new trace span for event service
-	subCtx, span := e.Provider.
-		Tracer("etcd persistence").
-		Start(ctx, "SetLastAccessed", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
-	defer span.End()
-
-	_, err := e.Client.Put(subCtx, key, strconv.FormatInt(lastAccessed, 10))
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("set last_accessed time for exhibit")
-
-	return nil
+	return e.Txn(ctx).SetLastAccessed(id, lastAccessed).Commit()
 }
 
 func (e *EtcdState) DeleteLastAccessed(ctx context.Context, id string) error {
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "last_accessed"
-
-	// create new trace span for event service
-	subCtx, span := e.Provider.
-		Tracer("etcd persistence").
-		Start(ctx, "DeleteLastAccessed", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
-	defer span.End()
-
-	_, err := e.Client.Delete(subCtx, key)
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("deleted last_accessed time for exhibit")
-
-	return nil
+	return e.Txn(ctx).DeleteLastAccessed(id).Commit()
 }
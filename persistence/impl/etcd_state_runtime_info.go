@@ -3,48 +3,23 @@ package impl
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"museum/domain"
 )
 
 func (e *EtcdState) SetRuntimeInfo(ctx context.Context, id string, runtimeInfo domain.ExhibitRuntimeInfo) error {
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "runtime_info"
-
-	// create new trace span for event service
-	subCtx, span := e.Provider.
-		Tracer("etcd persistence").
-		Start(ctx, "SetRuntimeInfo", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
-	defer span.End()
-
-	b, err := json.Marshal(runtimeInfo)
-	if err != nil {
-		return err
-	}
-
-	_, err = e.Client.Put(subCtx, key, string(b))
-	if err != nil {
-		return err
-	}
-
-	span.AddEvent("set runtime info for exhibit")
-
-	e.RuntimeInfoCacheMu.Lock()
-	defer e.RuntimeInfoCacheMu.Unlock()
-
-	if e.RuntimeInfoCache != nil {
-		e.RuntimeInfoCache[id] = runtimeInfo
-	}
-
-	return nil
+	return e.Txn(ctx).SetRuntimeInfo(id, runtimeInfo).Commit()
 }
 
 func (e *EtcdState) GetRuntimeInfo(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error) {
 	if e.RuntimeInfoCache != nil {
-		e.RuntimeInfoCacheMu.RLock()
-		defer e.RuntimeInfoCacheMu.RUnlock()
+		e.CacheMu.RLock()
+		runtimeInfo, ok := e.RuntimeInfoCache[id]
+		e.CacheMu.RUnlock()
 
-		if runtimeInfo, ok := e.RuntimeInfoCache[id]; ok {
+		if ok {
 			return runtimeInfo, nil
 		}
 	}
@@ -64,6 +39,10 @@ func (e *EtcdState) GetRuntimeInfo(ctx context.Context, id string) (domain.Exhib
 		return domain.ExhibitRuntimeInfo{}, err
 	}
 
+	if resp.Count == 0 {
+		return domain.ExhibitRuntimeInfo{}, errors.New("runtime_info for exhibit with id " + id + " not found")
+	}
+
 	span.AddEvent("found runtime info for exhibit")
 
 	runtimeInfo := domain.ExhibitRuntimeInfo{}
@@ -76,27 +55,5 @@ func (e *EtcdState) GetRuntimeInfo(ctx context.Context, id string) (domain.Exhib
 }
 
 func (e *EtcdState) DeleteRuntimeInfo(ctx context.Context, id string) error {
-	e.RuntimeInfoCacheMu.Lock()
-	defer e.RuntimeInfoCacheMu.Unlock()
-
-	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "runtime_info"
-
-	// create new trace span for event service
-	subCtx, span := e.Provider.
-		Tracer("etcd persistence").
-		Start(ctx, "DeleteRuntimeInfo", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
-	defer span.End()
-
-	span.AddEvent("deleting runtime info for exhibit")
-
-	_, err := e.Client.Delete(subCtx, key)
-	if err != nil {
-		return err
-	}
-
-	if e.RuntimeInfoCache != nil {
-		delete(e.RuntimeInfoCache, id)
-	}
-
-	return nil
+	return e.Txn(ctx).DeleteRuntimeInfo(id).Commit()
 }
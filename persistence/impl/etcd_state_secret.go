@@ -0,0 +1,38 @@
+package impl
+
+import (
+	"context"
+	etcd "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetOrCreateSecret reads etcd directly, secrets are read once when museum starts
+func (e *EtcdState) GetOrCreateSecret(ctx context.Context, name string, secret []byte) ([]byte, error) {
+	key := "/" + e.Config.GetEtcdBaseKey() + "/secrets/" + name
+
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "GetOrCreateSecret", trace.WithAttributes(attribute.String("key", key)))
+	defer span.End()
+
+	// the secret of the instance that stored one first wins, the others read it
+	res, err := e.Client.Txn(subCtx).
+		If(etcd.Compare(etcd.CreateRevision(key), "=", 0)).
+		Then(etcd.OpPut(key, string(secret))).
+		Else(etcd.OpGet(key)).
+		Commit()
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	if res.Succeeded {
+		span.AddEvent("stored secret")
+		return secret, nil
+	}
+
+	span.AddEvent("found secret")
+
+	return res.Responses[0].GetResponseRange().Kvs[0].Value, nil
+}
@@ -0,0 +1,47 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+)
+
+// GetStartupLogs reads etcd directly, startup logs are rarely read and are not worth caching
+func (e *EtcdState) GetStartupLogs(ctx context.Context, id string) (domain.StartupLogs, error) {
+	key := "/" + e.Config.GetEtcdBaseKey() + "/" + id + "/" + "startup_logs"
+
+	subCtx, span := e.Provider.
+		Tracer("etcd persistence").
+		Start(ctx, "GetStartupLogs", trace.WithAttributes(attribute.String("key", key), attribute.String("id", id)))
+	defer span.End()
+
+	resp, err := e.Client.Get(subCtx, key)
+	if err != nil {
+		return domain.StartupLogs{}, err
+	}
+
+	if resp.Count == 0 {
+		return domain.StartupLogs{}, errors.New("startup logs for exhibit with id " + id + " not found")
+	}
+
+	span.AddEvent("found startup logs for exhibit")
+
+	logs := domain.StartupLogs{}
+	err = json.Unmarshal(resp.Kvs[0].Value, &logs)
+	if err != nil {
+		return domain.StartupLogs{}, err
+	}
+
+	return logs, nil
+}
+
+func (e *EtcdState) SetStartupLogs(ctx context.Context, id string, logs domain.StartupLogs) error {
+	return e.Txn(ctx).SetStartupLogs(id, logs).Commit()
+}
+
+func (e *EtcdState) DeleteStartupLogs(ctx context.Context, id string) error {
+	return e.Txn(ctx).DeleteStartupLogs(id).Commit()
+}
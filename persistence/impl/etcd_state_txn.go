@@ -0,0 +1,274 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	etcd "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"museum/domain"
+	"slices"
+	"strconv"
+)
+
+// txnGuard is a key that must still have the mod revision for the transaction to succeed,
+// a revision of 0 means that the key must not exist
+type txnGuard struct {
+	key      string
+	revision int64
+	err      error
+}
+
+type EtcdStateTxn struct {
+	state *EtcdState
+	ctx   context.Context
+
+	// steps add the guards and ops of each write in order, they run on Commit
+	// so that reads they need are not done while the transaction is built
+	steps  []func(ctx context.Context) error
+	guards []txnGuard
+	ops    []etcd.Op
+	// deleted are the keys that ops of this transaction delete
+	deleted map[string]bool
+}
+
+func (e *EtcdState) Txn(ctx context.Context) *EtcdStateTxn {
+	return &EtcdStateTxn{
+		state:   e,
+		ctx:     ctx,
+		steps:   make([]func(ctx context.Context) error, 0),
+		guards:  make([]txnGuard, 0),
+		ops:     make([]etcd.Op, 0),
+		deleted: make(map[string]bool),
+	}
+}
+
+// op adds an op to the transaction. etcd rejects transactions that write a key twice,
+// so an earlier op on the same key is dropped and the later one wins like in the other backends.
+func (t *EtcdStateTxn) op(op etcd.Op) {
+	key := string(op.KeyBytes())
+	t.ops = slices.DeleteFunc(t.ops, func(o etcd.Op) bool {
+		return string(o.KeyBytes()) == key
+	})
+	t.ops = append(t.ops, op)
+	t.deleted[key] = op.IsDelete()
+}
+
+func (t *EtcdStateTxn) CreateExhibit(exhibit domain.Exhibit) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + exhibit.Id + "/" + "meta"
+		nameKey := "/" + t.state.Config.GetEtcdBaseKey() + "/names/" + exhibit.Name
+
+		b, err := marshalExhibit(exhibit)
+		if err != nil {
+			return err
+		}
+
+		// an exhibit that is deleted by this transaction is replaced, its delete already guards the keys
+		if !t.deleted[key] {
+			t.guards = append(t.guards, txnGuard{key: key, err: errors.New("exhibit with id " + exhibit.Id + " already exists")})
+		}
+		if !t.deleted[nameKey] {
+			t.guards = append(t.guards, txnGuard{key: nameKey, err: errors.New("exhibit with name " + exhibit.Name + " already exists")})
+		}
+		t.op(etcd.OpPut(key, string(b)))
+		t.op(etcd.OpPut(nameKey, exhibit.Id))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) DeleteExhibitById(id string) *EtcdStateTxn {
+	t.steps = append(t.steps, func(ctx context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "meta"
+		t.op(etcd.OpDelete(key))
+
+		// the lock keys are left to their holders, the deleting caller still holds one and waiters queue behind it.
+		// Keys of crashed instances expire with their session.
+
+		// the name is needed to drop the name index entry. It is read from etcd instead of the cache
+		// and the guard fails the transaction if the exhibit changes between the read and the commit.
+		res, err := t.state.Client.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if len(res.Kvs) == 0 {
+			// an exhibit created in the meantime is not replaced by an exhibit created in this transaction
+			t.guards = append(t.guards, txnGuard{key: key, err: errors.New("exhibit with id " + id + " was created while it was deleted")})
+			return nil
+		}
+
+		exhibit := struct {
+			Name string `json:"name"`
+		}{}
+		err = json.Unmarshal(res.Kvs[0].Value, &exhibit)
+		if err != nil {
+			return errors.New("error reading name of exhibit with id " + id + ": " + err.Error())
+		}
+
+		t.guards = append(t.guards, txnGuard{key: key, revision: res.Kvs[0].ModRevision, err: errors.New("exhibit with id " + id + " was changed while it was deleted")})
+		t.op(etcd.OpDelete("/" + t.state.Config.GetEtcdBaseKey() + "/names/" + exhibit.Name))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) SetRuntimeInfo(id string, runtimeInfo domain.ExhibitRuntimeInfo) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "runtime_info"
+
+		b, err := json.Marshal(runtimeInfo)
+		if err != nil {
+			return err
+		}
+
+		t.op(etcd.OpPut(key, string(b)))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) DeleteRuntimeInfo(id string) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "runtime_info"
+		t.op(etcd.OpDelete(key))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) SetLastAccessed(id string, lastAccessed int64) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "last_accessed"
+		t.op(etcd.OpPut(key, strconv.FormatInt(lastAccessed, 10)))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) DeleteLastAccessed(id string) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "last_accessed"
+		t.op(etcd.OpDelete(key))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) SetFixity(id string, report domain.FixityReport) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "fixity"
+
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+
+		t.op(etcd.OpPut(key, string(b)))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) DeleteFixity(id string) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "fixity"
+		t.op(etcd.OpDelete(key))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) SetStartupLogs(id string, logs domain.StartupLogs) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "startup_logs"
+
+		b, err := json.Marshal(logs)
+		if err != nil {
+			return err
+		}
+
+		t.op(etcd.OpPut(key, string(b)))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) DeleteStartupLogs(id string) *EtcdStateTxn {
+	t.steps = append(t.steps, func(context.Context) error {
+		key := "/" + t.state.Config.GetEtcdBaseKey() + "/" + id + "/" + "startup_logs"
+		t.op(etcd.OpDelete(key))
+		return nil
+	})
+
+	return t
+}
+
+func (t *EtcdStateTxn) Commit() error {
+	// create new trace span for event service
+	subCtx, span := t.state.Provider.
+		Tracer("etcd persistence").
+		Start(t.ctx, "Commit", trace.WithAttributes(attribute.Int("steps", len(t.steps))))
+	defer span.End()
+
+	var err error
+	for _, step := range t.steps {
+		err = errors.Join(err, step(subCtx))
+	}
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	span.SetAttributes(attribute.Int("ops", len(t.ops)), attribute.Int("guards", len(t.guards)))
+
+	cmps := make([]etcd.Cmp, 0, len(t.guards))
+	orElse := make([]etcd.Op, 0, len(t.guards))
+	for _, g := range t.guards {
+		cmps = append(cmps, etcd.Compare(etcd.ModRevision(g.key), "=", g.revision))
+		orElse = append(orElse, etcd.OpGet(g.key, etcd.WithKeysOnly()))
+	}
+
+	span.AddEvent("committing transaction")
+
+	res, err := t.state.Client.Txn(subCtx).If(cmps...).Then(t.ops...).Else(orElse...).Commit()
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if !res.Succeeded {
+		span.AddEvent("transaction guard failed")
+
+		// find out which guard failed to return a meaningful error
+		for i, r := range res.Responses {
+			revision := int64(0)
+			if kvs := r.GetResponseRange().Kvs; len(kvs) > 0 {
+				revision = kvs[0].ModRevision
+			}
+			if revision != t.guards[i].revision {
+				return t.guards[i].err
+			}
+		}
+
+		return errors.New("transaction failed")
+	}
+
+	span.AddEvent("transaction committed")
+
+	// the cache is only updated by the watch, wait for it so that this instance reads its own writes
+	t.state.waitForWrite(subCtx, res.Header.Revision)
+
+	span.AddEvent("transaction visible in cache")
+
+	return nil
+}
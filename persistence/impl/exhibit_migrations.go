@@ -0,0 +1,93 @@
+package impl
+
+import (
+	"encoding/json"
+	"errors"
+	"museum/domain"
+	"strconv"
+)
+
+// ExhibitSchemaVersion is the version exhibits are stored with by this version of museum.
+// It has to be increased, and a migration has to be added, whenever the stored format of an exhibit changes.
+const ExhibitSchemaVersion = 1
+
+// exhibitMigrations upgrade stored exhibits one version at a time, the migration at index i upgrades version i to i+1.
+// They work on the decoded JSON instead of domain.Exhibit, which only describes the current version.
+var exhibitMigrations = []func(record map[string]interface{}) error{
+	// version 0 exhibits were stored without a schema version and without their spec, all of them were v1 exhibits
+	func(record map[string]interface{}) error {
+		if spec, ok := record["spec"].(string); !ok || spec == "" {
+			record["spec"] = domain.ExhibitSpecV1
+		}
+		return nil
+	},
+}
+
+// storedExhibit is the format exhibits are stored in
+type storedExhibit struct {
+	SchemaVersion int `json:"schemaVersion"`
+	domain.Exhibit
+}
+
+func marshalExhibit(exhibit domain.Exhibit) ([]byte, error) {
+	return json.Marshal(storedExhibit{SchemaVersion: ExhibitSchemaVersion, Exhibit: exhibit})
+}
+
+// unmarshalExhibit reads a stored exhibit and upgrades it to the current schema version if it is older,
+// the returned version is the one the exhibit is stored with
+func unmarshalExhibit(b []byte) (domain.Exhibit, int, error) {
+	version := struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}{}
+	err := json.Unmarshal(b, &version)
+	if err != nil {
+		return domain.Exhibit{}, 0, err
+	}
+
+	stored := version.SchemaVersion
+	if stored > ExhibitSchemaVersion {
+		return domain.Exhibit{}, stored, errors.New("exhibit is stored with schema version " + strconv.Itoa(stored) + ", but this version of museum only supports versions up to " + strconv.Itoa(ExhibitSchemaVersion) + ", museum has to be upgraded to read it")
+	}
+
+	if stored < ExhibitSchemaVersion {
+		b, err = migrateExhibit(b, stored)
+		if err != nil {
+			return domain.Exhibit{}, stored, err
+		}
+	}
+
+	exhibit := storedExhibit{}
+	err = json.Unmarshal(b, &exhibit)
+	if err != nil {
+		return domain.Exhibit{}, stored, err
+	}
+
+	return exhibit.Exhibit, stored, nil
+}
+
+func migrateExhibit(b []byte, from int) ([]byte, error) {
+	record := make(map[string]interface{})
+	err := json.Unmarshal(b, &record)
+	if err != nil {
+		return nil, err
+	}
+
+	for v := from; v < ExhibitSchemaVersion; v++ {
+		err = exhibitMigrations[v](record)
+		if err != nil {
+			return nil, errors.New("error migrating exhibit from schema version " + strconv.Itoa(v) + " to " + strconv.Itoa(v+1) + ": " + err.Error())
+		}
+	}
+
+	record["schemaVersion"] = ExhibitSchemaVersion
+	return json.Marshal(record)
+}
+
+// newMigrationResult returns an empty result, so that all of its lists are encoded as such
+func newMigrationResult() domain.MigrationResult {
+	return domain.MigrationResult{
+		SchemaVersion: ExhibitSchemaVersion,
+		Migrated:      make([]string, 0),
+		Failed:        make(map[string]string),
+	}
+}
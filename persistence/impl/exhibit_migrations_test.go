@@ -0,0 +1,119 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	configImpl "museum/config/impl"
+	"museum/domain"
+	"sync"
+	"testing"
+)
+
+// legacyExhibit is an exhibit as it was stored before exhibits had a schema version
+const legacyExhibit = `{"id":"6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a","name":"legacy","expose":"nginx","lease":"1h","objects":[{"name":"nginx","image":"nginx","label":"latest"}]}`
+
+func TestUnmarshalExhibitMigratesOlderVersions(t *testing.T) {
+	exhibit, version, err := unmarshalExhibit([]byte(legacyExhibit))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, version)
+	assert.Equal(t, domain.ExhibitSpecV1, exhibit.Spec)
+	assert.Equal(t, "legacy", exhibit.Name)
+	assert.Equal(t, "nginx", exhibit.Objects[0].Image)
+
+	// written exhibits carry the current version and are read as they are
+	b, err := marshalExhibit(exhibit)
+	assert.NoError(t, err)
+
+	stored := make(map[string]interface{})
+	assert.NoError(t, json.Unmarshal(b, &stored))
+	assert.Equal(t, float64(ExhibitSchemaVersion), stored["schemaVersion"])
+
+	read, version, err := unmarshalExhibit(b)
+	assert.NoError(t, err)
+	assert.Equal(t, ExhibitSchemaVersion, version)
+	assert.Equal(t, exhibit, read)
+}
+
+func TestUnmarshalExhibitRejectsNewerVersions(t *testing.T) {
+	_, version, err := unmarshalExhibit([]byte(`{"schemaVersion":99,"name":"future"}`))
+	assert.EqualError(t, err, "exhibit is stored with schema version 99, but this version of museum only supports versions up to 1, museum has to be upgraded to read it")
+	assert.Equal(t, 99, version)
+}
+
+func TestMemoryStateMigrateExhibits(t *testing.T) {
+	m := &MemoryState{
+		Exhibits: map[string][]byte{
+			"6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a": []byte(legacyExhibit),
+			"future":                               []byte(`{"schemaVersion":99,"name":"future"}`),
+		},
+		Names:        map[string]string{"legacy": "6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a", "future": "future"},
+		RuntimeInfo:  make(map[string][]byte),
+		LastAccessed: make(map[string]int64),
+		Fixity:       make(map[string][]byte),
+		StartupLogs:  make(map[string][]byte),
+		Revision:     NewRevisionWaiter(),
+		Mu:           &sync.RWMutex{},
+		Locks:        NewLocalLocks(),
+	}
+	ctx := context.Background()
+
+	// newer exhibits are not listed, but reading them directly explains why
+	assert.Len(t, m.GetAllExhibits(ctx), 1)
+	_, err := m.GetExhibitById(ctx, "future")
+	assert.ErrorContains(t, err, "schema version 99")
+
+	result, err := m.MigrateExhibits(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, result.Migrated)
+	assert.Equal(t, 0, result.UpToDate)
+	assert.Contains(t, result.Failed, "future")
+	assert.Equal(t, int64(1), m.GetRevision())
+	assert.Contains(t, string(m.Exhibits["6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a"]), `"schemaVersion":1`)
+
+	// a second migration has nothing left to do
+	result, err = m.MigrateExhibits(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Migrated)
+	assert.Equal(t, 1, result.UpToDate)
+	assert.Equal(t, int64(1), m.GetRevision())
+}
+
+func TestBoltStateMigrateExhibits(t *testing.T) {
+	b := &BoltState{
+		Config:   &configImpl.EnvConfig{BoltPath: t.TempDir() + "/museum.db"},
+		Provider: noop.NewTracerProvider(),
+		Log:      zap.NewNop().Sugar(),
+	}
+	b.Init()
+	t.Cleanup(func() {
+		_ = b.Close()
+	})
+	ctx := context.Background()
+
+	assert.NoError(t, b.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltExhibitsBucket).Put([]byte("6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a"), []byte(legacyExhibit))
+	}))
+
+	exhibit, err := b.GetExhibitById(ctx, "6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ExhibitSpecV1, exhibit.Spec)
+
+	result, err := b.MigrateExhibits(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, result.Migrated)
+	assert.Empty(t, result.Failed)
+
+	assert.NoError(t, b.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltExhibitsBucket).Get([]byte("6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a"))
+		assert.Contains(t, string(v), `"schemaVersion":1`)
+		return nil
+	}))
+
+	migrated, err := b.GetExhibitById(ctx, "6f1c2a4e-0d7b-4c55-9a0e-2f4b8c1d9e3a")
+	assert.NoError(t, err)
+	assert.Equal(t, exhibit, migrated)
+}
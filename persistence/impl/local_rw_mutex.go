@@ -0,0 +1,80 @@
+package impl
+
+import (
+	"context"
+	"golang.org/x/sync/semaphore"
+	"math"
+	"sync"
+)
+
+// localRwMutexWeight is the weight of a writer, readers acquire a weight of one
+const localRwMutexWeight = math.MaxInt32
+
+// LocalRwMutex is an in-process util.RwErrMutex used by single node state backends.
+// It is built on a weighted semaphore because sync.RWMutex cannot give up waiting,
+// waiters are served in order, so a waiting writer blocks new readers like with sync.RWMutex.
+type LocalRwMutex struct {
+	Sem *semaphore.Weighted
+}
+
+func NewLocalRwMutex() *LocalRwMutex {
+	return &LocalRwMutex{Sem: semaphore.NewWeighted(localRwMutexWeight)}
+}
+
+func (l *LocalRwMutex) RLock(ctx context.Context) error {
+	return l.Sem.Acquire(ctx, 1)
+}
+
+func (l *LocalRwMutex) RUnlock() error {
+	l.Sem.Release(1)
+	return nil
+}
+
+func (l *LocalRwMutex) Lock(ctx context.Context) error {
+	return l.Sem.Acquire(ctx, localRwMutexWeight)
+}
+
+func (l *LocalRwMutex) Unlock() error {
+	l.Sem.Release(localRwMutexWeight)
+	return nil
+}
+
+// LocalLocks holds the in-process locks of a single node state backend by key
+type LocalLocks struct {
+	mu    sync.Mutex
+	locks map[string]*LocalRwMutex
+}
+
+func NewLocalLocks() *LocalLocks {
+	return &LocalLocks{locks: make(map[string]*LocalRwMutex)}
+}
+
+func (l *LocalLocks) Get(key string) *LocalRwMutex {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[key]
+	if !ok {
+		lock = NewLocalRwMutex()
+		l.locks[key] = lock
+	}
+
+	return lock
+}
+
+// Campaign leads the election once no one else in this process does, a single node has no other instances
+// to lose the leadership to. It leads until ctx ends.
+func (l *LocalLocks) Campaign(ctx context.Context, election string) (context.Context, error) {
+	lock := l.Get("elections/" + election)
+	err := lock.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = lock.Unlock()
+	}()
+
+	return ctx, nil
+}
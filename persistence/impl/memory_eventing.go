@@ -0,0 +1,169 @@
+package impl
+
+import (
+	"context"
+	"museum/domain"
+	"sync"
+)
+
+// MemoryEventing delivers events to subscribers of the same process and records every
+// dispatched event, it is used to test services without nats.
+type MemoryEventing struct {
+	CreatedEvents  []domain.Exhibit
+	StartingEvents []domain.ExhibitStartingStepEvent
+	StoppingEvents []domain.ExhibitStoppingEvent
+	FixityEvents   []domain.ExhibitFixityEvent
+
+	StartingChannels map[string][]chan domain.ExhibitStartingStepEvent
+	StoppingChannels map[string][]chan domain.ExhibitStoppingEvent
+	Mu               *sync.Mutex
+}
+
+func (m *MemoryEventing) DispatchExhibitCreatedEvent(_ context.Context, exhibit domain.Exhibit) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.CreatedEvents = append(m.CreatedEvents, exhibit)
+}
+
+func (m *MemoryEventing) DispatchExhibitStartingEvent(_ context.Context, exhibit domain.Exhibit, currentStepCount *int, step domain.ExhibitStartingStep) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	errStr := ""
+	if step.Error != nil {
+		errStr = step.Error.Error()
+	}
+
+	event := domain.ExhibitStartingStepEvent{
+		ExhibitId:        exhibit.Id,
+		Object:           exhibit.Objects[step.Object].Name,
+		Step:             step.Step.String(),
+		CurrentStepCount: *currentStepCount,
+		TotalStepCount:   exhibit.GetTotalSteps(),
+		Error:            errStr,
+	}
+
+	*currentStepCount++
+
+	m.StartingEvents = append(m.StartingEvents, event)
+	for _, c := range m.StartingChannels[exhibit.Id] {
+		// subscribers that don't keep up miss events, just like with nats core
+		select {
+		case c <- event:
+		default:
+		}
+	}
+}
+
+func (m *MemoryEventing) DispatchExhibitStoppingEvent(_ context.Context, exhibit domain.Exhibit) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	event := domain.ExhibitStoppingEvent{ExhibitId: exhibit.Id}
+
+	m.StoppingEvents = append(m.StoppingEvents, event)
+	for _, c := range m.StoppingChannels[exhibit.Id] {
+		select {
+		case c <- event:
+		default:
+		}
+	}
+}
+
+func (m *MemoryEventing) DispatchExhibitFixityEvent(_ context.Context, exhibit domain.Exhibit, report domain.FixityReport) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	m.FixityEvents = append(m.FixityEvents, domain.ExhibitFixityEvent{
+		ExhibitId:  exhibit.Id,
+		CheckedAt:  report.CheckedAt,
+		Mismatches: report.Mismatches,
+	})
+}
+
+func (m *MemoryEventing) GetExhibitStartingChannel(exhibitId string, parentCtx context.Context) (<-chan domain.ExhibitStartingStepEvent, context.CancelFunc, error) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	c := make(chan domain.ExhibitStartingStepEvent, 64)
+	m.StartingChannels[exhibitId] = append(m.StartingChannels[exhibitId], c)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	go func() {
+		<-ctx.Done()
+
+		m.Mu.Lock()
+		defer m.Mu.Unlock()
+
+		channels := m.StartingChannels[exhibitId]
+		for i, other := range channels {
+			if other == c {
+				m.StartingChannels[exhibitId] = append(channels[:i], channels[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	return c, cancel, nil
+}
+
+func (m *MemoryEventing) GetExhibitStoppingChannel(exhibitId string, parentCtx context.Context) (<-chan domain.ExhibitStoppingEvent, context.CancelFunc, error) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	c := make(chan domain.ExhibitStoppingEvent, 64)
+	m.StoppingChannels[exhibitId] = append(m.StoppingChannels[exhibitId], c)
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	go func() {
+		<-ctx.Done()
+
+		m.Mu.Lock()
+		defer m.Mu.Unlock()
+
+		channels := m.StoppingChannels[exhibitId]
+		for i, other := range channels {
+			if other == c {
+				m.StoppingChannels[exhibitId] = append(channels[:i], channels[i+1:]...)
+				break
+			}
+		}
+	}()
+
+	return c, cancel, nil
+}
+
+func (m *MemoryEventing) CanReceive() bool {
+	return true
+}
+
+// GetStartingEvents returns a copy of all starting events dispatched for an exhibit
+func (m *MemoryEventing) GetStartingEvents(exhibitId string) []domain.ExhibitStartingStepEvent {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	events := make([]domain.ExhibitStartingStepEvent, 0)
+	for _, e := range m.StartingEvents {
+		if e.ExhibitId == exhibitId {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
+
+// GetStoppingEvents returns a copy of all stopping events dispatched for an exhibit
+func (m *MemoryEventing) GetStoppingEvents(exhibitId string) []domain.ExhibitStoppingEvent {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	events := make([]domain.ExhibitStoppingEvent, 0)
+	for _, e := range m.StoppingEvents {
+		if e.ExhibitId == exhibitId {
+			events = append(events, e)
+		}
+	}
+
+	return events
+}
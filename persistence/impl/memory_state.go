@@ -0,0 +1,249 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"museum/domain"
+	"museum/util"
+	"sync"
+)
+
+// MemoryState is a State that only lives in memory, it is used to test services without etcd.
+// Values are stored serialized, like in the persistent backends, so callers never share slices or maps with the state.
+type MemoryState struct {
+	Exhibits     map[string][]byte
+	Names        map[string]string
+	RuntimeInfo  map[string][]byte
+	LastAccessed map[string]int64
+	Fixity       map[string][]byte
+	StartupLogs  map[string][]byte
+	Secrets      map[string][]byte
+	Revision     *RevisionWaiter
+	Mu           *sync.RWMutex
+	Locks        *LocalLocks
+}
+
+func (m *MemoryState) GetRwLock(_ context.Context, id string, lockName string) util.RwErrMutex {
+	return m.Locks.Get(id + "/" + "locks" + "/" + lockName)
+}
+
+func (m *MemoryState) Campaign(ctx context.Context, election string) (context.Context, error) {
+	return m.Locks.Campaign(ctx, election)
+}
+
+func (m *MemoryState) GetRevision() int64 {
+	return m.Revision.Get()
+}
+
+// Sync returns right away, every write is read right after it was committed
+func (m *MemoryState) Sync(context.Context) error {
+	return nil
+}
+
+func (m *MemoryState) WaitForRevision(ctx context.Context, revision int64) error {
+	return m.Revision.Wait(ctx, revision)
+}
+
+func (m *MemoryState) CreateExhibit(ctx context.Context, app domain.Exhibit) error {
+	return m.Txn(ctx).CreateExhibit(app).Commit()
+}
+
+func (m *MemoryState) GetExhibitById(_ context.Context, id string) (domain.Exhibit, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	v, ok := m.Exhibits[id]
+	if !ok {
+		return domain.Exhibit{}, domain.ExhibitNotFoundError{By: "id", Value: id}
+	}
+
+	exhibit, _, err := unmarshalExhibit(v)
+	if err != nil {
+		return domain.Exhibit{}, errors.New("error reading exhibit with id " + id + ": " + err.Error())
+	}
+
+	return exhibit, nil
+}
+
+func (m *MemoryState) GetExhibitIdByName(_ context.Context, name string) (string, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	id, ok := m.Names[name]
+	if !ok {
+		return "", domain.ExhibitNotFoundError{By: "name", Value: name}
+	}
+
+	return id, nil
+}
+
+func (m *MemoryState) GetAllExhibits(_ context.Context) []domain.Exhibit {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	exhibits := make([]domain.Exhibit, 0, len(m.Exhibits))
+	for _, v := range m.Exhibits {
+		exhibit, _, err := unmarshalExhibit(v)
+		if err != nil {
+			continue
+		}
+
+		exhibits = append(exhibits, exhibit)
+	}
+
+	return exhibits
+}
+
+func (m *MemoryState) DeleteExhibitById(ctx context.Context, id string) error {
+	return m.Txn(ctx).DeleteExhibitById(id).Commit()
+}
+
+func (m *MemoryState) SetRuntimeInfo(ctx context.Context, id string, runtimeInfo domain.ExhibitRuntimeInfo) error {
+	return m.Txn(ctx).SetRuntimeInfo(id, runtimeInfo).Commit()
+}
+
+func (m *MemoryState) GetRuntimeInfo(_ context.Context, id string) (domain.ExhibitRuntimeInfo, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	v, ok := m.RuntimeInfo[id]
+	if !ok {
+		return domain.ExhibitRuntimeInfo{}, errors.New("runtime_info for exhibit with id " + id + " not found")
+	}
+
+	runtimeInfo := domain.ExhibitRuntimeInfo{}
+	err := json.Unmarshal(v, &runtimeInfo)
+	if err != nil {
+		return domain.ExhibitRuntimeInfo{}, err
+	}
+
+	return runtimeInfo, nil
+}
+
+func (m *MemoryState) DeleteRuntimeInfo(ctx context.Context, id string) error {
+	return m.Txn(ctx).DeleteRuntimeInfo(id).Commit()
+}
+
+func (m *MemoryState) GetLastAccessed(_ context.Context, id string) (int64, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	lastAccessed, ok := m.LastAccessed[id]
+	if !ok {
+		return 0, errors.New("last_accessed for exhibit with id " + id + " not found")
+	}
+
+	return lastAccessed, nil
+}
+
+func (m *MemoryState) SetLastAccessed(ctx context.Context, id string, lastAccessed int64) error {
+	return m.Txn(ctx).SetLastAccessed(id, lastAccessed).Commit()
+}
+
+func (m *MemoryState) DeleteLastAccessed(ctx context.Context, id string) error {
+	return m.Txn(ctx).DeleteLastAccessed(id).Commit()
+}
+
+func (m *MemoryState) GetFixity(_ context.Context, id string) (domain.FixityReport, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	v, ok := m.Fixity[id]
+	if !ok {
+		return domain.FixityReport{}, errors.New("fixity for exhibit with id " + id + " not found")
+	}
+
+	report := domain.FixityReport{}
+	err := json.Unmarshal(v, &report)
+	if err != nil {
+		return domain.FixityReport{}, err
+	}
+
+	return report, nil
+}
+
+func (m *MemoryState) SetFixity(ctx context.Context, id string, report domain.FixityReport) error {
+	return m.Txn(ctx).SetFixity(id, report).Commit()
+}
+
+func (m *MemoryState) DeleteFixity(ctx context.Context, id string) error {
+	return m.Txn(ctx).DeleteFixity(id).Commit()
+}
+
+func (m *MemoryState) GetStartupLogs(_ context.Context, id string) (domain.StartupLogs, error) {
+	m.Mu.RLock()
+	defer m.Mu.RUnlock()
+
+	v, ok := m.StartupLogs[id]
+	if !ok {
+		return domain.StartupLogs{}, errors.New("startup logs for exhibit with id " + id + " not found")
+	}
+
+	logs := domain.StartupLogs{}
+	err := json.Unmarshal(v, &logs)
+	if err != nil {
+		return domain.StartupLogs{}, err
+	}
+
+	return logs, nil
+}
+
+func (m *MemoryState) SetStartupLogs(ctx context.Context, id string, logs domain.StartupLogs) error {
+	return m.Txn(ctx).SetStartupLogs(id, logs).Commit()
+}
+
+func (m *MemoryState) DeleteStartupLogs(ctx context.Context, id string) error {
+	return m.Txn(ctx).DeleteStartupLogs(id).Commit()
+}
+
+func (m *MemoryState) MigrateExhibits(_ context.Context) (domain.MigrationResult, error) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	result := newMigrationResult()
+	exhibits := maps.Clone(m.Exhibits)
+	for id, v := range m.Exhibits {
+		exhibit, version, err := unmarshalExhibit(v)
+		if err != nil {
+			result.Failed[id] = err.Error()
+			continue
+		}
+
+		if version == ExhibitSchemaVersion {
+			result.UpToDate++
+			continue
+		}
+
+		b, err := marshalExhibit(exhibit)
+		if err != nil {
+			result.Failed[id] = err.Error()
+			continue
+		}
+
+		exhibits[id] = b
+		result.Migrated = append(result.Migrated, exhibit.Name)
+	}
+
+	if len(result.Migrated) > 0 {
+		m.Exhibits = exhibits
+		m.Revision.Advance(m.Revision.Get() + 1)
+	}
+
+	return result, nil
+}
+
+func (m *MemoryState) GetOrCreateSecret(_ context.Context, name string, secret []byte) ([]byte, error) {
+	m.Mu.Lock()
+	defer m.Mu.Unlock()
+
+	if stored, ok := m.Secrets[name]; ok {
+		return stored, nil
+	}
+
+	m.Secrets[name] = secret
+	m.Revision.Advance(m.Revision.Get() + 1)
+
+	return secret, nil
+}
@@ -0,0 +1,195 @@
+package impl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"museum/domain"
+)
+
+// memoryStateSnapshot is a copy of the state maps a transaction is applied to,
+// it replaces the state maps only if every op succeeded
+type memoryStateSnapshot struct {
+	exhibits     map[string][]byte
+	names        map[string]string
+	runtimeInfo  map[string][]byte
+	lastAccessed map[string]int64
+	fixity       map[string][]byte
+	startupLogs  map[string][]byte
+}
+
+type MemoryStateTxn struct {
+	state *MemoryState
+
+	ops []func(s *memoryStateSnapshot) error
+}
+
+func (m *MemoryState) Txn(context.Context) *MemoryStateTxn {
+	return &MemoryStateTxn{
+		state: m,
+		ops:   make([]func(s *memoryStateSnapshot) error, 0),
+	}
+}
+
+func (t *MemoryStateTxn) CreateExhibit(exhibit domain.Exhibit) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		if _, ok := s.exhibits[exhibit.Id]; ok {
+			return errors.New("exhibit with id " + exhibit.Id + " already exists")
+		}
+
+		if _, ok := s.names[exhibit.Name]; ok {
+			return errors.New("exhibit with name " + exhibit.Name + " already exists")
+		}
+
+		b, err := marshalExhibit(exhibit)
+		if err != nil {
+			return err
+		}
+
+		s.exhibits[exhibit.Id] = b
+		s.names[exhibit.Name] = exhibit.Id
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) DeleteExhibitById(id string) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		// the name is needed to drop the name index entry
+		v, ok := s.exhibits[id]
+		if !ok {
+			return nil
+		}
+
+		exhibit := domain.Exhibit{}
+		if err := json.Unmarshal(v, &exhibit); err == nil {
+			delete(s.names, exhibit.Name)
+		}
+
+		delete(s.exhibits, id)
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) SetRuntimeInfo(id string, runtimeInfo domain.ExhibitRuntimeInfo) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		b, err := json.Marshal(runtimeInfo)
+		if err != nil {
+			return err
+		}
+
+		s.runtimeInfo[id] = b
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) DeleteRuntimeInfo(id string) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		delete(s.runtimeInfo, id)
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) SetLastAccessed(id string, lastAccessed int64) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		s.lastAccessed[id] = lastAccessed
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) DeleteLastAccessed(id string) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		delete(s.lastAccessed, id)
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) SetFixity(id string, report domain.FixityReport) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		b, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+
+		s.fixity[id] = b
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) DeleteFixity(id string) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		delete(s.fixity, id)
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) SetStartupLogs(id string, logs domain.StartupLogs) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		b, err := json.Marshal(logs)
+		if err != nil {
+			return err
+		}
+
+		s.startupLogs[id] = b
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) DeleteStartupLogs(id string) *MemoryStateTxn {
+	t.ops = append(t.ops, func(s *memoryStateSnapshot) error {
+		delete(s.startupLogs, id)
+		return nil
+	})
+
+	return t
+}
+
+func (t *MemoryStateTxn) Commit() error {
+	t.state.Mu.Lock()
+	defer t.state.Mu.Unlock()
+
+	snapshot := &memoryStateSnapshot{
+		exhibits:     maps.Clone(t.state.Exhibits),
+		names:        maps.Clone(t.state.Names),
+		runtimeInfo:  maps.Clone(t.state.RuntimeInfo),
+		lastAccessed: maps.Clone(t.state.LastAccessed),
+		fixity:       maps.Clone(t.state.Fixity),
+		startupLogs:  maps.Clone(t.state.StartupLogs),
+	}
+
+	for _, op := range t.ops {
+		err := op(snapshot)
+		if err != nil {
+			return err
+		}
+	}
+
+	t.state.Exhibits = snapshot.exhibits
+	t.state.Names = snapshot.names
+	t.state.RuntimeInfo = snapshot.runtimeInfo
+	t.state.LastAccessed = snapshot.lastAccessed
+	t.state.Fixity = snapshot.fixity
+	t.state.StartupLogs = snapshot.startupLogs
+
+	t.state.Revision.Advance(t.state.Revision.Get() + 1)
+
+	return nil
+}
@@ -156,6 +156,45 @@ func (n NatsEventing) DispatchExhibitStoppingEvent(ctx context.Context, exhibit
 	}
 }
 
+func (n NatsEventing) DispatchExhibitFixityEvent(ctx context.Context, exhibit domain.Exhibit, report domain.FixityReport) {
+	_, span := n.Provider.
+		Tracer("nats eventing").
+		Start(ctx, "DispatchExhibitFixityEvent", trace.WithAttributes(attribute.String("exhibitId", exhibit.Id), attribute.Int("mismatches", len(report.Mismatches))))
+	defer span.End()
+
+	n.Log.Debugw("nats eventing dispatching exhibit fixity event", "exhibitId", exhibit.Id, "mismatches", len(report.Mismatches))
+	span.AddEvent("dispatching exhibit fixity event")
+
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.New().String())
+	event.SetSource("museum")
+	event.SetType("exhibit.fixity.mismatch")
+	err := event.SetData(cloudevents.ApplicationJSON, domain.ExhibitFixityEvent{
+		ExhibitId:  exhibit.Id,
+		CheckedAt:  report.CheckedAt,
+		Mismatches: report.Mismatches,
+	})
+	if err != nil {
+		n.Log.Errorw("error setting event data", "error", err)
+		span.RecordError(err)
+		return
+	}
+
+	bytes, err := event.MarshalJSON()
+	if err != nil {
+		n.Log.Errorw("error marshalling event", "error", err)
+		span.RecordError(err)
+		return
+	}
+
+	err = n.Conn.Publish(n.Config.GetNatsBaseKey()+".exhibit."+exhibit.Id+".fixity", bytes)
+	if err != nil {
+		n.Log.Errorw("error publishing exhibit fixity event", "error", err)
+		span.RecordError(err)
+		return
+	}
+}
+
 func (n NatsEventing) GetExhibitStartingChannel(exhibitId string, parentCtx context.Context) (<-chan domain.ExhibitStartingStepEvent, context.CancelFunc, error) {
 	subChan := make(chan domain.ExhibitStartingStepEvent)
 
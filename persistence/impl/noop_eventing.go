@@ -22,6 +22,10 @@ func (n NoopEventing) DispatchExhibitStoppingEvent(ctx context.Context, exhibit
 	n.Log.Debugw("noop eventing dispatching exhibit stopping event", "exhibitId", exhibit.Id)
 }
 
+func (n NoopEventing) DispatchExhibitFixityEvent(_ context.Context, exhibit domain.Exhibit, report domain.FixityReport) {
+	n.Log.Debugw("noop eventing dispatching exhibit fixity event", "exhibitId", exhibit.Id, "mismatches", len(report.Mismatches))
+}
+
 func (n NoopEventing) GetExhibitStartingChannel(string, context.Context) (<-chan domain.ExhibitStartingStepEvent, context.CancelFunc, error) {
 	return make(chan domain.ExhibitStartingStepEvent), func() {}, nil
 }
@@ -0,0 +1,60 @@
+package impl
+
+import (
+	"context"
+	"sync"
+)
+
+// RevisionWaiter tracks the revision a state backend has applied and lets readers
+// wait until a given revision is visible
+type RevisionWaiter struct {
+	mu       sync.Mutex
+	revision int64
+	changed  chan struct{}
+}
+
+func NewRevisionWaiter() *RevisionWaiter {
+	return &RevisionWaiter{
+		changed: make(chan struct{}),
+	}
+}
+
+func (r *RevisionWaiter) Get() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.revision
+}
+
+// Advance sets the revision if it is newer than the current one and wakes up all waiters
+func (r *RevisionWaiter) Advance(revision int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if revision <= r.revision {
+		return
+	}
+
+	r.revision = revision
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+// Wait blocks until the revision is at least the given revision or the context is done
+func (r *RevisionWaiter) Wait(ctx context.Context, revision int64) error {
+	for {
+		r.mu.Lock()
+		current, changed := r.revision, r.changed
+		r.mu.Unlock()
+
+		if current >= revision {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
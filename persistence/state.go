@@ -11,11 +11,29 @@ import (
 // communication between museum instances. No business logic shall be contained here.
 type State interface {
 	GetRwLock(ctx context.Context, id string, lockName string) util.RwErrMutex
+	// Campaign blocks until this instance leads the election. The returned context ends once it does not lead
+	// anymore, e.g. because its session expired, the leadership is given up when ctx ends.
+	Campaign(ctx context.Context, election string) (context.Context, error)
+	Txn(ctx context.Context) StateTxn
+
+	// GetRevision returns the revision the reads of this instance are based on,
+	// it increases with every write that is visible to this instance
+	GetRevision() int64
+	// WaitForRevision blocks until the reads of this instance reflect at least the given revision,
+	// it can be used to read the writes of another instance
+	WaitForRevision(ctx context.Context, revision int64) error
+	// Sync blocks until the reads of this instance reflect every write that was committed before it was called,
+	// callers that took a lock use it to read what the previous holder wrote
+	Sync(ctx context.Context) error
 
 	CreateExhibit(ctx context.Context, app domain.Exhibit) error
 	GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error)
+	GetExhibitIdByName(ctx context.Context, name string) (string, error)
 	GetAllExhibits(ctx context.Context) []domain.Exhibit
 	DeleteExhibitById(ctx context.Context, id string) error
+	// MigrateExhibits rewrites all exhibits stored with an older schema version in the current one,
+	// reads upgrade older exhibits as well, but do not write them back
+	MigrateExhibits(ctx context.Context) (domain.MigrationResult, error)
 
 	SetRuntimeInfo(ctx context.Context, id string, runtimeInfo domain.ExhibitRuntimeInfo) error
 	GetRuntimeInfo(ctx context.Context, id string) (domain.ExhibitRuntimeInfo, error)
@@ -24,4 +42,16 @@ type State interface {
 	GetLastAccessed(ctx context.Context, id string) (int64, error)
 	SetLastAccessed(ctx context.Context, id string, lastAccessed int64) error
 	DeleteLastAccessed(ctx context.Context, id string) error
+
+	GetFixity(ctx context.Context, id string) (domain.FixityReport, error)
+	SetFixity(ctx context.Context, id string, report domain.FixityReport) error
+	DeleteFixity(ctx context.Context, id string) error
+
+	GetStartupLogs(ctx context.Context, id string) (domain.StartupLogs, error)
+	SetStartupLogs(ctx context.Context, id string, logs domain.StartupLogs) error
+	DeleteStartupLogs(ctx context.Context, id string) error
+
+	// GetOrCreateSecret returns the secret stored under name, secret is stored if there is none yet.
+	// Instances that create it at the same time all get the one that was stored first.
+	GetOrCreateSecret(ctx context.Context, name string, secret []byte) ([]byte, error)
 }
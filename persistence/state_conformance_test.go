@@ -0,0 +1,500 @@
+package persistence
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	etcd "go.etcd.io/etcd/client/v3"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+	"museum/config/impl"
+	"museum/domain"
+	"museum/observability"
+	"os"
+	"sort"
+	"testing"
+	"time"
+)
+
+// stateBackends returns a constructor for every backend the conformance suite runs against.
+// The etcd backend is only tested if MUSEUM_TEST_ETCD_HOST is set.
+func stateBackends() map[string]func(t *testing.T) State {
+	log := zap.NewNop().Sugar()
+
+	return map[string]func(t *testing.T) State{
+		"memory": func(t *testing.T) State {
+			return NewMemoryState()
+		},
+		"bolt": func(t *testing.T) State {
+			cfg := &impl.EnvConfig{BoltPath: t.TempDir() + "/museum.db"}
+			state := NewBoltState(cfg, observability.NewTracerProviderFactory(&tracetest.NoopExporter{}, cfg), log)
+			t.Cleanup(func() {
+				_ = state.(boltTxnState).Close()
+			})
+			return state
+		},
+		"etcd": func(t *testing.T) State {
+			host := os.Getenv("MUSEUM_TEST_ETCD_HOST")
+			if host == "" {
+				t.Skip("MUSEUM_TEST_ETCD_HOST not set, skipping etcd backend")
+			}
+
+			// every test gets its own base key so tests don't see each other's exhibits
+			cfg := &impl.EnvConfig{EtcdHost: host, EtcdBaseKey: "museum-test-" + uuid.New().String()}
+			client := NewEtcdClient(cfg, log)
+			state := NewEtcdState(cfg, client, observability.NewTracerProviderFactory(&tracetest.NoopExporter{}, cfg), log)
+			t.Cleanup(func() {
+				_ = state.(etcdTxnState).Close()
+				_, _ = client.Delete(context.Background(), "/"+cfg.EtcdBaseKey+"/", etcd.WithPrefix())
+				_ = client.Close()
+			})
+			return state
+		},
+	}
+}
+
+func runConformance(t *testing.T, test func(t *testing.T, state State)) {
+	for name, newState := range stateBackends() {
+		t.Run(name, func(t *testing.T) {
+			test(t, newState(t))
+		})
+	}
+}
+
+func newTestExhibit(name string) domain.Exhibit {
+	return domain.Exhibit{
+		Id:     uuid.New().String(),
+		Name:   name,
+		Expose: "nginx",
+		Lease:  "1h",
+		Objects: []domain.Object{
+			{Name: "nginx", Image: "nginx", Label: "latest"},
+		},
+	}
+}
+
+func TestStateCreateAndGetExhibit(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		exhibit := newTestExhibit("my-exhibit")
+
+		err := state.CreateExhibit(ctx, exhibit)
+		assert.NoError(t, err)
+
+		got, err := state.GetExhibitById(ctx, exhibit.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, exhibit.Name, got.Name)
+		assert.Equal(t, exhibit.Objects, got.Objects)
+
+		id, err := state.GetExhibitIdByName(ctx, exhibit.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, exhibit.Id, id)
+
+		_, err = state.GetExhibitById(ctx, uuid.New().String())
+		assert.Error(t, err)
+
+		_, err = state.GetExhibitIdByName(ctx, "does-not-exist")
+		assert.Error(t, err)
+	})
+}
+
+func TestStateGetAllExhibits(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		assert.Empty(t, state.GetAllExhibits(ctx))
+
+		a, b := newTestExhibit("a"), newTestExhibit("b")
+		assert.NoError(t, state.CreateExhibit(ctx, a))
+		assert.NoError(t, state.CreateExhibit(ctx, b))
+
+		names := make([]string, 0)
+		for _, e := range state.GetAllExhibits(ctx) {
+			names = append(names, e.Name)
+		}
+		sort.Strings(names)
+
+		assert.Equal(t, []string{"a", "b"}, names)
+	})
+}
+
+func TestStateDuplicateExhibit(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		exhibit := newTestExhibit("duplicate")
+		assert.NoError(t, state.CreateExhibit(ctx, exhibit))
+
+		// same id
+		assert.Error(t, state.CreateExhibit(ctx, exhibit))
+
+		// same name, different id
+		other := newTestExhibit("duplicate")
+		err := state.Txn(ctx).
+			SetLastAccessed(other.Id, 42).
+			SetRuntimeInfo(other.Id, domain.ExhibitRuntimeInfo{Status: domain.NotCreated}).
+			CreateExhibit(other).
+			Commit()
+		assert.Error(t, err)
+
+		// nothing of the failed transaction may be written
+		_, err = state.GetExhibitById(ctx, other.Id)
+		assert.Error(t, err)
+		_, err = state.GetRuntimeInfo(ctx, other.Id)
+		assert.Error(t, err)
+		_, err = state.GetLastAccessed(ctx, other.Id)
+		assert.Error(t, err)
+
+		id, err := state.GetExhibitIdByName(ctx, "duplicate")
+		assert.NoError(t, err)
+		assert.Equal(t, exhibit.Id, id)
+	})
+}
+
+func TestStateDeleteExhibit(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		exhibit := newTestExhibit("to-delete")
+		assert.NoError(t, state.CreateExhibit(ctx, exhibit))
+
+		assert.NoError(t, state.DeleteExhibitById(ctx, exhibit.Id))
+
+		// callers tell a missing exhibit from a failing state
+		notFound := domain.ExhibitNotFoundError{}
+		_, err := state.GetExhibitById(ctx, exhibit.Id)
+		assert.ErrorAs(t, err, &notFound)
+		_, err = state.GetExhibitIdByName(ctx, exhibit.Name)
+		assert.ErrorAs(t, err, &notFound)
+		assert.Equal(t, "name", notFound.By)
+
+		// the name can be reused after deletion
+		assert.NoError(t, state.CreateExhibit(ctx, newTestExhibit("to-delete")))
+	})
+}
+
+func TestStateReplaceExhibit(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		existing := newTestExhibit("replaced")
+		assert.NoError(t, state.CreateExhibit(ctx, existing))
+		assert.NoError(t, state.SetRuntimeInfo(ctx, existing.Id, domain.ExhibitRuntimeInfo{Status: domain.Stopped}))
+
+		// the exhibit and the one that takes its name are swapped in one transaction
+		replacement := newTestExhibit("replaced")
+		assert.NoError(t, state.Txn(ctx).
+			DeleteRuntimeInfo(existing.Id).
+			DeleteExhibitById(existing.Id).
+			SetRuntimeInfo(replacement.Id, domain.ExhibitRuntimeInfo{Status: domain.NotCreated}).
+			CreateExhibit(replacement).
+			Commit())
+
+		id, err := state.GetExhibitIdByName(ctx, "replaced")
+		assert.NoError(t, err)
+		assert.Equal(t, replacement.Id, id)
+		_, err = state.GetExhibitById(ctx, existing.Id)
+		assert.Error(t, err)
+
+		// an exhibit can also be replaced by one with the same id
+		replacement.Lease = "2h"
+		assert.NoError(t, state.Txn(ctx).
+			SetRuntimeInfo(replacement.Id, domain.ExhibitRuntimeInfo{Status: domain.Stopped}).
+			DeleteExhibitById(replacement.Id).
+			DeleteRuntimeInfo(replacement.Id).
+			SetRuntimeInfo(replacement.Id, domain.ExhibitRuntimeInfo{Status: domain.NotCreated}).
+			CreateExhibit(replacement).
+			Commit())
+
+		got, err := state.GetExhibitById(ctx, replacement.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "2h", got.Lease)
+		info, err := state.GetRuntimeInfo(ctx, replacement.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.NotCreated, info.Status)
+
+		// replacing does not bypass the guard against other exhibits with the same name
+		other := newTestExhibit("other")
+		assert.NoError(t, state.CreateExhibit(ctx, other))
+		taken := newTestExhibit("other")
+		assert.Error(t, state.Txn(ctx).DeleteExhibitById(replacement.Id).CreateExhibit(taken).Commit())
+		_, err = state.GetExhibitById(ctx, replacement.Id)
+		assert.NoError(t, err)
+	})
+}
+
+func TestStateRuntimeInfo(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		_, err := state.GetRuntimeInfo(ctx, id)
+		assert.Error(t, err)
+
+		info := domain.ExhibitRuntimeInfo{
+			Status:            domain.Running,
+			Hostname:          "my-exhibit_nginx",
+			RelatedContainers: []string{"abc"},
+		}
+		assert.NoError(t, state.SetRuntimeInfo(ctx, id, info))
+
+		got, err := state.GetRuntimeInfo(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, info, got)
+
+		info.Status = domain.Stopped
+		assert.NoError(t, state.SetRuntimeInfo(ctx, id, info))
+
+		got, err = state.GetRuntimeInfo(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.Stopped, got.Status)
+
+		assert.NoError(t, state.DeleteRuntimeInfo(ctx, id))
+		_, err = state.GetRuntimeInfo(ctx, id)
+		assert.Error(t, err)
+	})
+}
+
+func TestStateLastAccessed(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		_, err := state.GetLastAccessed(ctx, id)
+		assert.Error(t, err)
+
+		assert.NoError(t, state.SetLastAccessed(ctx, id, 1337))
+
+		got, err := state.GetLastAccessed(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1337), got)
+
+		assert.NoError(t, state.DeleteLastAccessed(ctx, id))
+		_, err = state.GetLastAccessed(ctx, id)
+		assert.Error(t, err)
+	})
+}
+
+func TestStateFixity(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		_, err := state.GetFixity(ctx, id)
+		assert.Error(t, err)
+
+		report := domain.FixityReport{
+			Status:     domain.FixityStatusMismatch,
+			CheckedAt:  1337,
+			BaselineAt: 42,
+			Baseline:   map[string]string{"volume:data": "sha256:abc"},
+			Mismatches: []domain.FixityMismatch{{Item: "volume:data", Expected: "sha256:abc", Actual: "sha256:def"}},
+		}
+		assert.NoError(t, state.SetFixity(ctx, id, report))
+
+		got, err := state.GetFixity(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, report, got)
+
+		assert.NoError(t, state.DeleteFixity(ctx, id))
+		_, err = state.GetFixity(ctx, id)
+		assert.Error(t, err)
+	})
+}
+
+func TestStateStartupLogs(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		_, err := state.GetStartupLogs(ctx, id)
+		assert.Error(t, err)
+
+		logs := domain.StartupLogs{At: 1337, Objects: map[string][]string{"db": {"database system is ready\n"}, "web": {"connection refused\n", "connection refused\n"}}}
+		assert.NoError(t, state.SetStartupLogs(ctx, id, logs))
+
+		got, err := state.GetStartupLogs(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, logs, got)
+
+		assert.NoError(t, state.DeleteStartupLogs(ctx, id))
+		_, err = state.GetStartupLogs(ctx, id)
+		assert.Error(t, err)
+	})
+}
+
+func TestStateSecret(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+
+		secret, err := state.GetOrCreateSecret(ctx, "access", []byte("first"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("first"), secret)
+
+		// instances that come later share the stored secret
+		secret, err = state.GetOrCreateSecret(ctx, "access", []byte("second"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("first"), secret)
+
+		secret, err = state.GetOrCreateSecret(ctx, "other", []byte("second"))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("second"), secret)
+	})
+}
+
+func TestStateRwLock(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		// two readers can hold the same lock
+		lock, other := state.GetRwLock(ctx, id, "exhibit"), state.GetRwLock(ctx, id, "exhibit")
+		assert.NoError(t, lock.RLock(ctx))
+		assert.NoError(t, other.RLock(ctx))
+		assert.NoError(t, other.RUnlock())
+		assert.NoError(t, lock.RUnlock())
+
+		assert.NoError(t, lock.Lock(ctx))
+		assert.NoError(t, lock.Unlock())
+	})
+}
+
+func TestStateCampaign(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx, resign := context.WithCancel(context.Background())
+		lead, err := state.Campaign(ctx, "scheduler")
+		assert.NoError(t, err)
+		assert.NoError(t, lead.Err())
+
+		// only one instance leads at a time
+		waitCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		_, err = state.Campaign(waitCtx, "scheduler")
+		assert.Error(t, err)
+
+		// the next one takes over once the leader resigned
+		resign()
+		assert.Eventually(t, func() bool { return lead.Err() != nil }, 5*time.Second, 10*time.Millisecond)
+
+		nextCtx, cancelNext := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelNext()
+		next, err := state.Campaign(nextCtx, "scheduler")
+		assert.NoError(t, err)
+		assert.NoError(t, next.Err())
+	})
+}
+
+func TestStateRwLockContext(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		writer := state.GetRwLock(ctx, id, "exhibit")
+		assert.NoError(t, writer.Lock(ctx))
+
+		// waiting for a held lock gives up with the context
+		waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, state.GetRwLock(ctx, id, "exhibit").RLock(waitCtx), context.DeadlineExceeded)
+		assert.ErrorIs(t, state.GetRwLock(ctx, id, "exhibit").Lock(waitCtx), context.DeadlineExceeded)
+
+		// the waiters that gave up do not block anybody once the lock is released
+		assert.NoError(t, writer.Unlock())
+
+		lockCtx, cancelLock := context.WithTimeout(ctx, 5*time.Second)
+		defer cancelLock()
+		reader := state.GetRwLock(ctx, id, "exhibit")
+		assert.NoError(t, reader.RLock(lockCtx))
+		assert.NoError(t, reader.RUnlock())
+		assert.NoError(t, writer.Lock(lockCtx))
+		assert.NoError(t, writer.Unlock())
+	})
+}
+
+func TestStateRwLockWriterWaitsForReaders(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		id := uuid.New().String()
+
+		reader := state.GetRwLock(ctx, id, "exhibit")
+		assert.NoError(t, reader.RLock(ctx))
+
+		acquired := make(chan error, 1)
+		go func() {
+			acquired <- state.GetRwLock(ctx, id, "exhibit").Lock(ctx)
+		}()
+
+		select {
+		case err := <-acquired:
+			t.Fatalf("writer acquired lock held by reader: %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		assert.NoError(t, reader.RUnlock())
+
+		select {
+		case err := <-acquired:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("writer did not acquire lock after reader released it")
+		}
+	})
+}
+
+func TestStateRevision(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		before := state.GetRevision()
+
+		assert.NoError(t, state.CreateExhibit(ctx, newTestExhibit("revision")))
+
+		// a write of this instance is visible as soon as it returns
+		after := state.GetRevision()
+		assert.Greater(t, after, before)
+		assert.NoError(t, state.WaitForRevision(ctx, after))
+
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, state.WaitForRevision(timeout, after+1000), context.DeadlineExceeded)
+	})
+}
+
+func TestStateDeleteExhibitKeepsHeldLocks(t *testing.T) {
+	runConformance(t, func(t *testing.T, state State) {
+		ctx := context.Background()
+		exhibit := newTestExhibit("locked")
+		assert.NoError(t, state.CreateExhibit(ctx, exhibit))
+
+		// the exhibit is deleted while its lock is held and another caller waits for it
+		held := state.GetRwLock(ctx, exhibit.Id, "exhibit")
+		assert.NoError(t, held.Lock(ctx))
+
+		waiter := state.GetRwLock(ctx, exhibit.Id, "exhibit")
+		acquired := make(chan error, 1)
+		go func() {
+			lockCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			acquired <- waiter.Lock(lockCtx)
+		}()
+		time.Sleep(100 * time.Millisecond)
+
+		assert.NoError(t, state.Txn(ctx).DeleteExhibitById(exhibit.Id).Commit())
+
+		select {
+		case <-acquired:
+			t.Fatal("the waiter got the lock while it was still held")
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		// callers that ask for the lock after the delete wait for the holder as well
+		lateCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		assert.Error(t, state.GetRwLock(ctx, exhibit.Id, "exhibit").Lock(lateCtx))
+
+		assert.NoError(t, held.Unlock())
+		assert.NoError(t, <-acquired)
+		assert.NoError(t, waiter.Unlock())
+
+		lockCtx, cancelLock := context.WithTimeout(ctx, 5*time.Second)
+		defer cancelLock()
+		late := state.GetRwLock(ctx, exhibit.Id, "exhibit")
+		assert.NoError(t, late.Lock(lockCtx))
+		assert.NoError(t, late.Unlock())
+	})
+}
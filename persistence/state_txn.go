@@ -0,0 +1,124 @@
+package persistence
+
+import (
+	"context"
+	"museum/domain"
+	"museum/persistence/impl"
+)
+
+// StateTxn collects writes to the shared state and applies them atomically.
+// Nothing is written until Commit is called, if any guard of a write fails
+// (e.g. an exhibit with the same name already exists), no write is applied.
+type StateTxn interface {
+	CreateExhibit(exhibit domain.Exhibit) StateTxn
+	DeleteExhibitById(id string) StateTxn
+
+	SetRuntimeInfo(id string, runtimeInfo domain.ExhibitRuntimeInfo) StateTxn
+	DeleteRuntimeInfo(id string) StateTxn
+
+	SetLastAccessed(id string, lastAccessed int64) StateTxn
+	DeleteLastAccessed(id string) StateTxn
+
+	SetFixity(id string, report domain.FixityReport) StateTxn
+	DeleteFixity(id string) StateTxn
+
+	SetStartupLogs(id string, logs domain.StartupLogs) StateTxn
+	DeleteStartupLogs(id string) StateTxn
+
+	Commit() error
+}
+
+// backendTxn is the transaction of a backend, its writes return the backend's own type
+type backendTxn[T any] interface {
+	CreateExhibit(exhibit domain.Exhibit) T
+	DeleteExhibitById(id string) T
+
+	SetRuntimeInfo(id string, runtimeInfo domain.ExhibitRuntimeInfo) T
+	DeleteRuntimeInfo(id string) T
+
+	SetLastAccessed(id string, lastAccessed int64) T
+	DeleteLastAccessed(id string) T
+
+	SetFixity(id string, report domain.FixityReport) T
+	DeleteFixity(id string) T
+
+	SetStartupLogs(id string, logs domain.StartupLogs) T
+	DeleteStartupLogs(id string) T
+
+	Commit() error
+}
+
+// stateTxn makes a StateTxn of a backend transaction
+type stateTxn[T backendTxn[T]] struct {
+	txn T
+}
+
+func (s stateTxn[T]) CreateExhibit(exhibit domain.Exhibit) StateTxn {
+	return stateTxn[T]{s.txn.CreateExhibit(exhibit)}
+}
+
+func (s stateTxn[T]) DeleteExhibitById(id string) StateTxn {
+	return stateTxn[T]{s.txn.DeleteExhibitById(id)}
+}
+
+func (s stateTxn[T]) SetRuntimeInfo(id string, runtimeInfo domain.ExhibitRuntimeInfo) StateTxn {
+	return stateTxn[T]{s.txn.SetRuntimeInfo(id, runtimeInfo)}
+}
+
+func (s stateTxn[T]) DeleteRuntimeInfo(id string) StateTxn {
+	return stateTxn[T]{s.txn.DeleteRuntimeInfo(id)}
+}
+
+func (s stateTxn[T]) SetLastAccessed(id string, lastAccessed int64) StateTxn {
+	return stateTxn[T]{s.txn.SetLastAccessed(id, lastAccessed)}
+}
+
+func (s stateTxn[T]) DeleteLastAccessed(id string) StateTxn {
+	return stateTxn[T]{s.txn.DeleteLastAccessed(id)}
+}
+
+func (s stateTxn[T]) SetFixity(id string, report domain.FixityReport) StateTxn {
+	return stateTxn[T]{s.txn.SetFixity(id, report)}
+}
+
+func (s stateTxn[T]) DeleteFixity(id string) StateTxn {
+	return stateTxn[T]{s.txn.DeleteFixity(id)}
+}
+
+func (s stateTxn[T]) SetStartupLogs(id string, logs domain.StartupLogs) StateTxn {
+	return stateTxn[T]{s.txn.SetStartupLogs(id, logs)}
+}
+
+func (s stateTxn[T]) DeleteStartupLogs(id string) StateTxn {
+	return stateTxn[T]{s.txn.DeleteStartupLogs(id)}
+}
+
+func (s stateTxn[T]) Commit() error {
+	return s.txn.Commit()
+}
+
+// the backends cannot import this package, their states return their own transactions
+
+type etcdTxnState struct {
+	*impl.EtcdState
+}
+
+func (s etcdTxnState) Txn(ctx context.Context) StateTxn {
+	return stateTxn[*impl.EtcdStateTxn]{s.EtcdState.Txn(ctx)}
+}
+
+type boltTxnState struct {
+	*impl.BoltState
+}
+
+func (s boltTxnState) Txn(ctx context.Context) StateTxn {
+	return stateTxn[*impl.BoltStateTxn]{s.BoltState.Txn(ctx)}
+}
+
+type memoryTxnState struct {
+	*impl.MemoryState
+}
+
+func (s memoryTxnState) Txn(ctx context.Context) StateTxn {
+	return stateTxn[*impl.MemoryStateTxn]{s.MemoryState.Txn(ctx)}
+}
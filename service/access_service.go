@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+	"museum/util/oidc"
+)
+
+type AccessService service.AccessService
+
+func NewAccessService(exhibitService service.ExhibitService, state persistence.State, config config.Config, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) AccessService {
+	secret := []byte(config.GetAccessSecret())
+	if len(secret) == 0 {
+		// every instance has to accept the links and sessions signed by the others, so the generated key is shared
+		log.Info("ACCESS_SECRET is not set, share links and visitor sessions are signed with a key that is generated once and kept in the state")
+		secret = make([]byte, 32)
+		_, err := rand.Read(secret)
+		if err != nil {
+			panic(err)
+		}
+
+		secret, err = state.GetOrCreateSecret(context.Background(), "access", secret)
+		if err != nil {
+			panic("error reading the access secret from the state: " + err.Error())
+		}
+	}
+
+	var provider *oidc.Provider
+	if config.GetOidcIssuer() != "" && config.GetOidcClientId() != "" {
+		provider = oidc.NewProvider(config.GetOidcIssuer(), config.GetOidcJwksUrl())
+	}
+
+	return &impl.AccessServiceImpl{
+		ExhibitService: exhibitService,
+		Config:         config,
+		Oidc:           provider,
+		Secret:         secret,
+		Provider:       factory.Build("access-service"),
+		Log:            log,
+	}
+}
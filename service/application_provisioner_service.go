@@ -1,7 +1,6 @@
 package service
 
 import (
-	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
 	"museum/config"
 	"museum/observability"
@@ -12,24 +11,29 @@ import (
 
 type ApplicationProvisionerService service.ApplicationProvisionerService
 
-func NewDockerApplicationProvisionerService(client *docker.Client,
+func NewDockerApplicationProvisionerService(client service.ContainerRuntime,
 	exhibitService service.ExhibitService,
 	environmentTemplateResolver service.EnvironmentTemplateResolverService,
 	runtimeInfoService service.RuntimeInfoService,
 	lastAccessedService service.LastAccessedService,
 	lockService service.LockService,
+	collectionService service.CollectionService,
+	capacityService service.CapacityService,
 	livecheckFactoryService LivecheckFactoryService,
 	eventing persistence.Eventing,
 	log *zap.SugaredLogger,
 	providerFactory *observability.TracerProviderFactory,
 	config config.Config,
-	volumeProvisionerFactory service.VolumeProvisionerFactoryService) ApplicationProvisionerService {
+	volumeProvisionerFactory service.VolumeProvisionerFactoryService,
+	logService LogService) ApplicationProvisionerService {
 	return &impl.DockerApplicationProvisionerService{
 		ExhibitService:              exhibitService,
 		LivecheckFactoryService:     livecheckFactoryService,
 		EnvironmentTemplateResolver: environmentTemplateResolver,
 		Client:                      client,
 		LockService:                 lockService,
+		CollectionService:           collectionService,
+		CapacityService:             capacityService,
 		RuntimeInfoService:          runtimeInfoService,
 		LastAccessedService:         lastAccessedService,
 		Eventing:                    eventing,
@@ -37,5 +41,6 @@ func NewDockerApplicationProvisionerService(client *docker.Client,
 		Provider:                    providerFactory.Build("docker-service"),
 		Config:                      config,
 		VolumeProvisionerFactory:    volumeProvisionerFactory,
+		LogService:                  logService,
 	}
 }
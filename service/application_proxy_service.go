@@ -9,10 +9,12 @@ import (
 
 type ApplicationProxyService service.ApplicationProxyService
 
-func NewDockerApplicationProxyService(resolver service.ApplicationResolverService, rewriteService service.RewriteService, log *zap.SugaredLogger, config config.Config) ApplicationProxyService {
+func NewDockerApplicationProxyService(resolver service.ApplicationResolverService, rewriteService service.RewriteService, loadService service.LoadService, authService service.AuthService, log *zap.SugaredLogger, config config.Config) ApplicationProxyService {
 	return &impl.DockerApplicationProxyService{
 		Resolver:       resolver,
 		RewriteService: rewriteService,
+		LoadService:    loadService,
+		AuthService:    authService,
 		Log:            log,
 		Config:         config,
 	}
@@ -1,8 +1,6 @@
 package service
 
 import (
-	docker "github.com/docker/docker/client"
-	"museum/persistence"
 	"museum/service/impl"
 	service "museum/service/interface"
 	"museum/util/cache"
@@ -17,12 +15,10 @@ func NewDockerHostApplicationResolverService(exhibitService service.ExhibitServi
 }
 
 func NewDockerExtHostApplicationResolverService(exhibitService service.ExhibitService,
-	client *docker.Client,
-	eventing persistence.Eventing) ApplicationResolverService {
+	client service.ContainerRuntime) ApplicationResolverService {
 	return &impl.DockerExtHostApplicationResolverService{
 		ExhibitService: exhibitService,
-		IpCache:        cache.NewLRU[string, string](1000),
+		EndpointCache:  cache.NewLRU[string, *impl.ResolvedEndpoints](1000),
 		Client:         client,
-		Eventing:       eventing,
 	}
 }
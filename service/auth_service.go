@@ -0,0 +1,42 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/domain"
+	"museum/observability"
+	"museum/service/impl"
+	service "museum/service/interface"
+	"museum/util/oidc"
+)
+
+type AuthService service.AuthService
+
+func NewAuthService(config config.Config, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) AuthService {
+	tokens := make([]domain.ApiToken, 0)
+	for _, entry := range config.GetApiTokens() {
+		token, err := domain.ParseApiToken(entry)
+		if err != nil {
+			log.Warnw("ignoring api token", "error", err)
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+
+	var provider *oidc.Provider
+	if config.GetOidcIssuer() != "" {
+		provider = oidc.NewProvider(config.GetOidcIssuer(), config.GetOidcJwksUrl())
+	}
+
+	if len(tokens) == 0 && provider == nil {
+		log.Warn("neither API_TOKENS nor OIDC_ISSUER are set, the api is open to everyone")
+	}
+
+	return &impl.AuthServiceImpl{
+		Config:   config,
+		Tokens:   tokens,
+		Oidc:     provider,
+		Provider: factory.Build("auth-service"),
+		Log:      log,
+	}
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type AutoscalerService service.AutoscalerService
+
+func NewAutoscalerService(state persistence.State, lockService service.LockService, loadService service.LoadService, provisionerService service.ApplicationProvisionerService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) AutoscalerService {
+	return &impl.AutoscalerServiceImpl{
+		Instance:                      uuid.New().String(),
+		State:                         state,
+		LockService:                   lockService,
+		LoadService:                   loadService,
+		ApplicationProvisionerService: provisionerService,
+		Provider:                      factory.Build("autoscaler-service"),
+		Log:                           log,
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"github.com/docker/go-units"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type CapacityService service.CapacityService
+
+func NewCapacityService(state persistence.State, lockService service.LockService, config config.Config, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) CapacityService {
+	if config.GetMaxRunningExhibits() < 0 {
+		log.Panicw("MAX_RUNNING_EXHIBITS must not be negative")
+	}
+
+	maxMemory := int64(0)
+	if config.GetMaxRunningMemory() != "" {
+		memory, err := units.RAMInBytes(config.GetMaxRunningMemory())
+		if err != nil {
+			log.Panicw("failed to parse MAX_RUNNING_MEMORY", "error", err)
+		}
+		maxMemory = memory
+	}
+
+	return &impl.CapacityServiceImpl{
+		MaxRunning:  config.GetMaxRunningExhibits(),
+		MaxMemory:   maxMemory,
+		Policy:      config.GetCapacityPolicy(),
+		State:       state,
+		LockService: lockService,
+		Provider:    factory.Build("capacity-service"),
+		Log:         log,
+	}
+}
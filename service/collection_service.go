@@ -0,0 +1,37 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/domain"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+	"os"
+)
+
+type CollectionService service.CollectionService
+
+func NewCollectionService(state persistence.State, lockService service.LockService, config config.Config, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) CollectionService {
+	collections := []domain.Collection{{Name: domain.DefaultCollection}}
+	if config.GetCollectionsFile() != "" {
+		data, err := os.ReadFile(config.GetCollectionsFile())
+		if err != nil {
+			log.Panicw("failed to read collections file", "error", err)
+		}
+
+		collections, err = domain.ParseCollections(data)
+		if err != nil {
+			log.Panicw("failed to parse collections file", "error", err)
+		}
+	}
+
+	return &impl.CollectionServiceImpl{
+		Collections: collections,
+		State:       state,
+		LockService: lockService,
+		Provider:    factory.Build("collection-service"),
+		Log:         log,
+	}
+}
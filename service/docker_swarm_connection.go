@@ -5,9 +5,12 @@ import (
 	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
 	"museum/config"
+	service "museum/service/interface"
 )
 
-func NewDockerClient(config config.Config, log *zap.SugaredLogger) *docker.Client {
+type ContainerRuntime service.ContainerRuntime
+
+func NewDockerClient(config config.Config, log *zap.SugaredLogger) ContainerRuntime {
 	ctx := context.Background()
 	c, err := docker.NewClientWithOpts(docker.WithHost(config.GetDockerHost()))
 	if err != nil {
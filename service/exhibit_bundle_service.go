@@ -0,0 +1,33 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type ExhibitBundleService service.ExhibitBundleService
+
+func NewExhibitBundleService(exhibitService service.ExhibitService,
+	collectionService service.CollectionService,
+	stateTransferService service.StateTransferService,
+	lockService service.LockService,
+	dockerClient service.ContainerRuntime,
+	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService,
+	config config.Config,
+	factory *observability.TracerProviderFactory,
+	log *zap.SugaredLogger) ExhibitBundleService {
+	return &impl.ExhibitBundleServiceImpl{
+		ExhibitService:           exhibitService,
+		CollectionService:        collectionService,
+		StateTransferService:     stateTransferService,
+		LockService:              lockService,
+		DockerClient:             dockerClient,
+		VolumeProvisionerFactory: volumeProvisionerFactoryService,
+		Config:                   config,
+		Provider:                 factory.Build("exhibit-bundle-service"),
+		Log:                      log,
+	}
+}
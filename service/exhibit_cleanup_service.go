@@ -10,11 +10,12 @@ import (
 
 type ExhibitCleanupService service.ExhibitCleanupService
 
-func NewExhibitCleanupService(exhibitService service.ExhibitService, lockService service.LockService, provisionerService service.ApplicationProvisionerService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger, config config.Config) ExhibitCleanupService {
+func NewExhibitCleanupService(exhibitService service.ExhibitService, lockService service.LockService, provisionerService service.ApplicationProvisionerService, capacityService service.CapacityService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger, config config.Config) ExhibitCleanupService {
 	return &impl.ExhibitCleanupServiceImpl{
 		ExhibitService:                exhibitService,
 		LockService:                   lockService,
 		ApplicationProvisionerService: provisionerService,
+		CapacityService:               capacityService,
 		Provider:                      factory.Build("cleanup-service"),
 		Log:                           log,
 		Config:                        config,
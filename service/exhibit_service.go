@@ -1,8 +1,8 @@
 package service
 
 import (
-	docker "github.com/docker/docker/client"
 	"go.uber.org/zap"
+	"museum/config"
 	"museum/observability"
 	"museum/persistence"
 	"museum/service/impl"
@@ -17,8 +17,10 @@ func NewExhibitService(state persistence.State,
 	lockService service.LockService,
 	factory *observability.TracerProviderFactory,
 	log *zap.SugaredLogger,
-	dockerClient *docker.Client,
-	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService) ExhibitService {
+	dockerClient service.ContainerRuntime,
+	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService,
+	collectionService service.CollectionService,
+	config config.Config) ExhibitService {
 	return &impl.ExhibitServiceImpl{
 		State:                    state,
 		Eventing:                 eventing,
@@ -28,5 +30,7 @@ func NewExhibitService(state persistence.State,
 		Log:                      log,
 		DockerClient:             dockerClient,
 		VolumeProvisionerFactory: volumeProvisionerFactoryService,
+		CollectionService:        collectionService,
+		Config:                   config,
 	}
 }
@@ -0,0 +1,637 @@
+package fake
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/google/uuid"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"io"
+	service "museum/service/interface"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Container is a container of the fake runtime
+type Container struct {
+	Id         string
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	Networks   []string
+	Running    bool
+	IpAddress  string
+	// Logs is the output of the container, lines written to Stderr start with "stderr: "
+	Logs string
+}
+
+// imageNameAnnotation names an image in the index of an image layout, docker sets it when saving images
+const imageNameAnnotation = "io.containerd.image.name"
+
+type exec struct {
+	containerName string
+	cmd           []string
+}
+
+// ContainerRuntime is an in-memory service.ContainerRuntime.
+// Failures can be injected per method (and optionally per container, image or network name) with FailOn,
+// the exit code of livecheck commands can be controlled with ExecExitCode.
+type ContainerRuntime struct {
+	Containers map[string]*Container
+	Networks   map[string]network.Inspect
+	Images     map[string]bool
+	Calls      []string
+
+	// ExecExitCode returns the exit code of a command executed inside a container, commands succeed if it is nil
+	ExecExitCode func(containerName string, cmd []string) int
+	// BeforeImagePull is called with the reference of every image that is pulled, it may use the runtime itself
+	BeforeImagePull func(refStr string)
+
+	failures map[string]error
+	execs    map[string]exec
+	// logs are the outputs of containers that are not created yet by their name
+	logs map[string]string
+	ips  int
+	mu   sync.Mutex
+}
+
+var _ service.ContainerRuntime = (*ContainerRuntime)(nil)
+
+func NewContainerRuntime() *ContainerRuntime {
+	return &ContainerRuntime{
+		Containers: make(map[string]*Container),
+		Networks:   make(map[string]network.Inspect),
+		Images:     make(map[string]bool),
+		Calls:      make([]string, 0),
+		failures:   make(map[string]error),
+		execs:      make(map[string]exec),
+		logs:       make(map[string]string),
+	}
+}
+
+// FailOn makes every call to method return err. If targets are given, only calls for
+// one of those containers, images or networks fail.
+func (f *ContainerRuntime) FailOn(method string, err error, targets ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(targets) == 0 {
+		f.failures[method] = err
+		return
+	}
+
+	for _, t := range targets {
+		f.failures[method+"/"+t] = err
+	}
+}
+
+// Reset removes all injected failures
+func (f *ContainerRuntime) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failures = make(map[string]error)
+}
+
+// GetCalls returns a copy of all calls made to the runtime in the form "Method(target)"
+func (f *ContainerRuntime) GetCalls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string{}, f.Calls...)
+}
+
+// GetContainer returns a copy of a container by its name or id
+func (f *ContainerRuntime) GetContainer(nameOrId string) (Container, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := f.findContainer(nameOrId)
+	if c == nil {
+		return Container{}, false
+	}
+
+	return *c, true
+}
+
+// call records a call and returns the injected failure for it, if any. f.mu must be held.
+func (f *ContainerRuntime) call(method string, target string) error {
+	f.Calls = append(f.Calls, method+"("+target+")")
+
+	if err, ok := f.failures[method+"/"+target]; ok {
+		return err
+	}
+
+	return f.failures[method]
+}
+
+func (f *ContainerRuntime) findContainer(nameOrId string) *Container {
+	if c, ok := f.Containers[nameOrId]; ok {
+		return c
+	}
+
+	for _, c := range f.Containers {
+		if c.Name == nameOrId {
+			return c
+		}
+	}
+
+	return nil
+}
+
+func (f *ContainerRuntime) findNetwork(nameOrId string) (network.Inspect, bool) {
+	if n, ok := f.Networks[nameOrId]; ok {
+		return n, true
+	}
+
+	for _, n := range f.Networks {
+		if n.ID == nameOrId {
+			return n, true
+		}
+	}
+
+	return network.Inspect{}, false
+}
+
+func (f *ContainerRuntime) ContainerCreate(_ context.Context, config *container.Config, hostConfig *container.HostConfig, _ *network.NetworkingConfig, _ *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ContainerCreate", containerName); err != nil {
+		return container.CreateResponse{}, err
+	}
+
+	if f.findContainer(containerName) != nil {
+		return container.CreateResponse{}, errdefs.Conflict(errors.New("container name " + containerName + " is already in use"))
+	}
+
+	if !f.Images[config.Image] {
+		return container.CreateResponse{}, errdefs.NotFound(errors.New("no such image: " + config.Image))
+	}
+
+	f.ips++
+	c := &Container{
+		Id:         uuid.New().String(),
+		Name:       containerName,
+		Config:     config,
+		HostConfig: hostConfig,
+		Networks:   make([]string, 0),
+		IpAddress:  "172.17.0." + strconv.Itoa(f.ips+1),
+		Logs:       f.logs[containerName],
+	}
+	f.Containers[c.Id] = c
+
+	return container.CreateResponse{ID: c.Id}, nil
+}
+
+func (f *ContainerRuntime) ContainerInspect(_ context.Context, containerID string) (types.ContainerJSON, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := f.findContainer(containerID)
+	if c == nil {
+		if err := f.call("ContainerInspect", containerID); err != nil {
+			return types.ContainerJSON{}, err
+		}
+		return types.ContainerJSON{}, errdefs.NotFound(errors.New("no such container: " + containerID))
+	}
+
+	if err := f.call("ContainerInspect", c.Name); err != nil {
+		return types.ContainerJSON{}, err
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:    c.Id,
+			Name:  "/" + c.Name,
+			State: &types.ContainerState{Running: c.Running},
+		},
+		Config: c.Config,
+		NetworkSettings: &types.NetworkSettings{
+			DefaultNetworkSettings: types.DefaultNetworkSettings{IPAddress: c.IpAddress},
+			Networks: map[string]*network.EndpointSettings{
+				"bridge": {IPAddress: c.IpAddress},
+			},
+		},
+	}, nil
+}
+
+func (f *ContainerRuntime) ContainerStart(_ context.Context, containerID string, _ container.StartOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := f.findContainer(containerID)
+	if c == nil {
+		_ = f.call("ContainerStart", containerID)
+		return errdefs.NotFound(errors.New("no such container: " + containerID))
+	}
+
+	if err := f.call("ContainerStart", c.Name); err != nil {
+		return err
+	}
+
+	c.Running = true
+	return nil
+}
+
+func (f *ContainerRuntime) ContainerStop(_ context.Context, containerID string, _ container.StopOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := f.findContainer(containerID)
+	if c == nil {
+		_ = f.call("ContainerStop", containerID)
+		return errdefs.NotFound(errors.New("no such container: " + containerID))
+	}
+
+	if err := f.call("ContainerStop", c.Name); err != nil {
+		return err
+	}
+
+	c.Running = false
+	return nil
+}
+
+func (f *ContainerRuntime) ContainerRemove(_ context.Context, containerID string, _ container.RemoveOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := f.findContainer(containerID)
+	if c == nil {
+		_ = f.call("ContainerRemove", containerID)
+		return errdefs.NotFound(errors.New("no such container: " + containerID))
+	}
+
+	if err := f.call("ContainerRemove", c.Name); err != nil {
+		return err
+	}
+
+	if c.Running {
+		return errdefs.Conflict(errors.New("cannot remove running container " + c.Name))
+	}
+
+	delete(f.Containers, c.Id)
+	return nil
+}
+
+// SetLogs replaces the output of a container, containers that are created later with the name start with it
+func (f *ContainerRuntime) SetLogs(nameOrId string, logs string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if c := f.findContainer(nameOrId); c != nil {
+		c.Logs = logs
+		return
+	}
+	f.logs[nameOrId] = logs
+}
+
+// ContainerLogs returns the logs multiplexed like docker does for containers without a tty, only Tail is supported
+func (f *ContainerRuntime) ContainerLogs(_ context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := f.findContainer(containerID)
+	if c == nil {
+		_ = f.call("ContainerLogs", containerID)
+		return nil, errdefs.NotFound(errors.New("no such container: " + containerID))
+	}
+
+	if err := f.call("ContainerLogs", c.Name); err != nil {
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(c.Logs, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if tail, err := strconv.Atoi(options.Tail); err == nil && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+
+	buf := &bytes.Buffer{}
+	stdout, stderr := stdcopy.NewStdWriter(buf, stdcopy.Stdout), stdcopy.NewStdWriter(buf, stdcopy.Stderr)
+	for _, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "stderr: "); ok {
+			_, _ = stderr.Write([]byte(rest))
+		} else {
+			_, _ = stdout.Write([]byte(line))
+		}
+	}
+
+	return io.NopCloser(buf), nil
+}
+
+func (f *ContainerRuntime) ContainerExecCreate(_ context.Context, containerName string, options container.ExecOptions) (types.IDResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ContainerExecCreate", containerName); err != nil {
+		return types.IDResponse{}, err
+	}
+
+	c := f.findContainer(containerName)
+	if c == nil {
+		return types.IDResponse{}, errdefs.NotFound(errors.New("no such container: " + containerName))
+	}
+
+	if !c.Running {
+		return types.IDResponse{}, errdefs.Conflict(errors.New("container " + containerName + " is not running"))
+	}
+
+	id := uuid.New().String()
+	f.execs[id] = exec{containerName: c.Name, cmd: options.Cmd}
+
+	return types.IDResponse{ID: id}, nil
+}
+
+func (f *ContainerRuntime) ContainerExecAttach(_ context.Context, execID string, _ container.ExecAttachOptions) (types.HijackedResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ContainerExecAttach", execID); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	if _, ok := f.execs[execID]; !ok {
+		return types.HijackedResponse{}, errdefs.NotFound(errors.New("no such exec: " + execID))
+	}
+
+	conn, other := net.Pipe()
+	_ = other.Close()
+
+	return types.HijackedResponse{
+		Conn:   conn,
+		Reader: bufio.NewReader(strings.NewReader("")),
+	}, nil
+}
+
+func (f *ContainerRuntime) ContainerExecInspect(_ context.Context, execID string) (container.ExecInspect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ContainerExecInspect", execID); err != nil {
+		return container.ExecInspect{}, err
+	}
+
+	e, ok := f.execs[execID]
+	if !ok {
+		return container.ExecInspect{}, errdefs.NotFound(errors.New("no such exec: " + execID))
+	}
+
+	exitCode := 0
+	if f.ExecExitCode != nil {
+		exitCode = f.ExecExitCode(e.containerName, e.cmd)
+	}
+
+	return container.ExecInspect{ExecID: execID, ExitCode: exitCode}, nil
+}
+
+func (f *ContainerRuntime) NetworkCreate(_ context.Context, name string, _ network.CreateOptions) (network.CreateResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("NetworkCreate", name); err != nil {
+		return network.CreateResponse{}, err
+	}
+
+	if _, ok := f.Networks[name]; ok {
+		return network.CreateResponse{}, errdefs.Conflict(errors.New("network with name " + name + " already exists"))
+	}
+
+	n := network.Inspect{ID: uuid.New().String(), Name: name, Driver: "bridge"}
+	f.Networks[name] = n
+
+	return network.CreateResponse{ID: n.ID}, nil
+}
+
+func (f *ContainerRuntime) NetworkInspect(_ context.Context, networkID string, _ network.InspectOptions) (network.Inspect, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("NetworkInspect", networkID); err != nil {
+		return network.Inspect{}, err
+	}
+
+	n, ok := f.findNetwork(networkID)
+	if !ok {
+		return network.Inspect{}, errdefs.NotFound(errors.New("network " + networkID + " not found"))
+	}
+
+	return n, nil
+}
+
+func (f *ContainerRuntime) NetworkConnect(_ context.Context, networkID, containerID string, _ *network.EndpointSettings) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.findNetwork(networkID)
+	if !ok {
+		_ = f.call("NetworkConnect", networkID)
+		return errdefs.NotFound(errors.New("network " + networkID + " not found"))
+	}
+
+	if err := f.call("NetworkConnect", n.Name); err != nil {
+		return err
+	}
+
+	c := f.findContainer(containerID)
+	if c == nil {
+		return errdefs.NotFound(errors.New("no such container: " + containerID))
+	}
+
+	c.Networks = append(c.Networks, n.Name)
+	return nil
+}
+
+func (f *ContainerRuntime) NetworkList(_ context.Context, _ network.ListOptions) ([]network.Summary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("NetworkList", ""); err != nil {
+		return nil, err
+	}
+
+	networks := make([]network.Summary, 0, len(f.Networks))
+	for _, n := range f.Networks {
+		networks = append(networks, n)
+	}
+
+	return networks, nil
+}
+
+func (f *ContainerRuntime) NetworkRemove(_ context.Context, networkID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n, ok := f.findNetwork(networkID)
+	if !ok {
+		_ = f.call("NetworkRemove", networkID)
+		return errdefs.NotFound(errors.New("network " + networkID + " not found"))
+	}
+
+	if err := f.call("NetworkRemove", n.Name); err != nil {
+		return err
+	}
+
+	delete(f.Networks, n.Name)
+	return nil
+}
+
+func (f *ContainerRuntime) ImageInspectWithRaw(_ context.Context, imageID string) (types.ImageInspect, []byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ImageInspectWithRaw", imageID); err != nil {
+		return types.ImageInspect{}, nil, err
+	}
+
+	if !f.Images[imageID] {
+		return types.ImageInspect{}, nil, errdefs.NotFound(errors.New("no such image: " + imageID))
+	}
+
+	return types.ImageInspect{ID: "sha256:" + imageID, RepoTags: []string{imageID}}, nil, nil
+}
+
+func (f *ContainerRuntime) ImagePull(_ context.Context, refStr string, _ image.PullOptions) (io.ReadCloser, error) {
+	if f.BeforeImagePull != nil {
+		f.BeforeImagePull(refStr)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ImagePull", refStr); err != nil {
+		return nil, err
+	}
+
+	f.Images[refStr] = true
+
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image for ` + refStr + `"}`)), nil
+}
+
+// ImageSave writes the requested images as a minimal OCI image layout, every image is a single blob that contains its reference
+func (f *ContainerRuntime) ImageSave(_ context.Context, imageIDs []string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	index := ocispec.Index{MediaType: ocispec.MediaTypeImageIndex, Manifests: make([]ocispec.Descriptor, 0, len(imageIDs))}
+	index.SchemaVersion = 2
+	blobs := make(map[string][]byte)
+
+	for _, id := range imageIDs {
+		if err := f.call("ImageSave", id); err != nil {
+			return nil, err
+		}
+
+		if !f.Images[id] {
+			return nil, errdefs.NotFound(errors.New("no such image: " + id))
+		}
+
+		content := []byte(id)
+		d := digest.FromBytes(content)
+		blobs["blobs/sha256/"+d.Encoded()] = content
+		index.Manifests = append(index.Manifests, ocispec.Descriptor{
+			MediaType:   ocispec.MediaTypeImageManifest,
+			Digest:      d,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{imageNameAnnotation: id},
+		})
+	}
+
+	indexJson, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	files := map[string][]byte{
+		ocispec.ImageLayoutFile: []byte(`{"imageLayoutVersion":"` + ocispec.ImageLayoutVersion + `"}`),
+		"index.json":            indexJson,
+	}
+	for name, content := range blobs {
+		files[name] = content
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		err = tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		if err != nil {
+			return nil, err
+		}
+		_, err = tw.Write(files[name])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(buf), nil
+}
+
+// ImageLoad adds the images named in the index.json of an image layout written by ImageSave
+func (f *ContainerRuntime) ImageLoad(_ context.Context, input io.Reader, _ bool) (image.LoadResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.call("ImageLoad", ""); err != nil {
+		return image.LoadResponse{}, err
+	}
+
+	index := ocispec.Index{}
+	tr := tar.NewReader(input)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return image.LoadResponse{}, err
+		}
+
+		if header.Name != "index.json" {
+			continue
+		}
+
+		err = json.NewDecoder(tr).Decode(&index)
+		if err != nil {
+			return image.LoadResponse{}, err
+		}
+	}
+
+	if len(index.Manifests) == 0 {
+		return image.LoadResponse{}, errors.New("no images found in archive")
+	}
+
+	out := strings.Builder{}
+	for _, m := range index.Manifests {
+		name := m.Annotations[imageNameAnnotation]
+		f.Images[name] = true
+		out.WriteString(`{"stream":"Loaded image: ` + name + `\n"}` + "\n")
+	}
+
+	return image.LoadResponse{Body: io.NopCloser(strings.NewReader(out.String())), JSON: true}, nil
+}
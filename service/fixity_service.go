@@ -0,0 +1,34 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type FixityService service.FixityService
+
+func NewFixityService(state persistence.State,
+	eventing persistence.Eventing,
+	exhibitService service.ExhibitService,
+	lockService service.LockService,
+	dockerClient service.ContainerRuntime,
+	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService,
+	config config.Config,
+	factory *observability.TracerProviderFactory,
+	log *zap.SugaredLogger) FixityService {
+	return &impl.FixityServiceImpl{
+		State:                    state,
+		Eventing:                 eventing,
+		ExhibitService:           exhibitService,
+		LockService:              lockService,
+		DockerClient:             dockerClient,
+		VolumeProvisionerFactory: volumeProvisionerFactoryService,
+		Config:                   config,
+		Provider:                 factory.Build("fixity-service"),
+		Log:                      log,
+	}
+}
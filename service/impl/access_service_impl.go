@@ -0,0 +1,337 @@
+package impl
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"museum/config"
+	"museum/domain"
+	"museum/http"
+	service "museum/service/interface"
+	"museum/util/oidc"
+	gohttp "net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// accessSessionLength is how long visitors keep access after they logged in or passed a token
+	accessSessionLength = 12 * time.Hour
+	// loginLength is how long visitors have to log in at the issuer
+	loginLength = 10 * time.Minute
+
+	accessCookiePrefix      = "museum_access_"
+	maintenanceCookiePrefix = "museum_maintenance_"
+	loginCookie             = "museum_login"
+)
+
+// loginState is passed through the issuer while the visitor logs in
+type loginState struct {
+	ExhibitId string `json:"e"`
+	ReturnTo  string `json:"r"`
+	Nonce     string `json:"n"`
+	ExpiresAt int64  `json:"x"`
+}
+
+type AccessServiceImpl struct {
+	ExhibitService service.ExhibitService
+	Config         config.Config
+	// Oidc is nil if no issuer or no client is configured, exhibits with an oidc policy are closed then
+	Oidc *oidc.Provider
+	// Secret signs share links, visitor sessions and logins
+	Secret   []byte
+	Provider trace.TracerProvider
+	Log      *zap.SugaredLogger
+}
+
+func (a AccessServiceImpl) sign(purpose string, values ...string) string {
+	mac := hmac.New(sha256.New, a.Secret)
+	mac.Write([]byte(purpose + "\x00" + strings.Join(values, "\x00")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedExpiry returns expiresAt with a signature over the exhibit, as it is used in share links and cookies
+func (a AccessServiceImpl) signedExpiry(purpose string, exhibitId string, expiresAt time.Time) string {
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expiry + "." + a.sign(purpose, exhibitId, expiry)
+}
+
+// verifyExpiry checks a value of signedExpiry and returns when it expires
+func (a AccessServiceImpl) verifyExpiry(purpose string, exhibitId string, value string) (time.Time, bool) {
+	expiry, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(a.sign(purpose, exhibitId, expiry))) {
+		return time.Time{}, false
+	}
+
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return time.Time{}, false
+	}
+
+	return time.Unix(unix, 0), true
+}
+
+func (a AccessServiceImpl) secureCookies() bool {
+	return strings.HasPrefix(a.Config.GetPublicUrl(), "https://")
+}
+
+// setExhibitCookie sets the cookie for every path of the exhibit, other exhibits never see it
+func (a AccessServiceImpl) setExhibitCookie(exhibit domain.Exhibit, cookie gohttp.Cookie, res *http.Response) {
+	cookie.HttpOnly = true
+	cookie.Secure = a.secureCookies()
+	cookie.SameSite = gohttp.SameSiteLaxMode
+	for _, path := range exhibit.Paths() {
+		cookie.Path = path
+		gohttp.SetCookie(res, &cookie)
+	}
+}
+
+// grant lets the visitor see the exhibit until expiresAt
+func (a AccessServiceImpl) grant(exhibit domain.Exhibit, expiresAt time.Time, res *http.Response) {
+	a.setExhibitCookie(exhibit, gohttp.Cookie{
+		Name:    accessCookiePrefix + exhibit.Id,
+		Value:   a.signedExpiry("session", exhibit.Id, expiresAt),
+		Expires: expiresAt,
+	}, res)
+}
+
+func remoteAddr(req *http.Request) (netip.Addr, error) {
+	addrPort, err := netip.ParseAddrPort(req.RemoteAddr)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return addrPort.Addr(), nil
+}
+
+func (a AccessServiceImpl) Check(ctx context.Context, exhibit domain.Exhibit, res *http.Response, req *http.Request) domain.AccessDecision {
+	_, span := a.Provider.
+		Tracer("access-service").
+		Start(ctx, "Check", trace.WithAttributes(attribute.String("exhibitId", exhibit.Id), attribute.String("access", exhibit.AccessType())))
+	defer span.End()
+
+	policy := exhibit.Access
+	if exhibit.AccessType() == domain.AccessPublic {
+		return domain.AccessGranted
+	}
+
+	// visitors that were let in before keep their access until the session ends
+	if cookie, err := req.Cookie(accessCookiePrefix + exhibit.Id); err == nil {
+		if _, ok := a.verifyExpiry("session", exhibit.Id, cookie.Value); ok {
+			span.AddEvent("session accepted")
+			return domain.AccessGranted
+		}
+	}
+
+	// share links grant access regardless of the policy, until they expire
+	if share := req.URL.Query().Get(domain.ShareLinkParam); share != "" {
+		if expiresAt, ok := a.verifyExpiry("share", exhibit.Id, share); ok {
+			span.AddEvent("share link accepted")
+			a.grant(exhibit, expiresAt, res)
+			return domain.AccessGrantedRedirect
+		}
+		a.Log.Infow("rejected share link", "exhibitId", exhibit.Id)
+	}
+
+	switch policy.Type {
+	case domain.AccessBasic:
+		user, password, ok := req.BasicAuth()
+		hash, known := policy.Users[user]
+		if ok && known && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			// bcrypt is slow on purpose, the session spares the following requests of the page from it
+			a.grant(exhibit, time.Now().Add(accessSessionLength), res)
+			return domain.AccessGranted
+		}
+		return domain.AccessUnauthorized
+	case domain.AccessToken:
+		token := req.URL.Query().Get(domain.AccessTokenParam)
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(policy.Token)) == 1 {
+			a.grant(exhibit, time.Now().Add(accessSessionLength), res)
+			return domain.AccessGrantedRedirect
+		}
+		return domain.AccessDenied
+	case domain.AccessIp:
+		addr, err := remoteAddr(req)
+		if err == nil && policy.AllowsAddr(addr) {
+			return domain.AccessGranted
+		}
+		return domain.AccessDenied
+	case domain.AccessOidc:
+		if a.Oidc == nil {
+			a.Log.Warnw("exhibit requires a login, but OIDC_ISSUER or OIDC_CLIENT_ID is not set", "exhibitId", exhibit.Id)
+			return domain.AccessDenied
+		}
+		return domain.AccessLoginRequired
+	default:
+		return domain.AccessDenied
+	}
+}
+
+// maintenanceToken is bound to the maintenance it was created for, it is worthless once the maintenance ends
+func (a AccessServiceImpl) maintenanceToken(exhibit domain.Exhibit) string {
+	return a.sign("maintenance", exhibit.Id, strconv.FormatInt(exhibit.RuntimeInfo.Maintenance.Since, 10))
+}
+
+func (a AccessServiceImpl) CheckMaintenance(ctx context.Context, exhibit domain.Exhibit, res *http.Response, req *http.Request) domain.AccessDecision {
+	_, span := a.Provider.
+		Tracer("access-service").
+		Start(ctx, "CheckMaintenance", trace.WithAttributes(attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	if exhibit.RuntimeInfo == nil || exhibit.RuntimeInfo.Maintenance == nil {
+		return domain.AccessGranted
+	}
+	token := a.maintenanceToken(exhibit)
+
+	if cookie, err := req.Cookie(maintenanceCookiePrefix + exhibit.Id); err == nil && hmac.Equal([]byte(cookie.Value), []byte(token)) {
+		return domain.AccessGranted
+	}
+
+	if link := req.URL.Query().Get(domain.MaintenanceParam); link != "" && hmac.Equal([]byte(link), []byte(token)) {
+		span.AddEvent("maintenance link accepted")
+		a.setExhibitCookie(exhibit, gohttp.Cookie{
+			Name:  maintenanceCookiePrefix + exhibit.Id,
+			Value: token,
+		}, res)
+		return domain.AccessGrantedRedirect
+	}
+
+	return domain.AccessDenied
+}
+
+func (a AccessServiceImpl) MaintenanceLink(exhibit domain.Exhibit) string {
+	return a.Config.GetPublicUrl() + "/exhibit/" + exhibit.Id + "/?" + domain.MaintenanceParam + "=" + a.maintenanceToken(exhibit)
+}
+
+func (a AccessServiceImpl) ShareLink(exhibit domain.Exhibit, expiresAt time.Time) string {
+	return a.Config.GetPublicUrl() + "/exhibit/" + exhibit.Id + "/?" + domain.ShareLinkParam + "=" + a.signedExpiry("share", exhibit.Id, expiresAt)
+}
+
+func (a AccessServiceImpl) redirectUri() string {
+	return a.Config.GetPublicUrl() + domain.AccessCallbackPath
+}
+
+func (a AccessServiceImpl) Login(ctx context.Context, exhibit domain.Exhibit, returnTo string, res *http.Response) (string, error) {
+	subCtx, span := a.Provider.
+		Tracer("access-service").
+		Start(ctx, "Login", trace.WithAttributes(attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	if a.Oidc == nil {
+		return "", errors.New("visitors cannot log in, OIDC_ISSUER or OIDC_CLIENT_ID is not set")
+	}
+
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	state := loginState{
+		ExhibitId: exhibit.Id,
+		ReturnTo:  returnTo,
+		Nonce:     base64.RawURLEncoding.EncodeToString(b),
+		ExpiresAt: time.Now().Add(loginLength).Unix(),
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	// the nonce binds the login to the browser that started it
+	gohttp.SetCookie(res, &gohttp.Cookie{
+		Name:     loginCookie,
+		Value:    state.Nonce,
+		Path:     domain.AccessCallbackPath,
+		MaxAge:   int(loginLength.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secureCookies(),
+		SameSite: gohttp.SameSiteLaxMode,
+	})
+
+	return a.Oidc.AuthCodeUrl(subCtx, a.Config.GetOidcClientId(), a.redirectUri(), a.Config.GetOidcScopes(), encoded+"."+a.sign("login", encoded), state.Nonce)
+}
+
+func (a AccessServiceImpl) FinishLogin(ctx context.Context, res *http.Response, req *http.Request) (string, error) {
+	subCtx, span := a.Provider.
+		Tracer("access-service").
+		Start(ctx, "FinishLogin")
+	defer span.End()
+
+	if a.Oidc == nil {
+		return "", errors.New("visitors cannot log in, OIDC_ISSUER or OIDC_CLIENT_ID is not set")
+	}
+
+	query := req.URL.Query()
+	if query.Get("error") != "" {
+		return "", errors.New("login failed: " + query.Get("error"))
+	}
+
+	encoded, signature, ok := strings.Cut(query.Get("state"), ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(a.sign("login", encoded))) {
+		return "", errors.New("login state is invalid")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	state := loginState{}
+	err = json.Unmarshal(payload, &state)
+	if err != nil {
+		return "", err
+	}
+	span.SetAttributes(attribute.String("exhibitId", state.ExhibitId))
+
+	if time.Now().Unix() >= state.ExpiresAt {
+		return "", errors.New("login took too long, please try again")
+	}
+
+	cookie, err := req.Cookie(loginCookie)
+	if err != nil || !hmac.Equal([]byte(cookie.Value), []byte(state.Nonce)) {
+		return "", errors.New("login was started in another browser")
+	}
+
+	claims, err := a.Oidc.Exchange(subCtx, a.Config.GetOidcClientId(), a.Config.GetOidcClientSecret(), a.redirectUri(), query.Get("code"))
+	if err != nil {
+		span.RecordError(err)
+		return "", err
+	}
+
+	if nonce, _ := claims["nonce"].(string); nonce != state.Nonce {
+		return "", errors.New("id token does not belong to this login")
+	}
+
+	exhibit, err := a.ExhibitService.GetExhibitById(subCtx, state.ExhibitId)
+	if err != nil {
+		return "", err
+	}
+
+	subject, _ := claims.GetSubject()
+	if exhibit.AccessType() != domain.AccessOidc || !exhibit.Access.AllowsGroups(oidc.Strings(claims, a.Config.GetOidcGroupsClaim())) {
+		a.Log.Infow("visitor is not allowed to see exhibit", "subject", subject, "exhibitId", exhibit.Id)
+		return "", errors.New("you are not allowed to see " + exhibit.Name)
+	}
+
+	a.Log.Infow("visitor logged in", "subject", subject, "exhibitId", exhibit.Id)
+	a.grant(exhibit, time.Now().Add(accessSessionLength), res)
+
+	// only paths of exhibits are followed, the state could otherwise send visitors anywhere
+	returnTo := "/exhibit/" + exhibit.Id + "/"
+	if u, err := url.Parse(state.ReturnTo); err == nil && u.Host == "" && u.Scheme == "" && strings.HasPrefix(u.Path, "/exhibit/") {
+		returnTo = u.RequestURI()
+	}
+	return returnTo, nil
+}
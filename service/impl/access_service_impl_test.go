@@ -0,0 +1,214 @@
+package impl
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	configImpl "museum/config/impl"
+	"museum/domain"
+	"museum/http"
+	"museum/util/oidc"
+	"museum/util/oidc/oidctest"
+	gohttp "net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestAccessService() AccessServiceImpl {
+	return AccessServiceImpl{
+		Config:   &configImpl.EnvConfig{PublicUrl: "https://museum.example", OidcClientId: "museum", OidcGroupsClaim: "groups", OidcScopes: []string{"openid"}},
+		Secret:   []byte("test-secret"),
+		Provider: noop.NewTracerProvider(),
+		Log:      zap.NewNop().Sugar(),
+	}
+}
+
+func newTestVisit(target string, cookies ...*gohttp.Cookie) (*http.Response, *http.Request, *httptest.ResponseRecorder) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(gohttp.MethodGet, target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	return &http.Response{ResponseWriter: rec}, &http.Request{Request: req}, rec
+}
+
+func restrictedExhibit(policy domain.AccessPolicy) domain.Exhibit {
+	exhibit := newTestExhibit("restricted")
+	exhibit.Id = "0b7c5d43-3f1a-4c57-9a45-6f3c1f1d2e10"
+	exhibit.Access = &policy
+	return exhibit
+}
+
+func TestCheckPublic(t *testing.T) {
+	a := newTestAccessService()
+	res, req, _ := newTestVisit("/exhibit/restricted/")
+
+	assert.Equal(t, domain.AccessGranted, a.Check(context.Background(), newTestExhibit("open"), res, req))
+	assert.Equal(t, domain.AccessGranted, a.Check(context.Background(), restrictedExhibit(domain.AccessPolicy{Type: domain.AccessPublic}), res, req))
+}
+
+func TestCheckBasic(t *testing.T) {
+	a := newTestAccessService()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("hunter2"), bcrypt.MinCost)
+	exhibit := restrictedExhibit(domain.AccessPolicy{Type: domain.AccessBasic, Users: map[string]string{"reviewer": string(hash)}})
+
+	res, req, _ := newTestVisit("/exhibit/restricted/")
+	assert.Equal(t, domain.AccessUnauthorized, a.Check(context.Background(), exhibit, res, req))
+
+	req.SetBasicAuth("reviewer", "wrong")
+	assert.Equal(t, domain.AccessUnauthorized, a.Check(context.Background(), exhibit, res, req))
+
+	req.SetBasicAuth("reviewer", "hunter2")
+	assert.Equal(t, domain.AccessGranted, a.Check(context.Background(), exhibit, res, req))
+}
+
+func TestCheckTokenKeepsSession(t *testing.T) {
+	a := newTestAccessService()
+	exhibit := restrictedExhibit(domain.AccessPolicy{Type: domain.AccessToken, Token: "0123456789abcdef"})
+
+	res, req, _ := newTestVisit("/exhibit/restricted/?" + domain.AccessTokenParam + "=wrong")
+	assert.Equal(t, domain.AccessDenied, a.Check(context.Background(), exhibit, res, req))
+
+	res, req, rec := newTestVisit("/exhibit/restricted/?" + domain.AccessTokenParam + "=0123456789abcdef")
+	assert.Equal(t, domain.AccessGrantedRedirect, a.Check(context.Background(), exhibit, res, req))
+
+	// the session is scoped to the paths of the exhibit, its id and its name
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, []string{"/exhibit/" + exhibit.Id + "/", "/exhibit/restricted/"}, []string{cookies[0].Path, cookies[1].Path})
+	assert.True(t, cookies[0].Secure)
+
+	// the session is enough afterward, but only for the exhibit it was granted for
+	res, req, _ = newTestVisit("/exhibit/restricted/style.css", cookies[0])
+	assert.Equal(t, domain.AccessGranted, a.Check(context.Background(), exhibit, res, req))
+
+	other := exhibit
+	other.Id = "5d0ff1a4-64b6-4b7e-8f0c-0f6f9f1f2a33"
+	res, req, _ = newTestVisit("/exhibit/other/", &gohttp.Cookie{Name: accessCookiePrefix + other.Id, Value: cookies[0].Value})
+	assert.Equal(t, domain.AccessDenied, a.Check(context.Background(), other, res, req))
+}
+
+func TestCheckIp(t *testing.T) {
+	a := newTestAccessService()
+	exhibit := restrictedExhibit(domain.AccessPolicy{Type: domain.AccessIp, Allow: []string{"10.0.0.0/8"}})
+
+	res, req, _ := newTestVisit("/exhibit/restricted/")
+	req.RemoteAddr = "10.2.3.4:52000"
+	assert.Equal(t, domain.AccessGranted, a.Check(context.Background(), exhibit, res, req))
+
+	req.RemoteAddr = "192.0.2.1:52000"
+	assert.Equal(t, domain.AccessDenied, a.Check(context.Background(), exhibit, res, req))
+}
+
+func TestShareLink(t *testing.T) {
+	a := newTestAccessService()
+	exhibit := restrictedExhibit(domain.AccessPolicy{Type: domain.AccessIp, Allow: []string{"10.0.0.0/8"}})
+
+	link, err := url.Parse(a.ShareLink(exhibit, time.Now().Add(time.Hour)))
+	assert.NoError(t, err)
+	assert.Equal(t, "museum.example", link.Host)
+
+	res, req, rec := newTestVisit(link.RequestURI())
+	assert.Equal(t, domain.AccessGrantedRedirect, a.Check(context.Background(), exhibit, res, req))
+	assert.Len(t, rec.Result().Cookies(), 2)
+
+	// links of other exhibits, tampered and expired links are rejected
+	other := exhibit
+	other.Id = "5d0ff1a4-64b6-4b7e-8f0c-0f6f9f1f2a33"
+	res, req, _ = newTestVisit(link.RequestURI())
+	assert.Equal(t, domain.AccessDenied, a.Check(context.Background(), other, res, req))
+
+	tampered := link.Query()
+	tampered.Set(domain.ShareLinkParam, "9999999999"+tampered.Get(domain.ShareLinkParam)[10:])
+	res, req, _ = newTestVisit("/exhibit/restricted/?" + tampered.Encode())
+	assert.Equal(t, domain.AccessDenied, a.Check(context.Background(), exhibit, res, req))
+
+	expired, _ := url.Parse(a.ShareLink(exhibit, time.Now().Add(-time.Minute)))
+	res, req, _ = newTestVisit(expired.RequestURI())
+	assert.Equal(t, domain.AccessDenied, a.Check(context.Background(), exhibit, res, req))
+}
+
+func TestOidcLogin(t *testing.T) {
+	s := newTestServices(t)
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	a := newTestAccessService()
+	a.ExhibitService = s.ExhibitService
+	a.Oidc = oidc.NewProvider(issuer.Url(), "")
+
+	exhibit := s.createExhibit(t, restrictedExhibit(domain.AccessPolicy{Type: domain.AccessOidc, Groups: []string{"lab"}}))
+
+	// without oidc the exhibit stays closed
+	res, req, _ := newTestVisit("/exhibit/restricted/")
+	assert.Equal(t, domain.AccessDenied, newTestAccessService().Check(context.Background(), exhibit, res, req))
+	assert.Equal(t, domain.AccessLoginRequired, a.Check(context.Background(), exhibit, res, req))
+
+	login := func(claims jwt.MapClaims) (string, *httptest.ResponseRecorder, error) {
+		res, _, rec := newTestVisit("/exhibit/restricted/")
+		loginUrl, err := a.Login(context.Background(), exhibit, "/exhibit/restricted/data?page=2", res)
+		assert.NoError(t, err)
+
+		u, _ := url.Parse(loginUrl)
+		assert.Equal(t, issuer.Url()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, "https://museum.example"+domain.AccessCallbackPath, u.Query().Get("redirect_uri"))
+
+		claims["nonce"] = u.Query().Get("nonce")
+		callback := domain.AccessCallbackPath + "?" + url.Values{"code": {issuer.Code(claims)}, "state": {u.Query().Get("state")}}.Encode()
+		res, req, callbackRec := newTestVisit(callback, rec.Result().Cookies()...)
+		returnTo, err := a.FinishLogin(context.Background(), res, req)
+		return returnTo, callbackRec, err
+	}
+
+	returnTo, rec, err := login(jwt.MapClaims{"sub": "alice", "groups": []string{"lab"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "/exhibit/restricted/data?page=2", returnTo)
+
+	res, req, _ = newTestVisit("/exhibit/restricted/", rec.Result().Cookies()...)
+	assert.Equal(t, domain.AccessGranted, a.Check(context.Background(), exhibit, res, req))
+
+	_, _, err = login(jwt.MapClaims{"sub": "mallory", "groups": []string{"visitors"}})
+	assert.ErrorContains(t, err, "not allowed")
+
+	// a login that was started in another browser is rejected
+	res, _, _ = newTestVisit("/exhibit/restricted/")
+	loginUrl, _ := a.Login(context.Background(), exhibit, "/exhibit/restricted/", res)
+	u, _ := url.Parse(loginUrl)
+	res, req, _ = newTestVisit(domain.AccessCallbackPath + "?" + url.Values{"code": {issuer.Code(jwt.MapClaims{"sub": "alice", "groups": []string{"lab"}, "nonce": u.Query().Get("nonce")})}, "state": {u.Query().Get("state")}}.Encode())
+	_, err = a.FinishLogin(context.Background(), res, req)
+	assert.Error(t, err)
+}
+
+func TestCheckMaintenance(t *testing.T) {
+	a := newTestAccessService()
+	exhibit := restrictedExhibit(domain.AccessPolicy{Type: domain.AccessPublic})
+	exhibit.RuntimeInfo = &domain.ExhibitRuntimeInfo{}
+
+	res, req, _ := newTestVisit("/exhibit/restricted/")
+	assert.Equal(t, domain.AccessGranted, a.CheckMaintenance(context.Background(), exhibit, res, req))
+
+	exhibit.RuntimeInfo.Maintenance = &domain.Maintenance{Since: time.Now().Unix()}
+	assert.Equal(t, domain.AccessDenied, a.CheckMaintenance(context.Background(), exhibit, res, req))
+
+	link, err := url.Parse(a.MaintenanceLink(exhibit))
+	assert.NoError(t, err)
+	res, req, rec := newTestVisit(link.RequestURI())
+	assert.Equal(t, domain.AccessGrantedRedirect, a.CheckMaintenance(context.Background(), exhibit, res, req))
+
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 2)
+	res, req, _ = newTestVisit("/exhibit/restricted/style.css", cookies[0])
+	assert.Equal(t, domain.AccessGranted, a.CheckMaintenance(context.Background(), exhibit, res, req))
+
+	// links and sessions end with the maintenance they were created for
+	exhibit.RuntimeInfo.Maintenance = &domain.Maintenance{Since: time.Now().Add(time.Hour).Unix()}
+	res, req, _ = newTestVisit("/exhibit/restricted/style.css", cookies[0])
+	assert.Equal(t, domain.AccessDenied, a.CheckMaintenance(context.Background(), exhibit, res, req))
+	res, req, _ = newTestVisit(link.RequestURI())
+	assert.Equal(t, domain.AccessDenied, a.CheckMaintenance(context.Background(), exhibit, res, req))
+}
@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/domain"
+	"museum/util/oidc"
+	"strings"
+)
+
+type AuthServiceImpl struct {
+	Config config.Config
+	Tokens []domain.ApiToken
+	// Oidc is nil if no issuer is configured
+	Oidc     *oidc.Provider
+	Provider trace.TracerProvider
+	Log      *zap.SugaredLogger
+}
+
+func (a AuthServiceImpl) Enabled() bool {
+	return len(a.Tokens) > 0 || a.Oidc != nil
+}
+
+func (a AuthServiceImpl) Authenticate(ctx context.Context, token string) (domain.Principal, error) {
+	subCtx, span := a.Provider.
+		Tracer("auth-service").
+		Start(ctx, "Authenticate")
+	defer span.End()
+
+	if t, ok := a.apiToken(token); ok {
+		span.SetAttributes(attribute.String("subject", "token:"+t.Name))
+		return t.Principal(), nil
+	}
+
+	// static tokens are opaque, only tokens that look like a jwt are handed to the issuer
+	if a.Oidc == nil || strings.Count(token, ".") != 2 {
+		return domain.Principal{}, errors.New("invalid token")
+	}
+
+	claims, err := a.Oidc.Verify(subCtx, token, a.Config.GetOidcAudience())
+	if err != nil {
+		span.RecordError(err)
+		return domain.Principal{}, errors.New("invalid token: " + err.Error())
+	}
+
+	subject, _ := claims.GetSubject()
+	principal := domain.NewPrincipal(subject, oidc.Strings(claims, a.Config.GetOidcRolesClaim()), oidc.Strings(claims, a.Config.GetOidcGroupsClaim()))
+	span.SetAttributes(attribute.String("subject", principal.Subject), attribute.String("role", principal.Role))
+	return principal, nil
+}
+
+func (a AuthServiceImpl) IsApiToken(token string) bool {
+	_, ok := a.apiToken(token)
+	return ok
+}
+
+// apiToken looks up a static api token, the tokens are compared in constant time
+func (a AuthServiceImpl) apiToken(token string) (domain.ApiToken, bool) {
+	for _, t := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return t, true
+		}
+	}
+	return domain.ApiToken{}, false
+}
@@ -0,0 +1,68 @@
+package impl
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	configImpl "museum/config/impl"
+	"museum/domain"
+	"museum/util/oidc"
+	"museum/util/oidc/oidctest"
+	"testing"
+)
+
+func newTestAuthService(issuer *oidctest.Issuer) AuthServiceImpl {
+	return AuthServiceImpl{
+		Config:   &configImpl.EnvConfig{OidcAudience: "museum", OidcRolesClaim: "roles", OidcGroupsClaim: "groups"},
+		Tokens:   []domain.ApiToken{{Name: "ci", Role: domain.RoleCurator, Token: "s3cret"}},
+		Oidc:     oidc.NewProvider(issuer.Url(), ""),
+		Provider: noop.NewTracerProvider(),
+		Log:      zap.NewNop().Sugar(),
+	}
+}
+
+func TestAuthenticateApiToken(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+	auth := newTestAuthService(issuer)
+
+	principal, err := auth.Authenticate(context.Background(), "s3cret")
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Principal{Subject: "token:ci", Role: domain.RoleCurator}, principal)
+
+	_, err = auth.Authenticate(context.Background(), "wrong")
+	assert.Error(t, err)
+
+	// the proxy only looks up static tokens, those of the issuer are not verified on every request
+	assert.True(t, auth.IsApiToken("s3cret"))
+	assert.False(t, auth.IsApiToken("wrong"))
+	assert.False(t, auth.IsApiToken(issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "museum", "roles": []string{"admin"}})))
+}
+
+func TestAuthenticateOidcToken(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+	auth := newTestAuthService(issuer)
+
+	token := issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "museum", "roles": []string{"viewer", "admin", "other"}, "groups": []string{"staff"}})
+	principal, err := auth.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Principal{Subject: "alice", Role: domain.RoleAdmin, Groups: []string{"staff"}}, principal)
+
+	// tokens without known roles authenticate, but only allow public routes
+	token = issuer.Token(jwt.MapClaims{"sub": "bob", "aud": "museum"})
+	principal, err = auth.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RolePublic, principal.Role)
+
+	token = issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "other", "roles": []string{"admin"}})
+	_, err = auth.Authenticate(context.Background(), token)
+	assert.Error(t, err)
+}
+
+func TestAuthEnabled(t *testing.T) {
+	assert.False(t, AuthServiceImpl{}.Enabled())
+	assert.True(t, AuthServiceImpl{Tokens: []domain.ApiToken{{Name: "ci", Role: domain.RoleViewer, Token: "t"}}}.Enabled())
+}
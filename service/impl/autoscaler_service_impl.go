@@ -0,0 +1,142 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util"
+	"time"
+)
+
+// loadTtl is how long the load an instance reported counts, instances that stop reporting are gone
+const loadTtl = time.Minute
+
+type AutoscalerServiceImpl struct {
+	// Instance tells the load of this instance apart from that of the others
+	Instance                      string
+	State                         persistence.State
+	LockService                   service.LockService
+	LoadService                   service.LoadService
+	ApplicationProvisionerService service.ApplicationProvisionerService
+	Provider                      trace.TracerProvider
+	Log                           *zap.SugaredLogger
+}
+
+// report shares the load of this instance, nothing is reported for exhibits that do not run
+func (a AutoscalerServiceImpl) report(ctx context.Context, exhibitId string, inFlight int) (err error) {
+	lock := a.LockService.GetRwLock(ctx, exhibitId, "runtime_info")
+	err = lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			a.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = errors.Join(err, e)
+		}
+	}(lock)
+
+	runtimeInfo, err := a.State.GetRuntimeInfo(ctx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	if runtimeInfo.Status != domain.Running {
+		return nil
+	}
+
+	if runtimeInfo.Load == nil {
+		runtimeInfo.Load = make(map[string]domain.InstanceLoad)
+	}
+	runtimeInfo.Load[a.Instance] = domain.InstanceLoad{InFlight: inFlight, ReportedAt: time.Now().Unix()}
+
+	return a.State.SetRuntimeInfo(ctx, exhibitId, runtimeInfo)
+}
+
+func (a AutoscalerServiceImpl) Report(ctx context.Context) error {
+	subCtx, span := a.Provider.
+		Tracer("autoscaler-service").
+		Start(ctx, "Report", trace.WithAttributes(attribute.String("instance", a.Instance)))
+	defer span.End()
+
+	var errs []error
+	for _, exhibit := range a.State.GetAllExhibits(subCtx) {
+		object, ok := exhibit.ExposedObject()
+		if !ok || !object.Replicas.Autoscaled() {
+			continue
+		}
+
+		err := a.report(subCtx, exhibit.Id, a.LoadService.TakePeak(exhibit.Id))
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a AutoscalerServiceImpl) Lead(ctx context.Context) (context.Context, error) {
+	return a.State.Campaign(ctx, "autoscaler")
+}
+
+func (a AutoscalerServiceImpl) Autoscale(ctx context.Context) error {
+	subCtx, span := a.Provider.
+		Tracer("autoscaler-service").
+		Start(ctx, "Autoscale", trace.WithAttributes(attribute.String("instance", a.Instance)))
+	defer span.End()
+
+	// another instance may scale the exhibits already
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	var errs []error
+	for _, exhibit := range a.State.GetAllExhibits(subCtx) {
+		object, ok := exhibit.ExposedObject()
+		if !ok || !object.Replicas.Autoscaled() {
+			continue
+		}
+
+		runtimeInfo, err := a.State.GetRuntimeInfo(subCtx, exhibit.Id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if runtimeInfo.Status != domain.Running {
+			continue
+		}
+
+		// replicas are removed one at a time, so that a short lull does not take them all away
+		total := runtimeInfo.TotalInFlight(time.Now(), loadTtl)
+		replicas := runtimeInfo.RunningReplicas()
+		desired := object.Replicas.Desired(total)
+		if desired < replicas {
+			desired = replicas - 1
+		}
+		if desired == replicas {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+
+		span.AddEvent("scaling exhibit " + exhibit.Id)
+		a.Log.Debugw("autoscaling exhibit", "exhibitId", exhibit.Id, "inFlight", total, "replicas", replicas, "desired", desired)
+
+		err = a.ApplicationProvisionerService.ScaleApplication(subCtx, exhibit.Id, desired)
+		if err != nil {
+			a.Log.Warnw("error autoscaling exhibit", "exhibitId", exhibit.Id, "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
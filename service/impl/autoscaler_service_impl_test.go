@@ -0,0 +1,189 @@
+package impl
+
+import (
+	"context"
+	"github.com/docker/docker/api/types/container"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func withReplicas(exhibit domain.Exhibit, replicas domain.Replicas) domain.Exhibit {
+	exhibit.Objects[1].Replicas = &replicas
+	return exhibit
+}
+
+func (s *testServices) newAutoscaler(instance string) AutoscalerServiceImpl {
+	return AutoscalerServiceImpl{
+		Instance:                      instance,
+		State:                         s.State,
+		LockService:                   s.LockService,
+		LoadService:                   &LoadServiceImpl{},
+		ApplicationProvisionerService: s.Provisioner,
+		Provider:                      noop.NewTracerProvider(),
+		Log:                           zap.NewNop().Sugar(),
+	}
+}
+
+func (s *testServices) replicas(t *testing.T, id string) (int, []string) {
+	t.Helper()
+
+	info, err := s.State.GetRuntimeInfo(context.Background(), id)
+	assert.NoError(t, err)
+	return info.RunningReplicas(), info.RelatedContainers
+}
+
+func TestStartApplicationStartsReplicas(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, withReplicas(newTestExhibit("replicas"), domain.Replicas{Count: 3}))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	replicas, containers := s.replicas(t, exhibit.Id)
+	assert.Equal(t, 3, replicas)
+	assert.Len(t, containers, 4)
+	assert.Len(t, s.Eventing.GetStartingEvents(exhibit.Id), exhibit.GetTotalSteps())
+
+	for _, name := range []string{"replicas_web", "replicas_web_2", "replicas_web_3"} {
+		c, ok := s.Runtime.GetContainer(name)
+		assert.True(t, ok, name)
+		assert.True(t, c.Running, name)
+	}
+
+	// replicas that do not run are left out by the resolver
+	assert.NoError(t, s.Runtime.ContainerStop(context.Background(), "replicas_web_2", container.StopOptions{}))
+
+	endpoints, err := s.Resolver.ResolveEndpoints(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 2)
+	assert.Equal(t, 0, endpoints[0].Replica)
+	assert.Equal(t, 2, endpoints[1].Replica)
+	assert.NotEqual(t, endpoints[0].Address, endpoints[1].Address)
+}
+
+func TestScaleApplication(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, withReplicas(newTestExhibit("scale"), domain.Replicas{Max: 3}))
+
+	// exhibits are only scaled while they run
+	assert.ErrorContains(t, s.Provisioner.ScaleApplication(context.Background(), exhibit.Id, 2), "cannot scale")
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	replicas, _ := s.replicas(t, exhibit.Id)
+	assert.Equal(t, 1, replicas)
+
+	assert.NoError(t, s.Provisioner.ScaleApplication(context.Background(), exhibit.Id, 3))
+	replicas, containers := s.replicas(t, exhibit.Id)
+	assert.Equal(t, 3, replicas)
+	assert.Len(t, containers, 4)
+
+	assert.NoError(t, s.Provisioner.ScaleApplication(context.Background(), exhibit.Id, 1))
+	replicas, containers = s.replicas(t, exhibit.Id)
+	assert.Equal(t, 1, replicas)
+	assert.Len(t, containers, 2)
+	for _, name := range []string{"scale_web_2", "scale_web_3"} {
+		_, ok := s.Runtime.GetContainer(name)
+		assert.False(t, ok, name)
+	}
+
+	assert.Error(t, s.Provisioner.ScaleApplication(context.Background(), exhibit.Id, 4))
+	assert.Error(t, s.Provisioner.ScaleApplication(context.Background(), exhibit.Id, 0))
+
+	// stopping and cleaning up removes every replica
+	assert.NoError(t, s.Provisioner.ScaleApplication(context.Background(), exhibit.Id, 2))
+	assert.NoError(t, s.Provisioner.StopApplication(context.Background(), exhibit.Id))
+	assert.NoError(t, s.Provisioner.CleanupApplication(context.Background(), exhibit.Id))
+	_, ok := s.Runtime.GetContainer("scale_web_2")
+	assert.False(t, ok)
+}
+
+func TestAutoscaleFollowsRequestsInFlight(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, withReplicas(newTestExhibit("autoscale"), domain.Replicas{Min: 1, Max: 4, Target: 2}))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	first, second := s.newAutoscaler("first"), s.newAutoscaler("second")
+
+	// the load of all instances counts, the leader scales with it
+	for i := 0; i < 3; i++ {
+		first.LoadService.Begin(exhibit.Id, 0)
+	}
+	done := second.LoadService.Begin(exhibit.Id, 0)
+	assert.NoError(t, second.Report(context.Background()))
+	assert.NoError(t, first.Report(context.Background()))
+	assert.NoError(t, first.Autoscale(context.Background()))
+
+	replicas, _ := s.replicas(t, exhibit.Id)
+	assert.Equal(t, 2, replicas)
+
+	// the peak of a period counts, even if the requests are done by then
+	for i := 0; i < 3; i++ {
+		second.LoadService.Begin(exhibit.Id, 1)
+	}
+	done()
+	assert.NoError(t, second.Report(context.Background()))
+	assert.NoError(t, first.Autoscale(context.Background()))
+
+	replicas, _ = s.replicas(t, exhibit.Id)
+	assert.Equal(t, 4, replicas)
+
+	// replicas are removed one at a time once the load is gone
+	first.LoadService = &LoadServiceImpl{}
+	second.LoadService = &LoadServiceImpl{}
+	assert.NoError(t, first.Report(context.Background()))
+	assert.NoError(t, second.Report(context.Background()))
+	assert.NoError(t, first.Autoscale(context.Background()))
+	replicas, _ = s.replicas(t, exhibit.Id)
+	assert.Equal(t, 3, replicas)
+	assert.NoError(t, first.Autoscale(context.Background()))
+	replicas, _ = s.replicas(t, exhibit.Id)
+	assert.Equal(t, 2, replicas)
+
+	// loads of instances that stopped reporting expire
+	info, err := s.State.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	info.Load["gone"] = domain.InstanceLoad{InFlight: 100, ReportedAt: time.Now().Add(-2 * loadTtl).Unix()}
+	assert.NoError(t, s.State.SetRuntimeInfo(context.Background(), exhibit.Id, info))
+
+	assert.NoError(t, first.Autoscale(context.Background()))
+	replicas, _ = s.replicas(t, exhibit.Id)
+	assert.Equal(t, 1, replicas)
+}
+
+func TestAutoscaleOnlyWhileLeading(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, withReplicas(newTestExhibit("autoscale"), domain.Replicas{Min: 1, Max: 4, Target: 2}))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	first, second := s.newAutoscaler("first"), s.newAutoscaler("second")
+
+	leaderCtx, resign := context.WithCancel(context.Background())
+	lead, err := first.Lead(leaderCtx)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = second.Lead(ctx)
+	assert.Error(t, err)
+
+	// an instance that does not lead anymore still reports, but scales nothing
+	for i := 0; i < 4; i++ {
+		first.LoadService.Begin(exhibit.Id, 0)
+	}
+	assert.NoError(t, first.Report(context.Background()))
+
+	resign()
+	assert.Error(t, first.Autoscale(lead))
+	replicas, _ := s.replicas(t, exhibit.Id)
+	assert.Equal(t, 1, replicas)
+
+	// the next leader takes over with the reported load
+	lead, err = second.Lead(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, second.Autoscale(lead))
+	replicas, _ = s.replicas(t, exhibit.Id)
+	assert.Equal(t, 2, replicas)
+}
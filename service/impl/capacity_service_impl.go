@@ -0,0 +1,174 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/docker/go-units"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	capacitypolicy "museum/config/capacity-policy"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util"
+	"slices"
+	"sort"
+	"time"
+)
+
+// clusterUsage is what the exhibits of the whole cluster use right now
+type clusterUsage struct {
+	// running and memory count the exhibits that are starting, running or stopping
+	running int
+	memory  int64
+	// queue holds the queued exhibits in the order they start in
+	queue []domain.Exhibit
+	// evictable holds the running exhibits that are not scheduled, the least recently accessed one first
+	evictable []domain.Exhibit
+}
+
+type CapacityServiceImpl struct {
+	// MaxRunning and MaxMemory cap the exhibits of the cluster, caps that are zero do not apply
+	MaxRunning  int
+	MaxMemory   int64
+	Policy      capacitypolicy.Policy
+	State       persistence.State
+	LockService service.LockService
+	Provider    trace.TracerProvider
+	Log         *zap.SugaredLogger
+}
+
+func (c CapacityServiceImpl) limited() bool {
+	return c.MaxRunning > 0 || c.MaxMemory > 0
+}
+
+// usage surveys the exhibits of the cluster, leaving out the one with the id except unless it is queued
+func (c CapacityServiceImpl) usage(ctx context.Context, except string) clusterUsage {
+	usage := clusterUsage{}
+	for _, exhibit := range c.State.GetAllExhibits(ctx) {
+		runtimeInfo, err := c.State.GetRuntimeInfo(ctx, exhibit.Id)
+		if err != nil {
+			continue
+		}
+		exhibit.RuntimeInfo = &runtimeInfo
+
+		if runtimeInfo.Status == domain.Queued {
+			usage.queue = append(usage.queue, exhibit)
+			continue
+		}
+		if exhibit.Id == except || !exhibit.Occupies() {
+			continue
+		}
+
+		_, memory := exhibit.Resources()
+		usage.running++
+		usage.memory += memory
+
+		if runtimeInfo.Status == domain.Running && !exhibit.Scheduled(time.Now()) {
+			exhibit.RuntimeInfo.LastAccessed, _ = c.State.GetLastAccessed(ctx, exhibit.Id)
+			usage.evictable = append(usage.evictable, exhibit)
+		}
+	}
+
+	domain.SortQueue(usage.queue)
+	sort.SliceStable(usage.evictable, func(i, j int) bool {
+		return usage.evictable[i].RuntimeInfo.LastAccessed < usage.evictable[j].RuntimeInfo.LastAccessed
+	})
+
+	return usage
+}
+
+// fits reports whether exhibit may start once the exhibits ahead of it in the queue started
+func (c CapacityServiceImpl) fits(usage clusterUsage, ahead []domain.Exhibit, exhibit domain.Exhibit) bool {
+	running := usage.running + len(ahead) + 1
+	_, memory := exhibit.Resources()
+	memory += usage.memory
+	for _, e := range ahead {
+		_, m := e.Resources()
+		memory += m
+	}
+
+	return (c.MaxRunning == 0 || running <= c.MaxRunning) && (c.MaxMemory == 0 || memory <= c.MaxMemory)
+}
+
+func (c CapacityServiceImpl) Admit(ctx context.Context, exhibit domain.Exhibit, start func(ctx context.Context) error) (admission domain.Admission, err error) {
+	subCtx, span := c.Provider.
+		Tracer("capacity-service").
+		Start(ctx, "Admit", trace.WithAttributes(attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	if !c.limited() {
+		return domain.Admission{Admitted: true}, start(subCtx)
+	}
+
+	if _, memory := exhibit.Resources(); c.MaxMemory > 0 && memory > c.MaxMemory {
+		return admission, errors.New("the exhibit needs " + units.BytesSize(float64(memory)) + " of memory, more than all exhibits may use together")
+	}
+
+	// the cluster admits one exhibit at a time, otherwise two instances could take the last room at once
+	lock := c.LockService.GetRwLock(subCtx, "cluster", "running")
+	err = lock.Lock(subCtx)
+	if err != nil {
+		return admission, err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			c.Log.Errorw("error unlocking cluster", "exhibitId", exhibit.Id, "error", e)
+			err = errors.Join(err, e)
+		}
+	}(lock)
+
+	usage := c.usage(subCtx, exhibit.Id)
+
+	// the exhibits that were queued before keep their turn
+	ahead := usage.queue
+	if i := slices.IndexFunc(usage.queue, func(e domain.Exhibit) bool { return e.Id == exhibit.Id }); i >= 0 {
+		ahead = usage.queue[:i]
+	}
+
+	if c.fits(usage, ahead, exhibit) {
+		return domain.Admission{Admitted: true}, start(subCtx)
+	}
+
+	if c.Policy == capacitypolicy.PolicyEvict && len(usage.evictable) > 0 {
+		evict := usage.evictable[0].Id
+		span.AddEvent("evicting exhibit " + evict)
+		c.Log.Infow("cluster is full, evicting the least recently accessed exhibit", "exhibitId", exhibit.Id, "evict", evict)
+		return domain.Admission{Evict: evict}, nil
+	}
+
+	span.AddEvent("cluster is full, queueing exhibit")
+	c.Log.Infow("cluster is full, exhibit is queued", "exhibitId", exhibit.Id, "position", len(ahead)+1)
+	return domain.Admission{Position: len(ahead) + 1}, nil
+}
+
+func (c CapacityServiceImpl) QueuePosition(ctx context.Context, exhibitId string) int {
+	subCtx, span := c.Provider.
+		Tracer("capacity-service").
+		Start(ctx, "QueuePosition", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
+	defer span.End()
+
+	queue := c.usage(subCtx, "").queue
+	return slices.IndexFunc(queue, func(e domain.Exhibit) bool { return e.Id == exhibitId }) + 1
+}
+
+func (c CapacityServiceImpl) Next(ctx context.Context) []string {
+	subCtx, span := c.Provider.
+		Tracer("capacity-service").
+		Start(ctx, "Next")
+	defer span.End()
+
+	usage := c.usage(subCtx, "")
+
+	next := make([]string, 0)
+	for i, exhibit := range usage.queue {
+		if !c.fits(usage, usage.queue[:i], exhibit) {
+			break
+		}
+		next = append(next, exhibit.Id)
+	}
+	return next
+}
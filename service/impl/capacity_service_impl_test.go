@@ -0,0 +1,106 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	capacitypolicy "museum/config/capacity-policy"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func (s *testServices) status(t *testing.T, id string) domain.Status {
+	t.Helper()
+
+	info, err := s.RuntimeInfoService.GetRuntimeInfo(context.Background(), id)
+	assert.NoError(t, err)
+	return info.Status
+}
+
+func TestStartApplicationQueuesWhenClusterIsFull(t *testing.T) {
+	s := newTestServices(t)
+	s.CapacityService.MaxRunning = 1
+
+	first := s.createExhibit(t, newTestExhibit("first"))
+	second := s.createExhibit(t, newTestExhibit("second"))
+	third := s.createExhibit(t, newTestExhibit("third"))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), first.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), second.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), third.Id))
+
+	assert.Equal(t, domain.Running, s.status(t, first.Id))
+	assert.Equal(t, domain.Queued, s.status(t, second.Id))
+	assert.Equal(t, 1, s.CapacityService.QueuePosition(context.Background(), second.Id))
+	assert.Equal(t, 2, s.CapacityService.QueuePosition(context.Background(), third.Id))
+	assert.Equal(t, 0, s.CapacityService.QueuePosition(context.Background(), first.Id))
+	assert.Empty(t, s.CapacityService.Next(context.Background()))
+
+	// the exhibit ahead keeps its turn, even when the one behind it asks again
+	assert.NoError(t, s.Provisioner.StopApplication(context.Background(), first.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), third.Id))
+	assert.Equal(t, domain.Queued, s.status(t, third.Id))
+	assert.Equal(t, []string{second.Id}, s.CapacityService.Next(context.Background()))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), second.Id))
+	assert.Equal(t, domain.Running, s.status(t, second.Id))
+	assert.Equal(t, 1, s.CapacityService.QueuePosition(context.Background(), third.Id))
+}
+
+func TestCleanupRemovesAbandonedExhibitsFromQueue(t *testing.T) {
+	s := newTestServices(t)
+	s.CapacityService.MaxRunning = 1
+
+	running := s.createExhibit(t, newTestExhibit("running"))
+	queued := s.createExhibit(t, newTestExhibit("queued"))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), running.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), queued.Id))
+
+	s.setRuntimeInfo(t, queued.Id, domain.Queued, time.Now().Add(-2*queueAbandonedAfter))
+
+	assert.NoError(t, s.Cleanup.Cleanup())
+
+	assert.Equal(t, domain.Stopped, s.status(t, queued.Id))
+	assert.Equal(t, domain.Running, s.status(t, running.Id))
+}
+
+func TestStartApplicationEvictsLeastRecentlyAccessed(t *testing.T) {
+	s := newTestServices(t)
+	s.CapacityService.MaxRunning = 2
+	s.CapacityService.Policy = capacitypolicy.PolicyEvict
+
+	old := s.createExhibit(t, newTestExhibit("old"))
+	recent := s.createExhibit(t, newTestExhibit("recent"))
+	next := s.createExhibit(t, newTestExhibit("next"))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), old.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), recent.Id))
+	s.setRuntimeInfo(t, old.Id, domain.Running, time.Now().Add(-10*time.Minute))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), next.Id))
+
+	assert.Equal(t, domain.Stopped, s.status(t, old.Id))
+	assert.Equal(t, domain.Running, s.status(t, recent.Id))
+	assert.Equal(t, domain.Running, s.status(t, next.Id))
+	_, ok := s.Runtime.GetContainer("old_web")
+	assert.False(t, ok)
+}
+
+func TestStartApplicationWithinClusterMemory(t *testing.T) {
+	s := newTestServices(t)
+	s.CapacityService.MaxMemory = 1 << 30
+
+	// each object declares 256m, so an exhibit needs 512m
+	first := s.createExhibit(t, withCollection(newTestExhibit("first"), domain.DefaultCollection, 0, "256m"))
+	second := s.createExhibit(t, withCollection(newTestExhibit("second"), domain.DefaultCollection, 0, "256m"))
+	third := s.createExhibit(t, withCollection(newTestExhibit("third"), domain.DefaultCollection, 0, "256m"))
+	huge := s.createExhibit(t, withCollection(newTestExhibit("huge"), domain.DefaultCollection, 0, "1g"))
+
+	for _, e := range []domain.Exhibit{first, second, third} {
+		assert.NoError(t, s.Provisioner.StartApplication(context.Background(), e.Id))
+	}
+	assert.Equal(t, domain.Running, s.status(t, second.Id))
+	assert.Equal(t, domain.Queued, s.status(t, third.Id))
+
+	assert.ErrorContains(t, s.Provisioner.StartApplication(context.Background(), huge.Id), "more than all exhibits may use")
+}
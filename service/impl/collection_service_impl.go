@@ -0,0 +1,188 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util"
+	"slices"
+	"sort"
+)
+
+// collectionLock returns the lock that partitions the exhibits by their collection, so exhibits of one
+// collection never wait for those of another one
+func collectionLock(ctx context.Context, lockService service.LockService, collection string) util.RwErrMutex {
+	return lockService.GetRwLock(ctx, "collections/"+collection, "exhibits")
+}
+
+// lockCollections acquires the locks of all collections, always in the same order so that callers
+// do not deadlock each other. The returned function releases them again.
+func lockCollections(ctx context.Context, lockService service.LockService, collections []string, write bool) (func() error, error) {
+	collections = slices.Clone(collections)
+	sort.Strings(collections)
+	collections = slices.Compact(collections)
+
+	locks := make([]util.RwErrMutex, 0, len(collections))
+	unlock := func() error {
+		var errs []error
+		for i := len(locks) - 1; i >= 0; i-- {
+			if write {
+				errs = append(errs, locks[i].Unlock())
+			} else {
+				errs = append(errs, locks[i].RUnlock())
+			}
+		}
+		return errors.Join(errs...)
+	}
+
+	for _, collection := range collections {
+		lock := collectionLock(ctx, lockService, collection)
+
+		var err error
+		if write {
+			err = lock.Lock(ctx)
+		} else {
+			err = lock.RLock(ctx)
+		}
+		if err != nil {
+			return nil, errors.Join(err, unlock())
+		}
+
+		locks = append(locks, lock)
+	}
+
+	return unlock, nil
+}
+
+// collectionsOf returns the collections the exhibits belong to
+func collectionsOf(exhibits []domain.Exhibit) []string {
+	collections := make([]string, 0)
+	for _, exhibit := range exhibits {
+		collections = append(collections, exhibit.CollectionName())
+	}
+	return collections
+}
+
+type CollectionServiceImpl struct {
+	Collections []domain.Collection
+	State       persistence.State
+	LockService service.LockService
+	Provider    trace.TracerProvider
+	Log         *zap.SugaredLogger
+}
+
+func (c CollectionServiceImpl) GetCollections() []domain.Collection {
+	return c.Collections
+}
+
+func (c CollectionServiceImpl) GetCollection(name string) (domain.Collection, error) {
+	for _, collection := range c.Collections {
+		if collection.Name == name {
+			return collection, nil
+		}
+	}
+	return domain.Collection{}, errors.New("collection " + name + " does not exist")
+}
+
+// usage sums up what the exhibits of a collection use, leaving out the exhibits with the ids in except
+func (c CollectionServiceImpl) usage(ctx context.Context, name string, except ...string) domain.CollectionUsage {
+	usage := domain.CollectionUsage{}
+	for _, exhibit := range c.State.GetAllExhibits(ctx) {
+		if exhibit.CollectionName() != name || slices.Contains(except, exhibit.Id) {
+			continue
+		}
+		usage.Exhibits++
+
+		runtimeInfo, err := c.State.GetRuntimeInfo(ctx, exhibit.Id)
+		if err != nil {
+			continue
+		}
+		exhibit.RuntimeInfo = &runtimeInfo
+
+		if exhibit.Occupies() {
+			cpus, memory := exhibit.Resources()
+			usage.Running++
+			usage.Cpus += cpus
+			usage.Memory += memory
+		}
+	}
+	return usage
+}
+
+func (c CollectionServiceImpl) GetUsage(ctx context.Context, name string) domain.CollectionUsage {
+	subCtx, span := c.Provider.
+		Tracer("collection-service").
+		Start(ctx, "GetUsage", trace.WithAttributes(attribute.String("collection", name)))
+	defer span.End()
+
+	return c.usage(subCtx, name)
+}
+
+func (c CollectionServiceImpl) CheckCreate(ctx context.Context, exhibit domain.Exhibit, replaces ...string) error {
+	subCtx, span := c.Provider.
+		Tracer("collection-service").
+		Start(ctx, "CheckCreate", trace.WithAttributes(attribute.String("collection", exhibit.CollectionName()), attribute.String("name", exhibit.Name)))
+	defer span.End()
+
+	collection, err := c.GetCollection(exhibit.CollectionName())
+	if err != nil {
+		return err
+	}
+
+	// a budget can only be kept if every container is limited
+	if collection.Quota.Budgeted() {
+		for _, o := range exhibit.Objects {
+			if o.Resources == nil || (collection.Quota.Cpus > 0 && o.Resources.Cpus == 0) || (collection.Quota.Memory != "" && o.Resources.MemoryBytes() == 0) {
+				return errors.New("object " + o.Name + " must declare the resources it uses, the collection " + collection.Name + " has a cpu or memory quota")
+			}
+		}
+	}
+
+	err = collection.Quota.AllowsCreate(c.usage(subCtx, collection.Name, replaces...))
+	if err != nil {
+		return errors.New("cannot create exhibit in collection " + collection.Name + ": " + err.Error())
+	}
+
+	return nil
+}
+
+func (c CollectionServiceImpl) Admit(ctx context.Context, exhibit domain.Exhibit, start func(ctx context.Context) error) (err error) {
+	subCtx, span := c.Provider.
+		Tracer("collection-service").
+		Start(ctx, "Admit", trace.WithAttributes(attribute.String("collection", exhibit.CollectionName()), attribute.String("exhibitId", exhibit.Id)))
+	defer span.End()
+
+	collection, err := c.GetCollection(exhibit.CollectionName())
+	if err != nil {
+		return err
+	}
+
+	// exhibits of other collections are admitted in parallel, they do not share a quota
+	lock := c.LockService.GetRwLock(subCtx, "collections/"+collection.Name, "running")
+	err = lock.Lock(subCtx)
+	if err != nil {
+		return err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			c.Log.Errorw("error unlocking collection", "collection", collection.Name, "error", e)
+			err = errors.Join(err, e)
+		}
+	}(lock)
+
+	err = collection.Quota.AllowsStart(c.usage(subCtx, collection.Name, exhibit.Id), exhibit)
+	if err != nil {
+		span.RecordError(err)
+		c.Log.Infow("exhibit was not admitted", "collection", collection.Name, "exhibitId", exhibit.Id, "reason", err)
+		return errors.New("cannot start exhibit in collection " + collection.Name + ": " + err.Error())
+	}
+
+	return start(subCtx)
+}
@@ -0,0 +1,115 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+// withCollection puts the exhibit into collection and limits each of its objects to cpus and memory
+func withCollection(exhibit domain.Exhibit, collection string, cpus float64, memory string) domain.Exhibit {
+	exhibit.Collection = collection
+	for i := range exhibit.Objects {
+		exhibit.Objects[i].Resources = &domain.Resources{Cpus: cpus, Memory: memory}
+	}
+	return exhibit
+}
+
+func TestCreateExhibitInCollection(t *testing.T) {
+	s := newTestServices(t)
+	s.CollectionService.Collections = append(s.CollectionService.Collections, domain.Collection{Name: "physics", Quota: domain.Quota{MaxExhibits: 1}})
+
+	created := s.createExhibit(t, withCollection(newTestExhibit("first"), "physics", 0, ""))
+	assert.Equal(t, "physics", created.Collection)
+	assert.Equal(t, domain.DefaultCollection, s.createExhibit(t, newTestExhibit("other")).Collection)
+
+	_, err := s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: withCollection(newTestExhibit("second"), "physics", 0, "")})
+	assert.ErrorContains(t, err, "maximum of 1 exhibits")
+
+	_, err = s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: withCollection(newTestExhibit("unknown"), "chemistry", 0, "")})
+	assert.ErrorContains(t, err, "does not exist")
+}
+
+func TestDeleteExhibitWaitsForCollection(t *testing.T) {
+	s := newTestServices(t)
+	s.CollectionService.Collections = append(s.CollectionService.Collections, domain.Collection{Name: "physics", Quota: domain.Quota{MaxExhibits: 1}})
+	exhibit := s.createExhibit(t, withCollection(newTestExhibit("first"), "physics", 0, ""))
+
+	// a creation that checks the quota holds the lock of the collection
+	ctx := context.Background()
+	lock := collectionLock(ctx, s.LockService, "physics")
+	assert.NoError(t, lock.Lock(ctx))
+
+	deleted := make(chan error, 1)
+	go func() {
+		deleted <- s.ExhibitService.DeleteExhibitById(ctx, exhibit.Id)
+	}()
+
+	select {
+	case <-deleted:
+		t.Fatal("the exhibit was deleted while the collection was locked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	assert.NoError(t, lock.Unlock())
+	assert.NoError(t, <-deleted)
+
+	// the deletion made room in the collection
+	s.createExhibit(t, withCollection(newTestExhibit("second"), "physics", 0, ""))
+}
+
+func TestCreateExhibitRequiresCuratorOfCollection(t *testing.T) {
+	s := newTestServices(t)
+	s.CollectionService.Collections = append(s.CollectionService.Collections, domain.Collection{Name: "physics"})
+
+	ctx := domain.WithPrincipal(context.Background(), domain.NewPrincipal("alice", []string{"curator@physics"}, nil))
+
+	_, err := s.ExhibitService.CreateExhibit(ctx, domain.CreateExhibit{Exhibit: newTestExhibit("default")})
+	assert.ErrorAs(t, err, &domain.ForbiddenError{})
+
+	_, err = s.ExhibitService.CreateExhibit(ctx, domain.CreateExhibit{Exhibit: withCollection(newTestExhibit("physics"), "physics", 0, "")})
+	assert.NoError(t, err)
+}
+
+func TestCollectionBudgetRequiresResources(t *testing.T) {
+	s := newTestServices(t)
+	s.CollectionService.Collections = append(s.CollectionService.Collections, domain.Collection{Name: "physics", Quota: domain.Quota{Memory: "1g"}})
+
+	_, err := s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: withCollection(newTestExhibit("unlimited"), "physics", 1, "")})
+	assert.ErrorContains(t, err, "must declare the resources")
+
+	s.createExhibit(t, withCollection(newTestExhibit("limited"), "physics", 0, "256m"))
+}
+
+func TestStartApplicationWithinQuota(t *testing.T) {
+	s := newTestServices(t)
+	s.CollectionService.Collections = append(s.CollectionService.Collections,
+		domain.Collection{Name: "physics", Quota: domain.Quota{MaxRunning: 1}},
+		domain.Collection{Name: "chemistry", Quota: domain.Quota{Cpus: 3}})
+
+	first := s.createExhibit(t, withCollection(newTestExhibit("first"), "physics", 0, ""))
+	second := s.createExhibit(t, withCollection(newTestExhibit("second"), "physics", 0, ""))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), first.Id))
+	assert.ErrorContains(t, s.Provisioner.StartApplication(context.Background(), second.Id), "maximum of 1 exhibits")
+
+	// the refused exhibit was not touched
+	refused, err := s.ExhibitService.GetExhibitById(context.Background(), second.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.NotCreated, refused.RuntimeInfo.Status)
+
+	// each exhibit of chemistry uses 2 cpus, the budget only fits one of them
+	small := s.createExhibit(t, withCollection(newTestExhibit("small"), "chemistry", 1, ""))
+	big := s.createExhibit(t, withCollection(newTestExhibit("big"), "chemistry", 1, ""))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), small.Id))
+	assert.ErrorContains(t, s.Provisioner.StartApplication(context.Background(), big.Id), "cpus")
+
+	usage := s.CollectionService.GetUsage(context.Background(), "chemistry")
+	assert.Equal(t, domain.CollectionUsage{Exhibits: 2, Running: 1, Cpus: 2}, usage)
+
+	// stopping frees the quota again
+	assert.NoError(t, s.Provisioner.StopApplication(context.Background(), first.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), second.Id))
+}
@@ -15,17 +15,23 @@ import (
 	"museum/persistence"
 	service "museum/service/interface"
 	"museum/util"
+	"slices"
 	"strconv"
 	"syscall"
 	"time"
 )
 
+// maxEvictions is how many running exhibits one start may evict before it waits in the queue instead
+const maxEvictions = 3
+
 type DockerApplicationProvisionerService struct {
 	ExhibitService              service.ExhibitService
 	LivecheckFactoryService     service.LivecheckFactoryService
 	EnvironmentTemplateResolver service.EnvironmentTemplateResolverService
-	Client                      *docker.Client
+	Client                      service.ContainerRuntime
 	LockService                 service.LockService
+	CollectionService           service.CollectionService
+	CapacityService             service.CapacityService
 	RuntimeInfoService          service.RuntimeInfoService
 	LastAccessedService         service.LastAccessedService
 	Eventing                    persistence.Eventing
@@ -33,6 +39,7 @@ type DockerApplicationProvisionerService struct {
 	Provider                    trace.TracerProvider
 	Config                      config.Config
 	VolumeProvisionerFactory    service.VolumeProvisionerFactoryService
+	LogService                  service.LogService
 }
 
 func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx context.Context, exhibit *domain.Exhibit) error {
@@ -79,12 +86,22 @@ func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx cont
 	// create a container on the swarm for each object
 	idx := 0
 	for _, o := range sortedObjects {
-		err := d.startExhibitObject(ctx, exhibit, o, networkInspect, idx, &stepCount, &containerNameMapping)
+		// replicas share the steps of their object, they only add to its total
+		var err error
+		replica := 0
+		for ; replica < o.Replicas.Initial(); replica++ {
+			err = d.startExhibitObject(ctx, exhibit, o.Replica(replica), networkInspect, idx, &stepCount, &containerNameMapping)
+			if err != nil {
+				break
+			}
+		}
 		if err != nil {
 			d.Log.Warnw("error starting exhibit object", "exhibit", exhibit.Name, "object", o.Name, "error", err)
 
 			// set the status to stopped
 			exhibit.RuntimeInfo.Status = domain.Stopped
+			exhibit.RuntimeInfo.Failure = startupFailure(o, err)
+			d.keepStartupLogs(ctx, exhibit, sortedObjects[:idx], o, replica, err)
 			e := d.RuntimeInfoService.SetRuntimeInfo(ctx, exhibit.Id, *exhibit.RuntimeInfo)
 			if e != nil {
 				d.Log.Errorw("error setting runtime info", "exhibit", exhibit.Name, "error", e)
@@ -94,6 +111,9 @@ func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx cont
 			d.Eventing.DispatchExhibitStoppingEvent(ctx, *exhibit)
 			return err
 		}
+		if o.Replicas != nil {
+			exhibit.RuntimeInfo.Replicas = o.Replicas.Initial()
+		}
 		idx++
 	}
 
@@ -102,7 +122,74 @@ func (d DockerApplicationProvisionerService) startApplicationInsideLock(ctx cont
 	return nil
 }
 
-func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Context, exhibit *domain.Exhibit, object domain.Object, network network.Inspect, idx int, stepCount *int, templateContainer *map[string]string) error {
+// startupError is an error of an object with the step it failed at and the end of its logs,
+// which are read before its container is removed
+type startupError struct {
+	step domain.ObjectStartingStep
+	logs string
+	err  error
+}
+
+func (s startupError) Error() string {
+	return s.err.Error()
+}
+
+func (s startupError) Unwrap() error {
+	return s.err
+}
+
+// startupFailure describes why an object failed to start, the failed page shows it to visitors
+func startupFailure(object domain.Object, err error) *domain.StartupFailure {
+	failure := &domain.StartupFailure{Object: object.Name, Error: err.Error(), At: time.Now().Unix()}
+
+	var s startupError
+	if errors.As(err, &s) {
+		failure.Step = s.step.String()
+	}
+	return failure
+}
+
+// keepStartupLogs keeps the end of the logs of the replica that failed to start and of the replicas started before it,
+// their containers are removed once the exhibit stopped. The start failed anyway, so errors are only logged.
+func (d DockerApplicationProvisionerService) keepStartupLogs(ctx context.Context, exhibit *domain.Exhibit, started []domain.Object, failed domain.Object, failedReplica int, err error) {
+	tail := func(o domain.Object, replicas int) []string {
+		logs := make([]string, 0, replicas)
+		for replica := 0; replica < replicas; replica++ {
+			logs = append(logs, d.LogService.Tail(ctx, exhibit.Name+"_"+o.Replica(replica).Name, domain.StartupLogTail))
+		}
+		return logs
+	}
+
+	logs := domain.StartupLogs{At: time.Now().Unix(), Objects: make(map[string][]string)}
+	for _, o := range started {
+		logs.Objects[o.Name] = tail(o, o.Replicas.Initial())
+	}
+
+	// replicas that failed before their container was started have not written anything
+	failedLogs := tail(failed, failedReplica)
+	var s startupError
+	if errors.As(err, &s) && s.step >= domain.ObjectStartingStepStart {
+		failedLogs = append(failedLogs, s.logs)
+	}
+	if len(failedLogs) > 0 {
+		logs.Objects[failed.Name] = failedLogs
+	}
+
+	e := d.LogService.SetStartupLogs(ctx, exhibit.Id, logs)
+	if e != nil {
+		d.Log.Warnw("error keeping startup logs", "exhibit", exhibit.Name, "error", e)
+	}
+}
+
+func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Context, exhibit *domain.Exhibit, object domain.Object, network network.Inspect, idx int, stepCount *int, templateContainer *map[string]string) (err error) {
+	step := domain.ObjectStartingStepClean
+	logs := ""
+	defer func() {
+		if err != nil {
+			err = startupError{step: step, logs: logs, err: err}
+		}
+	}()
+
 	containerImage := object.Image + ":" + object.Label
 	containerConfig := &container.Config{
 		Image: containerImage,
@@ -150,6 +237,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 		}
 	}
 
+	step = domain.ObjectStartingStepCreate
 	span.AddEvent("creating container")
 	d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
 		Object: idx,
@@ -162,9 +250,18 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 
 	var hostConfig *container.HostConfig = nil
 
+	// limit the container, the quota of the collection relies on it
+	if object.Resources != nil {
+		hostConfig = &container.HostConfig{}
+		hostConfig.NanoCPUs = int64(object.Resources.Cpus * 1e9)
+		hostConfig.Memory = object.Resources.MemoryBytes()
+	}
+
 	// setup container mounts
 	if len(object.Mounts) != 0 {
-		hostConfig = &container.HostConfig{}
+		if hostConfig == nil {
+			hostConfig = &container.HostConfig{}
+		}
 
 		for containerVolume, containerMount := range object.Mounts {
 			// find corresponding volume
@@ -222,6 +319,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 		return err
 	}
 
+	step = domain.ObjectStartingStepStart
 	span.AddEvent("starting container")
 	d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
 		Object: idx,
@@ -232,6 +330,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	err = d.Client.ContainerStart(ctx, create.ID, container.StartOptions{})
 	if err != nil {
 		d.Log.Errorw("error starting container", "container", name, "exhibitId", exhibit.Id, "error", err)
+		logs = d.LogService.Tail(ctx, create.ID, domain.StartupLogTail)
 		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
 			Object: idx,
 			Step:   domain.ObjectStartingStepStart,
@@ -245,6 +344,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 	}
 
 	if object.Livecheck != nil {
+		step = domain.ObjectStartingStepLivecheck
 		span.AddEvent("doing livecheck")
 		d.Eventing.DispatchExhibitStartingEvent(ctx, *exhibit, stepCount, domain.ExhibitStartingStep{
 			Object: idx,
@@ -261,6 +361,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 			})
 
 			d.Log.Debugw("livecheck failed, cleaning up container", "container", name, "exhibitId", exhibit.Id)
+			logs = d.LogService.Tail(ctx, create.ID, domain.StartupLogTail)
 			e := d.cleanupContainer(ctx, exhibit, create, name, object)
 			if e != nil {
 				return e
@@ -272,6 +373,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 
 	exhibit.RuntimeInfo.RelatedContainers = append(exhibit.RuntimeInfo.RelatedContainers, create.ID)
 
+	step = domain.ObjectStartingStepReady
 	// TODO: expose a random port and cache that instead of the container IP
 	// get container ip
 	inspect, err = d.Client.ContainerInspect(ctx, create.ID)
@@ -283,6 +385,7 @@ func (d DockerApplicationProvisionerService) startExhibitObject(ctx context.Cont
 			Error:  err,
 		})
 
+		logs = d.LogService.Tail(ctx, create.ID, domain.StartupLogTail)
 		e := d.cleanupContainer(ctx, exhibit, create, name, object)
 		if e != nil {
 			return e
@@ -432,7 +535,36 @@ func (d DockerApplicationProvisionerService) doLivecheck(ctx context.Context, ex
 	return nil
 }
 
-func (d DockerApplicationProvisionerService) applicationStartingStep(ctx context.Context, exhibitId string) (err error) {
+// evict stops a running exhibit to make room for another one
+func (d DockerApplicationProvisionerService) evict(ctx context.Context, exhibitId string) error {
+	d.Log.Infow("evicting exhibit", "exhibitId", exhibitId)
+
+	err := d.StopApplication(ctx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	return d.CleanupApplication(ctx, exhibitId)
+}
+
+// queue lets the exhibit wait for room in the cluster, every further start keeps it in the queue
+func (d DockerApplicationProvisionerService) queue(ctx context.Context, exhibit *domain.Exhibit) error {
+	if exhibit.RuntimeInfo.Status != domain.Queued {
+		exhibit.RuntimeInfo.Status = domain.Queued
+		exhibit.RuntimeInfo.QueuedAt = time.Now().UnixNano()
+
+		err := d.RuntimeInfoService.SetRuntimeInfo(ctx, exhibit.Id, *exhibit.RuntimeInfo)
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.LastAccessedService.SetLastAccessed(ctx, exhibit.Id, time.Now().Unix())
+}
+
+// applicationStartingStep marks the exhibit as starting, done is true if there is nothing left to start,
+// because the exhibit is already running or waits in the queue
+func (d DockerApplicationProvisionerService) applicationStartingStep(ctx context.Context, exhibitId string) (done bool, err error) {
 	subCtx, span := d.Provider.
 		Tracer("docker provisioner").
 		Start(ctx, "applicationStartingStep", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
@@ -440,15 +572,15 @@ func (d DockerApplicationProvisionerService) applicationStartingStep(ctx context
 
 	exhibit, err := d.ExhibitService.GetExhibitById(subCtx, exhibitId)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	span.AddEvent("acquiring runtime_info lock")
 
 	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
+	err = lock.Lock(subCtx)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	span.AddEvent("runtime_info lock acquired")
@@ -465,31 +597,58 @@ func (d DockerApplicationProvisionerService) applicationStartingStep(ctx context
 
 	// check that exhibit is not already started after lock is acquired
 	if exhibit.RuntimeInfo.Status == domain.Running {
-		return nil
+		return true, nil
 	}
 
-	if exhibit.RuntimeInfo.Status != domain.Stopped && exhibit.RuntimeInfo.Status != domain.NotCreated {
-		return errors.New(string("cannot start application in state " + exhibit.RuntimeInfo.Status))
+	if exhibit.RuntimeInfo.Status != domain.Stopped && exhibit.RuntimeInfo.Status != domain.NotCreated && exhibit.RuntimeInfo.Status != domain.Queued {
+		return false, errors.New(string("cannot start application in state " + exhibit.RuntimeInfo.Status))
 	}
 
 	span.AddEvent("setting exhibit status to starting")
 
-	exhibit.RuntimeInfo.Status = domain.Starting
-	exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
+	// the cluster has to have room for the exhibit, then it counts against the quota of its collection once it is starting
+	for evictions := 0; ; evictions++ {
+		admission, err := d.CapacityService.Admit(subCtx, exhibit, func(ctx context.Context) error {
+			return d.CollectionService.Admit(ctx, exhibit, func(ctx context.Context) error {
+				exhibit.RuntimeInfo.Status = domain.Starting
+				exhibit.RuntimeInfo.QueuedAt = 0
+				exhibit.RuntimeInfo.RelatedContainers = make([]string, 0)
+				exhibit.RuntimeInfo.Replicas = 0
+				exhibit.RuntimeInfo.Load = nil
+				exhibit.RuntimeInfo.Failure = nil
+
+				return d.RuntimeInfoService.SetRuntimeInfo(ctx, exhibitId, *exhibit.RuntimeInfo)
+			})
+		})
+		if err != nil {
+			return false, err
+		}
 
-	err = d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
-	if err != nil {
-		return err
+		if admission.Admitted {
+			break
+		}
+
+		// other instances may take the room we made, so eviction is only tried a few times
+		if admission.Evict != "" && evictions < maxEvictions {
+			err = d.evict(subCtx, admission.Evict)
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		span.AddEvent("exhibit queued at position " + strconv.Itoa(admission.Position))
+		return true, d.queue(subCtx, &exhibit)
 	}
 
 	span.AddEvent("exhibit status set to starting")
 
 	err = d.LastAccessedService.SetLastAccessed(subCtx, exhibitId, time.Now().Unix())
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	return false, nil
 }
 
 func (d DockerApplicationProvisionerService) applicationRunningStep(ctx context.Context, exhibitId string) (err error) {
@@ -501,7 +660,7 @@ func (d DockerApplicationProvisionerService) applicationRunningStep(ctx context.
 	span.AddEvent("acquiring runtime_info lock")
 
 	exhibitRlock := d.LockService.GetRwLock(subCtx, exhibitId, "exhibit")
-	err = exhibitRlock.RLock()
+	err = exhibitRlock.RLock(subCtx)
 	if err != nil {
 		d.Log.Errorw("error locking exhibit", "exhibitId", exhibitId, "error", err)
 		return err
@@ -525,7 +684,7 @@ func (d DockerApplicationProvisionerService) applicationRunningStep(ctx context.
 	span.AddEvent("acquiring runtime_info lock")
 
 	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
+	err = lock.Lock(subCtx)
 	if err != nil {
 		return err
 	}
@@ -560,12 +719,18 @@ func (d DockerApplicationProvisionerService) StartApplication(ctx context.Contex
 		Start(ctx, "StartApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId)))
 	defer span.End()
 
-	err := d.applicationStartingStep(subCtx, exhibitId)
+	done, err := d.applicationStartingStep(subCtx, exhibitId)
 	if err != nil {
 		d.Log.Errorw("error starting application", "exhibitId", exhibitId, "error", err)
 		return err
 	}
 
+	// another request started the application while we were waiting for the lock, or it has to wait for room
+	if done {
+		span.AddEvent("application already running or queued")
+		return nil
+	}
+
 	err = d.applicationRunningStep(subCtx, exhibitId)
 	if err != nil {
 		d.Log.Errorw("error starting application", "exhibitId", exhibitId, "error", err)
@@ -591,7 +756,7 @@ func (d DockerApplicationProvisionerService) applicationStoppingStep(ctx context
 	span.AddEvent("acquiring runtime_info lock")
 
 	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
+	err = lock.Lock(subCtx)
 	if err != nil {
 		return err
 	}
@@ -613,7 +778,15 @@ func (d DockerApplicationProvisionerService) applicationStoppingStep(ctx context
 		return nil
 	}
 
-	if exhibit.RuntimeInfo.Status != domain.Running {
+	// queued exhibits have nothing to stop, they just leave the queue
+	if exhibit.RuntimeInfo.Status == domain.Queued {
+		exhibit.RuntimeInfo.Status = domain.Stopped
+		exhibit.RuntimeInfo.QueuedAt = 0
+		return d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
+	}
+
+	// exhibits that are starting for too long are stopped by the cleanup service
+	if exhibit.RuntimeInfo.Status != domain.Running && exhibit.RuntimeInfo.Status != domain.Starting {
 		return errors.New(string("cannot stop application in state " + exhibit.RuntimeInfo.Status))
 	}
 
@@ -642,7 +815,7 @@ func (d DockerApplicationProvisionerService) applicationStoppedStep(ctx context.
 	span.AddEvent("acquiring runtime_info lock")
 
 	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
+	err = lock.Lock(subCtx)
 	if err != nil {
 		return err
 	}
@@ -703,6 +876,106 @@ func (d DockerApplicationProvisionerService) StopApplication(ctx context.Context
 	return nil
 }
 
+func (d DockerApplicationProvisionerService) ScaleApplication(ctx context.Context, exhibitId string, replicas int) (err error) {
+	subCtx, span := d.Provider.
+		Tracer("docker provisioner").
+		Start(ctx, "ScaleApplication", trace.WithAttributes(attribute.String("exhibitId", exhibitId), attribute.Int("replicas", replicas)))
+	defer span.End()
+
+	span.AddEvent("acquiring runtime_info lock")
+
+	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
+	err = lock.Lock(subCtx)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("runtime_info lock acquired")
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			d.Log.Errorw("error unlocking runtime_info", "exhibitId", exhibitId, "error", e)
+			err = errors.Join(err, e)
+		}
+	}(lock)
+
+	// the runtime info is read inside the lock, other instances may have scaled the exhibit in the meantime
+	exhibit, err := d.ExhibitService.GetCachedExhibitById(subCtx, exhibitId)
+	if err != nil {
+		return err
+	}
+
+	object, ok := exhibit.ExposedObject()
+	if !ok || object.Replicas == nil {
+		return errors.New("exhibit does not have replicas")
+	}
+
+	if replicas < 1 || replicas > object.Replicas.Most() {
+		return errors.New("exhibit can have between 1 and " + strconv.Itoa(object.Replicas.Most()) + " replicas")
+	}
+
+	if exhibit.RuntimeInfo.Status != domain.Running {
+		return errors.New(string("cannot scale application in state " + exhibit.RuntimeInfo.Status))
+	}
+
+	current := exhibit.RuntimeInfo.RunningReplicas()
+	if current == replicas {
+		return nil
+	}
+
+	d.Log.Infow("scaling exhibit", "exhibitId", exhibitId, "from", current, "to", replicas)
+
+	// the running replicas are saved even if scaling fails halfway
+	defer func() {
+		e := d.RuntimeInfoService.SetRuntimeInfo(subCtx, exhibitId, *exhibit.RuntimeInfo)
+		if e != nil {
+			err = errors.Join(err, e)
+		}
+	}()
+
+	networkInspect, err := d.Client.NetworkInspect(subCtx, exhibit.Name, network.InspectOptions{})
+	if err != nil {
+		return err
+	}
+
+	idx := slices.IndexFunc(exhibit.Objects, func(o domain.Object) bool { return o.Name == object.Name })
+	for replica := current; replica < replicas; replica++ {
+		span.AddEvent("starting replica " + strconv.Itoa(replica+1))
+
+		stepCount := 0
+		err = d.startExhibitObject(subCtx, &exhibit, object.Replica(replica), networkInspect, idx, &stepCount, &map[string]string{})
+		if err != nil {
+			return err
+		}
+		exhibit.RuntimeInfo.Replicas = replica + 1
+	}
+
+	// the replicas are removed from the last one on, so the names of the remaining ones stay without gaps
+	for replica := current - 1; replica >= replicas; replica-- {
+		name := exhibit.Name + "_" + object.Replica(replica).Name
+		span.AddEvent("removing container " + name)
+
+		inspect, err := d.Client.ContainerInspect(subCtx, name)
+		if err == nil {
+			err = d.Client.ContainerStop(subCtx, inspect.ID, container.StopOptions{})
+			if err == nil {
+				err = d.Client.ContainerRemove(subCtx, inspect.ID, container.RemoveOptions{})
+			}
+			if err != nil {
+				return err
+			}
+			exhibit.RuntimeInfo.RelatedContainers = slices.DeleteFunc(exhibit.RuntimeInfo.RelatedContainers, func(id string) bool { return id == inspect.ID })
+		} else if !docker.IsErrNotFound(err) {
+			return err
+		}
+
+		exhibit.RuntimeInfo.Replicas = replica
+	}
+
+	return nil
+}
+
 func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Context, exhibitId string) (err error) {
 	subCtx, span := d.Provider.
 		Tracer("docker provisioner").
@@ -717,7 +990,7 @@ func (d DockerApplicationProvisionerService) CleanupApplication(ctx context.Cont
 	span.AddEvent("acquiring runtime_info lock")
 
 	lock := d.LockService.GetRwLock(subCtx, exhibitId, "runtime_info")
-	err = lock.Lock()
+	err = lock.Lock(subCtx)
 	if err != nil {
 		return err
 	}
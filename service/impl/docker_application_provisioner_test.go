@@ -0,0 +1,166 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func TestStartApplication(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("start"))
+
+	err := s.Provisioner.StartApplication(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+
+	started, err := s.ExhibitService.GetExhibitById(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Running, started.RuntimeInfo.Status)
+	assert.Equal(t, "start_web", started.RuntimeInfo.Hostname)
+	assert.Len(t, started.RuntimeInfo.RelatedContainers, 2)
+
+	for _, name := range []string{"start_db", "start_web"} {
+		c, ok := s.Runtime.GetContainer(name)
+		assert.True(t, ok, name)
+		assert.True(t, c.Running, name)
+		assert.Equal(t, []string{"start"}, c.Networks, name)
+	}
+
+	// every step of every object is reported, without errors
+	events := s.Eventing.GetStartingEvents(exhibit.Id)
+	assert.Len(t, events, exhibit.GetTotalSteps())
+	for _, e := range events {
+		assert.Empty(t, e.Error)
+	}
+	assert.Equal(t, "web", events[len(events)-1].Object)
+	assert.Equal(t, domain.ObjectStartingStepReady.String(), events[len(events)-1].Step)
+}
+
+func TestStartApplicationAlreadyRunning(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("running"))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+	calls := len(s.Runtime.GetCalls())
+
+	// a second start must not touch the containers
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+	assert.Len(t, s.Runtime.GetCalls(), calls)
+}
+
+func TestStartApplicationCreateFails(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("create-fails"))
+
+	s.Runtime.FailOn("ContainerCreate", errors.New("no space left on device"), "create-fails_web")
+
+	err := s.Provisioner.StartApplication(context.Background(), exhibit.Id)
+	assert.EqualError(t, err, "no space left on device")
+
+	info, err := s.RuntimeInfoService.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stopped, info.Status)
+	assert.Empty(t, info.RelatedContainers)
+
+	// the failure is kept for the failed page until the next start
+	assert.Equal(t, "web", info.Failure.Object)
+	assert.Equal(t, domain.ObjectStartingStepCreate.String(), info.Failure.Step)
+	assert.Equal(t, "no space left on device", info.Failure.Error)
+	assert.True(t, info.Failure.Recent(time.Now()))
+
+	// the failing step is reported to the loading page and the exhibit is announced as stopping
+	failed := false
+	for _, e := range s.Eventing.GetStartingEvents(exhibit.Id) {
+		if e.Error != "" {
+			failed = true
+			assert.Equal(t, "web", e.Object)
+			assert.Equal(t, domain.ObjectStartingStepCreate.String(), e.Step)
+		}
+	}
+	assert.True(t, failed)
+	assert.Len(t, s.Eventing.GetStoppingEvents(exhibit.Id), 1)
+
+	// the exhibit can be started once the runtime recovers
+	s.Runtime.Reset()
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	info, err = s.RuntimeInfoService.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Nil(t, info.Failure)
+}
+
+func TestStartApplicationLivecheckNeverPasses(t *testing.T) {
+	s := newTestServices(t)
+
+	exhibit := newTestExhibit("livecheck")
+	exhibit.Objects[0].Livecheck = &domain.Livecheck{
+		Type:   domain.LivecheckTypeExec,
+		Config: domain.StringMap{"command": "pg_isready", "maxRetries": "3", "interval": "1ms"},
+	}
+	exhibit = s.createExhibit(t, exhibit)
+
+	s.Runtime.ExecExitCode = func(containerName string, cmd []string) int {
+		return 1
+	}
+
+	err := s.Provisioner.StartApplication(context.Background(), exhibit.Id)
+	assert.EqualError(t, err, "livecheck failed")
+
+	info, err := s.RuntimeInfoService.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stopped, info.Status)
+	assert.Equal(t, "db", info.Failure.Object)
+	assert.Equal(t, domain.ObjectStartingStepLivecheck.String(), info.Failure.Step)
+
+	// the container that failed its livecheck is removed, the next object is never started
+	_, ok := s.Runtime.GetContainer("livecheck_db")
+	assert.False(t, ok)
+	_, ok = s.Runtime.GetContainer("livecheck_web")
+	assert.False(t, ok)
+
+	execs := 0
+	for _, c := range s.Runtime.GetCalls() {
+		if c == "ContainerExecCreate(livecheck_db)" {
+			execs++
+		}
+	}
+	assert.Equal(t, 3, execs)
+}
+
+func TestStopAndCleanupApplication(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("stop"))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	assert.NoError(t, s.Provisioner.StopApplication(context.Background(), exhibit.Id))
+
+	info, err := s.RuntimeInfoService.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stopped, info.Status)
+
+	c, ok := s.Runtime.GetContainer("stop_web")
+	assert.True(t, ok)
+	assert.False(t, c.Running)
+
+	assert.NoError(t, s.Provisioner.CleanupApplication(context.Background(), exhibit.Id))
+
+	info, err = s.RuntimeInfoService.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, info.RelatedContainers)
+	assert.Empty(t, info.Hostname)
+	assert.Empty(t, s.Runtime.Containers)
+	assert.Empty(t, s.Runtime.Networks)
+}
+
+func TestCleanupApplicationRequiresStopped(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("cleanup-running"))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	err := s.Provisioner.CleanupApplication(context.Background(), exhibit.Id)
+	assert.Error(t, err)
+	assert.Len(t, s.Runtime.Containers, 2)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"go.uber.org/zap"
 	"io"
+	"math/rand/v2"
 	"museum/config"
 	"museum/domain"
 	"museum/http"
@@ -16,22 +17,118 @@ import (
 	"time"
 )
 
+// replicaCookiePrefix names the cookie that keeps visitors of exhibits with sticky sessions on their replica
+const replicaCookiePrefix = "museum_replica_"
+
+// museumCookiePrefix is shared by all cookies of museum, they are never forwarded to an exhibit
+const museumCookiePrefix = "museum_"
+
 type DockerApplicationProxyService struct {
 	Resolver       service.ApplicationResolverService
 	RewriteService service.RewriteService
-	Log            *zap.SugaredLogger
-	Config         config.Config
+	LoadService    service.LoadService
+	// AuthService recognizes the api tokens of museum, so they are not forwarded
+	AuthService service.AuthService
+	Log         *zap.SugaredLogger
+	Config      config.Config
+}
+
+// forwardHeader returns the header of the request without the credentials that belong to museum. Exhibits could
+// otherwise replay the sessions of other exhibits or call the api as the visitor.
+func (d *DockerApplicationProxyService) forwardHeader(exhibit domain.Exhibit, req *http.Request) gohttp.Header {
+	header := req.Header.Clone()
+
+	header.Del("Cookie")
+	cookies := make([]string, 0)
+	for _, cookie := range req.Cookies() {
+		if !strings.HasPrefix(cookie.Name, museumCookiePrefix) {
+			cookies = append(cookies, cookie.String())
+		}
+	}
+	if len(cookies) > 0 {
+		header.Set("Cookie", strings.Join(cookies, "; "))
+	}
+
+	scheme, credentials, _ := strings.Cut(header.Get("Authorization"), " ")
+	switch {
+	case strings.EqualFold(scheme, "Basic") && exhibit.AccessType() == domain.AccessBasic:
+		// the users of basic access are checked by museum, the exhibit does not know them
+		header.Del("Authorization")
+	case strings.EqualFold(scheme, "Bearer") && d.AuthService != nil:
+		// only the static api tokens are dropped, verifying every bearer token would slow down each proxied request
+		if d.AuthService.IsApiToken(strings.TrimSpace(credentials)) {
+			header.Del("Authorization")
+		}
+	}
+
+	return header
+}
+
+// pickEndpoint balances the requests to the endpoints by sending each one to the endpoint with the fewest requests
+// in flight. Visitors of exhibits with sticky sessions stay on their endpoint as long as it is healthy.
+func (d *DockerApplicationProxyService) pickEndpoint(exhibit domain.Exhibit, endpoints []domain.Endpoint, res *http.Response, req *http.Request) domain.Endpoint {
+	object, _ := exhibit.ExposedObject()
+	sticky := object.Replicas != nil && object.Replicas.Sticky
+
+	if sticky {
+		if cookie, err := req.Cookie(replicaCookiePrefix + exhibit.Id); err == nil {
+			for _, endpoint := range endpoints {
+				if strconv.Itoa(endpoint.Replica) == cookie.Value {
+					return endpoint
+				}
+			}
+		}
+	}
+
+	// ties are broken randomly, so idle replicas share the first requests
+	picked, least := endpoints[0], -1
+	ties := 0
+	for _, endpoint := range endpoints {
+		inFlight := d.LoadService.InFlight(exhibit.Id, endpoint.Replica)
+		switch {
+		case least == -1 || inFlight < least:
+			picked, least, ties = endpoint, inFlight, 1
+		case inFlight == least:
+			ties++
+			if rand.IntN(ties) == 0 {
+				picked = endpoint
+			}
+		}
+	}
+
+	// the exhibit is addressed by its id or its name, the cookie has to come back on both paths
+	if sticky {
+		for _, path := range exhibit.Paths() {
+			gohttp.SetCookie(res, &gohttp.Cookie{
+				Name:     replicaCookiePrefix + exhibit.Id,
+				Value:    strconv.Itoa(picked.Replica),
+				Path:     path,
+				HttpOnly: true,
+				Secure:   strings.HasPrefix(d.Config.GetPublicUrl(), "https://"),
+				SameSite: gohttp.SameSiteLaxMode,
+			})
+		}
+	}
+
+	return picked
 }
 
 func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, path string, res *http.Response, req *http.Request) error {
 	// forward to exhibit
-	ip, err := d.Resolver.ResolveApplication(req.Context(), exhibit.Id)
+	endpoints, err := d.Resolver.ResolveEndpoints(req.Context(), exhibit.Id)
 	if err != nil {
 		d.Log.Warnw("error resolving application", "error", err, "requestId", req.RequestID, "exhibitId", exhibit.Id)
 		res.WriteHeader(gohttp.StatusInternalServerError)
 		return err
 	}
 
+	endpoint := d.pickEndpoint(exhibit, endpoints, res, req)
+	ip := endpoint.Address
+
+	// the autoscaler scales the exhibit with the requests in flight
+	done := d.LoadService.Begin(exhibit.Id, endpoint.Replica)
+	defer done()
+
 	port := ""
 	for _, o := range exhibit.Objects {
 		if exhibit.Expose == o.Name {
@@ -85,7 +182,7 @@ func (d *DockerApplicationProxyService) ForwardRequest(exhibit domain.Exhibit, p
 		return err
 	}
 
-	proxyReq.Header = req.Header
+	proxyReq.Header = d.forwardHeader(exhibit, req)
 	proxyReq.Host = req.Host
 
 	//do request with timeout
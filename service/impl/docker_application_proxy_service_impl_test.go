@@ -0,0 +1,235 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	"io"
+	configImpl "museum/config/impl"
+	"museum/domain"
+	"museum/http"
+	gohttp "net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// staticResolver resolves every exhibit to the same address
+type staticResolver struct {
+	ip  string
+	err error
+}
+
+func (s staticResolver) ResolveEndpoints(context.Context, string) ([]domain.Endpoint, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []domain.Endpoint{{Address: s.ip}}, nil
+}
+
+func (s staticResolver) ResolveExhibitObject(domain.Exhibit, domain.Object) (string, error) {
+	return s.ip, s.err
+}
+
+func newProxyTest(t *testing.T, resolver staticResolver, upstream gohttp.HandlerFunc) (*DockerApplicationProxyService, domain.Exhibit) {
+	t.Helper()
+
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+
+	if resolver.ip == "" && resolver.err == nil {
+		resolver.ip = u.Hostname()
+	}
+
+	port := u.Port()
+	exhibit := domain.Exhibit{
+		Id:      "0b5fb5f2-7f48-4a4c-9fa4-0cbd3a4c3a64",
+		Name:    "proxy",
+		Expose:  "web",
+		Objects: []domain.Object{{Name: "web", Image: "nginx", Label: "latest", Port: &port}},
+	}
+
+	return &DockerApplicationProxyService{
+		Resolver:       resolver,
+		RewriteService: &RewriteServiceImpl{Log: zap.NewNop().Sugar()},
+		LoadService:    &LoadServiceImpl{},
+		AuthService:    AuthServiceImpl{Tokens: []domain.ApiToken{{Name: "ci", Role: domain.RoleAdmin, Token: "s3cret"}}, Provider: noop.NewTracerProvider()},
+		Log:            zap.NewNop().Sugar(),
+		Config:         &configImpl.EnvConfig{PublicUrl: "https://museum.example"},
+	}, exhibit
+}
+
+func forward(proxy *DockerApplicationProxyService, exhibit domain.Exhibit, path string, rawQuery string) (*httptest.ResponseRecorder, error) {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(gohttp.MethodGet, "/exhibit/"+exhibit.Id+"/"+path, nil)
+
+	err := proxy.ForwardRequest(exhibit, path, &http.Response{ResponseWriter: recorder}, &http.Request{
+		Request:        req,
+		RequestID:      "test",
+		RestPath:       path,
+		RawQueryParams: rawQuery,
+	})
+
+	return recorder, err
+}
+
+func TestForwardRequest(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		w.Header().Set("X-Upstream", "yes")
+		_, _ = io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery)
+	})
+
+	res, err := forward(proxy, exhibit, "index.html", "a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, gohttp.StatusOK, res.Code)
+	assert.Equal(t, "yes", res.Header().Get("X-Upstream"))
+	assert.Equal(t, "/index.html?a=b", res.Body.String())
+}
+
+func TestForwardRequestKeepsMuseumCredentials(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("Cookie")+"|"+r.Header.Get("Authorization"))
+	})
+
+	visit := func(authorization string) string {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(gohttp.MethodGet, "/exhibit/"+exhibit.Id+"/", nil)
+		req.Header.Set("Cookie", "museum_access_5d0ff1a4-64b6-4b7e-8f0c-0f6f9f1f2a33=1.sig; session=abc; museum_maintenance_"+exhibit.Id+"=x; museum_replica_"+exhibit.Id+"=1")
+		req.Header.Set("Authorization", authorization)
+
+		err := proxy.ForwardRequest(exhibit, "", &http.Response{ResponseWriter: recorder}, &http.Request{Request: req, RequestID: "test"})
+		assert.NoError(t, err)
+
+		// the request of the visitor is left as it was
+		assert.Contains(t, req.Header.Get("Cookie"), "museum_access_")
+		return recorder.Body.String()
+	}
+
+	// cookies of the exhibit itself and its own tokens are forwarded, those of museum are not
+	assert.Equal(t, "session=abc|", visit("Bearer s3cret"))
+	assert.Equal(t, "session=abc|Bearer app-token", visit("Bearer app-token"))
+	assert.Equal(t, "session=abc|Bearer eyJ.eyJ.sig", visit("Bearer eyJ.eyJ.sig"))
+}
+
+func TestForwardRequestRewritesRedirects(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		gohttp.Redirect(w, r, "/login", gohttp.StatusFound)
+	})
+
+	res, err := forward(proxy, exhibit, "admin", "")
+	assert.NoError(t, err)
+	assert.Equal(t, gohttp.StatusTemporaryRedirect, res.Code)
+	assert.Equal(t, "/exhibit/"+exhibit.Id+"/login", res.Header().Get("Location"))
+}
+
+func TestForwardRequestResolveFails(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{err: errors.New("exhibit is not running")}, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		t.Error("upstream must not be called")
+	})
+
+	res, err := forward(proxy, exhibit, "", "")
+	assert.EqualError(t, err, "exhibit is not running")
+	assert.Equal(t, gohttp.StatusInternalServerError, res.Code)
+}
+
+func TestForwardRequestUpstreamDown(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {})
+
+	// nothing listens on the exposed port anymore
+	port := "1"
+	exhibit.Objects[0].Port = &port
+
+	res, err := forward(proxy, exhibit, "", "")
+	assert.Error(t, err)
+	assert.Equal(t, gohttp.StatusInternalServerError, res.Code)
+}
+
+func TestForwardRequestCountsRequestsInFlight(t *testing.T) {
+	var proxy *DockerApplicationProxyService
+	var exhibit domain.Exhibit
+	proxy, exhibit = newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {
+		_, _ = io.WriteString(w, strconv.Itoa(proxy.LoadService.InFlight(exhibit.Id, 0)))
+	})
+
+	res, err := forward(proxy, exhibit, "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", res.Body.String())
+	assert.Equal(t, 0, proxy.LoadService.InFlight(exhibit.Id, 0))
+	assert.Equal(t, 1, proxy.LoadService.TakePeak(exhibit.Id))
+	assert.Equal(t, 0, proxy.LoadService.TakePeak(exhibit.Id))
+}
+
+func TestPickEndpointPrefersLeastRequestsInFlight(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {})
+	endpoints := []domain.Endpoint{{Replica: 0, Address: "10.0.0.1"}, {Replica: 1, Address: "10.0.0.2"}, {Replica: 2, Address: "10.0.0.3"}}
+
+	done := proxy.LoadService.Begin(exhibit.Id, 0)
+	defer done()
+	proxy.LoadService.Begin(exhibit.Id, 2)
+
+	for i := 0; i < 10; i++ {
+		res, req, rec := newTestVisit("/exhibit/" + exhibit.Id + "/")
+		assert.Equal(t, 1, proxy.pickEndpoint(exhibit, endpoints, res, req).Replica)
+
+		// without sticky sessions there is no cookie
+		assert.Empty(t, rec.Result().Cookies())
+	}
+}
+
+func TestPickEndpointKeepsStickySessions(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {})
+	exhibit.Objects[0].Replicas = &domain.Replicas{Count: 3, Sticky: true}
+	endpoints := []domain.Endpoint{{Replica: 0, Address: "10.0.0.1"}, {Replica: 1, Address: "10.0.0.2"}, {Replica: 2, Address: "10.0.0.3"}}
+
+	res, req, rec := newTestVisit("/exhibit/" + exhibit.Id + "/")
+	picked := proxy.pickEndpoint(exhibit, endpoints, res, req)
+
+	cookies := rec.Result().Cookies()
+	assert.Len(t, cookies, 2)
+	assert.Equal(t, "/exhibit/"+exhibit.Id+"/", cookies[0].Path)
+
+	// the visitor stays on the replica, even if others have fewer requests in flight
+	proxy.LoadService.Begin(exhibit.Id, picked.Replica)
+	proxy.LoadService.Begin(exhibit.Id, picked.Replica)
+	for i := 0; i < 10; i++ {
+		res, req, _ := newTestVisit("/exhibit/"+exhibit.Id+"/style.css", cookies[0])
+		assert.Equal(t, picked, proxy.pickEndpoint(exhibit, endpoints, res, req))
+	}
+
+	// replicas that are gone are replaced by another one
+	remaining := slices.DeleteFunc(slices.Clone(endpoints), func(e domain.Endpoint) bool { return e == picked })
+	res, req, rec = newTestVisit("/exhibit/"+exhibit.Id+"/", cookies[0])
+	assert.Contains(t, remaining, proxy.pickEndpoint(exhibit, remaining, res, req))
+	assert.Len(t, rec.Result().Cookies(), 2)
+}
+
+func TestPickEndpointKeepsStickySessionsByName(t *testing.T) {
+	proxy, exhibit := newProxyTest(t, staticResolver{}, func(w gohttp.ResponseWriter, r *gohttp.Request) {})
+	exhibit.Objects[0].Replicas = &domain.Replicas{Count: 3, Sticky: true}
+	endpoints := []domain.Endpoint{{Replica: 0, Address: "10.0.0.1"}, {Replica: 1, Address: "10.0.0.2"}, {Replica: 2, Address: "10.0.0.3"}}
+
+	res, req, rec := newTestVisit("/exhibit/" + exhibit.Name + "/")
+	picked := proxy.pickEndpoint(exhibit, endpoints, res, req)
+
+	// the browser only sends back the cookie whose path matches the request
+	jar, _ := cookiejar.New(nil)
+	base, _ := url.Parse("https://museum.example/exhibit/" + exhibit.Name + "/")
+	jar.SetCookies(base, rec.Result().Cookies())
+	sent := jar.Cookies(base.JoinPath("style.css"))
+	assert.Len(t, sent, 1)
+
+	proxy.LoadService.Begin(exhibit.Id, picked.Replica)
+	proxy.LoadService.Begin(exhibit.Id, picked.Replica)
+	for i := 0; i < 10; i++ {
+		res, req, _ := newTestVisit("/exhibit/"+exhibit.Name+"/style.css", sent...)
+		assert.Equal(t, picked, proxy.pickEndpoint(exhibit, endpoints, res, req))
+	}
+}
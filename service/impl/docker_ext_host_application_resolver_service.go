@@ -3,68 +3,66 @@ package impl
 import (
 	"context"
 	"errors"
-	docker "github.com/docker/docker/client"
 	"museum/domain"
-	"museum/persistence"
 	service "museum/service/interface"
 	"museum/util/cache"
+	"strings"
+	"time"
 )
 
+// endpointTtl is how long resolved endpoints are used before their health is checked again
+const endpointTtl = 10 * time.Second
+
+// ResolvedEndpoints are the healthy endpoints of an exhibit at the time they were resolved
+type ResolvedEndpoints struct {
+	endpoints  []domain.Endpoint
+	resolvedAt time.Time
+}
+
 type DockerExtHostApplicationResolverService struct {
 	ExhibitService service.ExhibitService
-	IpCache        *cache.LRU[string, string]
-	Client         *docker.Client
-	Eventing       persistence.Eventing
+	// EndpointCache is keyed by the containers of an exhibit, so restarting or scaling it never hits stale entries
+	EndpointCache *cache.LRU[string, *ResolvedEndpoints]
+	Client        service.ContainerRuntime
 }
 
-func (d DockerExtHostApplicationResolverService) ResolveApplication(ctx context.Context, exhibitId string) (string, error) {
-	exhibit, err := d.ExhibitService.GetExhibitById(ctx, exhibitId)
+func (d DockerExtHostApplicationResolverService) ResolveEndpoints(ctx context.Context, exhibitId string) ([]domain.Endpoint, error) {
+	exhibit, err := d.ExhibitService.GetCachedExhibitById(ctx, exhibitId)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if exhibit.RuntimeInfo.Status != domain.Running {
-		return "", errors.New("exhibit is not running")
-	}
-
-	if ip, ok := d.IpCache.Get(exhibit.RuntimeInfo.Hostname); ok && ip != "" {
-		return ip, nil
+		return nil, errors.New("exhibit is not running")
 	}
 
-	var hostContainer *domain.Object
-	for _, object := range exhibit.Objects {
-		if object.Name == exhibit.Expose {
-			hostContainer = &object
-		}
+	key := exhibitId + "/" + strings.Join(exhibit.RuntimeInfo.RelatedContainers, ",")
+	if resolved, ok := d.EndpointCache.Get(key); ok && time.Since(resolved.resolvedAt) < endpointTtl {
+		return resolved.endpoints, nil
 	}
 
-	if hostContainer == nil {
-		return "", errors.New("exhibit does not have an expose container")
+	object, ok := exhibit.ExposedObject()
+	if !ok {
+		return nil, errors.New("exhibit does not have an expose container")
 	}
 
-	ipStr, err := d.ResolveExhibitObject(exhibit, *hostContainer)
-	if err != nil {
-		return "", err
+	// replicas that are not running or unhealthy are left out until they recover
+	endpoints := make([]domain.Endpoint, 0, exhibit.RuntimeInfo.RunningReplicas())
+	for replica := 0; replica < exhibit.RuntimeInfo.RunningReplicas(); replica++ {
+		ip, err := d.ResolveExhibitObject(exhibit, object.Replica(replica))
+		if err != nil || ip == "" {
+			continue
+		}
+		endpoints = append(endpoints, domain.Endpoint{Replica: replica, Address: ip})
 	}
 
-	if ipStr == "" {
-		return "", errors.New("exhibit expose container does not have an IP address")
+	if len(endpoints) == 0 {
+		return nil, errors.New("exhibit expose container does not have a healthy replica")
 	}
 
-	d.IpCache.Put(exhibit.RuntimeInfo.Hostname, ipStr)
-
-	go func() {
-		channel, c, err := d.Eventing.GetExhibitStoppingChannel(exhibitId, context.Background())
-		if err != nil {
-			return
-		}
-		defer c()
-
-		<-channel
-		d.IpCache.Put(exhibit.RuntimeInfo.Hostname, "")
-	}()
+	d.EndpointCache.Put(key, &ResolvedEndpoints{endpoints: endpoints, resolvedAt: time.Now()})
 
-	return ipStr, nil
+	return endpoints, nil
 }
 
 func (d DockerExtHostApplicationResolverService) ResolveExhibitObject(exhibit domain.Exhibit, object domain.Object) (string, error) {
@@ -83,5 +81,9 @@ func (d DockerExtHostApplicationResolverService) ResolveExhibitObject(exhibit do
 		return "", errors.New("exhibit object is not running")
 	}
 
+	if inspect.ContainerJSONBase.State.Health != nil && inspect.ContainerJSONBase.State.Health.Status == "unhealthy" {
+		return "", errors.New("exhibit object is unhealthy")
+	}
+
 	return inspect.NetworkSettings.DefaultNetworkSettings.IPAddress, nil
 }
@@ -11,17 +11,28 @@ type DockerHostApplicationResolverService struct {
 	ExhibitService service.ExhibitService
 }
 
-func (d DockerHostApplicationResolverService) ResolveApplication(ctx context.Context, exhibitId string) (string, error) {
-	exhibit, err := d.ExhibitService.GetExhibitById(ctx, exhibitId)
+func (d DockerHostApplicationResolverService) ResolveEndpoints(ctx context.Context, exhibitId string) ([]domain.Endpoint, error) {
+	exhibit, err := d.ExhibitService.GetCachedExhibitById(ctx, exhibitId)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if exhibit.RuntimeInfo.Status != domain.Running {
-		return "", errors.New("exhibit is not running")
+		return nil, errors.New("exhibit is not running")
+	}
+
+	object, ok := exhibit.ExposedObject()
+	if !ok {
+		return nil, errors.New("exhibit does not have an expose container")
+	}
+
+	// the swarm resolves the names of the replicas, it only knows those that run
+	endpoints := make([]domain.Endpoint, 0, exhibit.RuntimeInfo.RunningReplicas())
+	for replica := 0; replica < exhibit.RuntimeInfo.RunningReplicas(); replica++ {
+		endpoints = append(endpoints, domain.Endpoint{Replica: replica, Address: exhibit.Name + "_" + object.Replica(replica).Name})
 	}
 
-	return exhibit.Name + "_" + exhibit.Expose, nil
+	return endpoints, nil
 }
 
 func (d DockerHostApplicationResolverService) ResolveExhibitObject(exhibit domain.Exhibit, object domain.Object) (string, error) {
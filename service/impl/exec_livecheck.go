@@ -3,13 +3,13 @@ package impl
 import (
 	"context"
 	"github.com/docker/docker/api/types/container"
-	docker "github.com/docker/docker/client"
 	"io"
 	"museum/domain"
+	service "museum/service/interface"
 )
 
 type ExecLivecheck struct {
-	Client *docker.Client
+	Client service.ContainerRuntime
 }
 
 func (e *ExecLivecheck) Check(ctx context.Context, exhibit domain.Exhibit, object domain.Object) (retry bool, err error) {
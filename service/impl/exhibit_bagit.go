@@ -0,0 +1,155 @@
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"museum/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tag files of a bag as defined by RFC 8493
+const (
+	bagDeclarationPath  = "bagit.txt"
+	bagInfoPath         = "bag-info.txt"
+	bagManifestPath     = "manifest-sha256.txt"
+	bagTagManifestPath  = "tagmanifest-sha256.txt"
+	bagSymlinksPath     = "symlinks.txt"
+	bagPayloadDirectory = "data/"
+)
+
+// bagWriter writes an exhibit as a serialized BagIt bag. The payload has the layout of a museum bundle,
+// the tag files are written after it once all checksums are known.
+// Symbolic links cannot be part of the payload of a bag, they are listed in symlinks.txt instead.
+type bagWriter struct {
+	tw       *tar.Writer
+	root     string
+	files    map[string]string
+	symlinks map[string]string
+	octets   int64
+}
+
+func newBagWriter(w io.Writer, exhibit domain.Exhibit) *bagWriter {
+	return &bagWriter{
+		tw:       tar.NewWriter(w),
+		root:     exhibit.Name + "/",
+		files:    make(map[string]string),
+		symlinks: make(map[string]string),
+	}
+}
+
+func (w *bagWriter) writeDir(name string, mode int64, modTime time.Time) error {
+	return w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: w.root + bagPayloadDirectory + name, Mode: mode, ModTime: modTime})
+}
+
+func (w *bagWriter) writeFile(name string, mode int64, modTime time.Time, size int64, r io.Reader) error {
+	err := w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: w.root + bagPayloadDirectory + name, Mode: mode, Size: size, ModTime: modTime})
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	_, err = io.CopyN(w.tw, io.TeeReader(r, h), size)
+	if err != nil {
+		return errors.New("error writing " + name + " to bag: " + err.Error())
+	}
+
+	w.files[name] = hex.EncodeToString(h.Sum(nil))
+	w.octets += size
+	return nil
+}
+
+func (w *bagWriter) writeSymlink(name string, target string, _ time.Time) error {
+	w.symlinks[name] = target
+	return nil
+}
+
+// finish writes the tag files, the tag manifest is written last
+func (w *bagWriter) finish(manifest domain.ExhibitBundleManifest) (map[string]string, error) {
+	tagFiles := make(map[string]string)
+	writeTagFile := func(name string, content string) error {
+		err := w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: w.root + name, Mode: 0644, Size: int64(len(content)), ModTime: time.Now()})
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(w.tw, content)
+		if err != nil {
+			return err
+		}
+
+		tagFiles[name] = checksum([]byte(content))
+		return nil
+	}
+
+	err := writeTagFile(bagDeclarationPath, "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n")
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeTagFile(bagInfoPath, w.bagInfo(manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeTagFile(bagManifestPath, manifestLines(w.files, bagPayloadDirectory))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(w.symlinks) > 0 {
+		links := &bytes.Buffer{}
+		for _, name := range sortedKeys(w.symlinks) {
+			links.WriteString(encodeBagPath(bagPayloadDirectory+name) + " -> " + encodeBagPath(w.symlinks[name]) + "\n")
+		}
+
+		err = writeTagFile(bagSymlinksPath, links.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = writeTagFile(bagTagManifestPath, manifestLines(tagFiles, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return w.files, w.tw.Close()
+}
+
+// bagInfo returns the metadata of the bag, labels are the reserved labels of RFC 8493
+func (w *bagWriter) bagInfo(manifest domain.ExhibitBundleManifest) string {
+	info := &bytes.Buffer{}
+	label := func(name string, value string) {
+		// values cannot span lines without indenting the continuation
+		info.WriteString(name + ": " + strings.ReplaceAll(value, "\n", "\n  ") + "\n")
+	}
+
+	label("Bagging-Date", time.Unix(manifest.CreatedAt, 0).UTC().Format(time.DateOnly))
+	label("Bag-Software-Agent", "museum")
+	label("External-Identifier", manifest.ExhibitId)
+	label("External-Description", "Exhibit "+manifest.Exhibit+" with the images "+strings.Join(manifest.Images, ", ")+" and the volumes "+strings.Join(manifest.Volumes, ", "))
+	label("Payload-Oxum", strconv.FormatInt(w.octets, 10)+"."+strconv.Itoa(len(w.files)))
+
+	return info.String()
+}
+
+// manifestLines formats checksums as the lines of a manifest, sorted by path
+func manifestLines(files map[string]string, prefix string) string {
+	names := sortedKeys(files)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, files[name]+"  "+encodeBagPath(prefix+name)+"\n")
+	}
+	return strings.Join(lines, "")
+}
+
+// encodeBagPath percent-encodes the characters that cannot be part of a path in a manifest
+func encodeBagPath(p string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(p)
+}
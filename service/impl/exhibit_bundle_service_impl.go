@@ -0,0 +1,816 @@
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"museum/config"
+	"museum/domain"
+	service "museum/service/interface"
+	"museum/util"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ExhibitBundleServiceImpl struct {
+	ExhibitService           service.ExhibitService
+	CollectionService        service.CollectionService
+	StateTransferService     service.StateTransferService
+	LockService              service.LockService
+	DockerClient             service.ContainerRuntime
+	VolumeProvisionerFactory service.VolumeProvisionerFactoryService
+	Config                   config.Config
+	Provider                 trace.TracerProvider
+	Log                      *zap.SugaredLogger
+}
+
+func (b ExhibitBundleServiceImpl) Export(ctx context.Context, id string, format domain.ExhibitBundleFormat, w io.Writer) (err error) {
+	subCtx, span := b.Provider.
+		Tracer("exhibit-bundle-service").
+		Start(ctx, "Export", trace.WithAttributes(attribute.String("exhibitId", id), attribute.String("format", string(format))))
+	defer span.End()
+
+	// the exhibit cannot be deleted while it is written
+	lock := b.LockService.GetRwLock(subCtx, id, "exhibit")
+	err = lock.RLock(subCtx)
+	if err != nil {
+		b.Log.Errorw("error locking exhibit", "exhibitId", id, "error", err)
+		return err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.RUnlock()
+		if e != nil {
+			b.Log.Errorw("error unlocking exhibit", "exhibitId", id, "error", e)
+			err = e
+		}
+	}(lock)
+
+	exhibit, err := b.ExhibitService.GetExhibitById(subCtx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range exhibit.Volumes {
+		err = checkVolumeDirName(v.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest := domain.ExhibitBundleManifest{
+		Version:   domain.ExhibitBundleVersion,
+		CreatedAt: time.Now().Unix(),
+		ExhibitId: exhibit.Id,
+		Exhibit:   exhibit.Name,
+		Images:    imageRefs(exhibit),
+		Volumes:   make([]string, 0, len(exhibit.Volumes)),
+	}
+
+	var bw bundleArchive
+	switch format {
+	case domain.ExhibitBundleFormatMuseum, "":
+		bw = &bundleWriter{tw: tar.NewWriter(w), files: make(map[string]string)}
+	case domain.ExhibitBundleFormatBagIt:
+		bw = newBagWriter(w, exhibit)
+	default:
+		return errors.New("unknown bundle format " + string(format))
+	}
+
+	definition, err := yaml.Marshal(exhibit)
+	if err != nil {
+		return err
+	}
+
+	err = bw.writeFile(domain.ExhibitBundleDefinitionPath, 0644, time.Now(), int64(len(definition)), bytes.NewReader(definition))
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("definition written")
+
+	// images that were removed from this host since the exhibit was created are pulled again
+	err = pullImages(subCtx, b.DockerClient, b.Log, exhibit)
+	if err != nil {
+		return err
+	}
+
+	err = b.writeImages(subCtx, bw, manifest.Images)
+	if err != nil {
+		return err
+	}
+
+	span.AddEvent("images written")
+
+	for _, v := range exhibit.Volumes {
+		provisioner, err := b.VolumeProvisionerFactory.GetForDriverType(v.Driver.Type)
+		if err != nil {
+			return err
+		}
+
+		hostPath, err := provisioner.ProvisionStorage(subCtx, v.Driver.Config)
+		if err != nil {
+			return err
+		}
+
+		err = writeTree(bw, domain.ExhibitBundleVolumesPath+v.Name+"/", hostPath, b.Log)
+		if err != nil {
+			return err
+		}
+
+		manifest.Volumes = append(manifest.Volumes, v.Name)
+	}
+
+	span.AddEvent("volumes written")
+
+	// the manifest is written last, a bundle without it is incomplete
+	manifest.Files, err = bw.finish(manifest)
+	if err != nil {
+		return err
+	}
+
+	span.SetAttributes(attribute.Int("files", len(manifest.Files)))
+	b.Log.Infow("exported exhibit bundle", "exhibitId", exhibit.Id, "format", format, "images", len(manifest.Images), "volumes", len(manifest.Volumes), "files", len(manifest.Files))
+
+	return nil
+}
+
+// writeImages saves the images as an image layout and writes it to the images directory of the bundle
+func (b ExhibitBundleServiceImpl) writeImages(ctx context.Context, bw bundleArchive, refs []string) error {
+	saved, err := b.DockerClient.ImageSave(ctx, refs)
+	if err != nil {
+		b.Log.Errorw("error saving images", "images", refs, "error", err)
+		return err
+	}
+
+	defer func(saved io.ReadCloser) {
+		err := saved.Close()
+		if err != nil {
+			b.Log.Warnw("error closing saved images", "error", err)
+		}
+	}(saved)
+
+	tr := tar.NewReader(saved)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name, err := cleanBundlePath(header.Name)
+		if err != nil {
+			return err
+		}
+		name = domain.ExhibitBundleImagesPath + name
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = bw.writeDir(name+"/", header.Mode, header.ModTime)
+		case tar.TypeReg:
+			err = bw.writeFile(name, header.Mode, header.ModTime, header.Size, tr)
+		case tar.TypeSymlink:
+			err = bw.writeSymlink(name, header.Linkname, header.ModTime)
+		default:
+			err = errors.New("saved images contain " + header.Name + ", which is not a file, directory or symbolic link")
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b ExhibitBundleServiceImpl) Import(ctx context.Context, r io.Reader) (domain.Exhibit, error) {
+	subCtx, span := b.Provider.
+		Tracer("exhibit-bundle-service").
+		Start(ctx, "Import")
+	defer span.End()
+
+	// the bundle is spooled to disk, nothing is imported before all checksums are verified
+	dir, err := os.MkdirTemp("", "museum-bundle-")
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	defer func(dir string) {
+		err := os.RemoveAll(dir)
+		if err != nil {
+			b.Log.Warnw("error removing spooled bundle", "path", dir, "error", err)
+		}
+	}(dir)
+
+	bundle, err := readBundle(r, dir)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	err = bundle.verify()
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	span.AddEvent("bundle verified")
+
+	definition, err := os.ReadFile(filepath.Join(dir, domain.ExhibitBundleDefinitionPath))
+	if err != nil {
+		return domain.Exhibit{}, errors.New("bundle does not contain " + domain.ExhibitBundleDefinitionPath)
+	}
+
+	exhibit := domain.Exhibit{}
+	err = yaml.Unmarshal(definition, &exhibit)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	if exhibit.Id == "" {
+		exhibit.Id = uuid.New().String()
+	}
+	span.SetAttributes(attribute.String("exhibitId", exhibit.Id), attribute.String("name", exhibit.Name))
+
+	err = domain.Authorize(subCtx, exhibit.CollectionName(), domain.RoleCurator)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	// checked up front so that nothing is restored for an exhibit that cannot be imported,
+	// the import checks again under the lock of the collection
+	err = b.CollectionService.CheckCreate(subCtx, exhibit)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+	if _, err := b.ExhibitService.GetCachedExhibitById(subCtx, exhibit.Id); err == nil {
+		return domain.Exhibit{}, errors.New("an exhibit with the id " + exhibit.Id + " already exists")
+	}
+	if _, err := b.ExhibitService.GetCachedExhibitByName(subCtx, exhibit.Name); err == nil {
+		return domain.Exhibit{}, errors.New("an exhibit with the name " + exhibit.Name + " already exists")
+	}
+
+	err = b.loadImages(subCtx, bundle)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	span.AddEvent("images loaded")
+
+	restored := make([]string, 0, len(exhibit.Volumes))
+	removeRestored := func() {
+		for _, p := range restored {
+			err := os.RemoveAll(p)
+			if err != nil {
+				b.Log.Warnw("error removing restored volume", "path", p, "exhibitId", exhibit.Id, "error", err)
+			}
+		}
+	}
+
+	// volume data is restored below the configured directory, the volumes of the exhibit point there
+	for i, v := range exhibit.Volumes {
+		target, err := b.restoreVolume(bundle, exhibit.Id, v.Name)
+		if err != nil {
+			removeRestored()
+			return domain.Exhibit{}, err
+		}
+		restored = append(restored, target)
+
+		exhibit.Volumes[i].Driver = domain.Driver{Type: "local", Config: domain.StringMap{"path": target}}
+	}
+
+	span.AddEvent("volumes restored")
+
+	result, err := b.StateTransferService.Import(subCtx, domain.StateBundle{
+		Version:  domain.StateBundleVersion,
+		Exhibits: []domain.StateBundleExhibit{{Exhibit: exhibit}},
+	}, domain.ImportSkip)
+	if err == nil && len(result.Skipped) > 0 {
+		err = errors.New("an exhibit with the id " + exhibit.Id + " or the name " + exhibit.Name + " already exists")
+	}
+	if reason, ok := result.Failed[exhibit.Name]; err == nil && ok {
+		err = errors.New(reason)
+	}
+	if err != nil {
+		removeRestored()
+		return domain.Exhibit{}, err
+	}
+
+	b.Log.Infow("imported exhibit bundle", "exhibitId", exhibit.Id, "images", len(bundle.manifest.Images), "volumes", len(exhibit.Volumes))
+
+	return exhibit, nil
+}
+
+// loadImages loads the image layout of the bundle into the container runtime
+func (b ExhibitBundleServiceImpl) loadImages(ctx context.Context, bundle *spooledBundle) error {
+	pr, pw := io.Pipe()
+	defer func(pr *io.PipeReader) {
+		_ = pr.Close()
+	}(pr)
+
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := bundle.writeTree(tw, domain.ExhibitBundleImagesPath)
+		if err == nil {
+			err = tw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+
+	res, err := b.DockerClient.ImageLoad(ctx, pr, true)
+	if err != nil {
+		b.Log.Errorw("error loading images", "images", bundle.manifest.Images, "error", err)
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			b.Log.Warnw("error closing load response", "error", err)
+		}
+	}(res.Body)
+
+	// errors that happen while loading are reported in the response
+	decoder := json.NewDecoder(res.Body)
+	for {
+		message := struct {
+			Error string `json:"error"`
+		}{}
+		err = decoder.Decode(&message)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if message.Error != "" {
+			b.Log.Errorw("error loading images", "images", bundle.manifest.Images, "error", message.Error)
+			return errors.New("error loading images: " + message.Error)
+		}
+	}
+}
+
+// restoreVolume copies the data of a volume to <BUNDLE_VOLUME_PATH>/<exhibit id>/<volume name> and returns that path
+func (b ExhibitBundleServiceImpl) restoreVolume(bundle *spooledBundle, exhibitId string, name string) (string, error) {
+	err := checkVolumeDirName(name)
+	if err != nil {
+		return "", err
+	}
+
+	if !slices.Contains(bundle.manifest.Volumes, name) {
+		return "", errors.New("bundle does not contain the data of volume " + name)
+	}
+
+	target, err := filepath.Abs(filepath.Join(b.Config.GetBundleVolumePath(), exhibitId, name))
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Lstat(target); err == nil {
+		return "", errors.New("volume directory " + target + " already exists")
+	}
+
+	err = bundle.restoreTree(domain.ExhibitBundleVolumesPath+name+"/", target)
+	if err != nil {
+		_ = os.RemoveAll(target)
+		return "", err
+	}
+
+	return target, nil
+}
+
+// imageRefs returns the image references of all objects, every image is listed once
+func imageRefs(exhibit domain.Exhibit) []string {
+	refs := make([]string, 0, len(exhibit.Objects))
+	for _, o := range exhibit.Objects {
+		ref := o.Image + ":" + o.Label
+		if !slices.Contains(refs, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// checkVolumeDirName makes sure that the data of a volume is kept in a directory of its own
+func checkVolumeDirName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return errors.New("volume name " + name + " cannot be used as a directory name in a bundle")
+	}
+	return nil
+}
+
+// cleanBundlePath normalizes the path of a tar entry and rejects paths that leave the bundle
+func cleanBundlePath(name string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(name, "./"))
+	if path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.New("bundle contains the invalid path " + name)
+	}
+	return cleaned, nil
+}
+
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// bundleArchive is the format an exhibit is exported in, names are paths of the museum bundle layout.
+// finish completes the archive and returns the checksums of the files written to it.
+type bundleArchive interface {
+	writeDir(name string, mode int64, modTime time.Time) error
+	writeFile(name string, mode int64, modTime time.Time, size int64, r io.Reader) error
+	writeSymlink(name string, target string, modTime time.Time) error
+	finish(manifest domain.ExhibitBundleManifest) (map[string]string, error)
+}
+
+// bundleWriter writes entries to a bundle and records the checksum of every file for the manifest.
+// Symbolic links are checksummed by their target.
+type bundleWriter struct {
+	tw    *tar.Writer
+	files map[string]string
+}
+
+func (w *bundleWriter) writeDir(name string, mode int64, modTime time.Time) error {
+	return w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: name, Mode: mode, ModTime: modTime})
+}
+
+func (w *bundleWriter) writeFile(name string, mode int64, modTime time.Time, size int64, r io.Reader) error {
+	err := w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: mode, Size: size, ModTime: modTime})
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	_, err = io.CopyN(w.tw, io.TeeReader(r, h), size)
+	if err != nil {
+		return errors.New("error writing " + name + " to bundle: " + err.Error())
+	}
+
+	w.files[name] = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+func (w *bundleWriter) writeSymlink(name string, target string, modTime time.Time) error {
+	err := w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: name, Linkname: target, Mode: 0777, ModTime: modTime})
+	if err != nil {
+		return err
+	}
+
+	w.files[name] = checksum([]byte(target))
+	return nil
+}
+
+func (w *bundleWriter) finish(manifest domain.ExhibitBundleManifest) (map[string]string, error) {
+	manifest.Files = w.files
+	m, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: domain.ExhibitBundleManifestPath, Mode: 0644, Size: int64(len(m)), ModTime: time.Now()})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = w.tw.Write(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return w.files, w.tw.Close()
+}
+
+// writeTree writes a directory of the host to the archive, special files like sockets are skipped
+func writeTree(w bundleArchive, prefix string, root string, log *zap.SugaredLogger) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+
+		name := prefix
+		if rel != "." {
+			name += filepath.ToSlash(rel)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return w.writeDir(strings.TrimSuffix(name, "/")+"/", int64(info.Mode().Perm()), info.ModTime())
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return w.writeSymlink(name, target, info.ModTime())
+		case d.Type().IsRegular():
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer func(f *os.File) {
+				_ = f.Close()
+			}(f)
+			return w.writeFile(name, int64(info.Mode().Perm()), info.ModTime(), info.Size(), f)
+		default:
+			log.Warnw("skipping special file in bundle", "path", p)
+			return nil
+		}
+	})
+}
+
+// spooledBundle is a bundle extracted to a temporary directory. Symbolic links are only kept in memory,
+// so that no entry of the bundle can be written through a link to a place outside the directory.
+type spooledBundle struct {
+	dir       string
+	manifest  domain.ExhibitBundleManifest
+	checksums map[string]string
+	symlinks  map[string]string
+}
+
+func readBundle(r io.Reader, dir string) (*spooledBundle, error) {
+	bundle := &spooledBundle{dir: dir, checksums: make(map[string]string), symlinks: make(map[string]string)}
+	hasManifest := false
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.New("error reading bundle: " + err.Error())
+		}
+
+		name, err := cleanBundlePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for link := range bundle.symlinks {
+			if name == link || strings.HasPrefix(name, link+"/") {
+				return nil, errors.New("bundle contains " + name + " below the symbolic link " + link)
+			}
+		}
+
+		if name == domain.ExhibitBundleManifestPath {
+			err = json.NewDecoder(tr).Decode(&bundle.manifest)
+			if err != nil {
+				return nil, errors.New("error reading bundle manifest: " + err.Error())
+			}
+			hasManifest = true
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = spoolFile(bundle, name, target, header, tr)
+		case tar.TypeSymlink:
+			if _, err := os.Lstat(target); err == nil {
+				return nil, errors.New("bundle contains " + name + " more than once")
+			}
+			bundle.symlinks[name] = header.Linkname
+			bundle.checksums[name] = checksum([]byte(header.Linkname))
+		default:
+			err = errors.New("bundle contains " + name + ", which is not a file, directory or symbolic link")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !hasManifest {
+		return nil, errors.New("bundle does not contain a manifest, it may be incomplete")
+	}
+
+	return bundle, nil
+}
+
+func spoolFile(bundle *spooledBundle, name string, target string, header *tar.Header, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(header.Mode&0777)|0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return errors.New("bundle contains " + name + " more than once")
+		}
+		return err
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r)
+	if err != nil {
+		_ = f.Close()
+		return errors.New("error reading " + name + " from bundle: " + err.Error())
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	bundle.checksums[name] = hex.EncodeToString(h.Sum(nil))
+	return os.Chtimes(target, header.ModTime, header.ModTime)
+}
+
+// verify checks that the bundle contains exactly the files of its manifest with the recorded checksums
+func (s *spooledBundle) verify() error {
+	if s.manifest.Version < 1 || s.manifest.Version > domain.ExhibitBundleVersion {
+		return errors.New("exhibit bundle version " + strconv.Itoa(s.manifest.Version) + " is not supported, supported versions are 1 to " + strconv.Itoa(domain.ExhibitBundleVersion))
+	}
+
+	problems := make([]string, 0)
+	for name, expected := range s.manifest.Files {
+		actual, ok := s.checksums[name]
+		switch {
+		case !ok:
+			problems = append(problems, name+" is missing")
+		case actual != expected:
+			problems = append(problems, name+" does not match its checksum")
+		}
+	}
+
+	for name := range s.checksums {
+		if _, ok := s.manifest.Files[name]; !ok {
+			problems = append(problems, name+" is not part of the manifest")
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New("bundle is damaged: " + strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// writeTree writes the entries below prefix to a tar archive, with paths relative to prefix
+func (s *spooledBundle) writeTree(tw *tar.Writer, prefix string) error {
+	root := filepath.Join(s.dir, filepath.FromSlash(prefix))
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+
+		err = tw.WriteHeader(header)
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(f)
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, target := range s.symlinks {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		err = tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: strings.TrimPrefix(name, prefix), Linkname: target, Mode: 0777})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreTree copies the entries below prefix to a directory of the host, symbolic links are created last
+func (s *spooledBundle) restoreTree(prefix string, target string) error {
+	root := filepath.Join(s.dir, filepath.FromSlash(prefix))
+	err := os.MkdirAll(target, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == root {
+			// volumes without any data only have a directory entry, which may be missing in handwritten bundles
+			return filepath.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(target, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(dst, info.Mode().Perm()|0700)
+		}
+
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func(f *os.File) {
+			_ = f.Close()
+		}(src)
+
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(f, src)
+		if err != nil {
+			_ = f.Close()
+			return err
+		}
+
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+
+		return os.Chtimes(dst, info.ModTime(), info.ModTime())
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, link := range s.symlinks {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		dst := filepath.Join(target, filepath.FromSlash(strings.TrimPrefix(name, prefix)))
+		err = os.MkdirAll(filepath.Dir(dst), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = os.Symlink(link, dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
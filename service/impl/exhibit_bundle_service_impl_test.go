@@ -0,0 +1,307 @@
+package impl
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"museum/domain"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newBundledExhibit creates an exhibit with a volume that contains a file, a nested file and a symbolic link
+func newBundledExhibit(t *testing.T, s *testServices) domain.Exhibit {
+	t.Helper()
+
+	data := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(data, "index.html"), []byte("<h1>hello</h1>"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(data, "assets"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(data, "assets", "style.css"), []byte("h1 {}"), 0600))
+	assert.NoError(t, os.Symlink("index.html", filepath.Join(data, "start.html")))
+
+	exhibit := newTestExhibit("bundled")
+	exhibit.Volumes = []domain.Volume{{Name: "site", Driver: domain.Driver{Type: "local", Config: domain.StringMap{"path": data}}}}
+	exhibit.Objects[1].Mounts = domain.StringMap{"site": "/usr/share/nginx/html"}
+
+	return s.createExhibit(t, exhibit)
+}
+
+// rewriteBundle copies a bundle entry by entry, edit can change an entry and its content or drop it by returning nil
+func rewriteBundle(t *testing.T, bundle []byte, edit func(header *tar.Header, content []byte) []byte) []byte {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	tw := tar.NewWriter(out)
+	tr := tar.NewReader(bytes.NewReader(bundle))
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err)
+
+		content, err := io.ReadAll(tr)
+		assert.NoError(t, err)
+
+		content = edit(header, content)
+		if content == nil {
+			continue
+		}
+
+		header.Size = int64(len(content))
+		assert.NoError(t, tw.WriteHeader(header))
+		_, err = tw.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+
+	return out.Bytes()
+}
+
+func TestExhibitBundleRoundTrip(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	created := newBundledExhibit(t, s)
+
+	bundle := &bytes.Buffer{}
+	assert.NoError(t, s.Bundle.Export(ctx, created.Id, domain.ExhibitBundleFormatMuseum, bundle))
+
+	// the manifest is the last entry and covers every other file
+	manifest := domain.ExhibitBundleManifest{}
+	names := make([]string, 0)
+	rewriteBundle(t, bundle.Bytes(), func(header *tar.Header, content []byte) []byte {
+		names = append(names, header.Name)
+		if header.Name == domain.ExhibitBundleManifestPath {
+			assert.NoError(t, json.Unmarshal(content, &manifest))
+		}
+		return content
+	})
+	assert.Equal(t, domain.ExhibitBundleManifestPath, names[len(names)-1])
+	assert.Equal(t, domain.ExhibitBundleVersion, manifest.Version)
+	assert.Equal(t, created.Id, manifest.ExhibitId)
+	assert.Equal(t, []string{"postgres:16", "nginx:latest"}, manifest.Images)
+	assert.Equal(t, []string{"site"}, manifest.Volumes)
+	assert.Contains(t, manifest.Files, "exhibit.yml")
+	assert.Contains(t, manifest.Files, "images/index.json")
+	assert.Contains(t, manifest.Files, "images/oci-layout")
+	assert.Equal(t, checksum([]byte("<h1>hello</h1>")), manifest.Files["volumes/site/index.html"])
+	assert.Equal(t, checksum([]byte("index.html")), manifest.Files["volumes/site/start.html"])
+
+	// import into an installation without registry access
+	other := newTestServices(t)
+	other.Runtime.FailOn("ImagePull", errors.New("no network"))
+
+	imported, err := other.Bundle.Import(ctx, bytes.NewReader(bundle.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, created.Id, imported.Id)
+	assert.Equal(t, "bundled", imported.Name)
+	assert.NotContains(t, other.Runtime.GetCalls(), "ImagePull(nginx:latest)")
+	assert.True(t, other.Runtime.Images["nginx:latest"])
+	assert.True(t, other.Runtime.Images["postgres:16"])
+
+	// the volume points to the restored data
+	stored, err := other.ExhibitService.GetExhibitById(ctx, created.Id)
+	assert.NoError(t, err)
+	restored := stored.Volumes[0].Driver.Config["path"]
+	assert.Equal(t, filepath.Join(other.Config.BundleVolumePath, created.Id, "site"), restored)
+
+	content, err := os.ReadFile(filepath.Join(restored, "assets", "style.css"))
+	assert.NoError(t, err)
+	assert.Equal(t, "h1 {}", string(content))
+
+	info, err := os.Stat(filepath.Join(restored, "assets", "style.css"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(restored, "start.html"))
+	assert.NoError(t, err)
+	assert.Equal(t, "index.html", link)
+
+	// the imported exhibit can be started
+	assert.NoError(t, other.Provisioner.StartApplication(ctx, imported.Id))
+}
+
+func TestExhibitBundleImportRejectsDamagedBundles(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	created := newBundledExhibit(t, s)
+
+	bundle := &bytes.Buffer{}
+	assert.NoError(t, s.Bundle.Export(ctx, created.Id, domain.ExhibitBundleFormatMuseum, bundle))
+
+	tests := []struct {
+		name string
+		edit func(header *tar.Header, content []byte) []byte
+		err  string
+	}{
+		{
+			name: "changed file",
+			edit: func(header *tar.Header, content []byte) []byte {
+				if header.Name == "volumes/site/index.html" {
+					return []byte("<h1>changed</h1>")
+				}
+				return content
+			},
+			err: "volumes/site/index.html does not match its checksum",
+		},
+		{
+			name: "missing file",
+			edit: func(header *tar.Header, content []byte) []byte {
+				if header.Name == "volumes/site/assets/style.css" {
+					return nil
+				}
+				return content
+			},
+			err: "volumes/site/assets/style.css is missing",
+		},
+		{
+			name: "added file",
+			edit: func(header *tar.Header, content []byte) []byte {
+				if header.Name == "volumes/site/index.html" {
+					header.Name = "volumes/site/other.html"
+				}
+				return content
+			},
+			err: "volumes/site/other.html is not part of the manifest",
+		},
+		{
+			name: "truncated",
+			edit: func(header *tar.Header, content []byte) []byte {
+				if header.Name == domain.ExhibitBundleManifestPath {
+					return nil
+				}
+				return content
+			},
+			err: "bundle does not contain a manifest",
+		},
+		{
+			name: "path outside the bundle",
+			edit: func(header *tar.Header, content []byte) []byte {
+				if header.Name == "volumes/site/index.html" {
+					header.Name = "../index.html"
+				}
+				return content
+			},
+			err: "bundle contains the invalid path ../index.html",
+		},
+		{
+			name: "file below a symbolic link",
+			edit: func(header *tar.Header, content []byte) []byte {
+				// the manifest is the only entry after the link
+				if header.Name == domain.ExhibitBundleManifestPath {
+					header.Name = "volumes/site/start.html/index.html"
+				}
+				return content
+			},
+			err: "below the symbolic link volumes/site/start.html",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			other := newTestServices(t)
+
+			_, err := other.Bundle.Import(ctx, bytes.NewReader(rewriteBundle(t, bundle.Bytes(), tt.edit)))
+			assert.ErrorContains(t, err, tt.err)
+
+			// nothing is imported from a damaged bundle
+			assert.Empty(t, other.ExhibitService.GetAllExhibits(ctx))
+			assert.Empty(t, other.Runtime.Images)
+		})
+	}
+}
+
+func TestExhibitBundleImportConflict(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	created := newBundledExhibit(t, s)
+
+	bundle := &bytes.Buffer{}
+	assert.NoError(t, s.Bundle.Export(ctx, created.Id, domain.ExhibitBundleFormatMuseum, bundle))
+
+	_, err := s.Bundle.Import(ctx, bytes.NewReader(bundle.Bytes()))
+	assert.ErrorContains(t, err, "already exists")
+
+	// an exhibit with the same name but another id conflicts as well
+	other := newTestServices(t)
+	other.createExhibit(t, newTestExhibit("bundled"))
+
+	_, err = other.Bundle.Import(ctx, bytes.NewReader(bundle.Bytes()))
+	assert.ErrorContains(t, err, "an exhibit with the name bundled already exists")
+
+	// no volume data is restored for a conflicting exhibit
+	entries, err := os.ReadDir(other.Config.BundleVolumePath)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestExhibitBundleExportBagIt(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	created := newBundledExhibit(t, s)
+
+	bag := &bytes.Buffer{}
+	assert.NoError(t, s.Bundle.Export(ctx, created.Id, domain.ExhibitBundleFormatBagIt, bag))
+
+	files := make(map[string][]byte)
+	names := make([]string, 0)
+	rewriteBundle(t, bag.Bytes(), func(header *tar.Header, content []byte) []byte {
+		names = append(names, header.Name)
+		if header.Typeflag == tar.TypeReg {
+			files[header.Name] = content
+		}
+		return content
+	})
+
+	// the tag manifest is written last, every entry is below the base directory
+	assert.Equal(t, "bundled/tagmanifest-sha256.txt", names[len(names)-1])
+	for _, name := range names {
+		assert.True(t, strings.HasPrefix(name, "bundled/"), name)
+	}
+
+	assert.Equal(t, "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n", string(files["bundled/bagit.txt"]))
+
+	// every payload file is listed with its checksum, symbolic links are listed separately
+	manifest := string(files["bundled/manifest-sha256.txt"])
+	octets := 0
+	count := 0
+	for _, line := range strings.Split(strings.TrimSuffix(manifest, "\n"), "\n") {
+		sum, name, ok := strings.Cut(line, "  ")
+		assert.True(t, ok)
+		assert.Equal(t, checksum(files["bundled/"+name]), sum, name)
+		octets += len(files["bundled/"+name])
+		count++
+	}
+	payload := 0
+	for name := range files {
+		if strings.HasPrefix(name, "bundled/data/") {
+			payload++
+		}
+	}
+	assert.Equal(t, payload, count)
+	assert.Contains(t, manifest, checksum([]byte("<h1>hello</h1>"))+"  data/volumes/site/index.html\n")
+	assert.NotContains(t, manifest, "start.html")
+	assert.Equal(t, "data/volumes/site/start.html -> index.html\n", string(files["bundled/symlinks.txt"]))
+
+	info := string(files["bundled/bag-info.txt"])
+	assert.Contains(t, info, "External-Identifier: "+created.Id+"\n")
+	assert.Contains(t, info, "Payload-Oxum: "+strconv.Itoa(octets)+"."+strconv.Itoa(count)+"\n")
+
+	// the tag manifest covers all other tag files
+	tagManifest := string(files["bundled/tagmanifest-sha256.txt"])
+	for _, name := range []string{"bagit.txt", "bag-info.txt", "manifest-sha256.txt", "symlinks.txt"} {
+		assert.Contains(t, tagManifest, checksum(files["bundled/"+name])+"  "+name+"\n")
+	}
+	assert.NotContains(t, tagManifest, "tagmanifest")
+}
@@ -11,10 +11,15 @@ import (
 	"time"
 )
 
+// queueAbandonedAfter is how long a queued exhibit keeps its place without visitors, the loading page asks for it
+// every few seconds
+const queueAbandonedAfter = time.Minute
+
 type ExhibitCleanupServiceImpl struct {
 	ExhibitService                service.ExhibitService
 	LockService                   service.LockService
 	ApplicationProvisionerService service.ApplicationProvisionerService
+	CapacityService               service.CapacityService
 	Provider                      trace.TracerProvider
 	Log                           *zap.SugaredLogger
 	Config                        config.Config
@@ -29,6 +34,14 @@ func (e ExhibitCleanupServiceImpl) cleanupExhibit(exhibit domain.Exhibit, idx in
 	count := e.ExhibitService.Count()
 	e.Log.Debugw("checking exhibit", "exhibitId", exhibit.Id, "current", idx+1, "total", count)
 
+	if exhibit.AlwaysOn() {
+		span.AddEvent("exhibit is always on, skipping")
+		return
+	}
+
+	// the lease of an exhibit does not expire while a window of its schedule is open
+	scheduled := exhibit.Scheduled(time.Now())
+
 	duration, err := time.ParseDuration(exhibit.Lease)
 	if err != nil {
 		e.Log.Warnw("error parsing lease duration", "error", err, "exhibitId", exhibit.Id)
@@ -42,14 +55,20 @@ func (e ExhibitCleanupServiceImpl) cleanupExhibit(exhibit domain.Exhibit, idx in
 		span.AddEvent("exhibit starting since " + startingSince + ", cleaning up")
 	}
 
-	leaseExpired := time.Now().After(time.Unix(exhibit.RuntimeInfo.LastAccessed, 0).Add(duration)) && exhibit.RuntimeInfo.Status == domain.Running
+	leaseExpired := time.Now().After(time.Unix(exhibit.RuntimeInfo.LastAccessed, 0).Add(duration)) && exhibit.RuntimeInfo.Status == domain.Running && !scheduled
 	if leaseExpired {
 		expiredBy := time.Now().Sub(time.Unix(exhibit.RuntimeInfo.LastAccessed, 0).Add(duration)).String()
 		e.Log.Infow("exhibit lease expired", "exhibitId", exhibit.Id, "expiredBy", expiredBy)
 		span.AddEvent("exhibit lease expired by " + expiredBy + ", cleaning up")
 	}
 
-	if leaseExpired || startingTooLong {
+	abandoned := exhibit.RuntimeInfo.Status == domain.Queued && !scheduled && time.Now().After(time.Unix(exhibit.RuntimeInfo.LastAccessed, 0).Add(queueAbandonedAfter))
+	if abandoned {
+		e.Log.Infow("queued exhibit was abandoned", "exhibitId", exhibit.Id)
+		span.AddEvent("queued exhibit was abandoned, removing it from the queue")
+	}
+
+	if leaseExpired || startingTooLong || abandoned {
 		err = e.ApplicationProvisionerService.StopApplication(subCtx, exhibit.Id)
 		if err != nil {
 			e.Log.Warnw("error stopping application", "error", err, "exhibitId", exhibit.Id)
@@ -80,6 +99,17 @@ func (e ExhibitCleanupServiceImpl) Cleanup() error {
 		e.cleanupExhibit(exhibit, i, ctx)
 	}
 
+	// queued exhibits start as soon as the cleanup made room for them
+	for _, id := range e.CapacityService.Next(ctx) {
+		span.AddEvent("starting queued exhibit " + id)
+		go func(id string) {
+			err := e.ApplicationProvisionerService.StartApplication(ctx, id)
+			if err != nil {
+				e.Log.Warnw("error starting queued exhibit", "error", err, "exhibitId", id)
+			}
+		}(id)
+	}
+
 	e.Log.Debug("finished cleaning up exhibits")
 
 	return nil
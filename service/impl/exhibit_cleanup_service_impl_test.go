@@ -0,0 +1,77 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func TestCleanupStopsExpiredExhibits(t *testing.T) {
+	s := newTestServices(t)
+
+	expired := s.createExhibit(t, newTestExhibit("expired"))
+	active := s.createExhibit(t, newTestExhibit("active"))
+	for _, e := range []domain.Exhibit{expired, active} {
+		assert.NoError(t, s.Provisioner.StartApplication(context.Background(), e.Id))
+	}
+
+	// the lease is 1h
+	s.setRuntimeInfo(t, expired.Id, domain.Running, time.Now().Add(-2*time.Hour))
+
+	assert.NoError(t, s.Cleanup.Cleanup())
+
+	info, err := s.RuntimeInfoService.GetRuntimeInfo(context.Background(), expired.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stopped, info.Status)
+	_, ok := s.Runtime.GetContainer("expired_web")
+	assert.False(t, ok)
+	assert.Len(t, s.Eventing.GetStoppingEvents(expired.Id), 1)
+
+	info, err = s.RuntimeInfoService.GetRuntimeInfo(context.Background(), active.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Running, info.Status)
+	c, ok := s.Runtime.GetContainer("active_web")
+	assert.True(t, ok)
+	assert.True(t, c.Running)
+}
+
+func TestCleanupStopsExhibitsStartingTooLong(t *testing.T) {
+	s := newTestServices(t)
+	s.Config.StartingTimeout = 60
+
+	exhibit := s.createExhibit(t, newTestExhibit("stuck"))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+
+	// the exhibit is still starting, long after the starting timeout
+	s.setRuntimeInfo(t, exhibit.Id, domain.Starting, time.Now().Add(-2*time.Minute))
+
+	assert.NoError(t, s.Cleanup.Cleanup())
+
+	info, err := s.RuntimeInfoService.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.Stopped, info.Status)
+
+	// the exhibit can be started again
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+}
+
+func TestCleanupStopFails(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("stop-fails"))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), exhibit.Id))
+	s.setRuntimeInfo(t, exhibit.Id, domain.Running, time.Now().Add(-2*time.Hour))
+
+	s.Runtime.FailOn("ContainerStop", errors.New("container is paused"), "stop-fails_db")
+
+	// failures are logged, the cleanup run itself does not fail
+	assert.NoError(t, s.Cleanup.Cleanup())
+
+	// containers are not removed if the exhibit could not be stopped
+	_, ok := s.Runtime.GetContainer("stop-fails_db")
+	assert.True(t, ok)
+	_, ok = s.Runtime.GetContainer("stop-fails_web")
+	assert.True(t, ok)
+}
@@ -10,11 +10,12 @@ import (
 	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"io"
+	"museum/config"
 	"museum/domain"
 	"museum/persistence"
 	service "museum/service/interface"
 	"museum/util"
-	"strconv"
+	"museum/util/schema"
 	"time"
 )
 
@@ -25,29 +26,40 @@ type ExhibitServiceImpl struct {
 	Provider                 trace.TracerProvider
 	LockService              service.LockService
 	Log                      *zap.SugaredLogger
-	DockerClient             *docker.Client
+	DockerClient             service.ContainerRuntime
 	VolumeProvisionerFactory service.VolumeProvisionerFactoryService
+	CollectionService        service.CollectionService
+	Config                   config.Config
 }
 
 func (e ExhibitServiceImpl) GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error) {
-	globalLock := e.LockService.GetRwLock(ctx, "all", "exhibits")
-	err := globalLock.RLock()
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "GetExhibitById("+id+")", trace.WithAttributes(attribute.String("exhibitId", id)))
+	defer span.End()
+
+	// the collection of an exhibit never changes, so it is known before its lock is acquired
+	exhibit, err := e.State.GetExhibitById(subCtx, id)
 	if err != nil {
-		e.Log.Errorw("error locking global lock", "error", err)
 		return domain.Exhibit{}, err
 	}
 
-	//TODO: span
+	partition := collectionLock(subCtx, e.LockService, exhibit.CollectionName())
+	err = partition.RLock(subCtx)
+	if err != nil {
+		e.Log.Errorw("error locking collection lock", "error", err, "collection", exhibit.CollectionName())
+		return domain.Exhibit{}, err
+	}
 
-	defer func(globalLock util.RwErrMutex) {
-		err := globalLock.RUnlock()
+	defer func(partition util.RwErrMutex) {
+		err := partition.RUnlock()
 		if err != nil {
-			e.Log.Errorw("error unlocking global lock", "error", err)
+			e.Log.Errorw("error unlocking collection lock", "error", err, "collection", exhibit.CollectionName())
 		}
-	}(globalLock)
+	}(partition)
 
-	lock := e.LockService.GetRwLock(ctx, id, "exhibit")
-	err = lock.RLock()
+	lock := e.LockService.GetRwLock(subCtx, id, "exhibit")
+	err = lock.RLock(subCtx)
 	if err != nil {
 		e.Log.Errorw("error locking exhibit lock", "error", err, "exhibitId", id)
 		return domain.Exhibit{}, err
@@ -60,12 +72,14 @@ func (e ExhibitServiceImpl) GetExhibitById(ctx context.Context, id string) (doma
 		}
 	}(lock)
 
-	exhibit, err := e.State.GetExhibitById(ctx, id)
+	span.AddEvent("locks acquired")
+
+	exhibit, err = e.State.GetExhibitById(subCtx, id)
 	if err != nil {
 		return domain.Exhibit{}, err
 	}
 
-	err = e.hydrateExhibit(ctx, id, &exhibit)
+	err = e.hydrateExhibit(subCtx, id, &exhibit)
 	if err != nil {
 		return domain.Exhibit{}, err
 	}
@@ -73,6 +87,61 @@ func (e ExhibitServiceImpl) GetExhibitById(ctx context.Context, id string) (doma
 	return exhibit, nil
 }
 
+// GetCachedExhibitById reads an exhibit without acquiring any locks. The state backends
+// serve reads from memory, so this is cheap enough for every proxied request, but the
+// exhibit might be changed concurrently. Anything that writes based on what it read
+// has to use GetExhibitById instead.
+func (e ExhibitServiceImpl) GetCachedExhibitById(ctx context.Context, id string) (domain.Exhibit, error) {
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "GetCachedExhibitById("+id+")", trace.WithAttributes(attribute.String("exhibitId", id)))
+	defer span.End()
+
+	exhibit, err := e.State.GetExhibitById(subCtx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	runtimeInfo, err := e.State.GetRuntimeInfo(subCtx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+	exhibit.RuntimeInfo = &runtimeInfo
+
+	lastAccessed, err := e.State.GetLastAccessed(subCtx, id)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+	exhibit.RuntimeInfo.LastAccessed = lastAccessed
+
+	return exhibit, nil
+}
+
+func (e ExhibitServiceImpl) GetCachedExhibitByName(ctx context.Context, name string) (domain.Exhibit, error) {
+	id, err := e.State.GetExhibitIdByName(ctx, name)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	return e.GetCachedExhibitById(ctx, id)
+}
+
+func (e ExhibitServiceImpl) GetExhibitByName(ctx context.Context, name string) (domain.Exhibit, error) {
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "GetExhibitByName("+name+")", trace.WithAttributes(attribute.String("name", name)))
+	defer span.End()
+
+	span.AddEvent("looking up name index")
+
+	id, err := e.State.GetExhibitIdByName(subCtx, name)
+	if err != nil {
+		return domain.Exhibit{}, err
+	}
+
+	return e.GetExhibitById(subCtx, id)
+}
+
 func (e ExhibitServiceImpl) hydrateExhibit(ctx context.Context, id string, exhibit *domain.Exhibit) error {
 	subCtx, span := e.Provider.
 		Tracer("exhibit-service").
@@ -99,26 +168,28 @@ func (e ExhibitServiceImpl) hydrateExhibit(ctx context.Context, id string, exhib
 }
 
 func (e ExhibitServiceImpl) GetAllExhibits(ctx context.Context) []domain.Exhibit {
-	lock := e.LockService.GetRwLock(ctx, "all", "exhibits")
-	err := lock.RLock()
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "GetAllExhibits")
+	defer span.End()
+
+	exhibits := e.State.GetAllExhibits(subCtx)
+
+	unlock, err := lockCollections(subCtx, e.LockService, collectionsOf(exhibits), false)
 	if err != nil {
-		e.Log.Errorw("error locking global lock", "error", err)
+		e.Log.Errorw("error locking collection locks", "error", err)
 		return nil
 	}
 
-	//TODO: span
-
-	defer func(lock util.RwErrMutex) {
-		err = lock.RUnlock()
+	defer func() {
+		err := unlock()
 		if err != nil {
-			e.Log.Errorw("error unlocking global lock", "error", err)
+			e.Log.Errorw("error unlocking collection locks", "error", err)
 		}
-	}(lock)
-
-	exhibits := e.State.GetAllExhibits(ctx)
+	}()
 
 	for i, exhibit := range exhibits {
-		err = e.hydrateExhibit(ctx, exhibit.Id, &exhibit)
+		err = e.hydrateExhibit(subCtx, exhibit.Id, &exhibit)
 		if err != nil {
 			continue
 		}
@@ -129,26 +200,86 @@ func (e ExhibitServiceImpl) GetAllExhibits(ctx context.Context) []domain.Exhibit
 }
 
 func (e ExhibitServiceImpl) DeleteExhibitById(ctx context.Context, id string) error {
-	lock := e.LockService.GetRwLock(ctx, id, "exhibit")
-	err := lock.Lock()
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "DeleteExhibitById("+id+")", trace.WithAttributes(attribute.String("exhibitId", id)))
+	defer span.End()
+
+	// the collection of an exhibit never changes, so it is known before its lock is acquired
+	exhibit, err := e.State.GetExhibitById(subCtx, id)
 	if err != nil {
-		e.Log.Errorw("error locking exhibit lock", "error", err, "exhibitId", id)
 		return err
 	}
 
-	//TODO: span
+	// deletions are serialised with creations in the collection, so the quota check of a creation sees them
+	partition := collectionLock(subCtx, e.LockService, exhibit.CollectionName())
+	err = partition.Lock(subCtx)
+	if err != nil {
+		e.Log.Errorw("error locking collection lock", "error", err, "collection", exhibit.CollectionName())
+		return err
+	}
+
+	defer func(partition util.RwErrMutex) {
+		err := partition.Unlock()
+		if err != nil {
+			e.Log.Errorw("error unlocking collection lock", "error", err, "collection", exhibit.CollectionName())
+		}
+	}(partition)
+
+	lock := e.LockService.GetRwLock(subCtx, id, "exhibit")
+	err = lock.Lock(subCtx)
+	if err != nil {
+		e.Log.Errorw("error locking exhibit lock", "error", err, "exhibitId", id)
+		return err
+	}
 
 	defer func() {
 		err := lock.Unlock()
 		if err != nil {
 			e.Log.Errorw("error unlocking exhibit lock", "error", err, "exhibitId", id)
 		}
-		// TODO: delete lock
 	}()
 
 	//stop exhibit if running
 
-	return e.State.DeleteExhibitById(ctx, id)
+	// the lock is released after the exhibit is gone, callers waiting for it find nothing left to read
+	return e.State.Txn(subCtx).
+		DeleteLastAccessed(id).
+		DeleteRuntimeInfo(id).
+		DeleteFixity(id).
+		DeleteStartupLogs(id).
+		DeleteExhibitById(id).
+		Commit()
+}
+
+func (e ExhibitServiceImpl) SetMaintenance(ctx context.Context, id string, maintenance *domain.Maintenance) (err error) {
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "SetMaintenance("+id+")", trace.WithAttributes(attribute.String("exhibitId", id), attribute.Bool("maintenance", maintenance != nil)))
+	defer span.End()
+
+	lock := e.LockService.GetRwLock(subCtx, id, "runtime_info")
+	err = lock.Lock(subCtx)
+	if err != nil {
+		e.Log.Errorw("error locking runtime_info lock", "error", err, "exhibitId", id)
+		return err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e2 := lock.Unlock()
+		if e2 != nil {
+			e.Log.Errorw("error unlocking runtime_info lock", "error", e2, "exhibitId", id)
+			err = errors.Join(err, e2)
+		}
+	}(lock)
+
+	runtimeInfo, err := e.State.GetRuntimeInfo(subCtx, id)
+	if err != nil {
+		return err
+	}
+
+	runtimeInfo.Maintenance = maintenance
+	return e.State.SetRuntimeInfo(subCtx, id, runtimeInfo)
 }
 
 func (e ExhibitServiceImpl) CreateExhibit(ctx context.Context, createExhibitRequest domain.CreateExhibit) (string, error) {
@@ -160,215 +291,192 @@ func (e ExhibitServiceImpl) CreateExhibit(ctx context.Context, createExhibitRequ
 
 	e.Log.Infow("creating new exhibit", "exhibitId", createExhibitRequest.Exhibit.Id)
 
-	globalLock := e.LockService.GetRwLock(subCtx, "all", "exhibits")
-	err := globalLock.Lock()
+	//TODO: check container address replacement in ENV
+
+	err := domain.Authorize(subCtx, createExhibitRequest.Exhibit.CollectionName(), domain.RoleCurator)
 	if err != nil {
-		e.Log.Errorw("error locking global lock", "error", err)
 		return "", err
 	}
 
-	//TODO: check container address replacement in ENV
-
-	defer func(globalLock util.RwErrMutex) {
-		err := globalLock.Unlock()
-		if err != nil {
-			e.Log.Errorw("error unlocking global lock", "error", err)
-		}
-	}(globalLock)
+	err = validateExhibit(&createExhibitRequest.Exhibit, e.VolumeProvisionerFactory)
+	if err != nil {
+		return "", err
+	}
 
-	// check that exhibit name is unique
-	exhibits := e.State.GetAllExhibits(subCtx)
-	for _, e := range exhibits {
-		if e.Name == createExhibitRequest.Exhibit.Name {
-			return "", errors.New("exhibit with name " + createExhibitRequest.Exhibit.Name + " already exists")
-		}
+	// checked up front to fail before pulling images, and again when the exhibit is written
+	err = e.CollectionService.CheckCreate(subCtx, createExhibitRequest.Exhibit)
+	if err != nil {
+		return "", err
 	}
 
-	// check that a container is exposed
-	if createExhibitRequest.Exhibit.Expose == "" {
-		return "", errors.New("exhibit must expose a container")
+	// check that exhibit name is unique, this is only a fast path to fail before pulling images,
+	// the name index is guarded again when the exhibit is written
+	if _, err := e.State.GetExhibitIdByName(subCtx, createExhibitRequest.Exhibit.Name); err == nil {
+		return "", errors.New("exhibit with name " + createExhibitRequest.Exhibit.Name + " already exists")
 	}
 
-	// check that exposed container has an exposed port
-	{
-		found := false
-		for i, c := range createExhibitRequest.Exhibit.Objects {
-			if c.Name == createExhibitRequest.Exhibit.Expose {
-				if c.Port == nil || *c.Port == "" {
-					createExhibitRequest.Exhibit.Objects[i].Port = new(string)
-					*createExhibitRequest.Exhibit.Objects[i].Port = "80"
-				}
-				found = true
-			}
-		}
+	//---------------------------------------------------
 
-		if !found {
-			return "", errors.New("exhibit must expose a container that is part of the exhibit")
-		}
+	// give exhibit a unique id, and a persistent identifier unless one was minted for it elsewhere
+	createExhibitRequest.Exhibit.Id = uuid.New().String()
+	if createExhibitRequest.Exhibit.Pid == "" {
+		createExhibitRequest.Exhibit.Pid = e.Config.GetPidPrefix() + createExhibitRequest.Exhibit.Id
+	}
+	createExhibitRequest.Exhibit.Collection = createExhibitRequest.Exhibit.CollectionName()
+	createExhibitRequest.Exhibit.CreatedAt = time.Now().Unix()
+	createExhibitRequest.Exhibit.UpdatedAt = createExhibitRequest.Exhibit.CreatedAt
+
+	// set runtime state
+	createExhibitRequest.Exhibit.RuntimeInfo = &domain.ExhibitRuntimeInfo{
+		Status:            domain.NotCreated,
+		RelatedContainers: []string{},
 	}
 
 	//---------------------------------------------------
 
-	// validate mount paths
-	mounts := make([]string, 0)
-	for _, c := range createExhibitRequest.Exhibit.Objects {
-		if len(c.Mounts) != 0 {
-			for mount := range c.Mounts {
-				mounts = append(mounts, mount)
-			}
-		}
+	err = pullImages(subCtx, e.DockerClient, e.Log, createExhibitRequest.Exhibit)
+	if err != nil {
+		return "", err
 	}
 
-	// check that volumes have required mounts
-	for _, m := range mounts {
-		found := false
-		for _, v := range createExhibitRequest.Exhibit.Volumes {
-			if v.Name == m {
-				found = true
-			}
-		}
+	span.AddEvent("writing exhibit")
 
-		if !found {
-			return "", errors.New("mount " + m + " does not have a corresponding volume")
-		}
+	// creating exhibits in a collection is serialised, so that concurrent creations cannot exceed its quota
+	lock := collectionLock(subCtx, e.LockService, createExhibitRequest.Exhibit.CollectionName())
+	err = lock.Lock(subCtx)
+	if err != nil {
+		e.Log.Errorw("error locking collection lock", "error", err, "collection", createExhibitRequest.Exhibit.CollectionName())
+		return "", err
 	}
 
-	for _, v := range createExhibitRequest.Exhibit.Volumes {
-		vp, err := e.VolumeProvisionerFactory.GetForDriverType(v.Driver.Type)
+	defer func(lock util.RwErrMutex) {
+		err := lock.Unlock()
 		if err != nil {
-			return "", err
+			e.Log.Errorw("error unlocking collection lock", "error", err, "collection", createExhibitRequest.Exhibit.CollectionName())
 		}
+	}(lock)
 
-		err = vp.CheckValidity(v.Driver.Config)
-		if err != nil {
-			return "", err
-		}
+	err = e.CollectionService.CheckCreate(subCtx, createExhibitRequest.Exhibit)
+	if err != nil {
+		return "", err
 	}
 
-	//---------------------------------------------------
-
-	// validate lease time
-	_, err = time.ParseDuration(createExhibitRequest.Exhibit.Lease)
+	// last_accessed, runtime_info and meta are written atomically, so a failed
+	// creation never leaves a half-created exhibit behind
+	err = e.State.Txn(subCtx).
+		SetLastAccessed(createExhibitRequest.Exhibit.Id, time.Now().Unix()).
+		SetRuntimeInfo(createExhibitRequest.Exhibit.Id, *createExhibitRequest.Exhibit.RuntimeInfo).
+		CreateExhibit(createExhibitRequest.Exhibit).
+		Commit()
 	if err != nil {
-		return "", errors.New("lease time must be a valid duration")
+		e.Log.Errorw("error creating exhibit", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
+		return "", err
 	}
 
-	// validate livechecks
-	for _, object := range createExhibitRequest.Exhibit.Objects {
-		l := object.Livecheck
-		if l == nil {
-			continue
-		}
+	e.Eventing.DispatchExhibitCreatedEvent(subCtx, createExhibitRequest.Exhibit)
+	e.Log.Debugw("created new exhibit", "exhibitId", createExhibitRequest.Exhibit.Id)
+
+	return createExhibitRequest.Exhibit.Id, nil
+}
+
+func (e ExhibitServiceImpl) Count() int {
+	return len(e.State.GetAllExhibits(context.Background()))
+}
 
-		// validate livecheck type
-		if l.Type != domain.LivecheckTypeHttp && l.Type != domain.LivecheckTypeExec {
-			return "", errors.New("livecheck type must be one of: " + domain.LivecheckTypeHttp + ", " + domain.LivecheckTypeExec + " (in object " + object.Name + ")")
+func (e ExhibitServiceImpl) ValidateExhibit(ctx context.Context, exhibit domain.Exhibit) []domain.ValidationProblem {
+	subCtx, span := e.Provider.
+		Tracer("exhibit-service").
+		Start(ctx, "ValidateExhibit", trace.WithAttributes(attribute.String("name", exhibit.Name)))
+	defer span.End()
+
+	problems := exhibitProblems(exhibit, e.VolumeProvisionerFactory)
+
+	if _, err := e.State.GetExhibitIdByName(subCtx, exhibit.Name); err == nil {
+		problems = append(problems, domain.ValidationProblem{Pointer: schema.Pointer("name"), Message: "exhibit with name " + exhibit.Name + " already exists"})
+	}
+
+	span.SetAttributes(attribute.Int("problems", len(problems)))
+
+	return problems
+}
+
+// exhibitProblems returns all problems of an exhibit, including those of volume drivers that depend on the host
+func exhibitProblems(exhibit domain.Exhibit, volumeProvisionerFactory service.VolumeProvisionerFactoryService) []domain.ValidationProblem {
+	problems := exhibit.Validate()
+
+	for i, v := range exhibit.Volumes {
+		vp, err := volumeProvisionerFactory.GetForDriverType(v.Driver.Type)
+		if err != nil {
+			problems = append(problems, domain.ValidationProblem{Pointer: schema.Pointer("volumes", i, "driver", "type"), Message: err.Error()})
+			continue
 		}
 
-		// check http livecheck
-		if l.Type == domain.LivecheckTypeHttp {
-			// check valid http method
-			method, ok := l.Config["method"]
-			if ok {
-				if method != "GET" && method != "POST" && method != "PUT" && method != "DELETE" {
-					return "", errors.New("http livecheck method must be one of: GET, POST, PUT, DELETE (in object " + object.Name + ")")
-				}
-			}
-
-			// check valid http status
-			status, ok := l.Config["status"]
-			if ok {
-				_, err := strconv.Atoi(status)
-				if err != nil {
-					return "", errors.New("http livecheck status must be a valid integer (in object " + object.Name + ")")
-				}
-			}
-
-			// check valid port
-			port, ok := l.Config["port"]
-			if ok {
-				_, err := strconv.Atoi(port)
-				if err != nil {
-					return "", errors.New("http livecheck port must be a valid integer (in object " + object.Name + ")")
-				}
-			}
+		err = vp.CheckValidity(v.Driver.Config)
+		if err != nil {
+			problems = append(problems, domain.ValidationProblem{Pointer: schema.Pointer("volumes", i, "driver", "config"), Message: err.Error()})
 		}
 	}
 
-	//---------------------------------------------------
+	return problems
+}
 
-	// give exhibit a unique id
-	createExhibitRequest.Exhibit.Id = uuid.New().String()
+// validateExhibit checks that an exhibit can be stored and started, the exposed object gets the default port if it has none
+func validateExhibit(exhibit *domain.Exhibit, volumeProvisionerFactory service.VolumeProvisionerFactoryService) error {
+	problems := exhibitProblems(*exhibit, volumeProvisionerFactory)
+	if len(problems) > 0 {
+		errs := make([]error, 0, len(problems))
+		for _, p := range problems {
+			errs = append(errs, p)
+		}
+		return errors.Join(errs...)
+	}
 
-	// set runtime state
-	createExhibitRequest.Exhibit.RuntimeInfo = &domain.ExhibitRuntimeInfo{
-		Status:            domain.NotCreated,
-		RelatedContainers: []string{},
+	for i, c := range exhibit.Objects {
+		if c.Name == exhibit.Expose && (c.Port == nil || *c.Port == "") {
+			exhibit.Objects[i].Port = new(string)
+			*exhibit.Objects[i].Port = "80"
+		}
 	}
 
-	//---------------------------------------------------
+	return nil
+}
 
-	e.Log.Infow("pulling images", "exhibitId", createExhibitRequest.Exhibit.Id)
-	for _, object := range createExhibitRequest.Exhibit.Objects {
-		e.Log.Debugw("pulling image", "image", object.Image+":"+object.Label, "exhibitId", createExhibitRequest.Exhibit.Id)
+// pullImages pulls the images of all objects of an exhibit that are not present yet
+func pullImages(ctx context.Context, client service.ContainerRuntime, log *zap.SugaredLogger, exhibit domain.Exhibit) error {
+	log.Infow("pulling images", "exhibitId", exhibit.Id)
+	for _, object := range exhibit.Objects {
+		log.Debugw("pulling image", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id)
 
-		inspect, _, err := e.DockerClient.ImageInspectWithRaw(subCtx, object.Image+":"+object.Label)
+		inspect, _, err := client.ImageInspectWithRaw(ctx, object.Image+":"+object.Label)
 		if err != nil && !docker.IsErrNotFound(err) {
-			e.Log.Errorw("error inspecting image", "image", object.Image+":"+object.Label, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			log.Errorw("error inspecting image", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 
 		if inspect.ID != "" {
-			e.Log.Debugw("image already pulled", "image", object.Image+":"+object.Label, "exhibitId", createExhibitRequest.Exhibit.Id)
+			log.Debugw("image already pulled", "image", object.Image+":"+object.Label, "exhibitId", exhibit.Id)
 			continue
 		}
 
 		containerImage := object.Image + ":" + object.Label
-		pull, err := e.DockerClient.ImagePull(ctx, containerImage, image.PullOptions{})
+		pull, err := client.ImagePull(ctx, containerImage, image.PullOptions{})
 		if err != nil {
-			e.Log.Errorw("error pulling image", "image", containerImage, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			log.Errorw("error pulling image", "image", containerImage, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 
 		_, err = io.ReadAll(pull)
 		if err != nil {
-			e.Log.Errorw("error reading pull response", "image", containerImage, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			log.Errorw("error reading pull response", "image", containerImage, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 
 		err = pull.Close()
 		if err != nil {
-			e.Log.Errorw("error closing pull response", "image", containerImage, "exhibitId", createExhibitRequest.Exhibit.Id, "error", err)
-			return "", err
+			log.Errorw("error closing pull response", "image", containerImage, "exhibitId", exhibit.Id, "error", err)
+			return err
 		}
 	}
 
-	err = e.State.SetLastAccessed(subCtx, createExhibitRequest.Exhibit.Id, time.Now().Unix())
-	if err != nil {
-		return "", err
-	}
-
-	err = e.State.SetRuntimeInfo(subCtx, createExhibitRequest.Exhibit.Id, *createExhibitRequest.Exhibit.RuntimeInfo)
-	if err != nil {
-		e.Log.Errorw("error setting runtime info, reverting", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
-		err = e.State.DeleteLastAccessed(subCtx, createExhibitRequest.Exhibit.Id)
-		return "", err
-	}
-
-	err = e.State.CreateExhibit(subCtx, createExhibitRequest.Exhibit)
-	if err != nil {
-		e.Log.Errorw("error creating exhibit, reverting", "error", err, "exhibitId", createExhibitRequest.Exhibit.Id)
-		err = e.State.DeleteLastAccessed(subCtx, createExhibitRequest.Exhibit.Id)
-		err = e.State.DeleteRuntimeInfo(subCtx, createExhibitRequest.Exhibit.Id)
-		return "", err
-	}
-
-	e.Eventing.DispatchExhibitCreatedEvent(subCtx, createExhibitRequest.Exhibit)
-	e.Log.Debugw("created new exhibit", "exhibitId", createExhibitRequest.Exhibit.Id)
-
-	return createExhibitRequest.Exhibit.Id, nil
-}
-
-func (e ExhibitServiceImpl) Count() int {
-	return len(e.State.GetAllExhibits(context.Background()))
+	return nil
 }
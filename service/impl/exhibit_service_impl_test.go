@@ -0,0 +1,174 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func TestCreateExhibitPullsMissingImages(t *testing.T) {
+	s := newTestServices(t)
+	s.Runtime.Images["postgres:16"] = true
+
+	exhibit := s.createExhibit(t, newTestExhibit("pull"))
+	assert.Equal(t, domain.NotCreated, exhibit.RuntimeInfo.Status)
+
+	calls := s.Runtime.GetCalls()
+	assert.NotContains(t, calls, "ImagePull(postgres:16)")
+	assert.Contains(t, calls, "ImagePull(nginx:latest)")
+
+	// the exposed object gets the default port
+	assert.Equal(t, "80", *exhibit.Objects[1].Port)
+	assert.Len(t, s.Eventing.CreatedEvents, 1)
+}
+
+func TestCreateExhibitPullFails(t *testing.T) {
+	s := newTestServices(t)
+	s.Runtime.FailOn("ImagePull", errors.New("manifest unknown"), "nginx:latest")
+
+	_, err := s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: newTestExhibit("pull-fails")})
+	assert.EqualError(t, err, "manifest unknown")
+
+	// nothing is written if the images cannot be pulled
+	assert.Empty(t, s.ExhibitService.GetAllExhibits(context.Background()))
+	assert.Empty(t, s.Eventing.CreatedEvents)
+}
+
+func TestCreateExhibitAssignsPersistentId(t *testing.T) {
+	s := newTestServices(t)
+	s.Config.PidPrefix = "ark:/99999/museum-"
+
+	exhibit := s.createExhibit(t, newTestExhibit("pid"))
+	assert.Equal(t, "ark:/99999/museum-"+exhibit.Id, exhibit.Pid)
+
+	// identifiers minted elsewhere are kept
+	minted := newTestExhibit("minted")
+	minted.Pid = "https://doi.org/10.5555/museum.1"
+	exhibit = s.createExhibit(t, minted)
+	assert.Equal(t, "https://doi.org/10.5555/museum.1", exhibit.Pid)
+}
+
+func TestCreateExhibitValidation(t *testing.T) {
+	s := newTestServices(t)
+	s.createExhibit(t, newTestExhibit("taken"))
+
+	tests := map[string]func(e *domain.Exhibit){
+		"no spec":        func(e *domain.Exhibit) { e.Spec = "" },
+		"unknown spec":   func(e *domain.Exhibit) { e.Spec = "v2" },
+		"duplicate name": func(e *domain.Exhibit) { e.Name = "taken" },
+		"invalid name":   func(e *domain.Exhibit) { e.Name = "-invalid" },
+		"uuid name":      func(e *domain.Exhibit) { e.Name = "0b5fb5f2-7f48-4a4c-9fa4-0cbd3a4c3a64" },
+		"no expose":      func(e *domain.Exhibit) { e.Expose = "" },
+		"unknown expose": func(e *domain.Exhibit) { e.Expose = "cache" },
+		"invalid lease":  func(e *domain.Exhibit) { e.Lease = "forever" },
+		"missing volume": func(e *domain.Exhibit) { e.Objects[0].Mounts = domain.StringMap{"data": "/var/lib/postgresql/data"} },
+		"bad livecheck":  func(e *domain.Exhibit) { e.Objects[0].Livecheck = &domain.Livecheck{Type: "tcp"} },
+		"unknown order":  func(e *domain.Exhibit) { e.Order = []string{"db", "cache", "web"} },
+		"bad http method": func(e *domain.Exhibit) {
+			e.Objects[1].Livecheck = &domain.Livecheck{Type: "http", Config: domain.StringMap{"method": "PATCH"}}
+		},
+	}
+
+	for name, modify := range tests {
+		t.Run(name, func(t *testing.T) {
+			exhibit := newTestExhibit("valid")
+			modify(&exhibit)
+
+			_, err := s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: exhibit})
+			assert.Error(t, err)
+		})
+	}
+
+	assert.Len(t, s.ExhibitService.GetAllExhibits(context.Background()), 1)
+}
+
+func TestValidateExhibit(t *testing.T) {
+	s := newTestServices(t)
+	s.createExhibit(t, newTestExhibit("taken"))
+
+	exhibit := newTestExhibit("taken")
+	exhibit.Lease = "forever"
+	exhibit.Volumes = []domain.Volume{{Name: "data", Driver: domain.Driver{Type: "nfs"}}}
+
+	problems := s.ExhibitService.ValidateExhibit(context.Background(), exhibit)
+	pointers := make([]string, 0)
+	for _, p := range problems {
+		pointers = append(pointers, p.Pointer)
+	}
+	assert.ElementsMatch(t, []string{"/lease", "/volumes/0/driver/type", "/name"}, pointers)
+
+	// creating reports every problem as well, not just the first one
+	_, err := s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: exhibit})
+	assert.ErrorContains(t, err, "/lease: lease time must be a valid duration")
+	assert.ErrorContains(t, err, "/volumes/0/driver/type")
+
+	assert.Empty(t, s.ExhibitService.ValidateExhibit(context.Background(), newTestExhibit("valid")))
+	assert.Equal(t, 1, s.ExhibitService.Count())
+}
+
+func TestGetAndDeleteExhibit(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("delete"))
+
+	byName, err := s.ExhibitService.GetExhibitByName(context.Background(), "delete")
+	assert.NoError(t, err)
+	assert.Equal(t, exhibit.Id, byName.Id)
+
+	assert.NoError(t, s.ExhibitService.DeleteExhibitById(context.Background(), exhibit.Id))
+
+	_, err = s.ExhibitService.GetExhibitById(context.Background(), exhibit.Id)
+	assert.Error(t, err)
+	_, err = s.ExhibitService.GetExhibitByName(context.Background(), "delete")
+	assert.Error(t, err)
+	_, err = s.State.GetRuntimeInfo(context.Background(), exhibit.Id)
+	assert.Error(t, err)
+	assert.Equal(t, 0, s.ExhibitService.Count())
+}
+
+func TestGetCachedExhibitDoesNotWaitForLocks(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("cached"))
+	ctx := context.Background()
+
+	lock := s.LockService.GetRwLock(ctx, exhibit.Id, "exhibit")
+	assert.NoError(t, lock.Lock(ctx))
+	defer func() {
+		assert.NoError(t, lock.Unlock())
+	}()
+
+	byId, err := s.ExhibitService.GetCachedExhibitById(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, exhibit, byId)
+
+	byName, err := s.ExhibitService.GetCachedExhibitByName(ctx, "cached")
+	assert.NoError(t, err)
+	assert.Equal(t, exhibit.Id, byName.Id)
+
+	// the locked read gives up
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err = s.ExhibitService.GetExhibitById(waitCtx, exhibit.Id)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSetMaintenance(t *testing.T) {
+	s := newTestServices(t)
+	exhibit := s.createExhibit(t, newTestExhibit("maintenance"))
+	ctx := context.Background()
+
+	maintenance := &domain.Maintenance{Message: "new objects are being added", Since: 1700000000, By: "curator"}
+	assert.NoError(t, s.ExhibitService.SetMaintenance(ctx, exhibit.Id, maintenance))
+
+	exhibit, err := s.ExhibitService.GetExhibitById(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, maintenance, exhibit.RuntimeInfo.Maintenance)
+
+	assert.NoError(t, s.ExhibitService.SetMaintenance(ctx, exhibit.Id, nil))
+
+	exhibit, err = s.ExhibitService.GetExhibitById(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Nil(t, exhibit.RuntimeInfo.Maintenance)
+}
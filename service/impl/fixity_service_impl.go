@@ -0,0 +1,324 @@
+package impl
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"io/fs"
+	"maps"
+	"museum/config"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+type FixityServiceImpl struct {
+	State                    persistence.State
+	Eventing                 persistence.Eventing
+	ExhibitService           service.ExhibitService
+	LockService              service.LockService
+	DockerClient             service.ContainerRuntime
+	VolumeProvisionerFactory service.VolumeProvisionerFactoryService
+	Config                   config.Config
+	Provider                 trace.TracerProvider
+	Log                      *zap.SugaredLogger
+}
+
+func (f FixityServiceImpl) GetReport(ctx context.Context, id string) (domain.FixityReport, error) {
+	return f.State.GetFixity(ctx, id)
+}
+
+func (f FixityServiceImpl) Check(ctx context.Context, id string) (domain.FixityReport, error) {
+	return f.check(ctx, id, false)
+}
+
+func (f FixityServiceImpl) CheckDue(ctx context.Context) error {
+	subCtx, span := f.Provider.
+		Tracer("fixity-service").
+		Start(ctx, "CheckDue")
+	defer span.End()
+
+	exhibits := f.ExhibitService.GetAllExhibits(subCtx)
+	mismatches := 0
+	for _, exhibit := range exhibits {
+		report, err := f.check(subCtx, exhibit.Id, true)
+		if err != nil {
+			f.Log.Warnw("error checking exhibit fixity", "error", err, "exhibitId", exhibit.Id)
+			continue
+		}
+
+		if report.Status == domain.FixityStatusMismatch {
+			mismatches++
+		}
+	}
+
+	span.SetAttributes(attribute.Int("exhibits", len(exhibits)), attribute.Int("mismatches", mismatches))
+
+	return nil
+}
+
+// due returns if the last check of a report is older than the fixity interval
+func (f FixityServiceImpl) due(report domain.FixityReport) bool {
+	interval := time.Duration(f.Config.GetFixityInterval()) * time.Hour
+	return time.Now().After(time.Unix(report.CheckedAt, 0).Add(interval))
+}
+
+func (f FixityServiceImpl) check(ctx context.Context, id string, onlyIfDue bool) (report domain.FixityReport, err error) {
+	subCtx, span := f.Provider.
+		Tracer("fixity-service").
+		Start(ctx, "Check", trace.WithAttributes(attribute.String("exhibitId", id)))
+	defer span.End()
+
+	// instances check an exhibit one after another, the second one finds the report of the first one
+	lock := f.LockService.GetRwLock(subCtx, id, "fixity")
+	err = lock.Lock(subCtx)
+	if err != nil {
+		f.Log.Errorw("error locking fixity lock", "exhibitId", id, "error", err)
+		return domain.FixityReport{}, err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		e := lock.Unlock()
+		if e != nil {
+			f.Log.Errorw("error unlocking fixity lock", "exhibitId", id, "error", e)
+		}
+	}(lock)
+
+	exhibit, err := f.ExhibitService.GetExhibitById(subCtx, id)
+	if err != nil {
+		return domain.FixityReport{}, err
+	}
+
+	previous, err := f.State.GetFixity(subCtx, id)
+	hasBaseline := err == nil
+	if hasBaseline && onlyIfDue && !f.due(previous) {
+		span.AddEvent("check not due")
+		return previous, nil
+	}
+
+	now := time.Now().Unix()
+	report = domain.FixityReport{
+		Status:     domain.FixityStatusOk,
+		CheckedAt:  now,
+		BaselineAt: now,
+		Baseline:   make(map[string]string),
+		Mismatches: make([]domain.FixityMismatch, 0),
+	}
+	if hasBaseline {
+		report.BaselineAt = previous.BaselineAt
+		report.Baseline = maps.Clone(previous.Baseline)
+	}
+
+	checksums, failures := f.checksums(subCtx, exhibit)
+	span.AddEvent("checksums computed")
+
+	// items that are checked for the first time become part of the baseline
+	for _, item := range sortedKeys(checksums) {
+		expected, ok := report.Baseline[item]
+		if !ok {
+			report.Baseline[item] = checksums[item]
+			continue
+		}
+
+		if expected != checksums[item] {
+			report.Mismatches = append(report.Mismatches, domain.FixityMismatch{Item: item, Expected: expected, Actual: checksums[item]})
+		}
+	}
+
+	for _, item := range sortedKeys(failures) {
+		report.Mismatches = append(report.Mismatches, domain.FixityMismatch{Item: item, Expected: report.Baseline[item], Error: failures[item].Error()})
+	}
+
+	if len(report.Mismatches) > 0 {
+		report.Status = domain.FixityStatusMismatch
+	}
+
+	err = f.State.SetFixity(subCtx, id, report)
+	if err != nil {
+		return domain.FixityReport{}, err
+	}
+
+	span.SetAttributes(attribute.Int("items", len(checksums)), attribute.Int("mismatches", len(report.Mismatches)))
+
+	if report.Status == domain.FixityStatusMismatch {
+		for _, m := range report.Mismatches {
+			f.Log.Warnw("fixity mismatch", "exhibitId", id, "item", m.Item, "expected", m.Expected, "actual", m.Actual, "error", m.Error)
+		}
+		f.Eventing.DispatchExhibitFixityEvent(subCtx, exhibit, report)
+	} else {
+		f.Log.Infow("fixity verified", "exhibitId", id, "items", len(checksums))
+	}
+
+	return report, nil
+}
+
+// checksums computes the checksum of every image and volume of an exhibit, items whose checksum
+// cannot be computed are returned with the reason
+func (f FixityServiceImpl) checksums(ctx context.Context, exhibit domain.Exhibit) (map[string]string, map[string]error) {
+	checksums := make(map[string]string)
+	failures := make(map[string]error)
+
+	for _, ref := range imageRefs(exhibit) {
+		sum, err := f.imageChecksum(ctx, ref)
+		if err != nil {
+			failures[domain.FixityItemImage+ref] = err
+			continue
+		}
+		checksums[domain.FixityItemImage+ref] = sum
+	}
+
+	for _, v := range exhibit.Volumes {
+		item := domain.FixityItemVolume + v.Name
+
+		provisioner, err := f.VolumeProvisionerFactory.GetForDriverType(v.Driver.Type)
+		if err != nil {
+			failures[item] = err
+			continue
+		}
+
+		hostPath, err := provisioner.ProvisionStorage(ctx, v.Driver.Config)
+		if err != nil {
+			failures[item] = err
+			continue
+		}
+
+		sum, err := treeChecksum(hostPath)
+		if err != nil {
+			failures[item] = err
+			continue
+		}
+		checksums[item] = sum
+	}
+
+	return checksums, failures
+}
+
+// imageChecksum returns the id of an image, which is the digest of its configuration.
+// The content of the image is verified as well, every blob of the saved image layout must match its digest.
+func (f FixityServiceImpl) imageChecksum(ctx context.Context, ref string) (string, error) {
+	inspect, _, err := f.DockerClient.ImageInspectWithRaw(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	saved, err := f.DockerClient.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return "", err
+	}
+
+	defer func(saved io.ReadCloser) {
+		err := saved.Close()
+		if err != nil {
+			f.Log.Warnw("error closing saved image", "image", ref, "error", err)
+		}
+	}(saved)
+
+	tr := tar.NewReader(saved)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return inspect.ID, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		dir, name := path.Split(path.Clean(strings.TrimPrefix(header.Name, "./")))
+		if header.Typeflag != tar.TypeReg || dir != "blobs/sha256/" {
+			continue
+		}
+
+		h := sha256.New()
+		_, err = io.Copy(h, tr)
+		if err != nil {
+			return "", err
+		}
+
+		if hex.EncodeToString(h.Sum(nil)) != name {
+			return "", errors.New("blob sha256:" + name + " does not match its digest")
+		}
+	}
+}
+
+// treeChecksum digests the paths and contents of all files below root, symbolic links are digested by their target.
+// Modes and modification times are not part of it, copies of the same data have the same checksum.
+func treeChecksum(root string) (string, error) {
+	lines := make([]string, 0)
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		switch {
+		case d.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, checksum([]byte(target))+"  "+rel+"\n")
+		case d.Type().IsRegular():
+			sum, err := fileChecksum(p)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, sum+"  "+rel+"\n")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// the walk is lexical already, sorting keeps the checksum independent of it
+	sort.Strings(lines)
+	return "sha256:" + checksum([]byte(strings.Join(lines, ""))), nil
+}
+
+func fileChecksum(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,107 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFixityCheckDetectsChanges(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	created := newBundledExhibit(t, s)
+	_, err := s.Fixity.GetReport(ctx, created.Id)
+	assert.Error(t, err)
+
+	// the first check records the baseline
+	report, err := s.Fixity.Check(ctx, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusOk, report.Status)
+	assert.Empty(t, report.Mismatches)
+	assert.Len(t, report.Baseline, 3)
+	assert.Equal(t, "sha256:nginx:latest", report.Baseline[domain.FixityItemImage+"nginx:latest"])
+	assert.Contains(t, report.Baseline, domain.FixityItemVolume+"site")
+
+	// nothing changed
+	report, err = s.Fixity.Check(ctx, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusOk, report.Status)
+	assert.Empty(t, s.Eventing.FixityEvents)
+
+	// a changed file and a removed image are reported
+	data := created.Volumes[0].Driver.Config["path"]
+	assert.NoError(t, os.WriteFile(filepath.Join(data, "index.html"), []byte("<h1>changed</h1>"), 0644))
+	delete(s.Runtime.Images, "postgres:16")
+
+	report, err = s.Fixity.Check(ctx, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusMismatch, report.Status)
+	assert.Len(t, report.Mismatches, 2)
+
+	volume := report.Mismatches[0]
+	assert.Equal(t, domain.FixityItemVolume+"site", volume.Item)
+	assert.Equal(t, report.Baseline[volume.Item], volume.Expected)
+	assert.NotEqual(t, volume.Expected, volume.Actual)
+
+	image := report.Mismatches[1]
+	assert.Equal(t, domain.FixityItemImage+"postgres:16", image.Item)
+	assert.Contains(t, image.Error, "no such image")
+
+	// the report is stored and dispatched
+	stored, err := s.Fixity.GetReport(ctx, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, report, stored)
+
+	assert.Len(t, s.Eventing.FixityEvents, 1)
+	assert.Equal(t, created.Id, s.Eventing.FixityEvents[0].ExhibitId)
+	assert.Equal(t, report.Mismatches, s.Eventing.FixityEvents[0].Mismatches)
+
+	// the baseline is kept, restoring the data resolves the mismatch
+	assert.NoError(t, os.WriteFile(filepath.Join(data, "index.html"), []byte("<h1>hello</h1>"), 0644))
+	s.Runtime.Images["postgres:16"] = true
+
+	report, err = s.Fixity.Check(ctx, created.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusOk, report.Status)
+}
+
+func TestFixityCheckDue(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	checked := s.createExhibit(t, newTestExhibit("checked"))
+	outdated := s.createExhibit(t, newTestExhibit("outdated"))
+	unchecked := s.createExhibit(t, newTestExhibit("unchecked"))
+
+	_, err := s.Fixity.Check(ctx, checked.Id)
+	assert.NoError(t, err)
+
+	report, err := s.Fixity.Check(ctx, outdated.Id)
+	assert.NoError(t, err)
+	report.CheckedAt = time.Now().Add(-25 * time.Hour).Unix()
+	assert.NoError(t, s.State.SetFixity(ctx, outdated.Id, report))
+
+	// a check that is not due does not touch the image
+	delete(s.Runtime.Images, "nginx:latest")
+	assert.NoError(t, s.Fixity.CheckDue(ctx))
+
+	report, err = s.Fixity.GetReport(ctx, checked.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusOk, report.Status)
+
+	report, err = s.Fixity.GetReport(ctx, outdated.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusMismatch, report.Status)
+	assert.Greater(t, report.CheckedAt, time.Now().Add(-time.Hour).Unix())
+
+	// exhibits that were never checked are due, items that could not be checksummed have no baseline
+	report, err = s.Fixity.GetReport(ctx, unchecked.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.FixityStatusMismatch, report.Status)
+	assert.NotContains(t, report.Baseline, domain.FixityItemImage+"nginx:latest")
+}
@@ -0,0 +1,72 @@
+package impl
+
+import (
+	"sync"
+)
+
+// LoadServiceImpl only knows the requests of this instance, the autoscaler shares them through the runtime info
+type LoadServiceImpl struct {
+	mu       sync.Mutex
+	inFlight map[string]map[int]int
+	peak     map[string]int
+}
+
+func (l *LoadServiceImpl) total(exhibitId string) int {
+	total := 0
+	for _, n := range l.inFlight[exhibitId] {
+		total += n
+	}
+	return total
+}
+
+func (l *LoadServiceImpl) Begin(exhibitId string, replica int) func() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight == nil {
+		l.inFlight = make(map[string]map[int]int)
+		l.peak = make(map[string]int)
+	}
+	if l.inFlight[exhibitId] == nil {
+		l.inFlight[exhibitId] = make(map[int]int)
+	}
+	l.inFlight[exhibitId][replica]++
+	l.peak[exhibitId] = max(l.peak[exhibitId], l.total(exhibitId))
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.inFlight[exhibitId][replica]--
+			if l.inFlight[exhibitId][replica] <= 0 {
+				delete(l.inFlight[exhibitId], replica)
+			}
+			if len(l.inFlight[exhibitId]) == 0 {
+				delete(l.inFlight, exhibitId)
+			}
+		})
+	}
+}
+
+func (l *LoadServiceImpl) InFlight(exhibitId string, replica int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.inFlight[exhibitId][replica]
+}
+
+func (l *LoadServiceImpl) TakePeak(exhibitId string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// the next period starts with what is in flight right now
+	peak := l.peak[exhibitId]
+	if current := l.total(exhibitId); current > 0 {
+		l.peak[exhibitId] = current
+	} else {
+		delete(l.peak, exhibitId)
+	}
+	return peak
+}
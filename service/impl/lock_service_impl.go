@@ -2,14 +2,139 @@ package impl
 
 import (
 	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/config"
 	"museum/persistence"
 	"museum/util"
+	"strconv"
+	"time"
 )
 
+// LockMetrics are the instruments lock contention is recorded with
+type LockMetrics struct {
+	WaitTime metric.Float64Histogram
+	Waiting  metric.Int64UpDownCounter
+	Timeouts metric.Int64Counter
+}
+
+func NewLockMetrics(meter metric.Meter) (*LockMetrics, error) {
+	waitTime, err := meter.Float64Histogram("museum.lock.wait.duration",
+		metric.WithDescription("time spent waiting to acquire a lock"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	waiting, err := meter.Int64UpDownCounter("museum.lock.waiting",
+		metric.WithDescription("number of callers currently waiting for a lock"))
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts, err := meter.Int64Counter("museum.lock.timeouts",
+		metric.WithDescription("number of lock acquisitions that gave up after the lock timeout"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &LockMetrics{WaitTime: waitTime, Waiting: waiting, Timeouts: timeouts}, nil
+}
+
 type LockServiceImpl struct {
-	State persistence.State
+	State    persistence.State
+	Config   config.Config
+	Provider trace.TracerProvider
+	Metrics  *LockMetrics
+	Log      *zap.SugaredLogger
 }
 
 func (l LockServiceImpl) GetRwLock(ctx context.Context, id string, lockName string) util.RwErrMutex {
-	return l.State.GetRwLock(ctx, id, lockName)
+	return &observedRwMutex{
+		lock:    l.State.GetRwLock(ctx, id, lockName),
+		service: l,
+		id:      id,
+		name:    lockName,
+	}
+}
+
+// observedRwMutex bounds waiting for a lock by the lock timeout and records how long callers wait for it
+type observedRwMutex struct {
+	lock    util.RwErrMutex
+	service LockServiceImpl
+	id      string
+	name    string
+}
+
+func (o *observedRwMutex) RLock(ctx context.Context) error {
+	return o.acquire(ctx, "read", o.lock.RLock, o.lock.RUnlock)
+}
+
+func (o *observedRwMutex) RUnlock() error {
+	return o.lock.RUnlock()
+}
+
+func (o *observedRwMutex) Lock(ctx context.Context) error {
+	return o.acquire(ctx, "write", o.lock.Lock, o.lock.Unlock)
+}
+
+func (o *observedRwMutex) Unlock() error {
+	return o.lock.Unlock()
+}
+
+func (o *observedRwMutex) acquire(ctx context.Context, mode string, acquire func(ctx context.Context) error, release func() error) error {
+	// create new trace span for lock service
+	subCtx, span := o.service.Provider.
+		Tracer("lock-service").
+		Start(ctx, "acquire "+mode+" lock "+o.name, trace.WithAttributes(attribute.String("exhibitId", o.id), attribute.String("lockName", o.name), attribute.String("mode", mode)))
+	defer span.End()
+
+	// an earlier deadline of the caller still applies
+	timeout := time.Duration(o.service.Config.GetLockTimeout()) * time.Second
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		subCtx, cancel = context.WithTimeout(subCtx, timeout)
+		defer cancel()
+	}
+
+	attrs := metric.WithAttributes(attribute.String("lock", o.name), attribute.String("mode", mode))
+
+	o.service.Metrics.Waiting.Add(ctx, 1, attrs)
+	start := time.Now()
+	err := acquire(subCtx)
+	waited := time.Since(start)
+	o.service.Metrics.Waiting.Add(ctx, -1, attrs)
+
+	o.service.Metrics.WaitTime.Record(ctx, waited.Seconds(), attrs)
+	span.SetAttributes(attribute.Int64("waitedMs", waited.Milliseconds()))
+
+	if err == nil {
+		span.AddEvent("lock acquired")
+
+		// the holder reads what it changes, so the reads have to include the writes of the previous holder
+		err = o.service.State.Sync(subCtx)
+		if err == nil {
+			return nil
+		}
+
+		span.RecordError(err)
+		if releaseErr := release(); releaseErr != nil {
+			o.service.Log.Warnw("could not release lock after failed sync", "exhibitId", o.id, "lockName", o.name, "mode", mode, "error", releaseErr)
+		}
+		return err
+	}
+
+	span.RecordError(err)
+
+	// only the lock timeout is reported as such, the caller gave up on its own otherwise
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		o.service.Metrics.Timeouts.Add(ctx, 1, attrs)
+		o.service.Log.Warnw("timed out waiting for lock", "exhibitId", o.id, "lockName", o.name, "mode", mode, "waited", waited)
+		return errors.New("timed out after " + strconv.Itoa(o.service.Config.GetLockTimeout()) + "s waiting for " + mode + " lock " + o.name + " of " + o.id)
+	}
+
+	return err
 }
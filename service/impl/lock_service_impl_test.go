@@ -0,0 +1,61 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"testing"
+	"time"
+)
+
+func TestLockTimeout(t *testing.T) {
+	s := newTestServices(t)
+	s.Config.LockTimeout = 1
+
+	reader := metricsdk.NewManualReader()
+	metrics, err := NewLockMetrics(metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("lock-service"))
+	assert.NoError(t, err)
+	s.LockService.Metrics = metrics
+
+	ctx := context.Background()
+	writer := s.LockService.GetRwLock(ctx, "exhibit-id", "exhibit")
+	assert.NoError(t, writer.Lock(ctx))
+
+	start := time.Now()
+	err = s.LockService.GetRwLock(ctx, "exhibit-id", "exhibit").RLock(ctx)
+	assert.EqualError(t, err, "timed out after 1s waiting for read lock exhibit of exhibit-id")
+	assert.WithinDuration(t, start.Add(time.Second), time.Now(), 500*time.Millisecond)
+
+	// a caller giving up on its own is not a lock timeout
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, s.LockService.GetRwLock(ctx, "exhibit-id", "exhibit").Lock(cancelled), context.Canceled)
+
+	assert.NoError(t, writer.Unlock())
+
+	rm := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(ctx, &rm))
+
+	recorded := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		recorded[m.Name] = m.Data
+	}
+
+	timeouts := recorded["museum.lock.timeouts"].(metricdata.Sum[int64])
+	assert.Len(t, timeouts.DataPoints, 1)
+	assert.Equal(t, int64(1), timeouts.DataPoints[0].Value)
+
+	waiting := recorded["museum.lock.waiting"].(metricdata.Sum[int64])
+	for _, dp := range waiting.DataPoints {
+		assert.Equal(t, int64(0), dp.Value)
+	}
+
+	// one acquisition per mode was measured: write, timed out read, cancelled write
+	waitTime := recorded["museum.lock.wait.duration"].(metricdata.Histogram[float64])
+	count := uint64(0)
+	for _, dp := range waitTime.DataPoints {
+		count += dp.Count
+	}
+	assert.Equal(t, uint64(3), count)
+}
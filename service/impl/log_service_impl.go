@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"io"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxTailBytes limits the output that is kept of a single object, so a chatty object cannot bloat the state
+const maxTailBytes = 64 << 10
+
+type LogServiceImpl struct {
+	ExhibitService service.ExhibitService
+	DockerClient   service.ContainerRuntime
+	State          persistence.State
+	Provider       trace.TracerProvider
+	Log            *zap.SugaredLogger
+}
+
+func (l LogServiceImpl) Logs(ctx context.Context, id string, object string, options domain.LogOptions, w io.Writer) error {
+	subCtx, span := l.Provider.
+		Tracer("log-service").
+		Start(ctx, "Logs", trace.WithAttributes(attribute.String("exhibitId", id), attribute.String("object", object), attribute.Bool("follow", options.Follow)))
+	defer span.End()
+
+	exhibit, err := l.ExhibitService.GetExhibitById(subCtx, id)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(exhibit.Objects, func(o domain.Object) bool { return o.Name == object })
+	if i < 0 {
+		return errors.New("object " + object + " is not part of exhibit " + exhibit.Name)
+	}
+	if options.Replica < 0 || options.Replica >= exhibit.Objects[i].Replicas.Most() {
+		return errors.New("object " + object + " has no replica " + strconv.Itoa(options.Replica+1))
+	}
+
+	tail := "all"
+	if options.Tail > 0 {
+		tail = strconv.Itoa(options.Tail)
+	}
+
+	// containers are named after the exhibit and the replica of the object, see startExhibitObject
+	logs, err := l.DockerClient.ContainerLogs(subCtx, exhibit.Name+"_"+exhibit.Objects[i].Replica(options.Replica).Name, container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: tail, Follow: options.Follow})
+	if errdefs.IsNotFound(err) {
+		// the containers of an exhibit that failed to start are removed, only their startup logs are left
+		if exhibit.RuntimeInfo.Failure != nil {
+			span.AddEvent("reading startup logs")
+			return l.startupLogs(subCtx, exhibit, object, options, w)
+		}
+		return errors.New("object " + object + " has no logs, the exhibit is not running")
+	}
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	defer logs.Close()
+
+	_, err = stdcopy.StdCopy(w, w, logs)
+	return err
+}
+
+func (l LogServiceImpl) startupLogs(ctx context.Context, exhibit domain.Exhibit, object string, options domain.LogOptions, w io.Writer) error {
+	startupLogs, err := l.State.GetStartupLogs(ctx, exhibit.Id)
+	if err != nil {
+		return errors.New("object " + object + " has no logs, the exhibit failed to start before its logs were kept")
+	}
+
+	replicas := startupLogs.Objects[object]
+	if options.Replica >= len(replicas) {
+		return errors.New("object " + object + " has no logs, it was not started before the exhibit failed to start")
+	}
+	logs := replicas[options.Replica]
+
+	lines := strings.SplitAfter(logs, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if options.Tail > 0 && options.Tail < len(lines) {
+		lines = lines[len(lines)-options.Tail:]
+	}
+
+	_, err = io.WriteString(w, strings.Join(lines, ""))
+	return err
+}
+
+func (l LogServiceImpl) Tail(ctx context.Context, nameOrId string, lines int) string {
+	subCtx, span := l.Provider.
+		Tracer("log-service").
+		Start(ctx, "Tail", trace.WithAttributes(attribute.String("container", nameOrId), attribute.Int("lines", lines)))
+	defer span.End()
+
+	logs, err := l.DockerClient.ContainerLogs(subCtx, nameOrId, container.LogsOptions{ShowStdout: true, ShowStderr: true, Tail: strconv.Itoa(lines)})
+	if err != nil {
+		span.RecordError(err)
+		l.Log.Debugw("error reading logs of container", "error", err, "container", nameOrId)
+		return ""
+	}
+	defer logs.Close()
+
+	buf := &bytes.Buffer{}
+	_, err = stdcopy.StdCopy(buf, buf, logs)
+	if err != nil {
+		span.RecordError(err)
+		l.Log.Debugw("error reading logs of container", "error", err, "container", nameOrId)
+	}
+
+	if buf.Len() > maxTailBytes {
+		return string(buf.Bytes()[buf.Len()-maxTailBytes:])
+	}
+	return buf.String()
+}
+
+func (l LogServiceImpl) SetStartupLogs(ctx context.Context, id string, logs domain.StartupLogs) error {
+	subCtx, span := l.Provider.
+		Tracer("log-service").
+		Start(ctx, "SetStartupLogs", trace.WithAttributes(attribute.String("exhibitId", id), attribute.Int("objects", len(logs.Objects))))
+	defer span.End()
+
+	return l.State.SetStartupLogs(subCtx, id, logs)
+}
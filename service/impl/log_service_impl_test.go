@@ -0,0 +1,123 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+)
+
+func TestLogsOfRunningExhibit(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	exhibit := s.createExhibit(t, newTestExhibit("logs"))
+
+	// containers only exist while the exhibit runs
+	err := s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{}, &bytes.Buffer{})
+	assert.EqualError(t, err, "object web has no logs, the exhibit is not running")
+
+	assert.NoError(t, s.Provisioner.StartApplication(ctx, exhibit.Id))
+	s.Runtime.SetLogs("logs_web", "starting nginx\nstderr: warning: no index.html\nready\n")
+
+	logs := &bytes.Buffer{}
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{}, logs))
+	assert.Equal(t, "starting nginx\nwarning: no index.html\nready\n", logs.String())
+
+	logs.Reset()
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{Tail: 2}, logs))
+	assert.Equal(t, "warning: no index.html\nready\n", logs.String())
+
+	err = s.Logs.Logs(ctx, exhibit.Id, "cache", domain.LogOptions{}, logs)
+	assert.EqualError(t, err, "object cache is not part of exhibit logs")
+}
+
+func TestLogsOfFailedStart(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	exhibit := newTestExhibit("failing")
+	exhibit.Objects[1].Livecheck = &domain.Livecheck{
+		Type:   domain.LivecheckTypeExec,
+		Config: domain.StringMap{"command": "curl -f localhost", "maxRetries": "1", "interval": "1ms"},
+	}
+	exhibit = s.createExhibit(t, exhibit)
+
+	s.Runtime.ExecExitCode = func(containerName string, cmd []string) int {
+		return 1
+	}
+	s.Runtime.SetLogs("failing_db", "database system is ready to accept connections\n")
+	s.Runtime.SetLogs("failing_web", "starting nginx\nstderr: could not resolve host db\n")
+
+	assert.EqualError(t, s.Provisioner.StartApplication(ctx, exhibit.Id), "livecheck failed")
+
+	// the container of web is removed, but the end of its logs is kept with those of the objects started before it
+	_, ok := s.Runtime.GetContainer("failing_web")
+	assert.False(t, ok)
+
+	logs := &bytes.Buffer{}
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{}, logs))
+	assert.Equal(t, "starting nginx\ncould not resolve host db\n", logs.String())
+
+	logs.Reset()
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{Tail: 1}, logs))
+	assert.Equal(t, "could not resolve host db\n", logs.String())
+
+	// db is still running until the exhibit stopped, its logs are read from its container either way
+	logs.Reset()
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "db", domain.LogOptions{}, logs))
+	assert.Equal(t, "database system is ready to accept connections\n", logs.String())
+
+	startupLogs, err := s.State.GetStartupLogs(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"database system is ready to accept connections\n"}, startupLogs.Objects["db"])
+
+	// the logs are dropped with the exhibit
+	assert.NoError(t, s.ExhibitService.DeleteExhibitById(ctx, exhibit.Id))
+	_, err = s.State.GetStartupLogs(ctx, exhibit.Id)
+	assert.Error(t, err)
+}
+
+func TestLogsOfReplicas(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	exhibit := withReplicas(newTestExhibit("replicated"), domain.Replicas{Count: 3})
+	exhibit.Objects[1].Livecheck = &domain.Livecheck{
+		Type:   domain.LivecheckTypeExec,
+		Config: domain.StringMap{"command": "curl -f localhost", "maxRetries": "1", "interval": "1ms"},
+	}
+	exhibit = s.createExhibit(t, exhibit)
+
+	// the second replica never becomes healthy, the third one is not started anymore
+	s.Runtime.ExecExitCode = func(containerName string, cmd []string) int {
+		if containerName == "replicated_web_2" {
+			return 1
+		}
+		return 0
+	}
+	s.Runtime.SetLogs("replicated_web", "first replica\n")
+	s.Runtime.SetLogs("replicated_web_2", "second replica\nstderr: could not resolve host db\n")
+
+	assert.EqualError(t, s.Provisioner.StartApplication(ctx, exhibit.Id), "livecheck failed")
+
+	startupLogs, err := s.State.GetStartupLogs(ctx, exhibit.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first replica\n", "second replica\ncould not resolve host db\n"}, startupLogs.Objects["web"])
+
+	// the container of the first replica is still there, the second one is only left in the startup logs
+	logs := &bytes.Buffer{}
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{}, logs))
+	assert.Equal(t, "first replica\n", logs.String())
+
+	logs.Reset()
+	assert.NoError(t, s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{Replica: 1}, logs))
+	assert.Equal(t, "second replica\ncould not resolve host db\n", logs.String())
+
+	err = s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{Replica: 2}, logs)
+	assert.EqualError(t, err, "object web has no logs, it was not started before the exhibit failed to start")
+
+	err = s.Logs.Logs(ctx, exhibit.Id, "web", domain.LogOptions{Replica: 3}, logs)
+	assert.EqualError(t, err, "object web has no replica 4")
+}
@@ -0,0 +1,396 @@
+package impl
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/domain"
+	service "museum/service/interface"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// oaiPageSize is the number of records in one response of a list, the rest is fetched with resumption tokens
+const oaiPageSize = 100
+
+// oaiVerb lists the arguments of a verb, the exclusive argument may not be combined with others
+type oaiVerb struct {
+	required  []string
+	optional  []string
+	exclusive string
+}
+
+var oaiVerbs = map[string]oaiVerb{
+	"Identify":            {},
+	"ListMetadataFormats": {optional: []string{"identifier"}},
+	"ListSets":            {exclusive: "resumptionToken"},
+	"ListIdentifiers":     {required: []string{"metadataPrefix"}, optional: []string{"from", "until", "set"}, exclusive: "resumptionToken"},
+	"ListRecords":         {required: []string{"metadataPrefix"}, optional: []string{"from", "until", "set"}, exclusive: "resumptionToken"},
+	"GetRecord":           {required: []string{"identifier", "metadataPrefix"}},
+}
+
+// oaiQuery is a selective harvest, resumption tokens carry it with the position of the last record returned
+type oaiQuery struct {
+	MetadataPrefix string `json:"p"`
+	Set            string `json:"s,omitempty"`
+	From           string `json:"f,omitempty"`
+	Until          string `json:"u,omitempty"`
+	Datestamp      int64  `json:"d,omitempty"`
+	Id             string `json:"i,omitempty"`
+	Cursor         int    `json:"c,omitempty"`
+}
+
+type OaiServiceImpl struct {
+	ExhibitService service.ExhibitService
+	Config         config.Config
+	Provider       trace.TracerProvider
+	Log            *zap.SugaredLogger
+	// PageSize overrides oaiPageSize if it is set
+	PageSize int
+}
+
+func (o OaiServiceImpl) Handle(ctx context.Context, args url.Values) domain.OaiPmh {
+	subCtx, span := o.Provider.
+		Tracer("oai-service").
+		Start(ctx, "Handle", trace.WithAttributes(attribute.String("verb", args.Get("verb"))))
+	defer span.End()
+
+	pmh := domain.NewOaiPmh(o.Config.GetPublicUrl()+"/oai", time.Now())
+	pmh.Request = domain.OaiRequest{
+		Verb:            args.Get("verb"),
+		Identifier:      args.Get("identifier"),
+		MetadataPrefix:  args.Get("metadataPrefix"),
+		From:            args.Get("from"),
+		Until:           args.Get("until"),
+		Set:             args.Get("set"),
+		ResumptionToken: args.Get("resumptionToken"),
+		BaseUrl:         pmh.Request.BaseUrl,
+	}
+
+	if !o.checkArguments(&pmh, args) {
+		return pmh
+	}
+
+	switch args.Get("verb") {
+	case "Identify":
+		o.identify(subCtx, &pmh)
+	case "ListMetadataFormats":
+		o.listMetadataFormats(subCtx, &pmh, args)
+	case "ListSets":
+		o.listSets(subCtx, &pmh, args)
+	case "ListIdentifiers":
+		exhibits, token, ok := o.list(subCtx, &pmh, args)
+		if ok {
+			pmh.ListIdentifiers = &domain.OaiListIdentifiers{ResumptionToken: token}
+			for _, exhibit := range exhibits {
+				pmh.ListIdentifiers.Headers = append(pmh.ListIdentifiers.Headers, o.header(exhibit))
+			}
+		}
+	case "ListRecords":
+		exhibits, token, ok := o.list(subCtx, &pmh, args)
+		if ok {
+			pmh.ListRecords = &domain.OaiListRecords{ResumptionToken: token}
+			for _, exhibit := range exhibits {
+				pmh.ListRecords.Records = append(pmh.ListRecords.Records, o.record(exhibit))
+			}
+		}
+	case "GetRecord":
+		o.getRecord(subCtx, &pmh, args)
+	}
+
+	for _, e := range pmh.Errors {
+		span.AddEvent("oai error", trace.WithAttributes(attribute.String("code", e.Code), attribute.String("message", e.Message)))
+	}
+
+	return pmh
+}
+
+// checkArguments reports a missing or unknown verb and arguments the verb does not take, repeated or missing
+func (o OaiServiceImpl) checkArguments(pmh *domain.OaiPmh, args url.Values) bool {
+	verbs := args["verb"]
+	if len(verbs) == 0 {
+		pmh.Fail(domain.OaiBadVerb, "verb is missing")
+		return false
+	}
+	if len(verbs) > 1 {
+		pmh.Fail(domain.OaiBadVerb, "verb must not be repeated")
+		return false
+	}
+	verb, ok := oaiVerbs[verbs[0]]
+	if !ok {
+		pmh.Fail(domain.OaiBadVerb, "illegal verb "+verbs[0])
+		return false
+	}
+
+	names := make([]string, 0, len(args))
+	for name := range args {
+		if name != "verb" {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if len(args[name]) > 1 {
+			pmh.Fail(domain.OaiBadArgument, "argument "+name+" must not be repeated")
+		} else if name != verb.exclusive && !slices.Contains(verb.required, name) && !slices.Contains(verb.optional, name) {
+			pmh.Fail(domain.OaiBadArgument, "illegal argument "+name+" for verb "+verbs[0])
+		}
+	}
+
+	if verb.exclusive != "" && args.Has(verb.exclusive) {
+		if len(names) > 1 {
+			pmh.Fail(domain.OaiBadArgument, verb.exclusive+" is an exclusive argument")
+		}
+	} else {
+		for _, name := range verb.required {
+			if !args.Has(name) {
+				pmh.Fail(domain.OaiBadArgument, "missing required argument "+name)
+			}
+		}
+	}
+
+	return len(pmh.Errors) == 0
+}
+
+func (o OaiServiceImpl) identify(ctx context.Context, pmh *domain.OaiPmh) {
+	earliest := time.Unix(0, 0).UTC()
+	exhibits := o.ExhibitService.GetAllExhibits(ctx)
+	for i, exhibit := range exhibits {
+		if i == 0 || exhibit.Datestamp().Before(earliest) {
+			earliest = exhibit.Datestamp()
+		}
+	}
+
+	pmh.Identify = &domain.OaiIdentify{
+		RepositoryName:    o.Config.GetPublisher(),
+		BaseUrl:           pmh.Request.BaseUrl,
+		ProtocolVersion:   "2.0",
+		AdminEmail:        o.Config.GetAdminEmail(),
+		EarliestDatestamp: earliest.Format(domain.OaiDatestampFormat),
+		// deleted exhibits are not remembered
+		DeletedRecord: "no",
+		Granularity:   "YYYY-MM-DDThh:mm:ssZ",
+	}
+}
+
+func (o OaiServiceImpl) listMetadataFormats(ctx context.Context, pmh *domain.OaiPmh, args url.Values) {
+	if args.Has("identifier") {
+		if _, err := o.exhibit(ctx, args.Get("identifier")); err != nil {
+			pmh.Fail(domain.OaiIdDoesNotExist, err.Error())
+			return
+		}
+	}
+
+	pmh.ListMetadataFormats = &domain.OaiListMetadataFormats{MetadataFormats: []domain.OaiMetadataFormat{domain.OaiDcFormat}}
+}
+
+// listSets returns all sets in one response, there are never enough of them to need resumption tokens
+func (o OaiServiceImpl) listSets(ctx context.Context, pmh *domain.OaiPmh, args url.Values) {
+	if args.Has("resumptionToken") {
+		pmh.Fail(domain.OaiBadResumptionToken, "the list of sets is never split")
+		return
+	}
+
+	pmh.ListSets = &domain.OaiListSets{Sets: domain.OaiSetsOf(o.ExhibitService.GetAllExhibits(ctx))}
+}
+
+func (o OaiServiceImpl) getRecord(ctx context.Context, pmh *domain.OaiPmh, args url.Values) {
+	exhibit, err := o.exhibit(ctx, args.Get("identifier"))
+	if err != nil {
+		pmh.Fail(domain.OaiIdDoesNotExist, err.Error())
+	}
+	if args.Get("metadataPrefix") != domain.OaiDcPrefix {
+		pmh.Fail(domain.OaiCannotDisseminateFormat, "metadata format "+args.Get("metadataPrefix")+" is not supported, only "+domain.OaiDcPrefix+" is")
+	}
+	if len(pmh.Errors) > 0 {
+		return
+	}
+
+	pmh.GetRecord = &domain.OaiGetRecord{Record: o.record(exhibit)}
+}
+
+// list selects a page of the exhibits a harvest matches, ordered by datestamp and id so that
+// a resumption token can continue after the last exhibit it returned
+func (o OaiServiceImpl) list(ctx context.Context, pmh *domain.OaiPmh, args url.Values) ([]domain.Exhibit, *domain.OaiResumptionToken, bool) {
+	query := oaiQuery{
+		MetadataPrefix: args.Get("metadataPrefix"),
+		Set:            args.Get("set"),
+		From:           args.Get("from"),
+		Until:          args.Get("until"),
+	}
+
+	resumed := args.Has("resumptionToken")
+	if resumed {
+		var err error
+		query, err = decodeOaiQuery(args.Get("resumptionToken"))
+		if err != nil {
+			pmh.Fail(domain.OaiBadResumptionToken, "resumption token is invalid")
+			return nil, nil, false
+		}
+	}
+
+	if query.MetadataPrefix != domain.OaiDcPrefix {
+		pmh.Fail(domain.OaiCannotDisseminateFormat, "metadata format "+query.MetadataPrefix+" is not supported, only "+domain.OaiDcPrefix+" is")
+		return nil, nil, false
+	}
+
+	from, until, err := parseOaiRange(query.From, query.Until)
+	if err != nil {
+		pmh.Fail(domain.OaiBadArgument, err.Error())
+		return nil, nil, false
+	}
+
+	exhibits := slices.DeleteFunc(o.ExhibitService.GetAllExhibits(ctx), func(e domain.Exhibit) bool {
+		return (query.Set != "" && !e.InOaiSet(query.Set)) || e.Datestamp().Before(from) || e.Datestamp().After(until)
+	})
+	slices.SortFunc(exhibits, func(a, b domain.Exhibit) int {
+		return cmp.Or(a.Datestamp().Compare(b.Datestamp()), strings.Compare(a.Id, b.Id))
+	})
+	completeListSize := len(exhibits)
+
+	if resumed {
+		exhibits = slices.DeleteFunc(exhibits, func(e domain.Exhibit) bool {
+			return cmp.Or(cmp.Compare(e.Datestamp().Unix(), query.Datestamp), strings.Compare(e.Id, query.Id)) <= 0
+		})
+	}
+
+	if len(exhibits) == 0 {
+		pmh.Fail(domain.OaiNoRecordsMatch, "no exhibits match the request")
+		return nil, nil, false
+	}
+
+	pageSize := cmp.Or(o.PageSize, oaiPageSize)
+	page := exhibits[:min(pageSize, len(exhibits))]
+
+	// the last response of a split list has an empty token
+	var token *domain.OaiResumptionToken
+	if len(page) < len(exhibits) {
+		last := page[len(page)-1]
+		next := query
+		next.Datestamp = last.Datestamp().Unix()
+		next.Id = last.Id
+		next.Cursor = query.Cursor + len(page)
+		token = &domain.OaiResumptionToken{CompleteListSize: completeListSize, Cursor: query.Cursor, Token: encodeOaiQuery(next)}
+	} else if resumed {
+		token = &domain.OaiResumptionToken{CompleteListSize: completeListSize, Cursor: query.Cursor}
+	}
+
+	return page, token, true
+}
+
+// exhibit looks up an exhibit by its oai identifier
+func (o OaiServiceImpl) exhibit(ctx context.Context, identifier string) (domain.Exhibit, error) {
+	id, ok := strings.CutPrefix(identifier, domain.OaiIdentifier(o.host(), ""))
+	if !ok {
+		return domain.Exhibit{}, errors.New("identifier " + identifier + " is not an identifier of this repository")
+	}
+
+	exhibit, err := o.ExhibitService.GetExhibitById(ctx, id)
+	if err != nil {
+		return domain.Exhibit{}, errors.New("exhibit " + identifier + " does not exist")
+	}
+
+	return exhibit, nil
+}
+
+func (o OaiServiceImpl) header(exhibit domain.Exhibit) domain.OaiHeader {
+	return domain.OaiHeader{
+		Identifier: domain.OaiIdentifier(o.host(), exhibit.Id),
+		Datestamp:  exhibit.Datestamp().Format(domain.OaiDatestampFormat),
+		SetSpecs:   exhibit.OaiSetSpecs(),
+	}
+}
+
+func (o OaiServiceImpl) record(exhibit domain.Exhibit) domain.OaiRecord {
+	return domain.OaiRecord{
+		Header:   o.header(exhibit),
+		Metadata: domain.OaiDcContainer{Dc: exhibit.OaiDc(o.Config.GetPublicUrl()+"/exhibit/"+exhibit.Id, o.Config.GetPublisher())},
+	}
+}
+
+// host is the repository identifier in oai identifiers, the host of the public url
+func (o OaiServiceImpl) host() string {
+	u, err := url.Parse(o.Config.GetPublicUrl())
+	if err != nil || u.Hostname() == "" {
+		return o.Config.GetHostname()
+	}
+	return u.Hostname()
+}
+
+// parseOaiRange parses the from and until arguments, which must have the same granularity.
+// The range is inclusive, until on day granularity includes the whole day.
+func parseOaiRange(from string, until string) (time.Time, time.Time, error) {
+	start, end := time.Unix(0, 0).UTC(), time.Unix(1<<62, 0).UTC()
+
+	fromDay, untilDay := len(from) == len(domain.OaiDayFormat), len(until) == len(domain.OaiDayFormat)
+	if from != "" && until != "" && fromDay != untilDay {
+		return start, end, errors.New("from and until must have the same granularity")
+	}
+
+	if from != "" {
+		t, err := parseOaiDate(from)
+		if err != nil {
+			return start, end, err
+		}
+		start = t
+	}
+
+	if until != "" {
+		t, err := parseOaiDate(until)
+		if err != nil {
+			return start, end, err
+		}
+		end = t
+		if untilDay {
+			end = end.Add(24*time.Hour - time.Second)
+		}
+	}
+
+	if end.Before(start) {
+		return start, end, errors.New("from must not be after until")
+	}
+
+	return start, end, nil
+}
+
+func parseOaiDate(s string) (time.Time, error) {
+	layout := domain.OaiDatestampFormat
+	if len(s) == len(domain.OaiDayFormat) {
+		layout = domain.OaiDayFormat
+	}
+
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return t, errors.New("date " + s + " must be formatted as YYYY-MM-DD or YYYY-MM-DDThh:mm:ssZ")
+	}
+	return t, nil
+}
+
+// resumption tokens are stateless, they encode the query and the position in the list
+func encodeOaiQuery(query oaiQuery) string {
+	b, _ := json.Marshal(query)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeOaiQuery(token string) (oaiQuery, error) {
+	var query oaiQuery
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return query, err
+	}
+
+	err = json.Unmarshal(b, &query)
+	if err == nil && query.Id == "" {
+		err = errors.New("resumption token has no position")
+	}
+	return query, err
+}
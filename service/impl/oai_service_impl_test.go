@@ -0,0 +1,140 @@
+package impl
+
+import (
+	"context"
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"net/url"
+	"testing"
+)
+
+func TestOaiListRecordsResumes(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	physics := newTestExhibit("oai-physics")
+	physics.Meta = map[string]interface{}{"collection": "Physics", "tags": []interface{}{"Simulation", "Fortran 77"}}
+	physics.Metadata = &domain.Metadata{Title: "Lattice Simulation", Creators: []domain.Creator{{Name: "Doe, Jane"}}, Year: 2021, Publication: "10.1000/182", License: "MIT"}
+	physics = s.createExhibit(t, physics)
+
+	chemistry := newTestExhibit("oai-chemistry")
+	chemistry.Meta = map[string]interface{}{"collection": "Chemistry"}
+	s.createExhibit(t, chemistry)
+	s.createExhibit(t, newTestExhibit("oai-untagged"))
+
+	// the first page is split off with a token
+	res := s.Oai.Handle(ctx, url.Values{"verb": {"ListIdentifiers"}, "metadataPrefix": {"oai_dc"}})
+	assert.Empty(t, res.Errors)
+	assert.Len(t, res.ListIdentifiers.Headers, 2)
+	token := res.ListIdentifiers.ResumptionToken
+	assert.Equal(t, 3, token.CompleteListSize)
+	assert.Equal(t, 0, token.Cursor)
+	assert.NotEmpty(t, token.Token)
+
+	// the last page has an empty token
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"ListIdentifiers"}, "resumptionToken": {token.Token}})
+	assert.Empty(t, res.Errors)
+	assert.Len(t, res.ListIdentifiers.Headers, 1)
+	assert.Equal(t, 2, res.ListIdentifiers.ResumptionToken.Cursor)
+	assert.Empty(t, res.ListIdentifiers.ResumptionToken.Token)
+
+	// sets include their subsets
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}, "set": {"collection"}})
+	assert.Empty(t, res.Errors)
+	assert.Len(t, res.ListRecords.Records, 2)
+	assert.Nil(t, res.ListRecords.ResumptionToken)
+
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}, "set": {"tag:fortran-77"}})
+	assert.Empty(t, res.Errors)
+	assert.Len(t, res.ListRecords.Records, 1)
+	record := res.ListRecords.Records[0]
+	assert.Equal(t, "oai:localhost:"+physics.Id, record.Header.Identifier)
+	assert.Equal(t, []string{"collection:physics", "tag:fortran-77", "tag:simulation"}, record.Header.SetSpecs)
+	assert.Equal(t, []string{"Lattice Simulation"}, record.Metadata.Dc.Titles)
+	assert.Equal(t, []string{"Doe, Jane"}, record.Metadata.Dc.Creators)
+	assert.Equal(t, []string{"https://doi.org/10.1000/182"}, record.Metadata.Dc.Relations)
+	assert.Equal(t, []string{physics.Pid, "http://localhost:8080/exhibit/" + physics.Id}, record.Metadata.Dc.Identifiers)
+
+	// datestamps are the creation times
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"ListIdentifiers"}, "metadataPrefix": {"oai_dc"}, "until": {"2000-01-01"}})
+	assert.Equal(t, domain.OaiNoRecordsMatch, res.Errors[0].Code)
+	assert.Nil(t, res.ListIdentifiers)
+
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"ListSets"}})
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, []domain.OaiSet{
+		{Spec: "collection", Name: "Collections"},
+		{Spec: "collection:chemistry", Name: "Chemistry"},
+		{Spec: "collection:physics", Name: "Physics"},
+		{Spec: "tag", Name: "Tags"},
+		{Spec: "tag:fortran-77", Name: "Fortran 77"},
+		{Spec: "tag:simulation", Name: "Simulation"},
+	}, res.ListSets.Sets)
+}
+
+func TestOaiGetRecord(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	created := s.createExhibit(t, newTestExhibit("oai-record"))
+	identifier := "oai:localhost:" + created.Id
+
+	res := s.Oai.Handle(ctx, url.Values{"verb": {"GetRecord"}, "identifier": {identifier}, "metadataPrefix": {"oai_dc"}})
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, "GetRecord", res.Request.Verb)
+	assert.Equal(t, []string{"oai-record"}, res.GetRecord.Record.Metadata.Dc.Titles)
+
+	body, err := xml.Marshal(res)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"`)
+	assert.Contains(t, string(body), `<oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/"`)
+	assert.Contains(t, string(body), `<dc:title>oai-record</dc:title>`)
+	assert.Contains(t, string(body), `<request verb="GetRecord" identifier="`+identifier+`" metadataPrefix="oai_dc">http://localhost:8080/oai</request>`)
+
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"GetRecord"}, "identifier": {"oai:localhost:missing"}, "metadataPrefix": {"marc"}})
+	assert.Equal(t, []string{domain.OaiIdDoesNotExist, domain.OaiCannotDisseminateFormat}, []string{res.Errors[0].Code, res.Errors[1].Code})
+
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"ListMetadataFormats"}, "identifier": {identifier}})
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, domain.OaiDcPrefix, res.ListMetadataFormats.MetadataFormats[0].MetadataPrefix)
+
+	res = s.Oai.Handle(ctx, url.Values{"verb": {"Identify"}})
+	assert.Empty(t, res.Errors)
+	assert.Equal(t, "http://localhost:8080/oai", res.Identify.BaseUrl)
+	assert.Equal(t, created.Datestamp().Format(domain.OaiDatestampFormat), res.Identify.EarliestDatestamp)
+}
+
+func TestOaiArgumentErrors(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	cases := []struct {
+		args url.Values
+		code string
+	}{
+		{url.Values{}, domain.OaiBadVerb},
+		{url.Values{"verb": {"Delete"}}, domain.OaiBadVerb},
+		{url.Values{"verb": {"Identify", "Identify"}}, domain.OaiBadVerb},
+		{url.Values{"verb": {"Identify"}, "set": {"tag"}}, domain.OaiBadArgument},
+		{url.Values{"verb": {"ListRecords"}}, domain.OaiBadArgument},
+		{url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}, "resumptionToken": {"x"}}, domain.OaiBadArgument},
+		{url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}, "from": {"2020-01-01"}, "until": {"2020-01-01T00:00:00Z"}}, domain.OaiBadArgument},
+		{url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}, "from": {"2021-01-01"}, "until": {"2020-01-01"}}, domain.OaiBadArgument},
+		{url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}, "from": {"yesterday"}}, domain.OaiBadArgument},
+		{url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"marc"}}, domain.OaiCannotDisseminateFormat},
+		{url.Values{"verb": {"ListRecords"}, "resumptionToken": {"not a token"}}, domain.OaiBadResumptionToken},
+		{url.Values{"verb": {"ListSets"}, "resumptionToken": {"x"}}, domain.OaiBadResumptionToken},
+		{url.Values{"verb": {"ListRecords"}, "metadataPrefix": {"oai_dc"}}, domain.OaiNoRecordsMatch},
+	}
+
+	for _, c := range cases {
+		res := s.Oai.Handle(ctx, c.args)
+		if assert.NotEmpty(t, res.Errors, c.args.Encode()) {
+			assert.Equal(t, c.code, res.Errors[0].Code, c.args.Encode())
+		}
+		if c.code == domain.OaiBadVerb || c.code == domain.OaiBadArgument {
+			assert.Equal(t, domain.OaiRequest{BaseUrl: "http://localhost:8080/oai"}, res.Request)
+		}
+	}
+}
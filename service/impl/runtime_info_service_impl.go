@@ -19,7 +19,7 @@ func (r RuntimeInfoServiceImpl) SetRuntimeInfo(ctx context.Context, id string, r
 
 func (r RuntimeInfoServiceImpl) GetRuntimeInfo(ctx context.Context, id string) (ri domain.ExhibitRuntimeInfo, err error) {
 	lock := r.LockService.GetRwLock(ctx, id, "runtime_info")
-	err = lock.RLock()
+	err = lock.RLock(ctx)
 	if err != nil {
 		return
 	}
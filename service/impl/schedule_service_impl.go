@@ -0,0 +1,78 @@
+package impl
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"time"
+)
+
+// scheduleLead is how long before a window opens the exhibit is started, so that it is ready when it opens
+const scheduleLead = 5 * time.Minute
+
+type ScheduleServiceImpl struct {
+	Config                        config.Config
+	State                         persistence.State
+	ExhibitService                service.ExhibitService
+	ApplicationProvisionerService service.ApplicationProvisionerService
+	Provider                      trace.TracerProvider
+	Log                           *zap.SugaredLogger
+}
+
+func (s ScheduleServiceImpl) Lead(ctx context.Context) (context.Context, error) {
+	return s.State.Campaign(ctx, "scheduler")
+}
+
+func (s ScheduleServiceImpl) Evaluate(ctx context.Context) error {
+	subCtx, span := s.Provider.
+		Tracer("schedule-service").
+		Start(ctx, "Evaluate")
+	defer span.End()
+
+	// an instance that lost the lead leaves the exhibits to the new leader
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	now := time.Now()
+	started := 0
+	for _, exhibit := range s.ExhibitService.GetAllExhibits(subCtx) {
+		if !exhibit.Scheduled(now) && !exhibit.Scheduled(now.Add(scheduleLead)) {
+			continue
+		}
+
+		// queued exhibits are asked again, that keeps their place in the queue
+		status := exhibit.RuntimeInfo.Status
+		if status != domain.Stopped && status != domain.NotCreated && status != domain.Queued {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		s.Log.Infow("starting scheduled exhibit", "exhibitId", exhibit.Id)
+		span.AddEvent("starting exhibit " + exhibit.Id)
+		started++
+
+		// the start outlives the leadership, but not the time an exhibit may take to start
+		go func(id string) {
+			startCtx, cancel := context.WithTimeout(context.WithoutCancel(subCtx), time.Duration(s.Config.GetStartingTimeout())*time.Second)
+			defer cancel()
+
+			err := s.ApplicationProvisionerService.StartApplication(startCtx, id)
+			if err != nil {
+				s.Log.Warnw("error starting scheduled exhibit", "error", err, "exhibitId", id)
+			}
+		}(exhibit.Id)
+	}
+
+	span.SetAttributes(attribute.Int("started", started))
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	capacitypolicy "museum/config/capacity-policy"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func (s *testServices) newScheduleService() ScheduleServiceImpl {
+	return ScheduleServiceImpl{
+		Config:                        s.Config,
+		State:                         s.State,
+		ExhibitService:                s.ExhibitService,
+		ApplicationProvisionerService: s.Provisioner,
+		Provider:                      noop.NewTracerProvider(),
+		Log:                           zap.NewNop().Sugar(),
+	}
+}
+
+// openWindow returns a schedule whose only window is open right now
+func openWindow() *domain.Schedule {
+	return &domain.Schedule{Windows: []domain.ScheduleWindow{{
+		From: time.Now().UTC().Add(-time.Hour).Format(domain.ScheduleTimeLayout),
+		To:   time.Now().UTC().Add(time.Hour).Format(domain.ScheduleTimeLayout),
+	}}}
+}
+
+func TestEvaluateStartsScheduledExhibits(t *testing.T) {
+	s := newTestServices(t)
+	schedules := s.newScheduleService()
+
+	lecture := newTestExhibit("lecture")
+	lecture.Schedule = openWindow()
+	lecture = s.createExhibit(t, lecture)
+
+	upcoming := newTestExhibit("upcoming")
+	upcoming.Schedule = &domain.Schedule{Windows: []domain.ScheduleWindow{{
+		From: time.Now().UTC().Add(scheduleLead / 2).Format(domain.ScheduleTimeLayout),
+		To:   time.Now().UTC().Add(time.Hour).Format(domain.ScheduleTimeLayout),
+	}}}
+	upcoming = s.createExhibit(t, upcoming)
+
+	later := newTestExhibit("later")
+	later.Schedule = &domain.Schedule{Windows: []domain.ScheduleWindow{{
+		From: time.Now().UTC().Add(2 * time.Hour).Format(domain.ScheduleTimeLayout),
+		To:   time.Now().UTC().Add(3 * time.Hour).Format(domain.ScheduleTimeLayout),
+	}}}
+	later = s.createExhibit(t, later)
+
+	lead, err := schedules.Lead(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, schedules.Evaluate(lead))
+
+	for _, e := range []domain.Exhibit{lecture, upcoming} {
+		assert.Eventually(t, func() bool { return s.status(t, e.Id) == domain.Running }, 5*time.Second, 10*time.Millisecond)
+	}
+	assert.Equal(t, domain.NotCreated, s.status(t, later.Id))
+}
+
+func TestLeadWaitsForTheLeader(t *testing.T) {
+	s := newTestServices(t)
+
+	leaderCtx, resign := context.WithCancel(context.Background())
+	lead, err := s.newScheduleService().Lead(leaderCtx)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = s.newScheduleService().Lead(ctx)
+	assert.Error(t, err)
+
+	// an instance that does not lead anymore starts nothing
+	scheduled := newTestExhibit("scheduled")
+	scheduled.Schedule = openWindow()
+	scheduled = s.createExhibit(t, scheduled)
+
+	resign()
+	assert.Error(t, s.newScheduleService().Evaluate(lead))
+	assert.Equal(t, domain.NotCreated, s.status(t, scheduled.Id))
+}
+
+func TestCleanupKeepsScheduledExhibits(t *testing.T) {
+	s := newTestServices(t)
+	s.Config.StartingTimeout = 60
+
+	scheduled := newTestExhibit("scheduled")
+	scheduled.Schedule = openWindow()
+	scheduled = s.createExhibit(t, scheduled)
+
+	alwaysOn := newTestExhibit("always-on")
+	alwaysOn.Schedule = &domain.Schedule{AlwaysOn: true}
+	alwaysOn = s.createExhibit(t, alwaysOn)
+
+	for _, e := range []domain.Exhibit{scheduled, alwaysOn} {
+		assert.NoError(t, s.Provisioner.StartApplication(context.Background(), e.Id))
+	}
+
+	// the lease is 1h, the always on exhibit is even stuck starting
+	s.setRuntimeInfo(t, scheduled.Id, domain.Running, time.Now().Add(-2*time.Hour))
+	s.setRuntimeInfo(t, alwaysOn.Id, domain.Starting, time.Now().Add(-2*time.Hour))
+
+	assert.NoError(t, s.Cleanup.Cleanup())
+
+	assert.Equal(t, domain.Running, s.status(t, scheduled.Id))
+	assert.Equal(t, domain.Starting, s.status(t, alwaysOn.Id))
+}
+
+func TestScheduledExhibitsAreNotEvicted(t *testing.T) {
+	s := newTestServices(t)
+	s.CapacityService.MaxRunning = 1
+	s.CapacityService.Policy = capacitypolicy.PolicyEvict
+
+	scheduled := newTestExhibit("scheduled")
+	scheduled.Schedule = openWindow()
+	scheduled = s.createExhibit(t, scheduled)
+	visited := s.createExhibit(t, newTestExhibit("visited"))
+
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), scheduled.Id))
+	assert.NoError(t, s.Provisioner.StartApplication(context.Background(), visited.Id))
+
+	assert.Equal(t, domain.Running, s.status(t, scheduled.Id))
+	assert.Equal(t, domain.Queued, s.status(t, visited.Id))
+}
@@ -0,0 +1,222 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	metricNoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+	capacitypolicy "museum/config/capacity-policy"
+	configImpl "museum/config/impl"
+	"museum/domain"
+	"museum/persistence"
+	persistenceImpl "museum/persistence/impl"
+	"museum/service/fake"
+	"museum/util/cache"
+	"testing"
+	"time"
+)
+
+// testServices wires the services the same way cmd/server does, but on top of
+// in-memory state, in-memory eventing and a fake container runtime
+type testServices struct {
+	State    persistence.State
+	Eventing *persistenceImpl.MemoryEventing
+	Runtime  *fake.ContainerRuntime
+	Config   *configImpl.EnvConfig
+
+	LockService        *LockServiceImpl
+	CollectionService  *CollectionServiceImpl
+	CapacityService    *CapacityServiceImpl
+	ExhibitService     *ExhibitServiceImpl
+	RuntimeInfoService *RuntimeInfoServiceImpl
+	Resolver           *DockerExtHostApplicationResolverService
+	Provisioner        *DockerApplicationProvisionerService
+	Cleanup            *ExhibitCleanupServiceImpl
+	StateTransfer      *StateTransferServiceImpl
+	Bundle             *ExhibitBundleServiceImpl
+	Fixity             *FixityServiceImpl
+	Oai                *OaiServiceImpl
+	Logs               *LogServiceImpl
+}
+
+func newTestServices(t *testing.T) *testServices {
+	t.Helper()
+
+	log := zap.NewNop().Sugar()
+	provider := noop.NewTracerProvider()
+	cfg := &configImpl.EnvConfig{Hostname: "localhost", Port: "8080", StartingTimeout: 280, LockTimeout: 5, BundleVolumePath: t.TempDir(), FixityInterval: 24, PidPrefix: "urn:uuid:"}
+
+	state := persistence.NewMemoryState()
+	eventing := persistence.NewMemoryEventing()
+	runtime := fake.NewContainerRuntime()
+
+	lockMetrics, err := NewLockMetrics(metricNoop.NewMeterProvider().Meter("lock-service"))
+	assert.NoError(t, err)
+	lockService := &LockServiceImpl{State: state, Config: cfg, Provider: provider, Metrics: lockMetrics, Log: log}
+	runtimeInfoService := &RuntimeInfoServiceImpl{State: state, LockService: lockService}
+	lastAccessedService := &LastAccessedServiceImpl{State: state}
+	collectionService := &CollectionServiceImpl{
+		Collections: []domain.Collection{{Name: domain.DefaultCollection}},
+		State:       state,
+		LockService: lockService,
+		Provider:    provider,
+		Log:         log,
+	}
+
+	capacityService := &CapacityServiceImpl{
+		Policy:      capacitypolicy.PolicyQueue,
+		State:       state,
+		LockService: lockService,
+		Provider:    provider,
+		Log:         log,
+	}
+
+	exhibitService := &ExhibitServiceImpl{
+		State:                    state,
+		Eventing:                 eventing,
+		RuntimeInfoService:       runtimeInfoService,
+		Provider:                 provider,
+		LockService:              lockService,
+		Log:                      log,
+		DockerClient:             runtime,
+		VolumeProvisionerFactory: &VolumeProvisionerFactoryServiceImpl{},
+		CollectionService:        collectionService,
+		Config:                   cfg,
+	}
+
+	resolver := &DockerExtHostApplicationResolverService{
+		ExhibitService: exhibitService,
+		EndpointCache:  cache.NewLRU[string, *ResolvedEndpoints](10),
+		Client:         runtime,
+	}
+
+	logs := &LogServiceImpl{ExhibitService: exhibitService, DockerClient: runtime, State: state, Provider: provider, Log: log}
+
+	provisioner := &DockerApplicationProvisionerService{
+		ExhibitService: exhibitService,
+		LivecheckFactoryService: &LivecheckFactoryServiceImpl{
+			HttpLivecheck: &HttpLivecheck{ApplicationResolverService: resolver, ExhibitService: exhibitService},
+			ExecLivecheck: &ExecLivecheck{Client: runtime},
+		},
+		EnvironmentTemplateResolver: &EnvironmentTemplateResolverServiceImpl{Config: cfg},
+		Client:                      runtime,
+		LockService:                 lockService,
+		CollectionService:           collectionService,
+		CapacityService:             capacityService,
+		RuntimeInfoService:          runtimeInfoService,
+		LastAccessedService:         lastAccessedService,
+		Eventing:                    eventing,
+		Log:                         log,
+		Provider:                    provider,
+		Config:                      cfg,
+		VolumeProvisionerFactory:    &VolumeProvisionerFactoryServiceImpl{},
+		LogService:                  logs,
+	}
+
+	cleanup := &ExhibitCleanupServiceImpl{
+		ExhibitService:                exhibitService,
+		LockService:                   lockService,
+		ApplicationProvisionerService: provisioner,
+		CapacityService:               capacityService,
+		Provider:                      provider,
+		Log:                           log,
+		Config:                        cfg,
+	}
+
+	stateTransfer := &StateTransferServiceImpl{
+		State:                    state,
+		Eventing:                 eventing,
+		ExhibitService:           exhibitService,
+		CollectionService:        collectionService,
+		Provisioner:              provisioner,
+		LockService:              lockService,
+		DockerClient:             runtime,
+		VolumeProvisionerFactory: &VolumeProvisionerFactoryServiceImpl{},
+		Provider:                 provider,
+		Log:                      log,
+	}
+
+	bundle := &ExhibitBundleServiceImpl{
+		ExhibitService:           exhibitService,
+		CollectionService:        collectionService,
+		StateTransferService:     stateTransfer,
+		LockService:              lockService,
+		DockerClient:             runtime,
+		VolumeProvisionerFactory: &VolumeProvisionerFactoryServiceImpl{},
+		Config:                   cfg,
+		Provider:                 provider,
+		Log:                      log,
+	}
+
+	fixity := &FixityServiceImpl{
+		State:                    state,
+		Eventing:                 eventing,
+		ExhibitService:           exhibitService,
+		LockService:              lockService,
+		DockerClient:             runtime,
+		VolumeProvisionerFactory: &VolumeProvisionerFactoryServiceImpl{},
+		Config:                   cfg,
+		Provider:                 provider,
+		Log:                      log,
+	}
+
+	return &testServices{
+		State:              state,
+		Eventing:           eventing,
+		Runtime:            runtime,
+		Config:             cfg,
+		LockService:        lockService,
+		CollectionService:  collectionService,
+		CapacityService:    capacityService,
+		ExhibitService:     exhibitService,
+		RuntimeInfoService: runtimeInfoService,
+		Resolver:           resolver,
+		Provisioner:        provisioner,
+		Cleanup:            cleanup,
+		StateTransfer:      stateTransfer,
+		Bundle:             bundle,
+		Fixity:             fixity,
+		Oai:                &OaiServiceImpl{ExhibitService: exhibitService, Config: cfg, Provider: provider, Log: log, PageSize: 2},
+		Logs:               logs,
+	}
+}
+
+func newTestExhibit(name string) domain.Exhibit {
+	return domain.Exhibit{
+		Spec:   domain.ExhibitSpecV1,
+		Name:   name,
+		Expose: "web",
+		Lease:  "1h",
+		Objects: []domain.Object{
+			{Name: "db", Image: "postgres", Label: "16"},
+			{Name: "web", Image: "nginx", Label: "latest"},
+		},
+		Order: []string{"db", "web"},
+	}
+}
+
+// createExhibit creates an exhibit through the exhibit service and returns it with its runtime info
+func (s *testServices) createExhibit(t *testing.T, exhibit domain.Exhibit) domain.Exhibit {
+	t.Helper()
+
+	id, err := s.ExhibitService.CreateExhibit(context.Background(), domain.CreateExhibit{Exhibit: exhibit})
+	assert.NoError(t, err)
+
+	created, err := s.ExhibitService.GetExhibitById(context.Background(), id)
+	assert.NoError(t, err)
+
+	return created
+}
+
+// setRuntimeInfo overwrites the runtime info and last accessed time of an exhibit
+func (s *testServices) setRuntimeInfo(t *testing.T, id string, status domain.Status, lastAccessed time.Time) {
+	t.Helper()
+
+	info, err := s.State.GetRuntimeInfo(context.Background(), id)
+	assert.NoError(t, err)
+
+	info.Status = status
+	assert.NoError(t, s.State.SetRuntimeInfo(context.Background(), id, info))
+	assert.NoError(t, s.State.SetLastAccessed(context.Background(), id, lastAccessed.Unix()))
+}
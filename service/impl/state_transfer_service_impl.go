@@ -0,0 +1,352 @@
+package impl
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"museum/domain"
+	"museum/persistence"
+	service "museum/service/interface"
+	"museum/util"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type StateTransferServiceImpl struct {
+	State                    persistence.State
+	Eventing                 persistence.Eventing
+	ExhibitService           service.ExhibitService
+	CollectionService        service.CollectionService
+	Provisioner              service.ApplicationProvisionerService
+	LockService              service.LockService
+	DockerClient             service.ContainerRuntime
+	VolumeProvisionerFactory service.VolumeProvisionerFactoryService
+	Provider                 trace.TracerProvider
+	Log                      *zap.SugaredLogger
+}
+
+// collections returns the configured collections and those that exhibits belong to
+func (s StateTransferServiceImpl) collections(ctx context.Context) []string {
+	collections := collectionsOf(s.State.GetAllExhibits(ctx))
+	for _, c := range s.CollectionService.GetCollections() {
+		collections = append(collections, c.Name)
+	}
+	return collections
+}
+
+func (s StateTransferServiceImpl) Export(ctx context.Context) (domain.StateBundle, error) {
+	subCtx, span := s.Provider.
+		Tracer("state-transfer-service").
+		Start(ctx, "Export")
+	defer span.End()
+
+	unlock, err := lockCollections(subCtx, s.LockService, s.collections(subCtx), false)
+	if err != nil {
+		s.Log.Errorw("error locking collection locks", "error", err)
+		return domain.StateBundle{}, err
+	}
+
+	defer func() {
+		err := unlock()
+		if err != nil {
+			s.Log.Errorw("error unlocking collection locks", "error", err)
+		}
+	}()
+
+	// the revision is read first, so the bundle contains at least everything up to it
+	revision := s.State.GetRevision()
+	exhibits := s.State.GetAllExhibits(subCtx)
+
+	// sorted for stable bundles that can be diffed
+	sort.Slice(exhibits, func(i, j int) bool {
+		return exhibits[i].Name < exhibits[j].Name
+	})
+
+	bundle := domain.StateBundle{
+		Version:    domain.StateBundleVersion,
+		ExportedAt: time.Now().Unix(),
+		Revision:   revision,
+		Exhibits:   make([]domain.StateBundleExhibit, 0, len(exhibits)),
+	}
+
+	for _, exhibit := range exhibits {
+		lastAccessed, err := s.State.GetLastAccessed(subCtx, exhibit.Id)
+		if err != nil {
+			s.Log.Warnw("exporting exhibit without last_accessed", "error", err, "exhibitId", exhibit.Id)
+			lastAccessed = 0
+		}
+
+		bundle.Exhibits = append(bundle.Exhibits, domain.StateBundleExhibit{
+			Exhibit:      exhibit,
+			LastAccessed: lastAccessed,
+		})
+	}
+
+	span.SetAttributes(attribute.Int("exhibits", len(bundle.Exhibits)), attribute.Int64("revision", revision))
+
+	return bundle, nil
+}
+
+func (s StateTransferServiceImpl) Import(ctx context.Context, bundle domain.StateBundle, mode domain.ImportConflictMode) (domain.StateImportResult, error) {
+	subCtx, span := s.Provider.
+		Tracer("state-transfer-service").
+		Start(ctx, "Import", trace.WithAttributes(attribute.Int("version", bundle.Version), attribute.Int("exhibits", len(bundle.Exhibits)), attribute.String("mode", string(mode))))
+	defer span.End()
+
+	if bundle.Version < 1 || bundle.Version > domain.StateBundleVersion {
+		return domain.StateImportResult{}, errors.New("state bundle version " + strconv.Itoa(bundle.Version) + " is not supported, supported versions are 1 to " + strconv.Itoa(domain.StateBundleVersion))
+	}
+
+	if mode != domain.ImportSkip && mode != domain.ImportOverwrite {
+		return domain.StateImportResult{}, errors.New("conflict mode must be one of: " + string(domain.ImportSkip) + ", " + string(domain.ImportOverwrite))
+	}
+
+	result := domain.StateImportResult{
+		Imported:    make([]string, 0),
+		Overwritten: make([]string, 0),
+		Skipped:     make([]string, 0),
+		Failed:      make(map[string]string),
+	}
+
+	// a failed exhibit does not stop the import, it is reported in the result
+	for _, entry := range bundle.Exhibits {
+		name := entry.Exhibit.Name
+
+		overwritten, skipped, err := s.importExhibit(subCtx, entry, mode)
+		switch {
+		case err != nil:
+			s.Log.Warnw("error importing exhibit", "error", err, "exhibitId", entry.Exhibit.Id, "name", name)
+			result.Failed[name] = err.Error()
+		case skipped:
+			result.Skipped = append(result.Skipped, name)
+		case overwritten:
+			result.Overwritten = append(result.Overwritten, name)
+		default:
+			result.Imported = append(result.Imported, name)
+		}
+	}
+
+	s.Log.Infow("imported state bundle", "imported", len(result.Imported), "overwritten", len(result.Overwritten), "skipped", len(result.Skipped), "failed", len(result.Failed))
+
+	return result, nil
+}
+
+func (s StateTransferServiceImpl) importExhibit(ctx context.Context, entry domain.StateBundleExhibit, mode domain.ImportConflictMode) (overwritten bool, skipped bool, err error) {
+	subCtx, span := s.Provider.
+		Tracer("state-transfer-service").
+		Start(ctx, "importExhibit", trace.WithAttributes(attribute.String("exhibitId", entry.Exhibit.Id), attribute.String("name", entry.Exhibit.Name)))
+	defer span.End()
+
+	exhibit := entry.Exhibit
+
+	// ids are kept, so urls of the exhibit keep working
+	if exhibit.Id == "" {
+		exhibit.Id = uuid.New().String()
+	} else if _, err := uuid.Parse(exhibit.Id); err != nil {
+		return false, false, errors.New("exhibit id " + exhibit.Id + " is not a uuid")
+	}
+
+	// bundles exported before the spec was stored contain v1 exhibits
+	if exhibit.Spec == "" {
+		exhibit.Spec = domain.ExhibitSpecV1
+	}
+
+	err = validateExhibit(&exhibit, s.VolumeProvisionerFactory)
+	if err != nil {
+		return false, false, err
+	}
+
+	// exhibits with the same id or the same name conflict with the imported one
+	conflicts := make([]string, 0)
+	if _, err := s.State.GetExhibitById(subCtx, exhibit.Id); err == nil {
+		conflicts = append(conflicts, exhibit.Id)
+	}
+	if id, err := s.State.GetExhibitIdByName(subCtx, exhibit.Name); err == nil && id != exhibit.Id {
+		conflicts = append(conflicts, id)
+	}
+
+	if len(conflicts) > 0 && mode == domain.ImportSkip {
+		span.AddEvent("exhibit skipped")
+		return false, true, nil
+	}
+
+	// the exhibits it replaces make room in the quota, checked up front to fail before pulling images
+	err = s.CollectionService.CheckCreate(subCtx, exhibit, conflicts...)
+	if err != nil {
+		return false, false, err
+	}
+	exhibit.Collection = exhibit.CollectionName()
+
+	err = pullImages(subCtx, s.DockerClient, s.Log, exhibit)
+	if err != nil {
+		return false, false, err
+	}
+
+	lastAccessed := entry.LastAccessed
+	if lastAccessed == 0 {
+		lastAccessed = time.Now().Unix()
+	}
+
+	// the exhibit changed in this installation, so harvesters pick it up again
+	exhibit.UpdatedAt = time.Now().Unix()
+	if exhibit.CreatedAt == 0 {
+		exhibit.CreatedAt = exhibit.UpdatedAt
+	}
+
+	// runtime_info is not part of the bundle, imported exhibits start out like newly created ones
+	exhibit.RuntimeInfo = &domain.ExhibitRuntimeInfo{
+		Status:            domain.NotCreated,
+		RelatedContainers: []string{},
+	}
+
+	// the collection may have filled up while the images were pulled, the exhibits are only stopped if it still fits
+	err = s.CollectionService.CheckCreate(subCtx, exhibit, conflicts...)
+	if err != nil {
+		return false, false, err
+	}
+
+	// stopping reads the exhibits with the collection lock, so they are stopped before it is taken
+	for _, id := range conflicts {
+		span.AddEvent("stopping exhibit " + id)
+
+		err = s.stopExhibit(subCtx, id)
+		if err != nil {
+			return false, false, err
+		}
+	}
+
+	lock := collectionLock(subCtx, s.LockService, exhibit.Collection)
+	err = lock.Lock(subCtx)
+	if err != nil {
+		return false, false, err
+	}
+
+	defer func(lock util.RwErrMutex) {
+		err := lock.Unlock()
+		if err != nil {
+			s.Log.Errorw("error unlocking collection lock", "error", err, "collection", exhibit.Collection)
+		}
+	}(lock)
+
+	// nothing is deleted unless the imported exhibit fits into the collection
+	err = s.CollectionService.CheckCreate(subCtx, exhibit, conflicts...)
+	if err != nil {
+		return false, false, err
+	}
+
+	// the exhibit locks are taken in the same order by every import
+	sort.Strings(conflicts)
+
+	txn := s.State.Txn(subCtx)
+	for _, id := range conflicts {
+		span.AddEvent("replacing exhibit " + id)
+
+		// starts wait for the exhibit lock, so the exhibit stays stopped until it is replaced
+		exhibitLock := s.LockService.GetRwLock(subCtx, id, "exhibit")
+		err = exhibitLock.Lock(subCtx)
+		if err != nil {
+			return false, false, err
+		}
+
+		defer func(lock util.RwErrMutex, id string) {
+			err := lock.Unlock()
+			if err != nil {
+				s.Log.Errorw("error unlocking exhibit lock", "error", err, "exhibitId", id)
+			}
+		}(exhibitLock, id)
+
+		runtimeInfo, err := s.State.GetRuntimeInfo(subCtx, id)
+		if err == nil && runtimeInfo.Status != domain.Stopped && runtimeInfo.Status != domain.NotCreated {
+			return false, false, errors.New("exhibit " + id + " was started again while it was replaced")
+		}
+
+		txn = txn.
+			DeleteLastAccessed(id).
+			DeleteRuntimeInfo(id).
+			DeleteFixity(id).
+			DeleteStartupLogs(id).
+			DeleteExhibitById(id)
+	}
+
+	// the replaced exhibits are deleted together with the creation, a failed import leaves them in place
+	err = txn.
+		SetLastAccessed(exhibit.Id, lastAccessed).
+		SetRuntimeInfo(exhibit.Id, *exhibit.RuntimeInfo).
+		CreateExhibit(exhibit).
+		Commit()
+	if err != nil {
+		return false, false, err
+	}
+
+	s.Eventing.DispatchExhibitCreatedEvent(subCtx, exhibit)
+	span.AddEvent("exhibit imported")
+
+	return len(conflicts) > 0, false, nil
+}
+
+// stopExhibit stops and cleans up an exhibit that is replaced, its containers would be orphaned otherwise
+func (s StateTransferServiceImpl) stopExhibit(ctx context.Context, id string) error {
+	exhibit, err := s.ExhibitService.GetExhibitById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	status := exhibit.RuntimeInfo.Status
+	if status == domain.Stopping {
+		return errors.New("exhibit " + exhibit.Name + " is stopping and cannot be replaced right now")
+	}
+
+	if status == domain.Running || status == domain.Starting || status == domain.Queued {
+		err = s.Provisioner.StopApplication(ctx, id)
+		if err != nil {
+			return err
+		}
+		status = domain.Stopped
+	}
+
+	if status == domain.Stopped {
+		return s.Provisioner.CleanupApplication(ctx, id)
+	}
+
+	return nil
+}
+
+func (s StateTransferServiceImpl) Migrate(ctx context.Context) (domain.MigrationResult, error) {
+	subCtx, span := s.Provider.
+		Tracer("state-transfer-service").
+		Start(ctx, "Migrate")
+	defer span.End()
+
+	// exhibits are not created or read while they are rewritten, in none of the collections
+	unlock, err := lockCollections(subCtx, s.LockService, s.collections(subCtx), true)
+	if err != nil {
+		s.Log.Errorw("error locking collection locks", "error", err)
+		return domain.MigrationResult{}, err
+	}
+
+	defer func() {
+		err := unlock()
+		if err != nil {
+			s.Log.Errorw("error unlocking collection locks", "error", err)
+		}
+	}()
+
+	result, err := s.State.MigrateExhibits(subCtx)
+	if err != nil {
+		span.RecordError(err)
+		return domain.MigrationResult{}, err
+	}
+
+	for id, reason := range result.Failed {
+		s.Log.Warnw("error migrating exhibit", "error", reason, "exhibitId", id)
+	}
+
+	span.SetAttributes(attribute.Int("migrated", len(result.Migrated)), attribute.Int("failed", len(result.Failed)))
+	s.Log.Infow("migrated stored exhibits", "schemaVersion", result.SchemaVersion, "migrated", len(result.Migrated), "upToDate", result.UpToDate, "failed", len(result.Failed))
+
+	return result, nil
+}
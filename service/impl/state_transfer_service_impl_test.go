@@ -0,0 +1,185 @@
+package impl
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"museum/domain"
+	"testing"
+	"time"
+)
+
+func TestExportImportRoundTrip(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	exhibit := newTestExhibit("exported")
+	exhibit.Meta = map[string]interface{}{"title": "An exhibit"}
+	created := s.createExhibit(t, exhibit)
+	s.createExhibit(t, newTestExhibit("another"))
+
+	assert.NoError(t, s.Provisioner.StartApplication(ctx, created.Id))
+	s.setRuntimeInfo(t, created.Id, domain.Running, time.Unix(1700000000, 0))
+
+	bundle, err := s.StateTransfer.Export(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.StateBundleVersion, bundle.Version)
+	assert.Equal(t, s.State.GetRevision(), bundle.Revision)
+	assert.Len(t, bundle.Exhibits, 2)
+
+	// exhibits are sorted by name
+	assert.Equal(t, "another", bundle.Exhibits[0].Exhibit.Name)
+	assert.Equal(t, created.Id, bundle.Exhibits[1].Exhibit.Id)
+	assert.Equal(t, int64(1700000000), bundle.Exhibits[1].LastAccessed)
+
+	// import into a fresh instance
+	other := newTestServices(t)
+	result, err := other.StateTransfer.Import(ctx, bundle, domain.ImportSkip)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"another", "exported"}, result.Imported)
+	assert.Empty(t, result.Failed)
+
+	imported, err := other.ExhibitService.GetExhibitByName(ctx, "exported")
+	assert.NoError(t, err)
+	assert.Equal(t, created.Id, imported.Id)
+	assert.Equal(t, "An exhibit", imported.Meta["title"])
+	assert.Equal(t, int64(1700000000), imported.RuntimeInfo.LastAccessed)
+
+	// runtime state is not carried over
+	assert.Equal(t, domain.NotCreated, imported.RuntimeInfo.Status)
+	assert.Empty(t, imported.RuntimeInfo.RelatedContainers)
+	assert.Contains(t, other.Runtime.GetCalls(), "ImagePull(nginx:latest)")
+	assert.Len(t, other.Eventing.CreatedEvents, 2)
+
+	// the imported exhibit can be started
+	assert.NoError(t, other.Provisioner.StartApplication(ctx, imported.Id))
+}
+
+func TestImportConflicts(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	existing := s.createExhibit(t, newTestExhibit("taken"))
+	assert.NoError(t, s.Provisioner.StartApplication(ctx, existing.Id))
+
+	replacement := newTestExhibit("taken")
+	replacement.Id = "8e5f1c7e-3d3c-4b8a-9f4e-2b7c1f0f6f11"
+	replacement.Lease = "2h"
+	bundle := domain.StateBundle{
+		Version:  domain.StateBundleVersion,
+		Exhibits: []domain.StateBundleExhibit{{Exhibit: replacement}},
+	}
+
+	result, err := s.StateTransfer.Import(ctx, bundle, domain.ImportSkip)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"taken"}, result.Skipped)
+
+	kept, err := s.ExhibitService.GetExhibitByName(ctx, "taken")
+	assert.NoError(t, err)
+	assert.Equal(t, existing.Id, kept.Id)
+	assert.Equal(t, domain.Running, kept.RuntimeInfo.Status)
+
+	result, err = s.StateTransfer.Import(ctx, bundle, domain.ImportOverwrite)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"taken"}, result.Overwritten)
+
+	replaced, err := s.ExhibitService.GetExhibitByName(ctx, "taken")
+	assert.NoError(t, err)
+	assert.Equal(t, replacement.Id, replaced.Id)
+	assert.Equal(t, "2h", replaced.Lease)
+	assert.Equal(t, domain.NotCreated, replaced.RuntimeInfo.Status)
+
+	// the containers of the replaced exhibit are removed
+	_, err = s.ExhibitService.GetExhibitById(ctx, existing.Id)
+	assert.Error(t, err)
+	_, ok := s.Runtime.GetContainer("taken_web")
+	assert.False(t, ok)
+}
+
+func TestImportOverwriteKeepsExhibitsOutsideQuota(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+	s.CollectionService.Collections = append(s.CollectionService.Collections, domain.Collection{Name: "physics", Quota: domain.Quota{MaxExhibits: 1}})
+
+	existing := s.createExhibit(t, newTestExhibit("taken"))
+	assert.NoError(t, s.Provisioner.StartApplication(ctx, existing.Id))
+
+	// the collection is empty when the import starts, it is full once the images are pulled
+	s.Runtime.BeforeImagePull = func(string) {
+		s.Runtime.BeforeImagePull = nil
+		squatter := newTestExhibit("squatter")
+		squatter.Collection = "physics"
+		s.createExhibit(t, squatter)
+	}
+
+	replacement := newTestExhibit("taken")
+	replacement.Id = "8e5f1c7e-3d3c-4b8a-9f4e-2b7c1f0f6f11"
+	replacement.Collection = "physics"
+	replacement.Objects[1].Image = "httpd"
+	bundle := domain.StateBundle{
+		Version:  domain.StateBundleVersion,
+		Exhibits: []domain.StateBundleExhibit{{Exhibit: replacement}},
+	}
+
+	result, err := s.StateTransfer.Import(ctx, bundle, domain.ImportOverwrite)
+	assert.NoError(t, err)
+	assert.Contains(t, result.Failed, "taken")
+	assert.Empty(t, result.Overwritten)
+
+	// the original exhibit is neither deleted nor stopped
+	kept, err := s.ExhibitService.GetExhibitByName(ctx, "taken")
+	assert.NoError(t, err)
+	assert.Equal(t, existing.Id, kept.Id)
+	assert.Equal(t, domain.Running, kept.RuntimeInfo.Status)
+	_, ok := s.Runtime.GetContainer("taken_web")
+	assert.True(t, ok)
+}
+
+func TestImportRejectsInvalidBundles(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	_, err := s.StateTransfer.Import(ctx, domain.StateBundle{Version: domain.StateBundleVersion + 1}, domain.ImportSkip)
+	assert.Error(t, err)
+
+	_, err = s.StateTransfer.Import(ctx, domain.StateBundle{Version: domain.StateBundleVersion}, "merge")
+	assert.Error(t, err)
+
+	// invalid exhibits fail on their own, the rest is imported
+	// exhibits of bundles exported before the spec was stored are v1 exhibits
+	noSpec := newTestExhibit("no-spec")
+	noSpec.Spec = ""
+	invalid := newTestExhibit("invalid")
+	invalid.Lease = "forever"
+	badId := newTestExhibit("bad-id")
+	badId.Id = "not-a-uuid"
+	bundle := domain.StateBundle{
+		Version: domain.StateBundleVersion,
+		Exhibits: []domain.StateBundleExhibit{
+			{Exhibit: invalid},
+			{Exhibit: badId},
+			{Exhibit: newTestExhibit("valid")},
+			{Exhibit: noSpec},
+		},
+	}
+
+	result, err := s.StateTransfer.Import(ctx, bundle, domain.ImportSkip)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"valid", "no-spec"}, result.Imported)
+	assert.Contains(t, result.Failed, "invalid")
+	assert.Contains(t, result.Failed, "bad-id")
+	assert.Equal(t, 2, s.ExhibitService.Count())
+}
+
+func TestMigrate(t *testing.T) {
+	s := newTestServices(t)
+	ctx := context.Background()
+
+	s.createExhibit(t, newTestExhibit("current"))
+
+	// exhibits created through the service are stored with the current schema version
+	result, err := s.StateTransfer.Migrate(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Migrated)
+	assert.Equal(t, 1, result.UpToDate)
+	assert.Empty(t, result.Failed)
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+	"museum/http"
+	"time"
+)
+
+// AccessService enforces the access policies of exhibits for their visitors
+type AccessService interface {
+	// Check decides whether the visitor of req may see the exhibit, credentials that were accepted are kept in a cookie
+	Check(ctx context.Context, exhibit domain.Exhibit, res *http.Response, req *http.Request) domain.AccessDecision
+	// CheckMaintenance decides whether the visitor of req may see the exhibit while it is in maintenance,
+	// only visitors that followed its maintenance link may
+	CheckMaintenance(ctx context.Context, exhibit domain.Exhibit, res *http.Response, req *http.Request) domain.AccessDecision
+	// MaintenanceLink returns a signed link that lets curators into the exhibit until its maintenance ends
+	MaintenanceLink(exhibit domain.Exhibit) string
+	// ShareLink returns a signed link to the exhibit that grants access to it until expiresAt
+	ShareLink(exhibit domain.Exhibit, expiresAt time.Time) string
+	// Login returns the url of the issuer that the visitor logs in at to see the exhibit, afterward they are sent to returnTo
+	Login(ctx context.Context, exhibit domain.Exhibit, returnTo string, res *http.Response) (string, error)
+	// FinishLogin checks the login that the issuer sent the visitor back from and grants them access to the exhibit,
+	// it returns the path the visitor came from
+	FinishLogin(ctx context.Context, res *http.Response, req *http.Request) (string, error)
+}
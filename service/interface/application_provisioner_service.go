@@ -6,4 +6,6 @@ type ApplicationProvisionerService interface {
 	StartApplication(ctx context.Context, exhibitId string) error
 	StopApplication(ctx context.Context, exhibitId string) error
 	CleanupApplication(ctx context.Context, exhibitId string) error
+	// ScaleApplication starts or removes replicas of the exposed object of a running exhibit
+	ScaleApplication(ctx context.Context, exhibitId string, replicas int) error
 }
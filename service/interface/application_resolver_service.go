@@ -6,6 +6,7 @@ import (
 )
 
 type ApplicationResolverService interface {
-	ResolveApplication(ctx context.Context, exhibitId string) (string, error)
+	// ResolveEndpoints returns the healthy replicas of the exposed object of a running exhibit
+	ResolveEndpoints(ctx context.Context, exhibitId string) ([]domain.Endpoint, error)
 	ResolveExhibitObject(exhibit domain.Exhibit, object domain.Object) (string, error)
 }
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+// AuthService authenticates the callers of the api
+type AuthService interface {
+	// Enabled is false if neither api tokens nor an OpenID Connect issuer are configured, the api is open then
+	Enabled() bool
+	// Authenticate returns the caller of a static api token or of an OpenID Connect bearer token
+	Authenticate(ctx context.Context, token string) (domain.Principal, error)
+	// IsApiToken reports whether token is one of the static api tokens, bearer tokens of the issuer are not verified
+	IsApiToken(token string) bool
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+)
+
+// AutoscalerService scales autoscaled exhibits with the requests all instances have in flight to them
+type AutoscalerService interface {
+	// Report shares the load of this instance in the runtime info of the autoscaled exhibits, every instance reports
+	Report(ctx context.Context) error
+	// Lead blocks until this instance is the one that scales the exhibits,
+	// the returned context ends once another instance may have taken over
+	Lead(ctx context.Context) (context.Context, error)
+	// Autoscale scales the exhibits whose load changed. It stops scaling once ctx ends, so it is passed the context of Lead.
+	Autoscale(ctx context.Context) error
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+// CapacityService enforces the cap on the exhibits that run in the whole cluster and keeps the queue of those
+// that wait for room
+type CapacityService interface {
+	// Admit calls start if the cluster has room for exhibit, start has to mark the exhibit as starting. Otherwise,
+	// depending on the capacity policy, the admission names the exhibit to evict or the position in the queue
+	Admit(ctx context.Context, exhibit domain.Exhibit, start func(ctx context.Context) error) (domain.Admission, error)
+	// QueuePosition returns the position of the exhibit in the queue, starting at 1, it is 0 if it does not wait
+	QueuePosition(ctx context.Context, exhibitId string) int
+	// Next returns the ids of the queued exhibits that fit into the cluster now, in the order they were queued
+	Next(ctx context.Context) []string
+}
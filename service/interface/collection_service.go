@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+// CollectionService knows the collections that own exhibits and enforces their quotas
+type CollectionService interface {
+	GetCollections() []domain.Collection
+	GetCollection(name string) (domain.Collection, error)
+	// GetUsage returns what the exhibits of a collection use right now
+	GetUsage(ctx context.Context, name string) domain.CollectionUsage
+	// CheckCreate returns why exhibit may not be created in its collection, the exhibits with the ids in replaces
+	// are about to be deleted and do not count
+	CheckCreate(ctx context.Context, exhibit domain.Exhibit, replaces ...string) error
+	// Admit calls start once the quota of the collection allows exhibit to start, start has to mark the exhibit as
+	// starting, no other exhibit of the collection is admitted until it returns
+	Admit(ctx context.Context, exhibit domain.Exhibit, start func(ctx context.Context) error) error
+}
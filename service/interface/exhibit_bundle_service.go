@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"io"
+	"museum/domain"
+)
+
+// ExhibitBundleService writes an exhibit together with its images and volume data into a single archive,
+// and recreates exhibits from such archives without access to a registry.
+// Exhibits can be exported as BagIt bags for archives as well, those cannot be imported.
+type ExhibitBundleService interface {
+	Export(ctx context.Context, id string, format domain.ExhibitBundleFormat, w io.Writer) error
+	Import(ctx context.Context, r io.Reader) (domain.Exhibit, error)
+}
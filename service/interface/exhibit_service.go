@@ -7,8 +7,16 @@ import (
 
 type ExhibitService interface {
 	GetExhibitById(ctx context.Context, id string) (domain.Exhibit, error)
+	GetExhibitByName(ctx context.Context, name string) (domain.Exhibit, error)
+	// GetCachedExhibitById and GetCachedExhibitByName read without locks, the result may be slightly stale
+	GetCachedExhibitById(ctx context.Context, id string) (domain.Exhibit, error)
+	GetCachedExhibitByName(ctx context.Context, name string) (domain.Exhibit, error)
 	GetAllExhibits(ctx context.Context) []domain.Exhibit
 	CreateExhibit(ctx context.Context, createExhibit domain.CreateExhibit) (string, error)
+	// ValidateExhibit runs all checks of CreateExhibit without creating the exhibit
+	ValidateExhibit(ctx context.Context, exhibit domain.Exhibit) []domain.ValidationProblem
 	DeleteExhibitById(ctx context.Context, id string) error
+	// SetMaintenance keeps visitors out of the exhibit while maintenance is set, nil ends the maintenance
+	SetMaintenance(ctx context.Context, id string, maintenance *domain.Maintenance) error
 	Count() int
 }
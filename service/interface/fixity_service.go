@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+// FixityService verifies that the images and volume data of exhibits did not change since they were first checked
+type FixityService interface {
+	// Check recomputes the checksums of an exhibit and compares them to its baseline, the first check records the baseline
+	Check(ctx context.Context, id string) (domain.FixityReport, error)
+	// CheckDue checks every exhibit whose last check is older than the fixity interval
+	CheckDue(ctx context.Context) error
+	GetReport(ctx context.Context, id string) (domain.FixityReport, error)
+}
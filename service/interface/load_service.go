@@ -0,0 +1,11 @@
+package service
+
+// LoadService counts the requests this instance has in flight to the replicas of exhibits
+type LoadService interface {
+	// Begin counts a request to a replica as in flight until the returned function is called
+	Begin(exhibitId string, replica int) func()
+	// InFlight returns the requests in flight to a replica
+	InFlight(exhibitId string, replica int) int
+	// TakePeak returns the most requests that were in flight to an exhibit at once since it was last called
+	TakePeak(exhibitId string) int
+}
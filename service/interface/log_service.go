@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+	"io"
+	"museum/domain"
+)
+
+// LogService reads the output of the containers of exhibits
+type LogService interface {
+	// Logs writes the output of an object of an exhibit to w, stdout and stderr are interleaved.
+	// Once the exhibit failed to start, the output its objects had until then is written instead.
+	Logs(ctx context.Context, id string, object string, options domain.LogOptions, w io.Writer) error
+	// Tail returns the last lines of the output of a container, it is empty if the output cannot be read
+	Tail(ctx context.Context, nameOrId string, lines int) string
+	// SetStartupLogs keeps the output of the objects of an exhibit that failed to start
+	SetStartupLogs(ctx context.Context, id string, logs domain.StartupLogs) error
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+	"net/url"
+)
+
+// OaiService answers OAI-PMH 2.0 requests, exhibits are the records of the repository
+type OaiService interface {
+	// Handle answers the request given by its arguments, including the verb. Protocol errors are part of the response.
+	Handle(ctx context.Context, args url.Values) domain.OaiPmh
+}
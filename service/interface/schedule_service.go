@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+)
+
+// ScheduleService starts the exhibits that have to run according to their schedule
+type ScheduleService interface {
+	// Lead blocks until this instance is the one that evaluates the schedules,
+	// the returned context ends once another instance may have taken over
+	Lead(ctx context.Context) (context.Context, error)
+	// Evaluate starts the exhibits whose schedule has an open window, or one that opens shortly.
+	// It stops starting exhibits once ctx ends, so it is passed the context of Lead.
+	Evaluate(ctx context.Context) error
+}
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+	"museum/domain"
+)
+
+// StateTransferService exports all exhibit definitions to a bundle and restores them from one,
+// it also upgrades the stored exhibits to the schema version of this museum
+type StateTransferService interface {
+	Export(ctx context.Context) (domain.StateBundle, error)
+	Import(ctx context.Context, bundle domain.StateBundle, mode domain.ImportConflictMode) (domain.StateImportResult, error)
+	Migrate(ctx context.Context) (domain.MigrationResult, error)
+}
@@ -1,7 +1,6 @@
 package service
 
 import (
-	docker "github.com/docker/docker/client"
 	"museum/service/impl"
 	service "museum/service/interface"
 )
@@ -18,7 +17,7 @@ func NewHttpLivecheck(applicationResolverService service.ApplicationResolverServ
 	})
 }
 
-func NewExecLivecheck(client *docker.Client) *ExecLivecheck {
+func NewExecLivecheck(client service.ContainerRuntime) *ExecLivecheck {
 	return (*ExecLivecheck)(&impl.ExecLivecheck{
 		Client: client,
 	})
@@ -0,0 +1,12 @@
+package service
+
+import (
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type LoadService service.LoadService
+
+func NewLoadService() LoadService {
+	return &impl.LoadServiceImpl{}
+}
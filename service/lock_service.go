@@ -1,6 +1,10 @@
 package service
 
 import (
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
 	"museum/persistence"
 	"museum/service/impl"
 	service "museum/service/interface"
@@ -8,8 +12,17 @@ import (
 
 type LockService service.LockService
 
-func NewLockService(state persistence.State) LockService {
+func NewLockService(state persistence.State, config config.Config, factory *observability.TracerProviderFactory, meterProvider metric.MeterProvider, log *zap.SugaredLogger) LockService {
+	metrics, err := impl.NewLockMetrics(meterProvider.Meter("lock-service"))
+	if err != nil {
+		log.Panicw("failed to create lock metrics", "error", err)
+	}
+
 	return &impl.LockServiceImpl{
-		State: state,
+		State:    state,
+		Config:   config,
+		Provider: factory.Build("lock-service"),
+		Metrics:  metrics,
+		Log:      log,
 	}
 }
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type LogService service.LogService
+
+func NewLogService(exhibitService service.ExhibitService, dockerClient service.ContainerRuntime, state persistence.State, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) LogService {
+	return &impl.LogServiceImpl{
+		ExhibitService: exhibitService,
+		DockerClient:   dockerClient,
+		State:          state,
+		Provider:       factory.Build("log-service"),
+		Log:            log,
+	}
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type OaiService service.OaiService
+
+func NewOaiService(exhibitService service.ExhibitService, config config.Config, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) OaiService {
+	return &impl.OaiServiceImpl{
+		ExhibitService: exhibitService,
+		Config:         config,
+		Provider:       factory.Build("oai-service"),
+		Log:            log,
+	}
+}
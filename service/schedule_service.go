@@ -0,0 +1,23 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/config"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type ScheduleService service.ScheduleService
+
+func NewScheduleService(config config.Config, state persistence.State, exhibitService service.ExhibitService, provisionerService service.ApplicationProvisionerService, factory *observability.TracerProviderFactory, log *zap.SugaredLogger) ScheduleService {
+	return &impl.ScheduleServiceImpl{
+		Config:                        config,
+		State:                         state,
+		ExhibitService:                exhibitService,
+		ApplicationProvisionerService: provisionerService,
+		Provider:                      factory.Build("schedule-service"),
+		Log:                           log,
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"go.uber.org/zap"
+	"museum/observability"
+	"museum/persistence"
+	"museum/service/impl"
+	service "museum/service/interface"
+)
+
+type StateTransferService service.StateTransferService
+
+func NewStateTransferService(state persistence.State,
+	eventing persistence.Eventing,
+	exhibitService service.ExhibitService,
+	collectionService service.CollectionService,
+	provisionerService service.ApplicationProvisionerService,
+	lockService service.LockService,
+	dockerClient service.ContainerRuntime,
+	volumeProvisionerFactoryService service.VolumeProvisionerFactoryService,
+	factory *observability.TracerProviderFactory,
+	log *zap.SugaredLogger) StateTransferService {
+	return &impl.StateTransferServiceImpl{
+		State:                    state,
+		Eventing:                 eventing,
+		ExhibitService:           exhibitService,
+		CollectionService:        collectionService,
+		Provisioner:              provisionerService,
+		LockService:              lockService,
+		DockerClient:             dockerClient,
+		VolumeProvisionerFactory: volumeProvisionerFactoryService,
+		Provider:                 factory.Build("state-transfer-service"),
+		Log:                      log,
+	}
+}
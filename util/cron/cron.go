@@ -0,0 +1,147 @@
+package cron
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expression is a parsed cron expression with the five fields minute, hour, day of month, month and day of week.
+// Fields are lists of values, ranges and steps like 1,15 or 9-17 or */10. Day of week 0 and 7 are both Sunday.
+// If both day fields are restricted, a day matches if either of them matches, as in crontab.
+type Expression struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny are true if the day fields are *
+	domAny, dowAny bool
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse reads a cron expression like "0 9 * * 1-5"
+func Parse(expression string) (Expression, error) {
+	parts := strings.Fields(expression)
+	if len(parts) != len(fields) {
+		return Expression{}, errors.New("cron expression must have 5 fields (minute hour day-of-month month day-of-week), it has " + strconv.Itoa(len(parts)))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, f := range fields {
+		b, err := parseField(parts[i], f)
+		if err != nil {
+			return Expression{}, err
+		}
+		bits[i] = b
+	}
+
+	// Sunday may be written as 7 as well
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return Expression{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: parts[2] == "*",
+		dowAny: parts[4] == "*",
+	}, nil
+}
+
+func parseField(value string, f field) (uint64, error) {
+	bits := uint64(0)
+	for _, item := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+
+		step := 1
+		if hasStep {
+			s, err := strconv.Atoi(stepPart)
+			if err != nil || s < 1 {
+				return 0, errors.New("step " + strconv.Quote(stepPart) + " of the " + f.name + " field must be a positive number")
+			}
+			step = s
+		}
+
+		from, to := f.min, f.max
+		if rangePart != "*" {
+			first, last, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			from, err = strconv.Atoi(first)
+			if err != nil {
+				return 0, errors.New(strconv.Quote(item) + " is not a valid " + f.name)
+			}
+			to = from
+			if isRange {
+				to, err = strconv.Atoi(last)
+				if err != nil {
+					return 0, errors.New(strconv.Quote(item) + " is not a valid " + f.name)
+				}
+			} else if hasStep {
+				to = f.max
+			}
+		}
+
+		if from < f.min || to > f.max || from > to {
+			return 0, errors.New(strconv.Quote(item) + " is out of range, the " + f.name + " must be between " + strconv.Itoa(f.min) + " and " + strconv.Itoa(f.max))
+		}
+
+		for v := from; v <= to; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (e Expression) dayMatches(t time.Time) bool {
+	dom := e.dom&(1<<t.Day()) != 0
+	dow := e.dow&(1<<int(t.Weekday())) != 0
+	if e.domAny || e.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t the expression matches, in the location of t.
+// It returns the zero time if the expression does not match within the next five years, e.g. for 0 0 30 2 *.
+func (e Expression) Next(t time.Time) time.Time {
+	// the fields are truncated in the location, its offset may not be whole hours
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if e.month&(1<<int(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !e.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if e.hour&(1<<t.Hour()) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if e.minute&(1<<t.Minute()) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,51 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database is not available")
+	}
+
+	// Monday, 19 October 2026
+	from := time.Date(2026, 10, 19, 10, 30, 0, 0, berlin)
+
+	tests := []struct {
+		expression string
+		next       time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 19, 10, 31, 0, 0, berlin)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 20, 9, 0, 0, 0, berlin)},
+		{"*/15 10 * * *", time.Date(2026, 10, 19, 10, 45, 0, 0, berlin)},
+		{"0 14 * * 0", time.Date(2026, 10, 25, 14, 0, 0, 0, berlin)},
+		{"0 14 * * 7", time.Date(2026, 10, 25, 14, 0, 0, 0, berlin)},
+		{"30 8 1,15 * *", time.Date(2026, 11, 1, 8, 30, 0, 0, berlin)},
+		// both day fields are restricted, either one matches
+		{"0 0 1 * 3", time.Date(2026, 10, 21, 0, 0, 0, 0, berlin)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, berlin)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, test := range tests {
+		e, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("%s: %v", test.expression, err)
+			continue
+		}
+		if next := e.Next(from); !next.Equal(test.next) {
+			t.Errorf("%s: expected %v, got %v", test.expression, test.next, next)
+		}
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expression := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expression); err == nil {
+			t.Errorf("expected %q to be rejected", expression)
+		}
+	}
+}
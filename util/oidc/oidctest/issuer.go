@@ -0,0 +1,113 @@
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const KeyId = "museum-test"
+
+// Issuer is a local OpenID Connect issuer for tests, it serves the discovery document and its keys, signs tokens and
+// redeems the codes of logins
+type Issuer struct {
+	Server *httptest.Server
+	Key    *rsa.PrivateKey
+	codes  map[string]jwt.MapClaims
+	mu     *sync.Mutex
+}
+
+func NewIssuer() *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	issuer := &Issuer{Key: key, codes: make(map[string]jwt.MapClaims), mu: &sync.Mutex{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.Url(),
+			"authorization_endpoint": issuer.Url() + "/authorize",
+			"token_endpoint":         issuer.Url() + "/token",
+			"jwks_uri":               issuer.Url() + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kid": KeyId,
+				"kty": "RSA",
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		issuer.mu.Lock()
+		claims, ok := issuer.codes[r.FormValue("code")]
+		delete(issuer.codes, r.FormValue("code"))
+		issuer.mu.Unlock()
+
+		clientId, _, _ := r.BasicAuth()
+		if !ok || r.FormValue("grant_type") != "authorization_code" {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		if _, ok := claims["aud"]; !ok {
+			claims["aud"] = clientId
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token_type": "Bearer", "id_token": issuer.Token(claims)})
+	})
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+func (i *Issuer) Url() string {
+	return i.Server.URL
+}
+
+func (i *Issuer) Close() {
+	i.Server.Close()
+}
+
+// Token signs claims with the key of the issuer, iss and exp are set unless claims has them already
+func (i *Issuer) Token(claims jwt.MapClaims) string {
+	if _, ok := claims["iss"]; !ok {
+		claims["iss"] = i.Url()
+	}
+	if _, ok := claims["exp"]; !ok {
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = KeyId
+	signed, err := token.SignedString(i.Key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+// Code returns a code of a login that the token endpoint redeems once for an id token with claims,
+// the audience is the client that redeems it unless claims has one
+func (i *Issuer) Code(claims jwt.MapClaims) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	code := strconv.Itoa(len(i.codes)) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	i.codes[code] = claims
+	return code
+}
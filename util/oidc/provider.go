@@ -0,0 +1,348 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keys are fetched again for an unknown key id at most once per refreshInterval
+const refreshInterval = time.Minute
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// Discovery is the part of the OpenID Connect discovery document that museum uses
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// Provider verifies the tokens of an OpenID Connect issuer. The discovery document and the keys of the issuer are
+// fetched on first use, the keys are fetched again when a token is signed by a key that is not known yet.
+type Provider struct {
+	issuer    string
+	jwksUrl   string
+	client    *http.Client
+	discovery *Discovery
+	keys      map[string]any
+	fetchedAt time.Time
+	mu        *sync.Mutex
+}
+
+// NewProvider creates a provider for issuer, the jwks url is discovered if it is empty
+func NewProvider(issuer string, jwksUrl string) *Provider {
+	return &Provider{
+		issuer:  strings.TrimSuffix(issuer, "/"),
+		jwksUrl: jwksUrl,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		keys:    make(map[string]any),
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (p *Provider) getJson(ctx context.Context, address string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return errors.New("could not get " + address + ": " + res.Status)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// Discover returns the discovery document of the issuer
+func (p *Provider) Discover(ctx context.Context) (Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.discover(ctx)
+}
+
+func (p *Provider) discover(ctx context.Context) (Discovery, error) {
+	if p.discovery != nil {
+		return *p.discovery, nil
+	}
+
+	discovery := Discovery{}
+	err := p.getJson(ctx, p.issuer+"/.well-known/openid-configuration", &discovery)
+	if err != nil {
+		return Discovery{}, err
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuer {
+		return Discovery{}, errors.New("discovery document is for issuer " + discovery.Issuer + " instead of " + p.issuer)
+	}
+
+	p.discovery = &discovery
+	return discovery, nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	jwksUrl := p.jwksUrl
+	if jwksUrl == "" {
+		discovery, err := p.discover(ctx)
+		if err != nil {
+			return err
+		}
+		jwksUrl = discovery.JwksUri
+	}
+
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{}
+	err := p.getJson(ctx, jwksUrl, &set)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]any)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		// keys of unsupported types are skipped, the issuer may publish keys that museum does not need
+		if key, err := k.publicKey(); err == nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	p.keys = keys
+	p.fetchedAt = time.Now()
+	return nil
+}
+
+func (p *Provider) key(ctx context.Context, kid string) (any, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	if time.Since(p.fetchedAt) > refreshInterval {
+		err := p.fetchKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// tokens without a key id can only be verified if the issuer has a single key
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, nil
+		}
+	}
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	return nil, errors.New("unknown signing key " + kid)
+}
+
+// Verify checks the signature, the issuer, the audience and the lifetime of token and returns its claims,
+// the audience is not checked if it is empty
+func (p *Provider) Verify(ctx context.Context, token string, audience string) (jwt.MapClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithIssuer(p.issuer),
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.key(ctx, kid)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// AuthCodeUrl returns the url that visitors log in at, the issuer sends them back to redirectUri with a code
+func (p *Provider) AuthCodeUrl(ctx context.Context, clientId string, redirectUri string, scopes []string, state string, nonce string) (string, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{
+		"response_type": {"code"},
+		"client_id":     {clientId},
+		"redirect_uri":  {redirectUri},
+		"scope":         {strings.Join(scopes, " ")},
+		"state":         {state},
+		"nonce":         {nonce},
+	}
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems the code of a login at the token endpoint and returns the verified claims of the id token
+func (p *Provider) Exchange(ctx context.Context, clientId string, clientSecret string, redirectUri string, code string) (jwt.MapClaims, error) {
+	discovery, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectUri},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(clientId), url.QueryEscape(clientSecret))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("could not redeem code: " + res.Status)
+	}
+
+	tokens := struct {
+		IdToken string `json:"id_token"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Verify(ctx, tokens.IdToken, clientId)
+}
+
+// Strings returns the values of a claim that is a string or a list of strings, nested claims are addressed with dots
+// like realm_access.roles
+func Strings(claims jwt.MapClaims, name string) []string {
+	var value any = map[string]any(claims)
+	for _, key := range strings.Split(name, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errors.New("unsupported curve " + k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errors.New("unsupported key type " + k.Kty)
+	}
+}
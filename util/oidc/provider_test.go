@@ -0,0 +1,72 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"museum/util/oidc/oidctest"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	provider := NewProvider(issuer.Url(), "")
+	claims, err := provider.Verify(context.Background(), issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "museum"}), "museum")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", claims["sub"])
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	provider := NewProvider(issuer.Url(), "")
+	ctx := context.Background()
+
+	_, err := provider.Verify(ctx, issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "other"}), "museum")
+	assert.Error(t, err, "wrong audience")
+
+	_, err = provider.Verify(ctx, issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "museum", "iss": "https://evil.example"}), "museum")
+	assert.Error(t, err, "wrong issuer")
+
+	_, err = provider.Verify(ctx, issuer.Token(jwt.MapClaims{"sub": "alice", "aud": "museum", "exp": time.Now().Add(-time.Hour).Unix()}), "museum")
+	assert.Error(t, err, "expired")
+
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "alice", "aud": "museum", "iss": issuer.Url(), "exp": time.Now().Add(time.Hour).Unix()})
+	token.Header["kid"] = oidctest.KeyId
+	signed, _ := token.SignedString(other)
+	_, err = provider.Verify(ctx, signed, "museum")
+	assert.Error(t, err, "wrong key")
+
+	_, err = provider.Verify(ctx, "not a token", "museum")
+	assert.Error(t, err)
+}
+
+func TestVerifyWithJwksUrl(t *testing.T) {
+	issuer := oidctest.NewIssuer()
+	defer issuer.Close()
+
+	provider := NewProvider(issuer.Url(), issuer.Url()+"/jwks")
+	_, err := provider.Verify(context.Background(), issuer.Token(jwt.MapClaims{"sub": "alice"}), "")
+	assert.NoError(t, err)
+}
+
+func TestStrings(t *testing.T) {
+	claims := jwt.MapClaims{
+		"roles":        []any{"viewer", "curator", 3},
+		"scope":        "openid profile",
+		"realm_access": map[string]any{"roles": []any{"admin"}},
+	}
+
+	assert.Equal(t, []string{"viewer", "curator"}, Strings(claims, "roles"))
+	assert.Equal(t, []string{"openid", "profile"}, Strings(claims, "scope"))
+	assert.Equal(t, []string{"admin"}, Strings(claims, "realm_access.roles"))
+	assert.Nil(t, Strings(claims, "groups"))
+	assert.Nil(t, Strings(claims, "scope.roles"))
+}
@@ -1,8 +1,12 @@
 package util
 
+import "context"
+
+// RwErrMutex is a readers-writer lock that may be shared between museum instances.
+// Acquiring it gives up with the context's error once ctx is done, the lock is then not held.
 type RwErrMutex interface {
-	RLock() error
+	RLock(ctx context.Context) error
 	RUnlock() error
-	Lock() error
+	Lock(ctx context.Context) error
 	Unlock() error
 }
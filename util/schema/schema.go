@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+)
+
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema that is generated from go types
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type is a single type name or a list of them
+	Type       any                `json:"type,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	// AdditionalProperties is false for structs and the schema of the values for maps
+	AdditionalProperties any      `json:"additionalProperties,omitempty"`
+	Items                *Schema  `json:"items,omitempty"`
+	Enum                 []string `json:"enum,omitempty"`
+	Pattern              string   `json:"pattern,omitempty"`
+}
+
+// Generate describes a go type by its yaml field names.
+// Fields tagged with `jsonschema:"required"` must be set, `description` tags are used as descriptions.
+// Strings accept any scalar, as yaml decodes numbers and booleans into strings as well (e.g. `label: 9.6`).
+func Generate(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return Generate(t.Elem())
+	case reflect.String:
+		return &Schema{Type: []string{"string", "number", "boolean"}}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: Generate(t.Elem())}
+	case reflect.Map:
+		s := &Schema{Type: "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			s.AdditionalProperties = Generate(t.Elem())
+		}
+		return s
+	case reflect.Struct:
+		return generateStruct(t)
+	default:
+		// interfaces can hold anything
+		return &Schema{}
+	}
+}
+
+func generateStruct(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		property := Generate(field.Type)
+		property.Description = field.Tag.Get("description")
+		s.Properties[name] = property
+
+		for _, option := range strings.Split(field.Tag.Get("jsonschema"), ",") {
+			if option == "required" {
+				s.Required = append(s.Required, name)
+			}
+		}
+	}
+
+	return s
+}
+
+// types returns the type names of the schema, an empty list allows every type
+func (s *Schema) types() []string {
+	switch t := s.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	default:
+		return []string{}
+	}
+}
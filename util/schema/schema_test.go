@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"testing"
+)
+
+type testItem struct {
+	Name   string            `yaml:"name" jsonschema:"required" description:"the name"`
+	Port   *int              `yaml:"port"`
+	Labels map[string]string `yaml:"labels"`
+	Hidden string            `yaml:"-"`
+}
+
+type testDocument struct {
+	Kind    string                 `yaml:"kind" jsonschema:"required"`
+	Enabled bool                   `yaml:"enabled"`
+	Items   []testItem             `yaml:"items"`
+	Extra   map[string]interface{} `yaml:"extra"`
+}
+
+func parse(t *testing.T, source string) *yaml.Node {
+	node := &yaml.Node{}
+	assert.NoError(t, yaml.Unmarshal([]byte(source), node))
+	return node
+}
+
+func TestGenerate(t *testing.T) {
+	s := Generate(reflect.TypeOf(testDocument{}))
+
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, false, s.AdditionalProperties)
+	assert.Equal(t, []string{"kind"}, s.Required)
+	assert.Equal(t, "boolean", s.Properties["enabled"].Type)
+	assert.Nil(t, s.Properties["extra"].AdditionalProperties)
+
+	item := s.Properties["items"].Items
+	assert.Equal(t, []string{"name"}, item.Required)
+	assert.Equal(t, "the name", item.Properties["name"].Description)
+	assert.Equal(t, "integer", item.Properties["port"].Type)
+	assert.Equal(t, []string{"string", "number", "boolean"}, item.Properties["labels"].AdditionalProperties.(*Schema).Type)
+	assert.NotContains(t, item.Properties, "hidden")
+}
+
+func TestValidateReportsAllViolations(t *testing.T) {
+	s := Generate(reflect.TypeOf(testDocument{}))
+	s.Properties["kind"].Enum = []string{"a", "b"}
+
+	violations := s.Validate(parse(t, `kind: c
+enabled: "yes"
+items:
+  - port: http
+    labels:
+      version: 1.2
+  - name: second
+    unknown: true
+extra:
+  anything: [1, 2]
+`))
+
+	assert.Equal(t, []Violation{
+		{Pointer: "/kind", Message: "must be one of: a, b", Line: 1, Column: 7},
+		{Pointer: "/enabled", Message: "expected boolean, got string", Line: 2, Column: 10},
+		{Pointer: "/items/0/port", Message: "expected integer, got string", Line: 4, Column: 11},
+		{Pointer: "/items/0/name", Message: "missing required field name", Line: 4, Column: 5},
+		{Pointer: "/items/1/unknown", Message: "unknown field unknown", Line: 8, Column: 5},
+	}, violations)
+
+	assert.Empty(t, s.Validate(parse(t, `{"kind": "a", "items": [{"name": "json works as well"}]}`)))
+}
+
+func TestLocate(t *testing.T) {
+	node := parse(t, `kind: a
+items:
+  - name: first
+    labels:
+      a/b: c
+`)
+
+	line, column := Locate(node, Pointer("items", 0, "labels", "a/b"))
+	assert.Equal(t, 5, line)
+	assert.Equal(t, 7, column)
+
+	// missing values are located at their closest parent
+	line, column = Locate(node, Pointer("items", 0, "port"))
+	assert.Equal(t, 3, line)
+	assert.Equal(t, 5, column)
+
+	line, column = Locate(node, "")
+	assert.Equal(t, 1, line)
+	assert.Equal(t, 1, column)
+}
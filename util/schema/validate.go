@@ -0,0 +1,189 @@
+package schema
+
+import (
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Violation is a value of a yaml document that does not match its schema
+type Violation struct {
+	// Pointer is the JSON pointer of the value, e.g. /objects/0/name
+	Pointer string
+	Message string
+	Line    int
+	Column  int
+}
+
+// Pointer builds a JSON pointer from reference tokens
+func Pointer(tokens ...any) string {
+	b := strings.Builder{}
+	for _, token := range tokens {
+		b.WriteString("/")
+		switch t := token.(type) {
+		case int:
+			b.WriteString(strconv.Itoa(t))
+		case string:
+			b.WriteString(strings.ReplaceAll(strings.ReplaceAll(t, "~", "~0"), "/", "~1"))
+		}
+	}
+	return b.String()
+}
+
+// Validate checks a yaml document against the schema and returns every violation, not just the first one
+func (s *Schema) Validate(node *yaml.Node) []Violation {
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return []Violation{{Message: "document is empty", Line: node.Line, Column: node.Column}}
+		}
+		node = node.Content[0]
+	}
+
+	violations := make([]Violation, 0)
+	s.validate(node, "", &violations)
+	return violations
+}
+
+func (s *Schema) validate(node *yaml.Node, pointer string, violations *[]Violation) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	// null is the same as leaving a field out, missing required fields are reported by their parent
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+
+	report := func(message string) {
+		*violations = append(*violations, Violation{Pointer: pointer, Message: message, Line: node.Line, Column: node.Column})
+	}
+
+	types := s.types()
+	if len(types) > 0 && !slices.Contains(types, nodeType(node)) && !(nodeType(node) == "integer" && slices.Contains(types, "number")) {
+		report("expected " + strings.Join(types, " or ") + ", got " + nodeType(node))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, node.Value) {
+			report("must be one of: " + strings.Join(s.Enum, ", "))
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(node.Value) {
+			report("must match " + s.Pattern)
+		}
+	case yaml.SequenceNode:
+		if s.Items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			s.Items.validate(item, pointer+Pointer(i), violations)
+		}
+	case yaml.MappingNode:
+		s.validateMapping(node, pointer, violations)
+	}
+}
+
+func (s *Schema) validateMapping(node *yaml.Node, pointer string, violations *[]Violation) {
+	present := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == "<<" {
+			continue
+		}
+
+		property, ok := s.Properties[key.Value]
+		if !ok {
+			additional, isSchema := s.AdditionalProperties.(*Schema)
+			if !isSchema && s.AdditionalProperties == false {
+				*violations = append(*violations, Violation{Pointer: pointer + Pointer(key.Value), Message: "unknown field " + key.Value, Line: key.Line, Column: key.Column})
+				continue
+			}
+			property = additional
+		}
+
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			continue
+		}
+
+		present[key.Value] = true
+		if property != nil {
+			property.validate(value, pointer+Pointer(key.Value), violations)
+		}
+	}
+
+	for _, name := range s.Required {
+		if !present[name] {
+			*violations = append(*violations, Violation{Pointer: pointer + Pointer(name), Message: "missing required field " + name, Line: node.Line, Column: node.Column})
+		}
+	}
+}
+
+// nodeType returns the JSON Schema type of a yaml node
+func nodeType(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.Tag {
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	default:
+		return "string"
+	}
+}
+
+// Locate returns the position of the value a JSON pointer points to in a yaml document.
+// Values of mappings are located at their key, values that do not exist at the closest parent that does.
+func Locate(node *yaml.Node, pointer string) (line int, column int) {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line, column = node.Line, node.Column
+
+	tokens := strings.Split(pointer, "/")[1:]
+	for _, token := range tokens {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		}
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			found := false
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					line, column = node.Content[i].Line, node.Content[i].Column
+					node = node.Content[i+1]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return line, column
+			}
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return line, column
+			}
+			node = node.Content[i]
+			line, column = node.Line, node.Column
+		default:
+			return line, column
+		}
+	}
+
+	return line, column
+}